@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio" // For waiting for Enter key
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 
 	"sentinelgo/sentinelgo/config" // Application configuration management.
+	"sentinelgo/sentinelgo/events" // Optional event stream for external dashboards.
+	"sentinelgo/sentinelgo/report" // Report sending, including the optional attempt recorder.
 	"sentinelgo/sentinelgo/tui"    // Terminal User Interface logic.
 	"sentinelgo/sentinelgo/utils"  // Utility functions, including the structured logger.
 
@@ -74,62 +77,121 @@ const appLogo = `
 // (e.g., `go build -ldflags="-X main.version=1.0.0"`).
 var version = "dev"
 
+// Paths of the application config and the structured log, relative to the working directory.
+// A config in another format at the same place (e.g. config/sentinel.toml) is used if there is
+// no config/sentinel.yaml; see config.ResolveConfigPath.
+const (
+	configPath  = "config/sentinel.yaml"
+	logFilePath = "sentinelgo_session.log"
+)
+
 // main is the entry point for the SentinelGo application.
 // It handles initial setup including:
 // - Displaying an ASCII art logo and version information.
 // - Loading application configuration from `config/sentinel.yaml`.
 // - Initializing a structured logger (output to `sentinelgo_session.log`).
+// - Refusing to start while the operator kill switch (SENTINEL_DISABLED or killswitchfile) is active.
 // - Creating the initial model for the Terminal User Interface (TUI).
 // - Starting and running the Bubble Tea TUI program.
 // It exits with status 1 if TUI initialization or execution fails.
+//
+// With -selftest, it instead runs the environment checks of selfTest, prints a pass/fail
+// checklist and exits with status 1 if any check failed.
 func main() {
+	selfTestFlag := flag.Bool("selftest", false, "check the config, proxy source, proxy health and log file, then exit")
+	flag.Parse()
+	if *selfTestFlag {
+		if !newSelfTest(config.ResolveConfigPath(configPath), logFilePath).run(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initial splash screen: Clear screen, print logo, version, and wait for Enter.
 	fmt.Print("[H[2J") // ANSI escape sequence to clear the terminal screen.
-	fmt.Println(appLogo)
+	fmt.Print(appLogo)
 	fmt.Printf("SentinelGo version %s\n", version)
 	fmt.Print("\nPress Enter to continue...")
 	_, _ = bufio.NewReader(os.Stdin).ReadBytes('\n') // Wait for Enter key press.
 	fmt.Print("[H[2J")                             // Clear screen again before starting the TUI.
 
 	// 1. Load Application Configuration
-	// Attempts to load from "config/sentinel.yaml".
-	// If loading fails or file not found, proceeds with default values defined in config.LoadAppConfig.
-	appCfg, err := config.LoadAppConfig("config/sentinel.yaml")
+	// Attempts to load from configPath ("config/sentinel.yaml"), using the defaults defined in
+	// config.LoadAppConfig if no config file exists. A config that cannot be read, parsed or
+	// validated is fatal: carrying on with defaults would silently drop every valid setting.
+	appCfg, err := config.LoadAppConfig(config.ResolveConfigPath(configPath))
 	if err != nil {
-		// Log to Stderr as the main logger might not be set up or might be file-based.
-		fmt.Fprintf(os.Stderr, "Warning: Error loading application config: %v. Proceeding with defaults.\n", err)
+		// Report to Stderr as the main logger is not set up yet.
+		fmt.Fprintf(os.Stderr, "Error loading application config: %v\n", err)
+		os.Exit(1)
 	}
 	if appCfg == nil { // Should only happen if LoadAppConfig has a bug and returns nil, nil
 		fmt.Fprintf(os.Stderr, "Critical error: AppConfig is nil after attempting to load. Using minimal fallback defaults.\n")
 		appCfg = &config.AppConfig{ // Provide minimal essential defaults.
-			MaxRetries:     3,
-			RiskThreshold:  75.0,
-			DefaultHeaders: make(map[string]string),
-			APIKeys:        make(map[string]string),
-			CustomCookies:  []http.Cookie{},
+			MaxRetries:      3,
+			RiskThreshold:   75.0,
+			MaxLogBodyBytes: 4096,
+			DefaultHeaders:  make(map[string]string),
+			APIKeys:         make(map[string]string),
+			CustomCookies:   []http.Cookie{},
 		}
 	}
 
 	// 2. Initialize Logger
-	// Logs to "sentinelgo_session.log". Falls back to Stderr if file cannot be opened.
-	logFile, logFileErr := os.OpenFile("sentinelgo_session.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	var appLogger *utils.Logger
+	// Logs to "sentinelgo_session.log", rotated by size if logmaxsizemb is set, with credential
+	// headers redacted. Falls back to Stderr if the file cannot be opened.
+	redactHeaders := appCfg.LogRedactHeaders // Besides those matched by utils.IsSensitiveHeader.
+	appLogger, logFileErr := utils.NewRotatingLogger(logFilePath, appCfg.LogLevel, int64(appCfg.LogMaxSizeMB*1024*1024), appCfg.LogMaxBackups, redactHeaders...)
 	if logFileErr != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not open log file 'sentinelgo_session.log': %v. Logging to Stderr for this session.\n", logFileErr)
-		appLogger = utils.NewLogger(os.Stderr, "INFO") // Default to INFO level for Stderr fallback.
+		fmt.Fprintf(os.Stderr, "Warning: Could not open log file '%s': %v. Logging to Stderr for this session.\n", logFilePath, logFileErr)
+		appLogger = utils.NewLogger(os.Stderr, appCfg.LogLevel, redactHeaders...) // Unset or invalid levels default to INFO.
 	} else {
-		appLogger = utils.NewLogger(logFile, "INFO") // Default to INFO level for file logger.
-		defer func() {                               // Ensure log file is closed on exit if successfully opened.
+		defer func() { // Ensure log file is closed on exit if successfully opened.
 			appLogger.Info(utils.LogEntry{Message: "SentinelGo application shutting down. Closing log file."})
-			logFile.Close()
+			appLogger.Close()
 		}()
 	}
 
+	appLogger.SetMaxBodyBytes(appCfg.MaxLogBodyBytes) // Keep log lines bounded for downstream log shippers.
+
+	// Refuse to run at all while the operator kill switch is active. Sessions check it again on start.
+	if err := appCfg.CheckKillSwitch(); err != nil {
+		appLogger.Error(utils.LogEntry{Message: "Startup refused", Error: err.Error()})
+		fmt.Fprintf(os.Stderr, "SentinelGo is disabled: %v\n", err)
+		os.Exit(1)
+	}
+
 	appLogger.Info(utils.LogEntry{Message: "SentinelGo application TUI starting..."})
 
 	// 3. Create Initial TUI Model
 	// The TUI model is initialized with the loaded (or default) application configuration and the logger.
 	initialModel := tui.NewInitialModel(appCfg, appLogger)
+	if err := initialModel.StartupError(); err != nil {
+		appLogger.Error(utils.LogEntry{Message: "Startup failed", Error: err.Error()})
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1) // Log writes are unbuffered, so skipping the deferred log file close loses nothing.
+	}
+	if appCfg.RecordFile != "" {
+		recorder, recordFile, recErr := report.OpenRecorder(appCfg.RecordFile)
+		if recErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v. Report attempts will not be recorded.\n", recErr)
+		} else {
+			defer recordFile.Close()
+			initialModel.SetRecorder(recorder)
+			appLogger.Info(utils.LogEntry{Message: "Recording report attempts", AdditionalData: map[string]interface{}{"file": appCfg.RecordFile}})
+		}
+	}
+
+	if appCfg.EventsAddr != "" {
+		eventServer, evErr := events.Serve(appCfg.EventsAddr)
+		if evErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v. Session events will not be published.\n", evErr)
+		} else {
+			defer eventServer.Close()
+			initialModel.SetEventServer(eventServer)
+			appLogger.Info(utils.LogEntry{Message: "Publishing session events", AdditionalData: map[string]interface{}{"addr": eventServer.Addr()}})
+		}
+	}
 
 	// 4. Create and Run Bubble Tea Program
 	// Uses tea.WithAltScreen() to enable alternate screen buffer for a cleaner TUI experience.
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+)
+
+// Health check parameters used by the -selftest proxy check.
+const (
+	selfTestHealthTimeout     = 10 * time.Second
+	selfTestHealthConcurrency = 10
+)
+
+// selfTest runs the -selftest environment checks: the config loads and validates, the proxy
+// source loads, at least one proxy is healthy, enough proxies are healthy for the planned send
+// rate (targetrps), and the log file is writable. Later checks use
+// what earlier ones loaded, so a check whose prerequisite failed is reported as skipped.
+type selfTest struct {
+	configPath    string
+	logPath       string
+	healthTimeout time.Duration
+	concurrency   int
+
+	cfg     *config.AppConfig   // Set by checkConfig once the config has loaded.
+	proxies []*proxy.ProxyInfo  // Set by checkProxySource once proxies have loaded.
+	pool    *proxy.ProxyManager // Set by checkProxyHealth once at least one proxy is healthy.
+}
+
+// selfTestCheck is one line of the self-test checklist. run returns a short detail shown on
+// success, or the reason for the failure. ready, if set, reports whether the check can run.
+type selfTestCheck struct {
+	name  string
+	run   func() (string, error)
+	ready func() bool
+}
+
+// newSelfTest returns a self-test of the config at configPath and the log file at logPath.
+func newSelfTest(configPath, logPath string) *selfTest {
+	return &selfTest{
+		configPath:    configPath,
+		logPath:       logPath,
+		healthTimeout: selfTestHealthTimeout,
+		concurrency:   selfTestHealthConcurrency,
+	}
+}
+
+// checks returns the checklist in the order it runs.
+func (st *selfTest) checks() []selfTestCheck {
+	return []selfTestCheck{
+		{name: "Config loads and validates", run: st.checkConfig},
+		{name: "Proxy source loads", run: st.checkProxySource, ready: func() bool { return st.cfg != nil }},
+		{name: "At least one proxy is healthy", run: st.checkProxyHealth, ready: func() bool { return len(st.proxies) > 0 }},
+		{name: "Pool covers the target rate", run: st.checkCapacity, ready: func() bool { return st.pool != nil }},
+		{name: "Log file is writable", run: st.checkLogFile},
+	}
+}
+
+// run executes every check, printing a pass/fail checklist to w, and reports whether all passed.
+func (st *selfTest) run(w io.Writer) bool {
+	fmt.Fprintln(w, "SentinelGo self-test")
+	failed, skipped := 0, 0
+	checks := st.checks()
+	for _, check := range checks {
+		if check.ready != nil && !check.ready() {
+			skipped++
+			fmt.Fprintf(w, "  [SKIP] %s: an earlier check failed\n", check.name)
+			continue
+		}
+		detail, err := check.run()
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "  [FAIL] %s: %v\n", check.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "  [PASS] %s (%s)\n", check.name, detail)
+	}
+	if failed > 0 || skipped > 0 {
+		fmt.Fprintf(w, "Self-test failed: %d of %d checks failed, %d skipped.\n", failed, len(checks), skipped)
+		return false
+	}
+	fmt.Fprintln(w, "Self-test passed.")
+	return true
+}
+
+// checkConfig loads the config like startup does, which validates it. A missing config file is not
+// an error, since defaults are used, but it is noted in the detail.
+func (st *selfTest) checkConfig() (string, error) {
+	cfg, err := config.LoadAppConfig(st.configPath)
+	if errors.Is(err, config.ErrInvalidConfig) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load '%s': %w", st.configPath, err)
+	}
+	st.cfg = cfg
+	if _, err := os.Stat(st.configPath); errors.Is(err, os.ErrNotExist) {
+		return st.configPath + " not found, using defaults", nil
+	}
+	return st.configPath, nil
+}
+
+// checkProxySource loads the proxies from the configured source, like the TUI does at startup.
+func (st *selfTest) checkProxySource() (string, error) {
+	source := st.cfg.ProxySource()
+	var proxies []*proxy.ProxyInfo
+	var err error
+	if proxy.IsAPISource(source) && st.cfg.ProxyAPITimeoutSeconds > 0 {
+		proxies, err = proxy.LoadProxiesFromAPI(source, time.Duration(st.cfg.ProxyAPITimeoutSeconds*float64(time.Second)))
+	} else {
+		proxies, err = proxy.LoadProxies(source)
+	}
+	if err == nil && st.cfg.StrictProxyRegions {
+		err = proxy.ValidateRegions(proxies)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(proxies) == 0 {
+		return "", fmt.Errorf("no proxies found in %s", source)
+	}
+	st.proxies = proxies
+	return fmt.Sprintf("%d proxies from %s", len(proxies), source), nil
+}
+
+// checkProxyHealth health-checks the loaded proxies and fails if none of them is healthy.
+func (st *selfTest) checkProxyHealth() (string, error) {
+	pm := proxy.NewProxyManager(st.proxies, proxy.StrategyRoundRobin, true)
+	pm.RegionHealthCheckURLs = st.cfg.RegionHealthCheckURLs
+	healthy := pm.CheckPoolHealth(st.healthTimeout, st.concurrency).Healthy
+	if healthy == 0 {
+		return "", fmt.Errorf("none of %d proxies passed the health check", len(st.proxies))
+	}
+	st.pool = pm
+	return fmt.Sprintf("%d/%d healthy", healthy, len(st.proxies)), nil
+}
+
+// checkCapacity fails if the healthy proxies cannot carry targetrps at perproxyrps each. It
+// passes without checking anything when targetrps is not set.
+func (st *selfTest) checkCapacity() (string, error) {
+	if st.cfg.TargetRPS <= 0 {
+		return "targetrps not set", nil
+	}
+	estimate, err := st.pool.EstimateCapacity(st.cfg.TargetRPS, st.cfg.PerProxyRPS)
+	if err != nil {
+		return "", err
+	}
+	if !estimate.Sufficient() {
+		return "", fmt.Errorf("pool too small for requested rate: %g req/s at %g req/s per proxy needs %d healthy proxies, %d are healthy",
+			estimate.TargetRPS, estimate.PerProxyRPS, estimate.Needed, estimate.Healthy)
+	}
+	return fmt.Sprintf("%g req/s needs %d of %d healthy proxies", estimate.TargetRPS, estimate.Needed, estimate.Healthy), nil
+}
+
+// checkLogFile opens the log file for appending, as startup does, without writing to it.
+func (st *selfTest) checkLogFile() (string, error) {
+	f, err := os.OpenFile(st.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("cannot open '%s' for writing: %w", st.logPath, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("cannot close '%s': %w", st.logPath, err)
+	}
+	return st.logPath, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSelfTestEnv writes a config whose proxy file lists proxyURL into a temporary directory and
+// returns a self-test of it with a short health check timeout.
+func newSelfTestEnv(t *testing.T, configYAML, proxyURL string) *selfTest {
+	t.Helper()
+	dir := t.TempDir()
+	proxyFile := filepath.Join(dir, "proxies.json")
+	require.NoError(t, os.WriteFile(proxyFile, []byte(fmt.Sprintf(`[{"proxy": %q, "region": "US"}]`, proxyURL)), 0600))
+	configFile := filepath.Join(dir, "sentinel.yaml")
+	yaml := fmt.Sprintf("defaultheaders:\n  ProxyFile: %q\n%s", proxyFile, configYAML)
+	require.NoError(t, os.WriteFile(configFile, []byte(yaml), 0600))
+
+	st := newSelfTest(configFile, filepath.Join(dir, "session.log"))
+	st.healthTimeout = 2 * time.Second
+	return st
+}
+
+// newHealthyProxy starts an httptest server that answers every proxied request with 200.
+func newHealthyProxy(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestSelfTest_AllPass(t *testing.T) {
+	st := newSelfTestEnv(t, "maxretries: 3\n", newHealthyProxy(t))
+	var out bytes.Buffer
+	assert.True(t, st.run(&out), out.String())
+	assert.Contains(t, out.String(), "[PASS] Config loads and validates")
+	assert.Contains(t, out.String(), "[PASS] Proxy source loads (1 proxies from")
+	assert.Contains(t, out.String(), "[PASS] At least one proxy is healthy (1/1 healthy)")
+	assert.Contains(t, out.String(), "[PASS] Pool covers the target rate (targetrps not set)")
+	assert.Contains(t, out.String(), "[PASS] Log file is writable")
+	assert.Contains(t, out.String(), "Self-test passed.")
+	assert.FileExists(t, st.logPath)
+}
+
+func TestSelfTest_InvalidConfigSkipsProxyChecks(t *testing.T) {
+	st := newSelfTestEnv(t, "maxretries: 0\n", newHealthyProxy(t))
+	var out bytes.Buffer
+	assert.False(t, st.run(&out))
+	assert.Contains(t, out.String(), "[FAIL] Config loads and validates: invalid configuration: maxretries must be at least 1")
+	assert.Contains(t, out.String(), "[SKIP] Proxy source loads")
+	assert.Contains(t, out.String(), "[SKIP] At least one proxy is healthy")
+	assert.Contains(t, out.String(), "[PASS] Log file is writable")
+	assert.Contains(t, out.String(), "[SKIP] Pool covers the target rate")
+	assert.Contains(t, out.String(), "1 of 5 checks failed, 3 skipped")
+}
+
+func TestSelfTest_NoHealthyProxy(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadURL := "http://" + ln.Addr().String()
+	ln.Close() // Connections to the proxy are refused.
+
+	st := newSelfTestEnv(t, "maxretries: 3\n", deadURL)
+	var out bytes.Buffer
+	assert.False(t, st.run(&out))
+	assert.Contains(t, out.String(), "[PASS] Proxy source loads")
+	assert.Contains(t, out.String(), "[FAIL] At least one proxy is healthy: none of 1 proxies passed the health check")
+}
+
+func TestSelfTest_PoolTooSmallForTargetRate(t *testing.T) {
+	st := newSelfTestEnv(t, "maxretries: 3\ntargetrps: 3\nperproxyrps: 2\n", newHealthyProxy(t))
+	var out bytes.Buffer
+	assert.False(t, st.run(&out))
+	assert.Contains(t, out.String(), "[FAIL] Pool covers the target rate: pool too small for requested rate: 3 req/s at 2 req/s per proxy needs 2 healthy proxies, 1 are healthy")
+
+	st = newSelfTestEnv(t, "maxretries: 3\ntargetrps: 2\nperproxyrps: 2\n", newHealthyProxy(t))
+	out.Reset()
+	assert.True(t, st.run(&out), out.String())
+	assert.Contains(t, out.String(), "[PASS] Pool covers the target rate (2 req/s needs 1 of 1 healthy proxies)")
+}
+
+func TestSelfTest_MissingProxySourceAndUnwritableLog(t *testing.T) {
+	st := newSelfTestEnv(t, "maxretries: 3\n", newHealthyProxy(t))
+	require.NoError(t, os.Remove(filepath.Join(filepath.Dir(st.configPath), "proxies.json")))
+	st.logPath = filepath.Join(t.TempDir(), "missing-dir", "session.log")
+
+	var out bytes.Buffer
+	assert.False(t, st.run(&out))
+	assert.Contains(t, out.String(), "[FAIL] Proxy source loads")
+	assert.Contains(t, out.String(), "[SKIP] At least one proxy is healthy")
+	assert.Contains(t, out.String(), "[FAIL] Log file is writable")
+}
+
+func TestSelfTest_MissingConfigUsesDefaults(t *testing.T) {
+	st := newSelfTest(filepath.Join(t.TempDir(), "sentinel.yaml"), filepath.Join(t.TempDir(), "session.log"))
+	detail, err := st.checkConfig()
+	require.NoError(t, err)
+	assert.Contains(t, detail, "not found, using defaults")
+	require.NotNil(t, st.cfg)
+}
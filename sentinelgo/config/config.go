@@ -1,17 +1,29 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"sentinelgo/sentinelgo/utils"
 )
 
 // AppConfig holds the application configuration, typically loaded from `sentinel.yaml`.
-// It defines settings for various aspects of the application's behavior.
+// It defines settings for various aspects of the application's behavior. JSON and TOML config
+// files use the same keys and value formats as YAML (see LoadAppConfig), so only yaml tags are
+// needed.
 type AppConfig struct {
 	// DefaultHeaders is a map of HTTP headers that are applied to all outgoing report requests.
 	// Example: {"User-Agent": "SentinelGo Client/1.0"}
@@ -31,6 +43,483 @@ type AppConfig struct {
 	// APIKeys is a map to store API keys for various external services that SentinelGo might integrate with.
 	// Example: {"virustotal": "your_vt_api_key_here"}
 	APIKeys map[string]string `yaml:"apikeys"`
+
+	// MaxTotalRequests caps the number of report requests (including retries) the reporter may send
+	// over its lifetime; the TUI uses one reporter for the whole process. Zero disables the cap.
+	MaxTotalRequests int `yaml:"maxtotalrequests"`
+
+	// MaxTotalBytes caps the total number of request and response body bytes transferred by the reporter
+	// over its lifetime, like MaxTotalRequests. Zero disables the cap.
+	MaxTotalBytes int64 `yaml:"maxtotalbytes"`
+
+	// SendEmptyBody makes report POSTs carry an explicit empty body with `Content-Length: 0`
+	// and a Content-Type header instead of a nil body, which some WAFs and proxies reject.
+	SendEmptyBody bool `yaml:"sendemptybody"`
+
+	// EmptyBodyContentType is the Content-Type sent with the empty body when SendEmptyBody is enabled.
+	// Defaults to "application/x-www-form-urlencoded" if unset.
+	EmptyBodyContentType string `yaml:"emptybodycontenttype"`
+
+	// RequestMethod is the HTTP method of report requests. Defaults to "POST" if unset.
+	RequestMethod string `yaml:"requestmethod"`
+
+	// RequestBodyTemplate, if set, is the body of every report request. "{{target}}" and
+	// "{{sessionID}}" in it are replaced by the report's target URL and session ID, escaped to
+	// suit RequestContentType (see report.DefaultRequestBuilder). It takes precedence over
+	// SendEmptyBody. Unset keeps the body-less request.
+	RequestBodyTemplate string `yaml:"requestbodytemplate"`
+
+	// RequestContentType is the Content-Type sent with RequestBodyTemplate's body.
+	// Defaults to "application/x-www-form-urlencoded" if unset.
+	RequestContentType string `yaml:"requestcontenttype"`
+
+	// MaxLogBodyBytes caps the bytes of request/response bodies written to each structured log entry.
+	// Longer bodies are truncated on a rune boundary. Zero disables the cap.
+	MaxLogBodyBytes int `yaml:"maxlogbodybytes"`
+
+	// LogRedactHeaders names headers, in addition to Authorization, Proxy-Authorization, Cookie,
+	// Set-Cookie and X-Api-Key, whose values are written as "***" in sentinelgo_session.log.
+	LogRedactHeaders []string `yaml:"logredactheaders"`
+
+	// ProxyLogIdentifier chooses how proxies are identified in sentinelgo_session.log, recordings
+	// and error messages, so proxy credentials are not written out: "host" (the default when
+	// empty) logs the proxy URL without credentials, "hash" a stable hash of the full URL such as
+	// "proxy-3f2a9c1b7d4e", and "full" the URL with credentials.
+	ProxyLogIdentifier string `yaml:"proxylogidentifier"`
+
+	// LogLevel is the minimum level of entries written to sentinelgo_session.log: "DEBUG", "INFO"
+	// (the default when empty), "WARN", "ERROR" or "FATAL". It can be changed from the Settings tab
+	// while the application runs.
+	LogLevel string `yaml:"loglevel"`
+
+	// LogMaxSizeMB, if positive, rotates sentinelgo_session.log once it would grow past this
+	// many megabytes, keeping LogMaxBackups rotated files (.1 being the newest). Zero disables
+	// rotation.
+	LogMaxSizeMB  float64 `yaml:"logmaxsizemb"`
+	LogMaxBackups int     `yaml:"logmaxbackups"`
+
+	// AutoPauseOnDegraded pauses a running session when a report fails because no healthy proxy
+	// is left, instead of letting every remaining report fail. The session can be resumed once
+	// proxies have been rechecked.
+	AutoPauseOnDegraded bool `yaml:"autopauseondegraded"`
+
+	// DisableKeepAlives makes every report request open a fresh connection instead of reusing one,
+	// so a server cannot correlate reports through a shared connection. Trades throughput for unlinkability.
+	DisableKeepAlives bool `yaml:"disablekeepalives"`
+
+	// AbortTimeoutSeconds is how long aborting a session waits for its report loop to stop before
+	// giving up and reporting it as stuck. Zero uses the session default of 10 seconds.
+	AbortTimeoutSeconds float64 `yaml:"aborttimeoutseconds"`
+
+	// StallTimeoutSeconds, if positive, makes a session warn when reports are in flight but none
+	// has started or finished for this long, e.g. because the target never responds. With
+	// FailOnStall set, the stalled reports are failed and the session ends in the Failed state.
+	StallTimeoutSeconds float64 `yaml:"stalltimeoutseconds"`
+	FailOnStall         bool    `yaml:"failonstall"`
+
+	// RetryBodySubstrings lists case-insensitive substrings that mark a 2xx response as a soft
+	// failure (e.g., a "captcha" or "try again" page served by a proxy). Such responses are
+	// retried with the next proxy instead of being counted as accepted.
+	RetryBodySubstrings []string `yaml:"retrybodysubstrings"`
+
+	// SoftBlockStatusCodes and SoftBlockBodyMarkers detect soft blocks: responses, such as a 429 or
+	// a CAPTCHA page served with status 200, by which the target refuses the proxy rather than the
+	// report. A response with one of the status codes, or whose body contains one of the markers
+	// (matched case-insensitively, whatever the status), fails the attempt and marks the proxy
+	// "unhealthy"; the report is retried through another proxy.
+	SoftBlockStatusCodes []int    `yaml:"softblockstatuscodes"`
+	SoftBlockBodyMarkers []string `yaml:"softblockbodymarkers"`
+
+	// RecordFile, if set, is a file to which every report attempt's full request and response
+	// are appended as JSON lines for debugging. Recordings can be replayed with report.ReplayTransport.
+	RecordFile string `yaml:"recordfile"`
+
+	// AuditProxySelection makes the proxy manager count how often each proxy is selected.
+	// The counts are included in each session's summary log entry to verify load is spread evenly.
+	AuditProxySelection bool `yaml:"auditproxyselection"`
+
+	// QueueTargets makes the TUI queue a target submitted while a session is active and start it
+	// once the active session finishes, instead of rejecting the submission.
+	QueueTargets bool `yaml:"queuetargets"`
+
+	// MaxConcurrentSessions is how many sessions the TUI runs at once. A target submitted while
+	// that many are active is queued (see QueueTargets) or rejected. Zero or 1 allows one session.
+	MaxConcurrentSessions int `yaml:"maxconcurrentsessions"`
+
+	// AutoSaveIntervalSeconds, if positive, makes each session periodically write its progress
+	// (counts and per-job statuses) to AutoSavePath so a crash during a long run loses little.
+	AutoSaveIntervalSeconds float64 `yaml:"autosaveintervalseconds"`
+
+	// AutoSavePath is the file that session progress is auto-saved to. Required for auto-save.
+	AutoSavePath string `yaml:"autosavepath"`
+
+	// HealthyMaxAgeSeconds, if positive, is how long a proxy's "healthy" status is trusted.
+	// Older results are downgraded to "unknown" and flagged for a recheck when proxies are selected.
+	HealthyMaxAgeSeconds float64 `yaml:"healthymaxageseconds"`
+
+	// MinRecheckIntervalSeconds, if positive, is the least time between two health checks of the
+	// same proxy. A proxy checked more recently is skipped and keeps its cached status.
+	MinRecheckIntervalSeconds float64 `yaml:"minrecheckintervalseconds"`
+
+	// HealthSnapshot, if set, is a proxy health file saved from the Proxy Management tab (Ctrl+S),
+	// or a directory whose newest such file is used. Its results are restored at startup, before
+	// the initial health check, so with MinRecheckIntervalSeconds recently checked proxies are
+	// not checked again.
+	HealthSnapshot string `yaml:"healthsnapshot"`
+
+	// RequireProxyFile makes startup fail when the proxy file cannot be loaded, for automation
+	// that must not run without proxies. When false, the TUI starts with an empty pool and a warning.
+	RequireProxyFile bool `yaml:"requireproxyfile"`
+
+	// CorrelationHeader, if set, is a header (e.g., "X-Request-ID") added to every report attempt
+	// with a unique "<job ID>-<attempt>" value, to correlate reports with server-side logs.
+	CorrelationHeader string `yaml:"correlationheader"`
+
+	// ProxyStrategy selects how proxies are picked for each attempt: "round-robin" (the default
+	// when empty), "random", "region-prioritized", "avoid-recent" or "weighted".
+	ProxyStrategy string `yaml:"proxystrategy"`
+
+	// AvoidRecentWindow is how many of the most recently used proxies the "avoid-recent" strategy
+	// will not reuse. Zero uses the default of 3.
+	AvoidRecentWindow int `yaml:"avoidrecentwindow"`
+
+	// StrategyFallbackBelow, if positive, makes selection fall back to round-robin while fewer
+	// than this many proxies are selectable, and return to ProxyStrategy once enough recover.
+	// Zero always uses ProxyStrategy.
+	StrategyFallbackBelow int `yaml:"strategyfallbackbelow"`
+
+	// MaxConsecutiveFailures, if positive, is how many report failures in a row that point at a
+	// proxy mark it "dead": it is no longer used until a health check re-run revives it. Zero
+	// (the default) never marks proxies dead.
+	MaxConsecutiveFailures int `yaml:"maxconsecutivefailures"`
+
+	// RegionHealthCheckURLs maps proxy regions (e.g., "US") to the health check URL used for
+	// proxies in that region, so checks hit a nearby endpoint. Other proxies use the default URL.
+	RegionHealthCheckURLs map[string]string `yaml:"regionhealthcheckurls"`
+
+	// TraceConnections adds connection details to each report attempt's log entry: whether the
+	// connection was reused and the negotiated TLS version and cipher suite (or handshake error).
+	// Intended for debugging handshake failures through particular proxies; off by default.
+	TraceConnections bool `yaml:"traceconnections"`
+
+	// RedirectRules decide the outcome of a report whose redirects were followed to another page,
+	// e.g. a confirmation page on another host (success) or a login page (failure). The first rule
+	// whose Match is found in the final URL's host and path wins; with no match, the final status decides.
+	RedirectRules []RedirectRule `yaml:"redirectrules"`
+
+	// KillSwitchFile, if set, is a path whose existence disables report sending, like the
+	// SENTINEL_DISABLED environment variable. Operators can create it to stop the tool remotely.
+	KillSwitchFile string `yaml:"killswitchfile"`
+
+	// ProxyStartMode controls whether proxies can be used before their first health check:
+	// ProxyStartModeCold (the default when empty) selects unchecked proxies optimistically so
+	// early reports do not stall, while ProxyStartModeWarm waits for checks to mark them healthy.
+	ProxyStartMode string `yaml:"proxystartmode"`
+
+	// ProxyAPITimeoutSeconds is the timeout for fetching the proxy list when the proxy source is
+	// an HTTP(S) API endpoint. Zero uses the loader's default of 15 seconds.
+	ProxyAPITimeoutSeconds float64 `yaml:"proxyapitimeoutseconds"`
+
+	// MaxConsecutiveSessionFailures, if positive, stops the TUI from starting queued targets
+	// automatically after this many sessions in a row end without a single successful report.
+	// A later successful session resumes the queue.
+	MaxConsecutiveSessionFailures int `yaml:"maxconsecutivesessionfailures"`
+
+	// StrictProxyRegions rejects the proxy list at startup if any proxy has a region that is not an
+	// ISO-3166 alpha-2 code. When false (the default), unrecognized regions only produce a warning.
+	StrictProxyRegions bool `yaml:"strictproxyregions"`
+
+	// BackoffBase and BackoffMax control the delay before retrying a report attempt that failed
+	// with a network error: min(BackoffMax, BackoffBase * 2^attempt) plus up to 25% jitter.
+	// Zero values use 1s and 2s respectively. In YAML they are duration strings such as "500ms".
+	BackoffBase time.Duration `yaml:"backoffbase"`
+	BackoffMax  time.Duration `yaml:"backoffmax"`
+
+	// BackoffStrategy selects how retry delays grow: BackoffExponential (the default when empty)
+	// as described above, or BackoffDecorrelated, where each delay is random between BackoffBase
+	// and three times the previous delay, capped at BackoffMax. Decorrelated delays keep the
+	// retries of concurrent sessions from falling into step.
+	BackoffStrategy string `yaml:"backoffstrategy"`
+
+	// RequestTimeout bounds a whole report attempt, from sending the request to reading the
+	// response body. ResponseHeaderTimeout bounds the wait for the response headers once the
+	// request has been written, and ExpectContinueTimeout the wait for a "100 Continue" before
+	// sending the body. Zero values use 30s, 20s and 5s respectively. In YAML they are duration
+	// strings such as "45s".
+	RequestTimeout        time.Duration `yaml:"requesttimeout"`
+	ResponseHeaderTimeout time.Duration `yaml:"responseheadertimeout"`
+	ExpectContinueTimeout time.Duration `yaml:"expectcontinuetimeout"`
+
+	// DryRun makes sessions rehearse their reports: proxies are selected, requests built and
+	// attempts logged (with outcome "dry_run"), but nothing is sent and every report succeeds
+	// after DryRunLatency. Dry-run attempts do not count against the request budget.
+	DryRun        bool          `yaml:"dryrun"`
+	DryRunLatency time.Duration `yaml:"dryrunlatency"`
+
+	// DelayBetweenReportsSeconds is how long a session waits after each report before sending
+	// the next one, and DelayJitterSeconds randomly shifts each wait by up to that much either
+	// way. Zero sends reports back to back.
+	DelayBetweenReportsSeconds float64 `yaml:"delaybetweenreportsseconds"`
+	DelayJitterSeconds         float64 `yaml:"delayjitterseconds"`
+
+	// GeoCacheFile, if set, is a JSON file caching GeoIP results (host -> region) across runs.
+	// Proxies loaded without a region take it from the cache. GeoCacheTTLHours is how long a
+	// cached result is trusted; zero uses the default of one week.
+	GeoCacheFile     string  `yaml:"geocachefile"`
+	GeoCacheTTLHours float64 `yaml:"geocachettlhours"`
+
+	// GeoIPDatabase, if set, is a MaxMind DB file (e.g. GeoLite2-Country.mmdb) used to look up
+	// the country of proxies loaded without a region. Results are cached in GeoCacheFile, if set.
+	GeoIPDatabase string `yaml:"geoipdatabase"`
+
+	// ReportConcurrency is how many reports a session sends at once. Values below 2 send them
+	// one at a time.
+	ReportConcurrency int `yaml:"reportconcurrency"`
+
+	// ReportsPerProxy makes a session send this many reports through one proxy before rotating
+	// to the next, instead of selecting a proxy for every attempt. Zero selects per attempt.
+	ReportsPerProxy int `yaml:"reportsperproxy"`
+
+	// TargetRPS is the send rate, in requests per second, that sessions are planned for, and
+	// PerProxyRPS the rate one proxy can safely sustain. When both are set, the -selftest check
+	// and the TUI's pool health checks warn if the pool has too few healthy proxies for the rate.
+	// They are planning figures only; sends are not throttled to them.
+	TargetRPS   float64 `yaml:"targetrps"`
+	PerProxyRPS float64 `yaml:"perproxyrps"`
+
+	// LogChannelPolicy is what a session does with a live log update when its listener (the TUI)
+	// falls behind: "drop-newest" (wait up to a second, then drop the new update), "drop-oldest"
+	// or "block". Empty uses the session's default, drop-newest; the TUI uses drop-oldest.
+	// Dropped updates are counted and still written to the log file.
+	LogChannelPolicy string `yaml:"logchannelpolicy"`
+
+	// TargetAllowlist and TargetBlocklist restrict which hosts sessions may target. Patterns
+	// are host names ("example.com"), "*.example.com" for any subdomain, or "*" for every host.
+	// A non-empty allowlist refuses every host it does not match; the blocklist always wins.
+	TargetAllowlist []string `yaml:"targetallowlist"`
+	TargetBlocklist []string `yaml:"targetblocklist"`
+
+	// EventsAddr, if set, publishes session and report events for external dashboards: as
+	// Server-Sent Events on a TCP address (e.g. "127.0.0.1:8090"), or as JSON lines on a Unix
+	// socket given as "unix:/path/to/socket". Empty disables the event stream.
+	EventsAddr string `yaml:"eventsaddr"`
+}
+
+// DefaultProxySource is the proxy file loaded when the config does not name one.
+const DefaultProxySource = "config/proxies.csv"
+
+// ErrInvalidConfig is returned by Validate when settings are out of range or inconsistent.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ProxySource returns the proxy file or API URL to load proxies from: the "ProxyFile" entry of
+// DefaultHeaders if set, otherwise DefaultProxySource.
+func (c *AppConfig) ProxySource() string {
+	if c == nil {
+		return DefaultProxySource
+	}
+	if source := c.DefaultHeadersSnapshot()["ProxyFile"]; source != "" {
+		return source
+	}
+	return DefaultProxySource
+}
+
+// mapsMu guards the DefaultHeaders and APIKeys fields of configs shared between goroutines, such
+// as the TUI's config, edited on the Settings tab while sessions send reports with it. The maps
+// are copy-on-write: SetDefaultHeader and SetAPIKey install a modified copy instead of changing
+// the map in place, so a map returned by DefaultHeadersSnapshot can be read without the lock.
+var mapsMu sync.RWMutex
+
+// DefaultHeadersSnapshot returns c.DefaultHeaders, which must not be modified. Use it to read the
+// headers of a config that SetDefaultHeader may be called on concurrently.
+func (c *AppConfig) DefaultHeadersSnapshot() map[string]string {
+	mapsMu.RLock()
+	defer mapsMu.RUnlock()
+	return c.DefaultHeaders
+}
+
+// SetDefaultHeader sets the DefaultHeaders entry name to value, or removes it if value is empty.
+// It is safe to call while other goroutines read the headers with DefaultHeadersSnapshot.
+func (c *AppConfig) SetDefaultHeader(name, value string) {
+	setMapEntry(&c.DefaultHeaders, name, value)
+}
+
+// SetAPIKey sets the APIKeys entry name to value, or removes it if value is empty, like
+// SetDefaultHeader.
+func (c *AppConfig) SetAPIKey(name, value string) {
+	setMapEntry(&c.APIKeys, name, value)
+}
+
+// setMapEntry replaces *m with a copy in which key is set to value, or removed if value is empty.
+func setMapEntry(m *map[string]string, key, value string) {
+	mapsMu.Lock()
+	defer mapsMu.Unlock()
+	updated := make(map[string]string, len(*m)+1)
+	for k, v := range *m {
+		updated[k] = v
+	}
+	if value == "" {
+		delete(updated, key)
+	} else {
+		updated[key] = value
+	}
+	*m = updated
+}
+
+// Validate returns an error wrapping ErrInvalidConfig listing every setting that is out of range
+// or inconsistent with another, so mistakes surface before a run rather than during it.
+func (c *AppConfig) Validate() error {
+	var problems []string
+	if c.MaxRetries < 1 {
+		problems = append(problems, fmt.Sprintf("maxretries must be at least 1 (got %d)", c.MaxRetries))
+	}
+	if c.RiskThreshold < 0 || c.RiskThreshold > 100 {
+		problems = append(problems, fmt.Sprintf("riskthreshold must be between 0 and 100 (got %g)", c.RiskThreshold))
+	}
+	nonNegative := map[string]float64{
+		"maxtotalrequests":           float64(c.MaxTotalRequests),
+		"maxtotalbytes":              float64(c.MaxTotalBytes),
+		"maxconsecutivefailures":     float64(c.MaxConsecutiveFailures),
+		"maxconcurrentsessions":      float64(c.MaxConcurrentSessions),
+		"reportconcurrency":          float64(c.ReportConcurrency),
+		"reportsperproxy":            float64(c.ReportsPerProxy),
+		"delaybetweenreportsseconds": c.DelayBetweenReportsSeconds,
+		"delayjitterseconds":         c.DelayJitterSeconds,
+		"autosaveintervalseconds":    c.AutoSaveIntervalSeconds,
+		"minrecheckintervalseconds":  c.MinRecheckIntervalSeconds,
+		"aborttimeoutseconds":        c.AbortTimeoutSeconds,
+		"stalltimeoutseconds":        c.StallTimeoutSeconds,
+		"logmaxsizemb":               c.LogMaxSizeMB,
+		"logmaxbackups":              float64(c.LogMaxBackups),
+		"strategyfallbackbelow":      float64(c.StrategyFallbackBelow),
+		"targetrps":                  c.TargetRPS,
+		"perproxyrps":                c.PerProxyRPS,
+		"requesttimeout":             c.RequestTimeout.Seconds(),
+		"responseheadertimeout":      c.ResponseHeaderTimeout.Seconds(),
+		"expectcontinuetimeout":      c.ExpectContinueTimeout.Seconds(),
+		"dryrunlatency":              c.DryRunLatency.Seconds(),
+	}
+	keys := make([]string, 0, len(nonNegative))
+	for key := range nonNegative {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if nonNegative[key] < 0 {
+			problems = append(problems, fmt.Sprintf("%s cannot be negative (got %g)", key, nonNegative[key]))
+		}
+	}
+	if c.AutoSaveIntervalSeconds > 0 && c.AutoSavePath == "" {
+		problems = append(problems, "autosaveintervalseconds is set but autosavepath is empty")
+	}
+	if c.TargetRPS > 0 && c.PerProxyRPS <= 0 {
+		problems = append(problems, "targetrps is set but perproxyrps is not")
+	}
+	if c.FailOnStall && c.StallTimeoutSeconds <= 0 {
+		problems = append(problems, "failonstall is set but stalltimeoutseconds is not")
+	}
+	switch strings.ToLower(c.LogChannelPolicy) {
+	case "", "drop-newest", "drop-oldest", "block":
+	default:
+		problems = append(problems, fmt.Sprintf("logchannelpolicy must be \"drop-newest\", \"drop-oldest\" or \"block\" (got %q)", c.LogChannelPolicy))
+	}
+	if c.ProxyStartMode != "" && !strings.EqualFold(c.ProxyStartMode, ProxyStartModeCold) && !strings.EqualFold(c.ProxyStartMode, ProxyStartModeWarm) {
+		problems = append(problems, fmt.Sprintf("proxystartmode must be %q or %q (got %q)", ProxyStartModeCold, ProxyStartModeWarm, c.ProxyStartMode))
+	}
+	switch strings.ToLower(c.ProxyLogIdentifier) {
+	case "", "host", "hash", "full":
+	default:
+		problems = append(problems, fmt.Sprintf("proxylogidentifier must be \"host\", \"hash\" or \"full\" (got %q)", c.ProxyLogIdentifier))
+	}
+	switch strings.ToUpper(c.LogLevel) {
+	case "", "DEBUG", "INFO", "WARN", "ERROR", "FATAL":
+	default:
+		problems = append(problems, fmt.Sprintf("loglevel must be DEBUG, INFO, WARN, ERROR or FATAL (got %q)", c.LogLevel))
+	}
+	if c.BackoffStrategy != "" && !strings.EqualFold(c.BackoffStrategy, BackoffExponential) && !strings.EqualFold(c.BackoffStrategy, BackoffDecorrelated) {
+		problems = append(problems, fmt.Sprintf("backoffstrategy must be %q or %q (got %q)", BackoffExponential, BackoffDecorrelated, c.BackoffStrategy))
+	}
+	headerNames := make([]string, 0, len(c.DefaultHeaders))
+	for name := range c.DefaultHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		if !ValidHeaderName(name) {
+			problems = append(problems, fmt.Sprintf("defaultheaders key %q is not a valid header name", name))
+		} else if strings.ContainsAny(c.DefaultHeaders[name], "\r\n\x00") {
+			problems = append(problems, fmt.Sprintf("defaultheaders value of %q contains a line break or NUL byte", name))
+		}
+	}
+	if c.RequestMethod != "" && !ValidHeaderName(c.RequestMethod) { // Methods are tokens, like header names.
+		problems = append(problems, fmt.Sprintf("requestmethod %q is not a valid HTTP method", c.RequestMethod))
+	}
+	if strings.ContainsAny(c.RequestContentType, "\r\n\x00") {
+		problems = append(problems, "requestcontenttype contains a line break or NUL byte")
+	}
+	if c.CorrelationHeader != "" && !ValidHeaderName(c.CorrelationHeader) {
+		problems = append(problems, fmt.Sprintf("correlationheader %q is not a valid header name", c.CorrelationHeader))
+	}
+	for i := range c.CustomCookies {
+		if err := c.CustomCookies[i].Valid(); err != nil {
+			problems = append(problems, fmt.Sprintf("customcookies[%d] %q: %v", i, c.CustomCookies[i].Name, err))
+		}
+	}
+	for i, code := range c.SoftBlockStatusCodes {
+		if code < 100 || code > 599 {
+			problems = append(problems, fmt.Sprintf("softblockstatuscodes[%d] must be an HTTP status code between 100 and 599 (got %d)", i, code))
+		}
+	}
+	for i, rule := range c.RedirectRules {
+		if rule.Match == "" {
+			problems = append(problems, fmt.Sprintf("redirectrules[%d] has an empty match", i))
+		}
+		if rule.Outcome != RedirectOutcomeSuccess && rule.Outcome != RedirectOutcomeFailure {
+			problems = append(problems, fmt.Sprintf("redirectrules[%d] outcome must be %q or %q (got %q)", i, RedirectOutcomeSuccess, RedirectOutcomeFailure, rule.Outcome))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidHeaderName reports whether name is a valid HTTP header field name: a non-empty token of
+// letters, digits and the characters !#$%&'*+-.^_`|~ (RFC 7230, section 3.2.6).
+func ValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Values for AppConfig.ProxyStartMode.
+const (
+	ProxyStartModeCold = "cold"
+	ProxyStartModeWarm = "warm"
+)
+
+// Values for AppConfig.BackoffStrategy.
+const (
+	BackoffExponential  = "exponential"
+	BackoffDecorrelated = "decorrelated"
+)
+
+// Outcomes a RedirectRule can map a redirect target to.
+const (
+	RedirectOutcomeSuccess = "success"
+	RedirectOutcomeFailure = "failure"
+)
+
+// RedirectRule maps redirect targets containing Match (case-insensitive, matched against the
+// final URL's host and path) to an outcome: RedirectOutcomeSuccess or RedirectOutcomeFailure.
+type RedirectRule struct {
+	Match   string `yaml:"match"`
+	Outcome string `yaml:"outcome"`
 }
 
 // SessionState holds persistent data related to user sessions or application state
@@ -49,21 +538,133 @@ type SessionState struct {
 	LastProxyConfig string `json:"lastproxyconfig"`
 }
 
-// LoadAppConfig reads a YAML configuration file specified by `filePath`,
-// unmarshals it into an AppConfig struct, and returns it.
+// ErrUnsupportedConfigFormat is returned by LoadAppConfig and SaveAppConfig for config file
+// paths without a .yaml, .yml, .json or .toml extension.
+var ErrUnsupportedConfigFormat = errors.New("unsupported config file format")
+
+// Config file formats, chosen by file extension (see configFormat).
+const (
+	formatYAML = "yaml"
+	formatJSON = "json"
+	formatTOML = "toml"
+)
+
+// configFormat returns the format of the config file at path, from its extension.
+func configFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	case ".json":
+		return formatJSON, nil
+	case ".toml":
+		return formatTOML, nil
+	}
+	return "", fmt.Errorf("%w: '%s' (use a .yaml, .yml, .json or .toml file)", ErrUnsupportedConfigFormat, path)
+}
+
+// ResolveConfigPath returns path if it exists. Otherwise it returns the first existing file with
+// the same name and another config extension (e.g. "config/sentinel.toml" for
+// "config/sentinel.yaml"), trying .yaml, .yml, .json and .toml in turn, or path itself if none
+// exists. This lets the application find a config kept in another format at its usual location.
+func ResolveConfigPath(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range []string{".yaml", ".yml", ".json", ".toml"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return path
+}
+
+// unmarshalConfig decodes data, in the given format, into cfg. JSON and TOML documents are
+// re-encoded as YAML and decoded with the yaml tags, so keys and values (e.g. durations written
+// as "500ms") mean the same in every format.
+func unmarshalConfig(format string, data []byte, cfg *AppConfig) error {
+	var doc interface{}
+	switch format {
+	case formatYAML:
+		return yaml.Unmarshal(data, cfg)
+	case formatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return err
+		}
+		doc = fromJSONNumbers(doc)
+	case formatTOML:
+		table, err := unmarshalTOML(data)
+		if err != nil {
+			return err
+		}
+		doc = table
+	}
+	yamlData, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(yamlData, cfg)
+}
+
+// fromJSONNumbers replaces the json.Numbers in a decoded JSON document with int64s, or float64s
+// for numbers that are not integers, which YAML encodes as numbers rather than strings.
+func fromJSONNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = fromJSONNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = fromJSONNumbers(value)
+		}
+	}
+	return v
+}
+
+// marshalConfig encodes cfg in the given format. JSON and TOML documents are built from its
+// YAML encoding, so they hold the same keys and values.
+func marshalConfig(format string, cfg *AppConfig) ([]byte, error) {
+	yamlData, err := yaml.Marshal(cfg)
+	if err != nil || format == formatYAML {
+		return yamlData, err
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(yamlData, &doc); err != nil {
+		return nil, err
+	}
+	if format == formatJSON {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		return append(data, '\n'), err
+	}
+	return marshalTOML(doc)
+}
+
+// LoadAppConfig reads the configuration file specified by `filePath`, in YAML (.yaml or .yml),
+// JSON (.json) or TOML (.toml) format as given by its extension, unmarshals it into an
+// AppConfig struct, and returns it. Every format uses the same keys (the yaml tags).
 // If the file does not exist, it returns a default AppConfig with predefined values
-// (e.g., MaxRetries: 3, RiskThreshold: 75.0) and no error.
-// Errors during file reading (other than not found) or YAML unmarshaling are returned.
+// (e.g., MaxRetries: 3, RiskThreshold: 75.0, MaxLogBodyBytes: 4096) and no error.
+// Errors during file reading (other than not found) or unmarshaling are returned, as is an
+// error wrapping ErrUnsupportedConfigFormat for any other extension. If the file parses but
+// fails Validate, an error wrapping ErrInvalidConfig that lists every problem is returned, with a
+// nil config: the file's settings are never replaced by defaults behind the user's back.
 func LoadAppConfig(filePath string) (*AppConfig, error) {
-	// Default configuration values.
-	config := &AppConfig{
-		MaxRetries:     3,
-		RiskThreshold:  75.0,
-		DefaultHeaders: make(map[string]string),
-		APIKeys:        make(map[string]string),
-		CustomCookies:  []http.Cookie{}, // Ensure empty slice, not nil
+	format, err := configFormat(filePath)
+	if err != nil {
+		return nil, err
 	}
 
+	config := defaultAppConfig()
+
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -74,11 +675,11 @@ func LoadAppConfig(filePath string) (*AppConfig, error) {
 		return nil, err
 	}
 
-	// Unmarshal the YAML data into the config struct.
-	err = yaml.Unmarshal(data, config)
+	// Unmarshal the data into the config struct.
+	err = unmarshalConfig(format, data, config)
 	if err != nil {
-		// YAML parsing error.
-		return nil, err
+		// Parsing error.
+		return nil, fmt.Errorf("failed to parse %s config '%s': %w", strings.ToUpper(format), filePath, err)
 	}
 
 	// Ensure maps and slices are not nil if YAML parsing results in them being nil
@@ -93,9 +694,26 @@ func LoadAppConfig(filePath string) (*AppConfig, error) {
 		config.CustomCookies = []http.Cookie{}
 	}
 
+	// Settings that parse but make no sense (e.g. maxretries: 0) are rejected as a whole rather
+	// than being half-applied.
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
+// defaultAppConfig returns the configuration used when no config file exists.
+func defaultAppConfig() *AppConfig {
+	return &AppConfig{
+		MaxRetries:      3,
+		RiskThreshold:   75.0,
+		MaxLogBodyBytes: 4096,
+		DefaultHeaders:  make(map[string]string),
+		APIKeys:         make(map[string]string),
+		CustomCookies:   []http.Cookie{}, // Ensure empty slice, not nil
+	}
+}
+
 // LoadSessionState reads a JSON file specified by `filePath`,
 // unmarshals it into a SessionState struct, and returns it.
 // If the file does not exist, it returns a default (empty) SessionState struct and no error.
@@ -162,13 +780,136 @@ func SaveSessionState(filePath string, state *SessionState) error {
 	return os.WriteFile(filePath, data, 0600) // Use 0600 for user-private file permissions.
 }
 
-// SaveAppConfig marshals the provided AppConfig struct to YAML and writes it to the
-// file specified by `filePath`. It overwrites the file if it already exists.
-// File permissions are set to 0644.
+// SaveAppConfig marshals the provided AppConfig struct to YAML, JSON or TOML, as given by the
+// extension of `filePath` (see LoadAppConfig), and writes it to that file. It overwrites the file
+// if it already exists. File permissions are set to 0644.
 func SaveAppConfig(filePath string, cfg *AppConfig) error {
-	data, err := yaml.Marshal(cfg)
+	format, err := configFormat(filePath)
+	if err != nil {
+		return err
+	}
+	data, err := marshalConfig(format, cfg)
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(filePath, data, 0644) // Use 0644 for config files (readable by owner/group).
 }
+
+// RedactedValue replaces secrets in the output of AppConfig.Redacted.
+const RedactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the config that is safe to share: API key values, custom cookie
+// values and the values of credential-bearing default headers (Authorization, Cookie, anything
+// naming a token, secret, API key or password) are replaced with RedactedValue.
+// The receiver is not modified.
+func (c *AppConfig) Redacted() *AppConfig {
+	redacted := *c
+	if c.DefaultHeaders != nil {
+		redacted.DefaultHeaders = make(map[string]string, len(c.DefaultHeaders))
+		for name, value := range c.DefaultHeaders {
+			if IsSensitiveHeader(name) {
+				value = RedactedValue
+			}
+			redacted.DefaultHeaders[name] = value
+		}
+	}
+	if c.APIKeys != nil {
+		redacted.APIKeys = make(map[string]string, len(c.APIKeys))
+		for name := range c.APIKeys {
+			redacted.APIKeys[name] = RedactedValue
+		}
+	}
+	if c.CustomCookies != nil {
+		redacted.CustomCookies = make([]http.Cookie, len(c.CustomCookies))
+		for i, cookie := range c.CustomCookies {
+			cookie.Value = RedactedValue
+			redacted.CustomCookies[i] = cookie
+		}
+	}
+	redacted.RetryBodySubstrings = append([]string(nil), c.RetryBodySubstrings...)
+	redacted.SoftBlockStatusCodes = append([]int(nil), c.SoftBlockStatusCodes...)
+	redacted.SoftBlockBodyMarkers = append([]string(nil), c.SoftBlockBodyMarkers...)
+	return &redacted
+}
+
+// IsSensitiveHeader reports whether a header name suggests its value is a credential, e.g.
+// "Authorization" or "X-Api-Token". It matches the same headers the log redacts (see
+// utils.IsSensitiveHeader).
+func IsSensitiveHeader(name string) bool {
+	return utils.IsSensitiveHeader(name)
+}
+
+// KillSwitchEnvVar is the environment variable that disables report sending when set to a true
+// value (any value other than an explicit false such as "0" or "false").
+const KillSwitchEnvVar = "SENTINEL_DISABLED"
+
+// ErrDisabled is returned by CheckKillSwitch when the kill switch is active.
+var ErrDisabled = errors.New("report sending is disabled by the operator kill switch")
+
+// CheckKillSwitch returns an error wrapping ErrDisabled if report sending has been disabled,
+// either through KillSwitchEnvVar or by the existence of c.KillSwitchFile. It is checked at
+// startup and each time a session starts, so a switch flipped while the app runs stops new
+// sessions. A nil config only checks the environment variable.
+func (c *AppConfig) CheckKillSwitch() error {
+	if value := strings.TrimSpace(os.Getenv(KillSwitchEnvVar)); value != "" {
+		if disabled, err := strconv.ParseBool(value); err != nil || disabled {
+			return fmt.Errorf("%w (%s is set)", ErrDisabled, KillSwitchEnvVar)
+		}
+	}
+	if c != nil && c.KillSwitchFile != "" {
+		if _, err := os.Stat(c.KillSwitchFile); err == nil {
+			return fmt.Errorf("%w (%s exists)", ErrDisabled, c.KillSwitchFile)
+		}
+	}
+	return nil
+}
+
+// ErrTargetNotAllowed is returned by CheckTarget when a target is refused by the allowlist or blocklist.
+var ErrTargetNotAllowed = errors.New("target is not allowed by the target policy")
+
+// CheckTarget returns an error wrapping ErrTargetNotAllowed if targetURL's host is matched by
+// TargetBlocklist, or if TargetAllowlist is set and does not match it. It is checked when a
+// target is submitted and each time a session starts. A nil config, or one without either
+// list, allows every target.
+func (c *AppConfig) CheckTarget(targetURL string) error {
+	if c == nil || (len(c.TargetAllowlist) == 0 && len(c.TargetBlocklist) == 0) {
+		return nil
+	}
+	parsed, err := url.Parse(strings.TrimSpace(targetURL))
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Errorf("%w: cannot determine the host of '%s'", ErrTargetNotAllowed, targetURL)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, pattern := range c.TargetBlocklist {
+		if matchHostPattern(host, pattern) {
+			return fmt.Errorf("%w: %s is blocked by '%s'", ErrTargetNotAllowed, host, pattern)
+		}
+	}
+	if len(c.TargetAllowlist) == 0 {
+		return nil
+	}
+	for _, pattern := range c.TargetAllowlist {
+		if matchHostPattern(host, pattern) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowlist", ErrTargetNotAllowed, host)
+}
+
+// matchHostPattern reports whether host (lowercase) matches pattern: "*" matches every host,
+// "*.example.com" matches subdomains of example.com but not example.com itself, and any other
+// pattern must equal the host, ignoring case and a trailing dot.
+func matchHostPattern(host, pattern string) bool {
+	pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+	host = strings.TrimSuffix(host, ".")
+	switch {
+	case pattern == "":
+		return false
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	default:
+		return host == pattern
+	}
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,6 +33,8 @@ maxretries: 5
 riskthreshold: 60.0
 apikeys:
   testservice: "testapikey"
+backoffbase: 500ms
+backoffmax: 30s
 `
 	err := os.WriteFile(tempYAMLPath, []byte(yamlContent), 0600)
 	require.NoError(t, err, "Failed to write temp sentinel.yaml for testing")
@@ -45,6 +49,8 @@ apikeys:
 	assert.Equal(t, "testapikey", cfg.APIKeys["testservice"], "API key should match")
 	require.Len(t, cfg.CustomCookies, 1, "Should be one custom cookie")
 	assert.Equal(t, "test_session", cfg.CustomCookies[0].Name, "Cookie name should match")
+	assert.Equal(t, 500*time.Millisecond, cfg.BackoffBase, "Durations are parsed from strings")
+	assert.Equal(t, 30*time.Second, cfg.BackoffMax)
 
 	// Test loading a non-existent YAML file
 	nonExistentPath := filepath.Join(tempDir, "non_existent.yaml")
@@ -55,6 +61,7 @@ apikeys:
 	// Check default values (as defined in LoadAppConfig)
 	assert.Equal(t, 3, defaultCfg.MaxRetries, "Default MaxRetries should be 3")
 	assert.Equal(t, 75.0, defaultCfg.RiskThreshold, "Default RiskThreshold should be 75.0")
+	assert.Equal(t, 4096, defaultCfg.MaxLogBodyBytes, "Default MaxLogBodyBytes should be 4096")
 	assert.NotNil(t, defaultCfg.DefaultHeaders, "DefaultHeaders map should be initialized")
 	assert.NotNil(t, defaultCfg.APIKeys, "APIKeys map should be initialized")
 	assert.Empty(t, defaultCfg.CustomCookies, "CustomCookies should be empty by default")
@@ -144,3 +151,305 @@ func TestLoadAppConfig_ActualFile(t *testing.T) {
 	assert.Equal(t, 80.5, cfg.RiskThreshold) // From the default file created in earlier step
 	assert.Equal(t, "SentinelGo Client v1.0", cfg.DefaultHeaders["User-Agent"])
 }
+
+func TestAppConfig_Redacted(t *testing.T) {
+	cfg := &AppConfig{
+		MaxRetries:     3,
+		DefaultHeaders: map[string]string{"User-Agent": "Sentinel/1.0", "Authorization": "Bearer abc", "X-Auth-Token": "tok"},
+		APIKeys:        map[string]string{"virustotal": "vt-secret"},
+		CustomCookies:  []http.Cookie{{Name: "session", Value: "cookie-secret", Domain: "example.com"}},
+	}
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, 3, redacted.MaxRetries)
+	assert.Equal(t, "Sentinel/1.0", redacted.DefaultHeaders["User-Agent"])
+	assert.Equal(t, RedactedValue, redacted.DefaultHeaders["Authorization"])
+	assert.Equal(t, RedactedValue, redacted.DefaultHeaders["X-Auth-Token"])
+	assert.Equal(t, RedactedValue, redacted.APIKeys["virustotal"])
+	require.Len(t, redacted.CustomCookies, 1)
+	assert.Equal(t, "session", redacted.CustomCookies[0].Name)
+	assert.Equal(t, RedactedValue, redacted.CustomCookies[0].Value)
+
+	// The original config is untouched.
+	assert.Equal(t, "Bearer abc", cfg.DefaultHeaders["Authorization"])
+	assert.Equal(t, "vt-secret", cfg.APIKeys["virustotal"])
+	assert.Equal(t, "cookie-secret", cfg.CustomCookies[0].Value)
+}
+
+func TestAppConfig_CheckKillSwitch(t *testing.T) {
+	t.Setenv(KillSwitchEnvVar, "")
+	var nilCfg *AppConfig
+	assert.NoError(t, nilCfg.CheckKillSwitch())
+
+	for _, value := range []string{"1", "true", "yes"} {
+		t.Setenv(KillSwitchEnvVar, value)
+		assert.ErrorIs(t, nilCfg.CheckKillSwitch(), ErrDisabled, "value %q", value)
+	}
+	for _, value := range []string{"0", "false"} {
+		t.Setenv(KillSwitchEnvVar, value)
+		assert.NoError(t, nilCfg.CheckKillSwitch(), "value %q", value)
+	}
+
+	switchFile := filepath.Join(t.TempDir(), "disabled")
+	cfg := &AppConfig{KillSwitchFile: switchFile}
+	assert.NoError(t, cfg.CheckKillSwitch(), "a missing switch file leaves sending enabled")
+	require.NoError(t, os.WriteFile(switchFile, nil, 0600))
+	err := cfg.CheckKillSwitch()
+	assert.ErrorIs(t, err, ErrDisabled)
+	assert.Contains(t, err.Error(), switchFile)
+}
+
+func TestAppConfig_CheckTarget(t *testing.T) {
+	var nilCfg *AppConfig
+	assert.NoError(t, nilCfg.CheckTarget("http://anything.example.com"))
+	assert.NoError(t, (&AppConfig{}).CheckTarget("not a url"), "no policy allows every target")
+
+	cfg := &AppConfig{
+		TargetAllowlist: []string{"example.com", "*.example.org"},
+		TargetBlocklist: []string{"blocked.example.org"},
+	}
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{"https://example.com/report", true},
+		{"https://EXAMPLE.com:8443/report", true},
+		{"https://www.example.com/report", false}, // Plain patterns do not cover subdomains.
+		{"https://a.example.org/report", true},
+		{"https://a.b.example.org/report", true},
+		{"https://example.org/report", false}, // Wildcards do not cover the apex domain.
+		{"https://blocked.example.org/report", false},
+		{"https://badexample.org/report", false},
+		{"https://other.com/report", false},
+		{"/relative/path", false},
+	}
+	for _, tt := range tests {
+		err := cfg.CheckTarget(tt.target)
+		if tt.allowed {
+			assert.NoError(t, err, "target %q", tt.target)
+		} else {
+			assert.ErrorIs(t, err, ErrTargetNotAllowed, "target %q", tt.target)
+		}
+	}
+
+	blockOnly := &AppConfig{TargetBlocklist: []string{"*.internal", "localhost"}}
+	assert.NoError(t, blockOnly.CheckTarget("https://example.com"))
+	err := blockOnly.CheckTarget("http://db.internal/report")
+	assert.ErrorIs(t, err, ErrTargetNotAllowed)
+	assert.Contains(t, err.Error(), "*.internal")
+	assert.ErrorIs(t, blockOnly.CheckTarget("http://LOCALHOST:8080"), ErrTargetNotAllowed)
+
+	everything := &AppConfig{TargetBlocklist: []string{"*"}}
+	assert.ErrorIs(t, everything.CheckTarget("https://example.com"), ErrTargetNotAllowed)
+}
+
+func TestAppConfig_Validate(t *testing.T) {
+	cfg, err := LoadAppConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate(), "the defaults are valid")
+
+	cfg.MaxRetries = 0
+	cfg.RiskThreshold = 120
+	cfg.ReportConcurrency = -1
+	cfg.AutoSaveIntervalSeconds = 30
+	cfg.FailOnStall = true
+	cfg.ProxyStartMode = "lukewarm"
+	cfg.BackoffStrategy = "linear"
+	cfg.LogLevel = "verbose"
+	cfg.TargetRPS = 5
+	cfg.RedirectRules = []RedirectRule{{Match: "/login", Outcome: "maybe"}}
+	cfg.SoftBlockStatusCodes = []int{429, 1000}
+	cfg.RequestTimeout = -time.Second
+	err = cfg.Validate()
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	for _, problem := range []string{"maxretries", "riskthreshold", "reportconcurrency cannot be negative", "autosavepath is empty", "failonstall is set", "proxystartmode", "backoffstrategy", "loglevel", "perproxyrps is not", "redirectrules[0] outcome", "softblockstatuscodes[1]", "requesttimeout cannot be negative"} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+func TestAppConfig_ValidateHeadersAndCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *AppConfig)
+		problem string
+	}{
+		{"header name with a space", func(c *AppConfig) { c.DefaultHeaders["User Agent"] = "x" }, `defaultheaders key "User Agent" is not a valid header name`},
+		{"header name with a colon", func(c *AppConfig) { c.DefaultHeaders["Accept:"] = "x" }, `defaultheaders key "Accept:" is not a valid header name`},
+		{"empty header name", func(c *AppConfig) { c.DefaultHeaders[""] = "x" }, `defaultheaders key "" is not a valid header name`},
+		{"header value with a line break", func(c *AppConfig) { c.DefaultHeaders["X-Test"] = "a\r\nX-Injected: b" }, `defaultheaders value of "X-Test" contains a line break`},
+		{"correlation header", func(c *AppConfig) { c.CorrelationHeader = "X Request ID" }, `correlationheader "X Request ID" is not a valid header name`},
+		{"request method with a space", func(c *AppConfig) { c.RequestMethod = "PO ST" }, `requestmethod "PO ST" is not a valid HTTP method`},
+		{"request content type with a line break", func(c *AppConfig) { c.RequestContentType = "text/plain\nX-Injected: b" }, "requestcontenttype contains a line break"},
+		{"cookie without a name", func(c *AppConfig) { c.CustomCookies = []http.Cookie{{Value: "v"}} }, `customcookies[0] "": http: invalid Cookie.Name`},
+		{"cookie name with a separator", func(c *AppConfig) { c.CustomCookies = []http.Cookie{{Name: "a;b", Value: "v"}} }, `customcookies[0] "a;b": http: invalid Cookie.Name`},
+		{"cookie value with a quote", func(c *AppConfig) {
+			c.CustomCookies = []http.Cookie{{Name: "ok", Value: "v"}, {Name: "bad", Value: `say "hi"`}}
+		}, `customcookies[1] "bad": http: invalid byte '"' in Cookie.Value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadAppConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+			require.NoError(t, err)
+			cfg.DefaultHeaders["User-Agent"] = "Sentinel/1.0"
+			cfg.CustomCookies = []http.Cookie{{Name: "session", Value: "abc123", Path: "/"}}
+			require.NoError(t, cfg.Validate())
+
+			tt.modify(cfg)
+			err = cfg.Validate()
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Contains(t, err.Error(), tt.problem)
+		})
+	}
+}
+
+func TestLoadAppConfig_InvalidIsRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentinel.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("maxretries: 0\nriskthreshold: 500\ndefaultheaders:\n  \"Bad Header\": x\n"), 0600))
+
+	cfg, err := LoadAppConfig(path)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	for _, problem := range []string{"maxretries must be at least 1 (got 0)", "riskthreshold must be between 0 and 100 (got 500)", `"Bad Header" is not a valid header name`} {
+		assert.Contains(t, err.Error(), problem)
+	}
+	assert.Nil(t, cfg, "no defaults are returned in place of the file's settings")
+}
+
+func TestAppConfig_ProxySource(t *testing.T) {
+	var nilCfg *AppConfig
+	assert.Equal(t, DefaultProxySource, nilCfg.ProxySource())
+	cfg := &AppConfig{DefaultHeaders: map[string]string{"ProxyFile": "custom.json"}}
+	assert.Equal(t, "custom.json", cfg.ProxySource())
+}
+
+func TestSaveAppConfig_FormatsRoundTrip(t *testing.T) {
+	cfg := &AppConfig{
+		DefaultHeaders: map[string]string{"User-Agent": "Sentinel/1.0", "Accept-Language": "en-US,en;q=0.9", "ProxyFile": "config/proxies.json", "X-Note": `quote " and \ backslash`},
+		CustomCookies: []http.Cookie{
+			{Name: "session", Value: "abc=123", Path: "/", Domain: "example.com", HttpOnly: true, Expires: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{Name: "tracking", Value: "opt-out", MaxAge: 3600, SameSite: http.SameSiteLaxMode},
+		},
+		MaxRetries:            5,
+		RiskThreshold:         60.5,
+		APIKeys:               map[string]string{"virustotal": "vt-key"},
+		MaxTotalBytes:         1 << 40,
+		SendEmptyBody:         true,
+		LogRedactHeaders:      []string{"X-Session"},
+		LogLevel:              "DEBUG",
+		LogMaxSizeMB:          2,
+		RetryBodySubstrings:   []string{"captcha", "try again\nlater"},
+		ProxyStrategy:         "weighted",
+		RegionHealthCheckURLs: map[string]string{"US": "https://us.example.com/health"},
+		RedirectRules:         []RedirectRule{{Match: "example.com/done", Outcome: "success"}, {Match: "/login", Outcome: "failure"}},
+		BackoffBase:           500 * time.Millisecond,
+		BackoffMax:            time.Minute,
+		TargetRPS:             12.5,
+		PerProxyRPS:           2.5,
+		TargetAllowlist:       []string{"*.example.com"},
+		EventsAddr:            "unix:/tmp/sentinel.sock",
+	}
+
+	// Every format must load back the same config as YAML, which nil slices come back from empty.
+	dir := t.TempDir()
+	var reference *AppConfig
+	for _, name := range []string{"sentinel.yaml", "sentinel.yml", "sentinel.json", "sentinel.toml"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, SaveAppConfig(path, cfg), name)
+		loaded, err := LoadAppConfig(path)
+		require.NoError(t, err, name)
+		if reference == nil {
+			reference = loaded
+			assert.Equal(t, cfg.DefaultHeaders, loaded.DefaultHeaders)
+			require.Len(t, loaded.CustomCookies, 2)
+			assert.True(t, cfg.CustomCookies[0].Expires.Equal(loaded.CustomCookies[0].Expires))
+			assert.Equal(t, cfg.CustomCookies[1].Value, loaded.CustomCookies[1].Value)
+			assert.Equal(t, cfg.RedirectRules, loaded.RedirectRules)
+			assert.Equal(t, cfg.RetryBodySubstrings, loaded.RetryBodySubstrings)
+			assert.Equal(t, cfg.MaxTotalBytes, loaded.MaxTotalBytes)
+			assert.Equal(t, cfg.BackoffBase, loaded.BackoffBase)
+			assert.Equal(t, cfg.TargetRPS, loaded.TargetRPS)
+			continue
+		}
+		require.Len(t, loaded.CustomCookies, 2, name)
+		for i := range loaded.CustomCookies { // Equal instants may differ in their *time.Location.
+			assert.True(t, reference.CustomCookies[i].Expires.Equal(loaded.CustomCookies[i].Expires), name)
+			loaded.CustomCookies[i].Expires = reference.CustomCookies[i].Expires
+		}
+		assert.Equal(t, reference, loaded, name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "sentinel.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"backoffbase": "500ms"`, "JSON uses the YAML keys and duration strings")
+	data, err = os.ReadFile(filepath.Join(dir, "sentinel.toml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `backoffbase = "500ms"`)
+	assert.Contains(t, string(data), "[[redirectrules]]")
+}
+
+func TestLoadAppConfig_TOMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	tomlPath := filepath.Join(dir, "sentinel.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte(`# Hand-written TOML config.
+maxretries = 4
+riskthreshold = 70
+backoffbase = "250ms"
+proxystrategy = 'region-prioritized'
+targetallowlist = [
+  "example.com",
+  "*.example.org", # Trailing comma and comments are fine.
+]
+
+[defaultheaders]
+User-Agent = "TOMLAgent/1.0"
+
+[[customcookies]]
+name = "session"
+value = "abc"
+`), 0600))
+	cfg, err := LoadAppConfig(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, 4, cfg.MaxRetries)
+	assert.Equal(t, 70.0, cfg.RiskThreshold, "integers are accepted for float settings")
+	assert.Equal(t, 250*time.Millisecond, cfg.BackoffBase)
+	assert.Equal(t, "region-prioritized", cfg.ProxyStrategy)
+	assert.Equal(t, []string{"example.com", "*.example.org"}, cfg.TargetAllowlist)
+	assert.Equal(t, "TOMLAgent/1.0", cfg.DefaultHeaders["User-Agent"])
+	require.Len(t, cfg.CustomCookies, 1)
+	assert.Equal(t, "session", cfg.CustomCookies[0].Name)
+	assert.NotNil(t, cfg.APIKeys, "keys missing from the file keep their defaults")
+	assert.Equal(t, 4096, cfg.MaxLogBodyBytes)
+
+	jsonPath := filepath.Join(dir, "sentinel.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"maxretries": 2, "maxtotalbytes": 9007199254740993, "backoffmax": "45s"}`), 0600))
+	cfg, err = LoadAppConfig(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.MaxRetries)
+	assert.Equal(t, int64(9007199254740993), cfg.MaxTotalBytes, "large integers keep their precision")
+	assert.Equal(t, 45*time.Second, cfg.BackoffMax)
+
+	require.NoError(t, os.WriteFile(tomlPath, []byte("maxretries = 4\nmaxretries = 5\n"), 0600))
+	_, err = LoadAppConfig(tomlPath)
+	assert.ErrorContains(t, err, "already been defined")
+}
+
+func TestAppConfig_UnsupportedFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentinel.ini")
+	_, err := LoadAppConfig(path)
+	assert.ErrorIs(t, err, ErrUnsupportedConfigFormat)
+	assert.ErrorIs(t, SaveAppConfig(path, &AppConfig{}), ErrUnsupportedConfigFormat)
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr), "nothing is written")
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "sentinel.yaml")
+	assert.Equal(t, yamlPath, ResolveConfigPath(yamlPath), "no config at all")
+
+	tomlPath := filepath.Join(dir, "sentinel.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte("maxretries = 1\n"), 0600))
+	assert.Equal(t, tomlPath, ResolveConfigPath(yamlPath))
+
+	require.NoError(t, os.WriteFile(yamlPath, []byte("maxretries: 1\n"), 0600))
+	assert.Equal(t, yamlPath, ResolveConfigPath(yamlPath), "the given path wins")
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// This file reads and writes TOML (https://toml.io/en/v1.0.0) config files with
+// github.com/BurntSushi/toml, as trees of map[string]interface{}, []interface{} and scalars,
+// which AppConfig is decoded from through its YAML form (see unmarshalConfig). Parsed integers
+// are int64 and floats float64; offset and local date-times are time.Time (local ones in UTC),
+// while local dates and times stay strings.
+
+// unmarshalTOML parses the TOML document data.
+func unmarshalTOML(data []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return normalizeTOML(doc).(map[string]interface{}), nil
+}
+
+// normalizeTOML converts the values decoded by toml.Decode to the forms unmarshalTOML documents:
+// arrays of tables become []interface{}, and local date-times, dates and times (time.Times in the
+// toml package's "datetime-local", "date-local" and "time-local" zones) become UTC times or strings.
+func normalizeTOML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeTOML(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeTOML(value)
+		}
+	case []map[string]interface{}:
+		tables := make([]interface{}, len(v))
+		for i, table := range v {
+			tables[i] = normalizeTOML(table)
+		}
+		return tables
+	case time.Time:
+		switch v.Location().String() {
+		case "datetime-local":
+			return time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+		case "date-local":
+			return v.Format("2006-01-02")
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		}
+	}
+	return v
+}
+
+// marshalTOML writes doc as a TOML document. Keys are sorted; nested tables and non-empty
+// arrays of tables are written as [table] and [[array]] sections after the plain values.
+func marshalTOML(doc map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
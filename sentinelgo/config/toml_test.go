@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalTOML(t *testing.T) {
+	doc, err := unmarshalTOML([]byte(`
+basic = "tab\there \"quoted\" \u00e9"
+literal = 'C:\path\no escapes'
+multi = """
+first line
+second line\
+    continued"""
+multiliteral = '''
+raw \n ''line'''''
+hex = 0xff
+underscored = 1_000_000
+negative = -17
+float = 6.5e-1
+infinite = -inf
+offset = 2030-01-02T03:04:05Z
+local = 2030-01-02 03:04:05.5
+date = 2030-01-02
+"quoted key" = true
+dotted.key = "value"
+inline = { a = 1, b = [ "x", 'y' ] }
+
+[table]
+nested.deep = false
+
+[[array]]
+name = "first"
+
+[array.sub]
+n = 1
+
+[[array]]
+name = "second"
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "tab\there \"quoted\" é", doc["basic"])
+	assert.Equal(t, `C:\path\no escapes`, doc["literal"])
+	assert.Equal(t, "first line\nsecond linecontinued", doc["multi"])
+	assert.Equal(t, "raw \\n ''line''", doc["multiliteral"])
+	assert.Equal(t, int64(255), doc["hex"])
+	assert.Equal(t, int64(1000000), doc["underscored"])
+	assert.Equal(t, int64(-17), doc["negative"])
+	assert.Equal(t, 0.65, doc["float"])
+	assert.Equal(t, math.Inf(-1), doc["infinite"])
+	assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), doc["offset"])
+	assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 5e8, time.UTC), doc["local"])
+	assert.Equal(t, "2030-01-02", doc["date"])
+	assert.Equal(t, true, doc["quoted key"])
+	assert.Equal(t, map[string]interface{}{"key": "value"}, doc["dotted"])
+	assert.Equal(t, map[string]interface{}{"a": int64(1), "b": []interface{}{"x", "y"}}, doc["inline"])
+	assert.Equal(t, map[string]interface{}{"nested": map[string]interface{}{"deep": false}}, doc["table"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "first", "sub": map[string]interface{}{"n": int64(1)}},
+		map[string]interface{}{"name": "second"},
+	}, doc["array"])
+}
+
+func TestUnmarshalTOML_Errors(t *testing.T) {
+	for doc, want := range map[string]string{
+		"a = \"unterminated\n":     "line 1 (last key \"a\"): strings cannot contain newlines",
+		"a = 1\na = 2\n":           "line 2 (last key \"a\"): Key 'a' has already been defined",
+		"a = 1\n[a]\n":             "line 2: Key 'a' has already been defined",
+		"a = 007\n":                "cannot have leading zeroes",
+		"a = 1 b = 2\n":            "but got 'b' instead",
+		"a = [1, 2\n":              "expected a comma (',') or array terminator (']')",
+		"[table\n":                 "expected '.' or ']' to end table name",
+		"a = \"bad \\q escape\"\n": "invalid escape in string",
+		"a = 2030-13-45\n":         "invalid datetime",
+		"= 1\n":                    "key name appears blank",
+	} {
+		_, err := unmarshalTOML([]byte(doc))
+		assert.ErrorContains(t, err, want, "%q", doc)
+	}
+}
+
+func TestMarshalTOML_RoundTrip(t *testing.T) {
+	doc := map[string]interface{}{
+		"string":   "line\nbreak \"quoted\" \\ \x01",
+		"Odd Key":  "needs quoting",
+		"int":      int64(-42),
+		"float":    2.0,
+		"small":    1e-7,
+		"bool":     true,
+		"time":     time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC),
+		"strings":  []interface{}{"a", "b"},
+		"empty":    []interface{}{},
+		"emptymap": map[string]interface{}{},
+		"table":    map[string]interface{}{"inner": map[string]interface{}{"x": int64(1)}, "y": "z"},
+		"tables": []interface{}{
+			map[string]interface{}{"name": "a", "sub": map[string]interface{}{"k": "v"}},
+			map[string]interface{}{"name": "b"},
+		},
+		"mixed": []interface{}{map[string]interface{}{"k": int64(1)}, int64(2)},
+	}
+	data, err := marshalTOML(doc)
+	require.NoError(t, err)
+	parsed, err := unmarshalTOML(data)
+	require.NoError(t, err, string(data))
+	assert.Equal(t, doc, parsed, string(data))
+
+	data, err = marshalTOML(map[string]interface{}{"a": map[string]interface{}{"b": nil, "c": int64(1)}})
+	require.NoError(t, err)
+	parsed, err = unmarshalTOML(data)
+	require.NoError(t, err, string(data))
+	assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"c": int64(1)}}, parsed, "TOML has no null value, so nil values are left out")
+}
+
+// FuzzTOMLRoundTrip checks that every document unmarshalTOML accepts is written by marshalTOML
+// in a form that reads back to the same document. The written forms are compared, rather than
+// the documents, because NaN is not equal to itself.
+func FuzzTOMLRoundTrip(f *testing.F) {
+	for _, seed := range []string{
+		"maxretries = 3\nrequesttimeout = \"30s\"\n",
+		"a = 'literal'\nb = \"\"\"\nmulti\"\"\"\nc = [1, 2.5, \"x\", { d = true }]\n",
+		"f = nan\ng = -inf\nh = 1e300\n",
+		"t = 2030-01-02T03:04:05.123Z\nl = 2030-01-02 03:04:05\nd = 2030-01-02\nlt = 03:04:05\n",
+		"[defaultheaders]\n\"User-Agent\" = \"Agent/1.0\"\n\n[[tables]]\nname = \"a\"\n[tables.sub]\nk = 1\n",
+		"\"odd key\" = 0x1F\ndotted.key.path = \"\\u00e9\\t\"\nempty = {}\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := unmarshalTOML(data)
+		if err != nil {
+			return
+		}
+		written, err := marshalTOML(doc)
+		if err != nil {
+			t.Fatalf("marshalTOML failed for %q: %v", data, err)
+		}
+		reparsed, err := unmarshalTOML(written)
+		if err != nil {
+			t.Fatalf("written document does not parse: %v\n%s", err, written)
+		}
+		rewritten, err := marshalTOML(reparsed)
+		if err != nil {
+			t.Fatalf("marshalTOML failed on the reparsed document: %v", err)
+		}
+		if !bytes.Equal(written, rewritten) {
+			t.Fatalf("round trip changed the document:\n%s\nbecame\n%s", written, rewritten)
+		}
+	})
+}
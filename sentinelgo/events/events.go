@@ -0,0 +1,362 @@
+// Package events publishes session and report events as a machine-readable JSON stream, so
+// external dashboards can follow SentinelGo without scraping the TUI. Serve listens on a TCP
+// address, streaming Server-Sent Events over HTTP, or on a Unix socket, streaming one JSON
+// object per line. Watch subscribes the server to a session's events.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sentinelgo/sentinelgo/session"
+)
+
+// Type identifies the kind of an Event.
+type Type string
+
+// Event types, in the order a session produces them.
+const (
+	SessionStarted Type = "session_started" // The session started (or restarted) running.
+	SessionState   Type = "session_state"   // The session was paused, resumed or is stopping.
+	ReportResult   Type = "report_result"   // A report finished, successfully or not.
+	SessionEnded   Type = "session_ended"   // The session completed, stopped, was aborted or failed.
+)
+
+// SSEPath is the HTTP path serving the Server-Sent Events stream.
+const SSEPath = "/events"
+
+// unixPrefix marks an address passed to Serve as a Unix socket path.
+const unixPrefix = "unix:"
+
+// clientBuffer is how many events are queued for each client. Events for a client whose queue
+// is full are dropped (see Server.Dropped) rather than stalling the sessions.
+const clientBuffer = 256
+
+// writeTimeout bounds each write to a Unix socket client, so a stuck client is disconnected.
+const writeTimeout = 10 * time.Second
+
+// Event is one entry of the stream. Session events carry the new state and, except for
+// SessionStarted, the previous one; ReportResult events carry the finished report. Every event
+// carries the session's progress when it was produced.
+type Event struct {
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+	Target    string    `json:"target"`
+	State     string    `json:"state,omitempty"`
+	PrevState string    `json:"prev_state,omitempty"`
+	Progress  Progress  `json:"progress"`
+	Report    *Report   `json:"report,omitempty"`
+}
+
+// Progress is the session's report counts.
+type Progress struct {
+	Total      int `json:"total"`
+	Attempted  int `json:"attempted"`
+	Successful int `json:"successful"`
+	Failed     int `json:"failed"`
+}
+
+// Report describes a finished report job.
+type Report struct {
+	JobID          string  `json:"job_id"`
+	Number         int     `json:"number"` // 1-based position of the report in the session.
+	Status         string  `json:"status"` // "success" or "failed".
+	Error          string  `json:"error,omitempty"`
+	LogID          string  `json:"log_id,omitempty"`
+	LatencyMS      float64 `json:"latency_ms,omitempty"`
+	ResponseStatus int     `json:"response_status,omitempty"`
+}
+
+// Server streams published events to every connected client. Clients only receive events
+// published after they connect.
+type Server struct {
+	listener net.Listener
+	http     *http.Server // Nil when serving a Unix socket.
+
+	mu      sync.Mutex
+	clients map[chan Event]struct{}
+	closed  bool
+	dropped atomic.Int64
+}
+
+// Serve starts an event server on addr and returns once it is listening. An addr of the form
+// "unix:/path/to/socket" serves JSON lines on that Unix socket, replacing a stale socket file
+// left by an earlier run; any other addr (e.g. "127.0.0.1:8090") is a TCP address serving
+// Server-Sent Events at SSEPath. Call Close to stop it.
+func Serve(addr string) (*Server, error) {
+	s := &Server{clients: make(map[chan Event]struct{})}
+	if strings.HasPrefix(addr, unixPrefix) {
+		path := strings.TrimPrefix(addr, unixPrefix)
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for event clients on unix socket '%s': %w", path, err)
+		}
+		s.listener = ln
+		go s.acceptLoop()
+		return s, nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for event clients on '%s': %w", addr, err)
+	}
+	s.listener = ln
+	mux := http.NewServeMux()
+	mux.HandleFunc(SSEPath, s.handleSSE)
+	s.http = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go s.http.Serve(ln)
+	return s, nil
+}
+
+// Addr returns the address the server listens on: "unix:" and the socket path, or the TCP
+// address (useful when Serve was given port 0).
+func (s *Server) Addr() string {
+	if s.http == nil {
+		return unixPrefix + s.listener.Addr().String()
+	}
+	return s.listener.Addr().String()
+}
+
+// Clients returns how many clients are connected.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Dropped returns how many events were not delivered to a client because its queue was full.
+func (s *Server) Dropped() int {
+	return int(s.dropped.Load())
+}
+
+// Publish sends e to every connected client without blocking.
+func (s *Server) Publish(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Watch publishes sess's events: a session event for every state transition and a
+// ReportResult for every finished report. It chains to any OnStateChange or OnJobComplete
+// callback already set, so call it before Start. Report results are delivered through
+// OnJobComplete, so they may arrive out of order, including after SessionEnded.
+func (s *Server) Watch(sess *session.Session) {
+	id, target := sess.ID, sess.TargetURL
+	prevStateChange := sess.OnStateChange
+	sess.OnStateChange = func(from session.SessionState, progress session.SessionProgress) {
+		if prevStateChange != nil {
+			prevStateChange(from, progress)
+		}
+		s.Publish(stateEvent(id, from, progress))
+	}
+	prevJobComplete := sess.OnJobComplete
+	sess.OnJobComplete = func(job session.ReportJob) {
+		if prevJobComplete != nil {
+			prevJobComplete(job)
+		}
+		s.Publish(reportEvent(id, target, job, sess.Progress()))
+	}
+}
+
+// Close stops accepting clients and disconnects the connected ones.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for ch := range s.clients {
+		delete(s.clients, ch)
+		close(ch)
+	}
+	s.mu.Unlock()
+	if s.http != nil {
+		return s.http.Close()
+	}
+	if err := s.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// subscribe registers a new client and returns its event queue, or nil if the server is closed.
+func (s *Server) subscribe() chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	ch := make(chan Event, clientBuffer)
+	s.clients[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe removes a client and closes its queue. It is safe to call more than once.
+func (s *Server) unsubscribe(ch chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[ch]; ok {
+		delete(s.clients, ch)
+		close(ch)
+	}
+}
+
+// handleSSE streams events to an HTTP client as Server-Sent Events until it disconnects.
+func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := s.subscribe()
+	if ch == nil {
+		http.Error(w, "event server closed", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribe(ch)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// acceptLoop serves Unix socket clients until the listener is closed.
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn writes events to a Unix socket client, one JSON object per line, until it
+// disconnects or the server closes. Anything the client sends is ignored.
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	ch := s.subscribe()
+	if ch == nil {
+		return
+	}
+	defer s.unsubscribe(ch)
+	go func() { // Notice a disconnect even while no events are published.
+		io.Copy(io.Discard, conn)
+		s.unsubscribe(ch)
+	}()
+	enc := json.NewEncoder(conn)
+	for e := range ch {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := enc.Encode(e); err != nil {
+			return
+		}
+	}
+}
+
+// stateEvent builds the event for a session's transition from the state from to progress.State.
+func stateEvent(sessionID string, from session.SessionState, progress session.SessionProgress) Event {
+	e := Event{
+		Type:      SessionState,
+		Time:      time.Now(),
+		SessionID: sessionID,
+		Target:    progress.Target,
+		State:     stateName(progress.State),
+		PrevState: stateName(from),
+		Progress:  progressOf(progress),
+	}
+	switch progress.State {
+	case session.Running:
+		if from != session.Paused {
+			e.Type = SessionStarted
+		}
+	case session.Completed, session.Stopped, session.Aborted, session.Failed:
+		e.Type = SessionEnded
+	}
+	return e
+}
+
+// reportEvent builds the ReportResult event for a finished job.
+func reportEvent(sessionID, target string, job session.ReportJob, progress session.SessionProgress) Event {
+	return Event{
+		Type:      ReportResult,
+		Time:      job.EndTime,
+		SessionID: sessionID,
+		Target:    target,
+		Progress:  progressOf(progress),
+		Report: &Report{
+			JobID:          job.ID,
+			Number:         job.ReportNumber,
+			Status:         job.Status,
+			Error:          job.Error,
+			LogID:          job.LogID,
+			LatencyMS:      float64(job.Latency) / float64(time.Millisecond),
+			ResponseStatus: job.ResponseStatus,
+		},
+	}
+}
+
+// progressOf returns the report counts of a session progress snapshot.
+func progressOf(p session.SessionProgress) Progress {
+	return Progress{Total: p.Total, Attempted: p.Attempted, Successful: p.Successful, Failed: p.Failed}
+}
+
+// stateName returns the lowercase name of state used in events.
+func stateName(state session.SessionState) string {
+	switch state {
+	case session.Idle:
+		return "idle"
+	case session.Running:
+		return "running"
+	case session.Paused:
+		return "paused"
+	case session.Stopping:
+		return "stopping"
+	case session.Stopped:
+		return "stopped"
+	case session.Completed:
+		return "completed"
+	case session.Aborted:
+		return "aborted"
+	case session.Failed:
+		return "failed"
+	}
+	return "unknown"
+}
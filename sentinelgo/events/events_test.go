@@ -0,0 +1,160 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/report"
+	"sentinelgo/sentinelgo/session"
+)
+
+// okReporter accepts every report.
+type okReporter struct{}
+
+func (okReporter) SendReport(ctx context.Context, targetURL, sessionID string) (*report.ReportResult, error) {
+	return &report.ReportResult{StatusCode: http.StatusOK, LogID: "log-1", Latency: time.Millisecond}, nil
+}
+
+// runWatchedSession runs a session of numReports reports watched by srv once a client is connected.
+func runWatchedSession(t *testing.T, srv *Server, numReports int) *session.Session {
+	t.Helper()
+	require.Eventually(t, func() bool { return srv.Clients() == 1 }, 5*time.Second, time.Millisecond)
+	s := session.NewSession(okReporter{}, "http://example.com/report", numReports)
+	srv.Watch(s)
+	require.NoError(t, s.Start())
+	go func() {
+		for range s.LogChannel {
+		}
+	}()
+	require.NoError(t, s.Wait(context.Background()))
+	return s
+}
+
+// collectRun reads events from next until the session has ended and numReports results arrived.
+func collectRun(t *testing.T, numReports int, next func() (Event, error)) []Event {
+	t.Helper()
+	var events []Event
+	ended, results := false, 0
+	for !ended || results < numReports {
+		e, err := next()
+		require.NoError(t, err)
+		events = append(events, e)
+		switch e.Type {
+		case SessionEnded:
+			ended = true
+		case ReportResult:
+			results++
+		}
+	}
+	return events
+}
+
+// assertRun checks the events of a completed session of numReports successful reports.
+func assertRun(t *testing.T, s *session.Session, numReports int, events []Event) {
+	t.Helper()
+	require.NotEmpty(t, events)
+	assert.Equal(t, SessionStarted, events[0].Type)
+	assert.Equal(t, "running", events[0].State)
+	var ended *Event
+	numbers := make(map[int]bool)
+	for i := range events {
+		e := events[i]
+		assert.Equal(t, s.ID, e.SessionID)
+		assert.Equal(t, "http://example.com/report", e.Target)
+		switch e.Type {
+		case ReportResult:
+			require.NotNil(t, e.Report)
+			assert.Equal(t, "success", e.Report.Status)
+			assert.Equal(t, "log-1", e.Report.LogID)
+			assert.Equal(t, http.StatusOK, e.Report.ResponseStatus)
+			numbers[e.Report.Number] = true
+		case SessionEnded:
+			ended = &e
+		}
+	}
+	assert.Len(t, numbers, numReports, "one result per report")
+	require.NotNil(t, ended)
+	assert.Equal(t, "completed", ended.State)
+	assert.Equal(t, "running", ended.PrevState)
+	assert.Equal(t, Progress{Total: numReports, Attempted: numReports, Successful: numReports}, ended.Progress)
+}
+
+func TestServe_SSE(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0")
+	require.NoError(t, err)
+	defer srv.Close()
+
+	resp, err := http.Get("http://" + srv.Addr() + SSEPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	s := runWatchedSession(t, srv, 3)
+	reader := bufio.NewReader(resp.Body)
+	events := collectRun(t, 3, func() (Event, error) {
+		var eventType string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return Event{}, err
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if strings.HasPrefix(line, "event: ") {
+				eventType = strings.TrimPrefix(line, "event: ")
+			} else if strings.HasPrefix(line, "data: ") {
+				var e Event
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+				assert.Equal(t, string(e.Type), eventType, "the SSE event name is the event type")
+				return e, err
+			}
+		}
+	})
+	assertRun(t, s, 3, events)
+}
+
+func TestServe_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	srv, err := Serve("unix:" + path)
+	require.NoError(t, err)
+	defer srv.Close()
+	assert.Equal(t, "unix:"+path, srv.Addr())
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := runWatchedSession(t, srv, 2)
+	dec := json.NewDecoder(conn)
+	events := collectRun(t, 2, func() (Event, error) {
+		var e Event
+		err := dec.Decode(&e)
+		return e, err
+	})
+	assertRun(t, s, 2, events)
+
+	// A client that disconnects is unsubscribed.
+	conn.Close()
+	require.Eventually(t, func() bool { return srv.Clients() == 0 }, 5*time.Second, time.Millisecond)
+}
+
+func TestServe_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	ln.(*net.UnixListener).SetUnlinkOnClose(false) // Leave the socket file behind, as a crash would.
+	require.NoError(t, ln.Close())
+
+	srv, err := Serve("unix:" + path)
+	require.NoError(t, err)
+	assert.NoError(t, srv.Close())
+}
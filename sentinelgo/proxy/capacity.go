@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+)
+
+// CapacityEstimate compares the number of healthy proxies a send rate needs with the pool.
+type CapacityEstimate struct {
+	TargetRPS   float64 // The desired send rate across the pool, in requests per second.
+	PerProxyRPS float64 // The rate one proxy can safely sustain.
+	Needed      int     // Healthy proxies needed for TargetRPS (see EstimateProxiesNeeded).
+	Healthy     int     // Healthy proxies in the pool.
+}
+
+// Sufficient reports whether the pool has enough healthy proxies for the target rate.
+func (e CapacityEstimate) Sufficient() bool {
+	return e.Healthy >= e.Needed
+}
+
+// Shortfall returns how many more healthy proxies the target rate needs, or 0 if it has enough.
+func (e CapacityEstimate) Shortfall() int {
+	if e.Sufficient() {
+		return 0
+	}
+	return e.Needed - e.Healthy
+}
+
+// EstimateProxiesNeeded returns how many proxies, each sending at most perProxyRPS requests per
+// second, are needed to send targetRPS requests per second in total: targetRPS / perProxyRPS,
+// rounded up. A targetRPS of 0 or less needs no proxies. perProxyRPS must be positive.
+func EstimateProxiesNeeded(targetRPS, perProxyRPS float64) (int, error) {
+	if math.IsNaN(targetRPS) || math.IsInf(targetRPS, 0) {
+		return 0, fmt.Errorf("invalid target rate %v", targetRPS)
+	}
+	if targetRPS <= 0 {
+		return 0, nil
+	}
+	if !(perProxyRPS > 0) || math.IsInf(perProxyRPS, 0) {
+		return 0, fmt.Errorf("per-proxy rate must be a positive number (got %v)", perProxyRPS)
+	}
+	// The small tolerance keeps float error (e.g., 0.3/0.1 = 2.9999999999999996) from costing a proxy.
+	needed := math.Ceil(targetRPS/perProxyRPS - 1e-9)
+	if needed > math.MaxInt32 {
+		return 0, fmt.Errorf("target rate %v at %v per proxy needs too many proxies", targetRPS, perProxyRPS)
+	}
+	return int(needed), nil
+}
+
+// EstimateCapacity compares the healthy proxies needed for targetRPS at perProxyRPS each (see
+// EstimateProxiesNeeded) with the healthy proxies in the pool.
+// The method is thread-safe.
+func (pm *ProxyManager) EstimateCapacity(targetRPS, perProxyRPS float64) (CapacityEstimate, error) {
+	needed, err := EstimateProxiesNeeded(targetRPS, perProxyRPS)
+	if err != nil {
+		return CapacityEstimate{}, err
+	}
+	estimate := CapacityEstimate{TargetRPS: targetRPS, PerProxyRPS: perProxyRPS, Needed: needed}
+	for _, status := range pm.PoolSnapshot() {
+		if status == "healthy" {
+			estimate.Healthy++
+		}
+	}
+	return estimate, nil
+}
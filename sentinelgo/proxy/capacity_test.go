@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateProxiesNeeded(t *testing.T) {
+	tests := []struct {
+		name                   string
+		targetRPS, perProxyRPS float64
+		want                   int
+	}{
+		{"exact multiple", 10, 2, 5},
+		{"rounds up", 10, 3, 4},
+		{"below one proxy's rate", 0.5, 2, 1},
+		{"float error does not round up", 0.3, 0.1, 3},
+		{"fractional rates", 2.5, 0.25, 10},
+		{"zero target needs none", 0, 2, 0},
+		{"negative target needs none", -1, 2, 0},
+		{"zero target with zero per-proxy rate", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateProxiesNeeded(tt.targetRPS, tt.perProxyRPS)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, perProxy := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		_, err := EstimateProxiesNeeded(10, perProxy)
+		assert.Error(t, err, "per-proxy rate %v", perProxy)
+	}
+	_, err := EstimateProxiesNeeded(math.NaN(), 1)
+	assert.Error(t, err)
+	_, err = EstimateProxiesNeeded(1e12, 1e-3)
+	assert.ErrorContains(t, err, "too many proxies")
+}
+
+func TestProxyManager_EstimateCapacity(t *testing.T) {
+	pm := NewProxyManager([]*ProxyInfo{
+		newTestProxy(t, "http://a.example.com:8080", "US", "healthy"),
+		newTestProxy(t, "http://b.example.com:8080", "US", "healthy"),
+		newTestProxy(t, "http://c.example.com:8080", "US", "unhealthy"),
+	}, StrategyRoundRobin, true)
+
+	estimate, err := pm.EstimateCapacity(5, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, estimate.Needed)
+	assert.Equal(t, 2, estimate.Healthy)
+	assert.False(t, estimate.Sufficient())
+	assert.Equal(t, 1, estimate.Shortfall())
+
+	estimate, err = pm.EstimateCapacity(4, 2)
+	require.NoError(t, err)
+	assert.True(t, estimate.Sufficient())
+	assert.Zero(t, estimate.Shortfall())
+
+	_, err = pm.EstimateCapacity(4, 0)
+	assert.Error(t, err)
+}
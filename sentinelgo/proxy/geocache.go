@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGeoCacheTTL is how long a cached GeoIP result is trusted when no TTL is given.
+const DefaultGeoCacheTTL = 7 * 24 * time.Hour
+
+// geoCacheEntry is one cached host -> region result, as stored on disk.
+type geoCacheEntry struct {
+	Region    string    `json:"region"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// GeoCache is a small on-disk cache of GeoIP results keyed by proxy host, consulted by
+// GeoCheckProxy before the GeoIP database or API so stable pools are not looked up again on
+// every run. Entries older than the TTL are treated as missing and dropped on Save.
+// The cache is safe for concurrent use.
+type GeoCache struct {
+	path    string
+	ttl     time.Duration
+	now     func() time.Time // Replaced in tests to expire entries without waiting.
+	mu      sync.Mutex
+	entries map[string]geoCacheEntry
+}
+
+// LoadGeoCache opens the cache stored at path. A missing file yields an empty cache that is
+// created on the first Save. A ttl of zero or less uses DefaultGeoCacheTTL.
+func LoadGeoCache(path string, ttl time.Duration) (*GeoCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultGeoCacheTTL
+	}
+	c := &GeoCache{path: path, ttl: ttl, now: time.Now, entries: make(map[string]geoCacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP cache '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoIP cache '%s': %w", path, err)
+	}
+	return c, nil
+}
+
+// Get returns the cached region for host. ok is false on a miss or if the entry has expired.
+func (c *GeoCache) Get(host string) (region string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[strings.ToLower(host)]
+	if !found || c.expiredLocked(entry) {
+		return "", false
+	}
+	return entry.Region, true
+}
+
+// Put records the region looked up for host.
+func (c *GeoCache) Put(host, region string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[strings.ToLower(host)] = geoCacheEntry{Region: region, FetchedAt: c.now()}
+}
+
+// Save writes the unexpired entries back to the cache file.
+func (c *GeoCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for host, entry := range c.entries {
+		if c.expiredLocked(entry) {
+			delete(c.entries, host)
+		}
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal GeoIP cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write GeoIP cache '%s': %w", c.path, err)
+	}
+	return nil
+}
+
+// expiredLocked reports whether entry is older than the cache's TTL. Callers must hold c.mu.
+func (c *GeoCache) expiredLocked(entry geoCacheEntry) bool {
+	return c.now().Sub(entry.FetchedAt) > c.ttl
+}
@@ -0,0 +1,356 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strings"
+)
+
+// ErrGeoIPNotConfigured is returned by GeoCheckProxy when a proxy's region is not cached and no
+// GeoIP lookup (e.g. a GeoIPDB, see AppConfig.GeoIPDatabase) is configured.
+var ErrGeoIPNotConfigured = errors.New("no GeoIP database configured")
+
+// ErrGeoIPNotFound is returned by GeoIPDB lookups for addresses the database has no country for.
+var ErrGeoIPNotFound = errors.New("address not found in GeoIP database")
+
+// mmdbMetadataMarker precedes the metadata section at the end of a MaxMind DB file.
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// mmdbDataSeparator is the size of the zeroed separator between the search tree and the data section.
+const mmdbDataSeparator = 16
+
+// GeoIPDB is a MaxMind DB (.mmdb) file, such as GeoLite2-Country or GeoLite2-City, loaded into
+// memory to look up the country of IP addresses. It is safe for concurrent use.
+type GeoIPDB struct {
+	tree       []byte // The search tree.
+	data       []byte // The data section.
+	nodeCount  uint
+	recordSize uint // Bits per record: 24, 28 or 32.
+	ipv4Start  uint // Node at which IPv4 lookups start in an IPv6 tree.
+	ipVersion  int
+}
+
+// OpenGeoIPDB reads the MaxMind DB file at path.
+func OpenGeoIPDB(path string) (*GeoIPDB, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database '%s': %w", path, err)
+	}
+	db, err := parseGeoIPDB(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoIP database '%s': %w", path, err)
+	}
+	return db, nil
+}
+
+// parseGeoIPDB parses a MaxMind DB file held in buf.
+func parseGeoIPDB(buf []byte) (*GeoIPDB, error) {
+	markerAt := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if markerAt < 0 {
+		return nil, errors.New("metadata marker not found")
+	}
+	metaSection := buf[markerAt+len(mmdbMetadataMarker):]
+	meta, _, err := (&mmdbDecoder{section: metaSection}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	metaMap, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("metadata is not a map")
+	}
+	nodeCount, okNodes := metaMap["node_count"].(uint64)
+	recordSize, okSize := metaMap["record_size"].(uint64)
+	ipVersion, okVersion := metaMap["ip_version"].(uint64)
+	if !okNodes || !okSize || !okVersion {
+		return nil, errors.New("metadata lacks node_count, record_size or ip_version")
+	}
+	if recordSize != 24 && recordSize != 28 && recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d", recordSize)
+	}
+	if ipVersion != 4 && ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported IP version %d", ipVersion)
+	}
+	treeSize := nodeCount * recordSize / 4 // Two records per node.
+	if treeSize+mmdbDataSeparator > uint64(markerAt) {
+		return nil, errors.New("search tree exceeds the file")
+	}
+
+	db := &GeoIPDB{
+		tree:       buf[:treeSize],
+		data:       buf[treeSize+mmdbDataSeparator : markerAt],
+		nodeCount:  uint(nodeCount),
+		recordSize: uint(recordSize),
+		ipVersion:  int(ipVersion),
+	}
+	if db.ipVersion == 6 { // IPv4 addresses live under ::/96, after 96 zero bits.
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.record(node, 0)
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of node.
+func (db *GeoIPDB) record(node, bit uint) uint {
+	switch db.recordSize {
+	case 24:
+		b := db.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := db.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(db.tree[node*8+bit*4:]))
+	}
+}
+
+// Country returns the ISO-3166 alpha-2 country code of ip: the record's country, or its
+// registered country if it has none. It returns an error wrapping ErrGeoIPNotFound if the
+// database has no country for ip.
+func (db *GeoIPDB) Country(ip net.IP) (string, error) {
+	bits, node := ip.To4(), uint(0)
+	if bits != nil {
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	} else if bits = ip.To16(); bits == nil || db.ipVersion == 4 {
+		return "", fmt.Errorf("%w: %s", ErrGeoIPNotFound, ip)
+	}
+	for i := 0; i < len(bits)*8 && node < db.nodeCount; i++ {
+		node = db.record(node, uint(bits[i/8]>>(7-i%8))&1)
+	}
+	if node <= db.nodeCount { // Equal to nodeCount means no data.
+		return "", fmt.Errorf("%w: %s", ErrGeoIPNotFound, ip)
+	}
+	offset := node - db.nodeCount - mmdbDataSeparator
+	value, _, err := (&mmdbDecoder{section: db.data}).decode(offset)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode GeoIP record for %s: %w", ip, err)
+	}
+	record, _ := value.(map[string]interface{})
+	for _, key := range []string{"country", "registered_country"} {
+		if country, ok := record[key].(map[string]interface{}); ok {
+			if code, ok := country["iso_code"].(string); ok && code != "" {
+				return strings.ToUpper(code), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrGeoIPNotFound, ip)
+}
+
+// Lookup returns the country code of host, an IP address or a name resolved with DNS (the first
+// address with a country wins). The DNS lookup is abandoned once ctx is done. It is a GeoLookupFunc.
+func (db *GeoIPDB) Lookup(ctx context.Context, host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return db.Country(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", host, err)
+	}
+	err = fmt.Errorf("%w: '%s' has no addresses", ErrGeoIPNotFound, host)
+	for _, addr := range addrs {
+		var country string
+		if country, err = db.Country(addr.IP); err == nil {
+			return country, nil
+		}
+	}
+	return "", err
+}
+
+// mmdbDecoder decodes values of a MaxMind DB data or metadata section.
+type mmdbDecoder struct {
+	section  []byte
+	visiting map[uint]bool // Pointer targets being decoded, to reject cycles.
+}
+
+// Data field types of the MaxMind DB format.
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+// decode decodes the value at offset, following pointers, and returns it with the offset of the
+// next value. Maps decode to map[string]interface{}, arrays to []interface{}, unsigned and
+// signed integers to uint64 and int64, doubles and floats to float64, strings to string and
+// bytes to []byte. Pointers to pointers, which the format forbids, and pointers back into a
+// value being decoded are errors, so a corrupt file cannot make decoding loop forever.
+func (d *mmdbDecoder) decode(offset uint) (interface{}, uint, error) {
+	ctrl, err := d.byteAt(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset++
+	typ := uint(ctrl >> 5)
+	if typ == mmdbPointer {
+		target, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		targetCtrl, err := d.byteAt(target)
+		if err != nil {
+			return nil, 0, err
+		}
+		if uint(targetCtrl>>5) == mmdbPointer {
+			return nil, 0, fmt.Errorf("pointer at offset %d points to another pointer", offset-1)
+		}
+		if d.visiting[target] {
+			return nil, 0, fmt.Errorf("pointer cycle at offset %d", offset-1)
+		}
+		if d.visiting == nil {
+			d.visiting = make(map[uint]bool)
+		}
+		d.visiting[target] = true
+		value, _, err := d.decode(target)
+		delete(d.visiting, target)
+		return value, next, err
+	}
+	if typ == mmdbExtended {
+		ext, err := d.byteAt(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		typ, offset = 7+uint(ext), offset+1
+	}
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		extra := size - 28 // 1, 2 or 3 more size bytes.
+		b, err := d.bytes(offset, extra)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += extra
+		n := uint(0)
+		for _, c := range b {
+			n = n<<8 | uint(c)
+		}
+		size = [...]uint{29, 285, 65821}[extra-1] + n
+	}
+
+	switch typ {
+	case mmdbMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			keyStr, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("map key at offset %d is not a string", offset)
+			}
+			if m[keyStr], offset, err = d.decode(next); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case mmdbArray:
+		a := make([]interface{}, size)
+		for i := range a {
+			if a[i], offset, err = d.decode(offset); err != nil {
+				return nil, 0, err
+			}
+		}
+		return a, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+	switch typ {
+	case mmdbString:
+		return string(b), offset, nil
+	case mmdbBytes, mmdbUint128:
+		return append([]byte(nil), b...), offset, nil
+	case mmdbDouble, mmdbFloat:
+		if typ == mmdbDouble && size == 8 {
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+		}
+		if typ == mmdbFloat && size == 4 {
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+		}
+		return nil, 0, fmt.Errorf("invalid float size %d at offset %d", size, offset-size)
+	case mmdbUint16, mmdbUint32, mmdbUint64, mmdbInt32:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid integer size %d at offset %d", size, offset-size)
+		}
+		n := uint64(0)
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		if typ == mmdbInt32 {
+			return int64(int32(uint32(n))), offset, nil
+		}
+		return n, offset, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %d at offset %d", typ, offset-size)
+}
+
+// pointer decodes the pointer whose control byte is ctrl and whose remaining bytes start at
+// offset, returning the offset it points to and the offset after the pointer.
+func (d *mmdbDecoder) pointer(ctrl byte, offset uint) (target, next uint, err error) {
+	size := uint(ctrl>>3)&0x3 + 1
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	n := uint(0)
+	if size < 4 {
+		n = uint(ctrl & 0x7) // The low control bits are the pointer's high bits.
+	}
+	for _, c := range b {
+		n = n<<8 | uint(c)
+	}
+	switch size {
+	case 2:
+		n += 2048
+	case 3:
+		n += 526336
+	}
+	return n, offset + size, nil
+}
+
+// byteAt returns the byte at offset.
+func (d *mmdbDecoder) byteAt(offset uint) (byte, error) {
+	if offset >= uint(len(d.section)) {
+		return 0, fmt.Errorf("offset %d out of range", offset)
+	}
+	return d.section[offset], nil
+}
+
+// bytes returns the n bytes at offset.
+func (d *mmdbDecoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.section)) {
+		return nil, fmt.Errorf("%d bytes at offset %d out of range", n, offset)
+	}
+	return d.section[offset : offset+n], nil
+}
@@ -2,8 +2,11 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,11 +15,19 @@ import (
 // It should be a reliable, fast, and lightweight endpoint. httpbin.org/get reflects the request's origin.
 const defaultHealthCheckURL = "http://httpbin.org/get"
 
+// ErrCheckedRecently is reported by the manager's batch checks for a proxy skipped under its
+// minimum recheck interval (see ProxyManager.SetMinRecheckInterval) whose cached status is not
+// "healthy".
+var ErrCheckedRecently = errors.New("proxy checked too recently")
+
 // CheckProxyHealth attempts to make a lightweight HTTP GET request via the given proxy
 // to a specified health check URL (or a default one).
 // It updates the proxy's `HealthStatus`, `Latency`, and `LastChecked` fields based on the outcome.
 //
 // Parameters:
+//   - ctx: Cancels the check; the in-flight request is torn down. If the check fails because ctx
+//     was cancelled, the proxy's status is left unchanged (the check was abandoned, not failed)
+//     and ctx.Err() is returned.
 //   - proxy: A pointer to the ProxyInfo struct for the proxy to be checked. This struct will be updated.
 //   - timeout: The maximum duration to wait for the health check request to complete.
 //   - healthCheckURL (optional): A variadic string. If provided, the first non-empty string
@@ -27,10 +38,11 @@ const defaultHealthCheckURL = "http://httpbin.org/get"
 //   - An error if the proxy is unhealthy (e.g., request error, timeout, non-200 status code),
 //     or if the proxy's URL is nil. The error message provides context about the failure.
 //
-// The `proxy.HealthStatus` is set to "healthy" or "unhealthy".
+// The `proxy.HealthStatus` is set to "healthy" or "unhealthy"; pinned proxies are never marked
+// unhealthy, though the error is still returned.
 // `proxy.LastChecked` is always updated to the current time.
 // `proxy.Latency` records the duration of the health check request.
-func CheckProxyHealth(proxy *ProxyInfo, timeout time.Duration, healthCheckURL ...string) error {
+func CheckProxyHealth(ctx context.Context, proxy *ProxyInfo, timeout time.Duration, healthCheckURL ...string) error {
 	checkURL := defaultHealthCheckURL
 	if len(healthCheckURL) > 0 && healthCheckURL[0] != "" {
 		checkURL = healthCheckURL[0]
@@ -41,11 +53,10 @@ func CheckProxyHealth(proxy *ProxyInfo, timeout time.Duration, healthCheckURL ..
 		return fmt.Errorf("cannot check health of a nil ProxyInfo")
 	}
 	if proxy.URL == nil {
-		proxy.HealthStatus = "unhealthy" // Mark as unhealthy if URL is nil
+		markUnhealthy(proxy) // Mark as unhealthy if URL is nil
 		proxy.LastChecked = time.Now()
 		return fmt.Errorf("proxy '%s' (source: %s) has a nil URL", proxy.OriginalString, proxy.Source)
 	}
-
 	// Create an HTTP client configured to use the proxy and the specified timeout.
 	client := &http.Client{
 		Transport: &http.Transport{Proxy: http.ProxyURL(proxy.URL)},
@@ -53,21 +64,24 @@ func CheckProxyHealth(proxy *ProxyInfo, timeout time.Duration, healthCheckURL ..
 	}
 
 	startTime := time.Now()
-	// Create a new request with context to allow for cancellation if needed, although client.Timeout is primary.
-	req, err := http.NewRequestWithContext(context.Background(), "GET", checkURL, nil)
+	// Tie the request to ctx, so cancelling it tears down an in-flight check.
+	req, err := http.NewRequestWithContext(ctx, "GET", checkURL, nil)
 	if err != nil {
-		proxy.HealthStatus = "unhealthy"
+		markUnhealthy(proxy)
 		proxy.LastChecked = time.Now()
 		return fmt.Errorf("failed to create health check request for proxy '%s' to URL '%s': %w", proxy.OriginalString, checkURL, err)
 	}
 
 	// Perform the HTTP GET request.
 	resp, err := client.Do(req)
-	proxy.LastChecked = time.Now()      // Update last checked time regardless of outcome.
+	proxy.LastChecked = time.Now()        // Update last checked time regardless of outcome.
 	proxy.Latency = time.Since(startTime) // Record latency.
 
 	if err != nil {
-		proxy.HealthStatus = "unhealthy"
+		if ctx.Err() != nil { // Abandoned by the caller; not a verdict on the proxy.
+			return ctx.Err()
+		}
+		markUnhealthy(proxy)
 		return fmt.Errorf("health check for proxy '%s' to URL '%s' failed: %w", proxy.OriginalString, checkURL, err)
 	}
 	defer resp.Body.Close() // Ensure the response body is closed.
@@ -75,32 +89,76 @@ func CheckProxyHealth(proxy *ProxyInfo, timeout time.Duration, healthCheckURL ..
 	// Check if the status code indicates a healthy proxy.
 	if resp.StatusCode == http.StatusOK {
 		proxy.HealthStatus = "healthy"
+		proxy.NeedsRecheck = false
 	} else {
-		proxy.HealthStatus = "unhealthy"
+		markUnhealthy(proxy)
 		return fmt.Errorf("health check for proxy '%s' to URL '%s' returned non-200 status: %d %s", proxy.OriginalString, checkURL, resp.StatusCode, resp.Status)
 	}
 
 	return nil
 }
 
+// markUnhealthy records a failed health check on proxy. Pinned proxies keep their current status.
+func markUnhealthy(proxy *ProxyInfo) {
+	proxy.NeedsRecheck = false // The check happened, so the stale flag no longer applies.
+	if !proxy.Pinned {
+		proxy.HealthStatus = "unhealthy"
+	}
+}
+
+// StatusTransition records a proxy whose health status changed during a batch check.
+type StatusTransition struct {
+	Proxy string `json:"proxy"` // The proxy's URL without credentials (see LogIdentifier).
+	From  string `json:"from"`  // Status before the check.
+	To    string `json:"to"`    // Status after the check.
+}
+
+// BatchCheckResult summarizes a batch health check, e.g. for the TUI to report once the check
+// has finished. It holds no pointers into the pool, so it can be passed around and serialized.
+type BatchCheckResult struct {
+	Checked     int                `json:"checked"`               // Proxies whose check was started.
+	Healthy     int                `json:"healthy"`               // Checked proxies that were "healthy" afterwards.
+	Revived     int                `json:"revived,omitempty"`     // Dead proxies restored by RevivalCheck, if the caller ran one.
+	Transitions []StatusTransition `json:"transitions,omitempty"` // Status changes, sorted by proxy.
+	Elapsed     time.Duration      `json:"elapsed"`               // Wall time of the whole batch.
+}
+
 // BatchCheckProxies concurrently checks the health of a list of proxies.
 // It uses a specified number of goroutines (`concurrency`) to perform checks in parallel.
 //
 // Parameters:
+//   - ctx: Cancels the batch: no new checks are launched once it is done, and in-flight checks are
+//     torn down, leaving those proxies' statuses unchanged.
 //   - proxies: A slice of `*ProxyInfo` structs to be checked. Each struct is updated by `CheckProxyHealth`.
 //   - checkTimeout: The timeout duration for each individual proxy health check.
 //   - concurrency: The maximum number of concurrent health check goroutines. If less than 1, it defaults to 1.
 //   - healthCheckURL (optional): The URL(s) to use for health checks, passed to `CheckProxyHealth`.
 //
 // This function logs the outcome of each health check (success or failure with error details)
-// to standard output using `fmt.Printf`. It does not return aggregated results or errors directly,
-// relying on the updates to the `ProxyInfo` structs and the console logs for feedback.
-func BatchCheckProxies(proxies []*ProxyInfo, checkTimeout time.Duration, concurrency int, healthCheckURL ...string) {
+// to standard output using `fmt.Printf`. Besides the updates to the `ProxyInfo` structs, it
+// returns a BatchCheckResult summarizing the batch.
+func BatchCheckProxies(ctx context.Context, proxies []*ProxyInfo, checkTimeout time.Duration, concurrency int, healthCheckURL ...string) BatchCheckResult {
+	checkURL := ""
+	if len(healthCheckURL) > 0 {
+		checkURL = healthCheckURL[0]
+	}
+	return batchCheck(ctx, proxies, checkTimeout, concurrency, 0, func(*ProxyInfo) string { return checkURL })
+}
+
+// batchCheck implements BatchCheckProxies, checking each proxy against urlFor(proxy).
+// An empty URL means defaultHealthCheckURL. If minRecheck is positive, proxies checked more
+// recently than that are skipped (see checkedRecently).
+func batchCheck(ctx context.Context, proxies []*ProxyInfo, checkTimeout time.Duration, concurrency int, minRecheck time.Duration, urlFor func(*ProxyInfo) string) BatchCheckResult {
 	if concurrency <= 0 {
 		concurrency = 1 // Ensure at least one worker goroutine.
 	}
+	start := time.Now()
 
-	var wg sync.WaitGroup
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex // Protects result.
+		result BatchCheckResult
+	)
 	// Semaphore to limit the number of concurrent goroutines.
 	semaphore := make(chan struct{}, concurrency)
 
@@ -109,14 +167,33 @@ func BatchCheckProxies(proxies []*ProxyInfo, checkTimeout time.Duration, concurr
 			fmt.Printf("Skipping health check for a nil ProxyInfo entry.\n")
 			continue
 		}
+		select {
+		case semaphore <- struct{}{}: // Acquire a slot in the semaphore.
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil { // Cancelled; launch no further checks.
+			break
+		}
 		wg.Add(1)
-		semaphore <- struct{}{} // Acquire a slot in the semaphore.
+		result.Checked++
 
 		go func(proxyToCheck *ProxyInfo) {
 			defer wg.Done()                // Signal completion for this goroutine.
 			defer func() { <-semaphore }() // Release the slot in the semaphore.
 
-			err := CheckProxyHealth(proxyToCheck, checkTimeout, healthCheckURL...)
+			before := proxyToCheck.HealthStatus
+			skip, err := checkedRecently(proxyToCheck, minRecheck)
+			if !skip {
+				err = CheckProxyHealth(ctx, proxyToCheck, checkTimeout, urlFor(proxyToCheck))
+			}
+			mu.Lock()
+			if proxyToCheck.HealthStatus == "healthy" {
+				result.Healthy++
+			}
+			if proxyToCheck.HealthStatus != before {
+				result.Transitions = append(result.Transitions, StatusTransition{Proxy: transitionID(proxyToCheck), From: before, To: proxyToCheck.HealthStatus})
+			}
+			mu.Unlock()
 			// Log the result of the health check.
 			// In a more complex application, this might send results to a channel or use a structured logger.
 			if err != nil {
@@ -130,32 +207,295 @@ func BatchCheckProxies(proxies []*ProxyInfo, checkTimeout time.Duration, concurr
 	}
 
 	wg.Wait() // Wait for all health check goroutines to complete.
+	sort.Slice(result.Transitions, func(i, j int) bool { return result.Transitions[i].Proxy < result.Transitions[j].Proxy })
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// checkedRecently reports whether proxy was checked less than minRecheck ago, in which case it
+// keeps its cached result rather than being checked again, so overlapping startup, periodic and
+// manual checks do not hammer it. The error is nil if the cached status is "healthy", otherwise
+// it wraps ErrCheckedRecently. Proxies flagged with NeedsRecheck, or never checked, are not skipped.
+func checkedRecently(proxy *ProxyInfo, minRecheck time.Duration) (bool, error) {
+	since := time.Since(proxy.LastChecked)
+	if minRecheck <= 0 || proxy.LastChecked.IsZero() || proxy.NeedsRecheck || since >= minRecheck {
+		return false, nil
+	}
+	if proxy.HealthStatus == "healthy" {
+		return true, nil
+	}
+	return true, fmt.Errorf("%w: proxy '%s' was checked %v ago and is %s", ErrCheckedRecently, proxy.OriginalString, since.Round(time.Millisecond), proxy.HealthStatus)
+}
+
+// transitionID returns how p is named in a StatusTransition: its URL without credentials, or
+// its original string if it has no URL.
+func transitionID(p *ProxyInfo) string {
+	if p.URL == nil {
+		return p.OriginalString
+	}
+	return LogIdentifier(p.URL.String(), LogIDHost)
+}
+
+// HealthCheckURLFor returns the health check URL for p: the entry of RegionHealthCheckURLs
+// matching p's region (case-insensitively), or "" to use the default URL. p is read without
+// pm.mu, so pass a copy rather than a pool entry. The method is thread-safe.
+func (pm *ProxyManager) HealthCheckURLFor(p *ProxyInfo) string {
+	if p == nil || p.Region == "" {
+		return ""
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for region, checkURL := range pm.RegionHealthCheckURLs {
+		if strings.EqualFold(region, p.Region) {
+			return checkURL
+		}
+	}
+	return ""
+}
+
+// CheckPoolHealth runs CheckProxies over the whole pool. Dead proxies (StatusDead) are skipped;
+// use RevivalCheck for them. Quarantined proxies (StatusQuarantined) are skipped too, since they
+// stay out of rotation until released by hand. It returns the batch's result.
+func (pm *ProxyManager) CheckPoolHealth(checkTimeout time.Duration, concurrency int) BatchCheckResult {
+	pm.mu.Lock()
+	var proxies []*ProxyInfo
+	for _, p := range pm.Proxies {
+		if p != nil && p.HealthStatus != StatusDead && p.HealthStatus != StatusQuarantined {
+			proxies = append(proxies, p)
+		}
+	}
+	pm.mu.Unlock()
+	return pm.CheckProxies(context.Background(), proxies, checkTimeout, concurrency)
+}
+
+// CheckProxies checks proxies of the pool like BatchCheckProxies, each against the URL for its
+// region (see HealthCheckURLFor) so checks hit a nearby endpoint. The checks run on copies taken
+// under pm.mu, and their results are applied to the pool's entries under pm.mu, so sessions can
+// keep selecting and updating proxies meanwhile. A status changed by something else while its
+// check ran (e.g. a proxy marked dead by failed reports) is kept, and so is a quarantine
+// (StatusQuarantined), which only a manual status change lifts. It returns the batch's result.
+func (pm *ProxyManager) CheckProxies(ctx context.Context, proxies []*ProxyInfo, checkTimeout time.Duration, concurrency int) BatchCheckResult {
+	pm.mu.Lock()
+	var checked, probes []*ProxyInfo
+	var before []string // Status of each checked proxy when its copy was taken.
+	for _, p := range proxies {
+		if p != nil {
+			probe := *p
+			checked = append(checked, p)
+			probes = append(probes, &probe)
+			before = append(before, p.HealthStatus)
+		}
+	}
+	minRecheck := pm.minRecheckInterval
+	pm.mu.Unlock()
+
+	result := batchCheck(ctx, probes, checkTimeout, concurrency, minRecheck, pm.HealthCheckURLFor)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for i, p := range checked {
+		p.LastChecked = probes[i].LastChecked
+		p.Latency = probes[i].Latency
+		if p.HealthStatus == before[i] && p.HealthStatus != StatusQuarantined {
+			p.HealthStatus = probes[i].HealthStatus
+			p.NeedsRecheck = probes[i].NeedsRecheck
+		}
+	}
+	return result
+}
+
+// CheckProxy checks the single pool proxy p with CheckProxies and returns a copy of p with the
+// result applied, so the caller can read the outcome without racing later updates.
+func (pm *ProxyManager) CheckProxy(ctx context.Context, p *ProxyInfo, checkTimeout time.Duration) ProxyInfo {
+	pm.CheckProxies(ctx, []*ProxyInfo{p}, checkTimeout, 1)
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return *p
+}
+
+// RevivalCheck re-tests the dead proxies in the pool (see MaxConsecutiveFailures) like
+// CheckPoolHealth and restores those that pass to "healthy" with their failure count reset.
+// Proxies that fail again stay dead. It returns the revived proxies.
+func (pm *ProxyManager) RevivalCheck(checkTimeout time.Duration, concurrency int) []*ProxyInfo {
+	// Check copies, so a failed check does not demote a dead proxy to "unhealthy" and the
+	// pool's entries are only changed under pm.mu.
+	pm.mu.Lock()
+	var dead, probes []*ProxyInfo
+	for _, p := range pm.Proxies {
+		if p != nil && p.HealthStatus == StatusDead {
+			probe := *p
+			dead = append(dead, p)
+			probes = append(probes, &probe)
+		}
+	}
+	minRecheck := pm.minRecheckInterval
+	pm.mu.Unlock()
+	if len(dead) == 0 {
+		return nil
+	}
+
+	batchCheck(context.Background(), probes, checkTimeout, concurrency, minRecheck, pm.HealthCheckURLFor)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	var revived []*ProxyInfo
+	for i, p := range dead {
+		p.LastChecked = probes[i].LastChecked
+		p.Latency = probes[i].Latency
+		if p.HealthStatus == StatusDead && probes[i].HealthStatus == "healthy" {
+			p.HealthStatus = "healthy"
+			p.NeedsRecheck = false
+			p.ConsecutiveFailures = 0
+			revived = append(revived, p)
+		}
+	}
+	return revived
 }
 
-// GeoCheckProxy is a placeholder for future Geo-IP lookup functionality.
-// Currently, it does not perform any action or modify the proxy.
+// FindHealthyProxies checks proxies concurrently like BatchCheckProxies, but stops as soon as
+// targetHealthy healthy proxies have been found: no new checks are launched and in-flight checks
+// are cancelled. This makes "give me N usable proxies" fast on large pools. Cancelled checks leave
+// the proxy's status unchanged. A targetHealthy of 0 or less checks every proxy.
 //
 // Parameters:
-//   - proxy: A pointer to the ProxyInfo struct. Its Region field might be populated by this function in the future.
-//
-// Returns:
-//   - `nil` (currently, as it's a placeholder).
+//   - ctx: Cancels the whole search early; the proxies found so far are returned.
+//   - proxies: The proxies to check. Each checked struct is updated by the health check.
+//   - targetHealthy: Number of healthy proxies after which the search stops.
+//   - checkTimeout: The timeout duration for each individual proxy health check.
+//   - concurrency: The maximum number of concurrent checks. If less than 1, it defaults to 1.
+//   - healthCheckURL (optional): The URL to use for health checks, passed to the health check.
 //
-// Example future use:
-//  if proxy.Region == "" {
-//      region, err := someGeoIPService.Lookup(proxy.URL.Hostname())
-//      if err == nil { proxy.Region = region }
-//  }
-func GeoCheckProxy(proxy *ProxyInfo) error {
-	// Placeholder: Actual Geo-IP lookup logic would be implemented here.
-	// This could involve calling an external API or using a local GeoIP database.
-	// For example:
-	// if proxy != nil && proxy.Region == "" && proxy.URL != nil {
-	//     // hostname, _, err := net.SplitHostPort(proxy.URL.Host) // More robust way to get host
-	//     // if err != nil { hostname = proxy.URL.Host }
-	//     // Look up 'hostname' via a Geo-IP service/database.
-	//     // proxy.Region = "Fetched-Region" // Example update
-	//     // fmt.Printf("Geo-check (placeholder) for proxy %s. Current region: %s\n", proxy.OriginalString, proxy.Region)
-	// }
+// Returns the healthy proxies found, in the order their checks completed (at most targetHealthy).
+func FindHealthyProxies(ctx context.Context, proxies []*ProxyInfo, targetHealthy int, checkTimeout time.Duration, concurrency int, healthCheckURL ...string) []*ProxyInfo {
+	if concurrency <= 0 {
+		concurrency = 1 // Ensure at least one worker goroutine.
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex // Protects healthy.
+		healthy []*ProxyInfo
+	)
+	// Semaphore to limit the number of concurrent goroutines.
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, p := range proxies {
+		if p == nil {
+			continue
+		}
+		select {
+		case semaphore <- struct{}{}: // Acquire a slot in the semaphore.
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil { // Target reached or caller cancelled; launch no further checks.
+			break
+		}
+		wg.Add(1)
+
+		go func(proxyToCheck *ProxyInfo) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // Release the slot in the semaphore.
+
+			if err := CheckProxyHealth(ctx, proxyToCheck, checkTimeout, healthCheckURL...); err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if targetHealthy > 0 && len(healthy) >= targetHealthy {
+				return // Finished after the target was already met.
+			}
+			healthy = append(healthy, proxyToCheck)
+			if targetHealthy > 0 && len(healthy) >= targetHealthy {
+				cancel() // Target met: cancel in-flight checks.
+			}
+		}(p)
+	}
+
+	wg.Wait()
+	return healthy
+}
+
+// GeoLookupFunc resolves a proxy host to a region code, e.g. via a local GeoIP database (see
+// GeoIPDB.Lookup) or an external API. It should give up once ctx is done.
+type GeoLookupFunc func(ctx context.Context, host string) (string, error)
+
+// GeoCheckProxy fills in the region of a proxy that has none. The cache, if non-nil, is consulted
+// first; on a miss the host is resolved with lookup, which is passed ctx, and the result is cached.
+// With a nil lookup, a cache miss returns ErrGeoIPNotConfigured. Proxies that already have a
+// region are left unchanged.
+func GeoCheckProxy(ctx context.Context, proxy *ProxyInfo, lookup GeoLookupFunc, cache *GeoCache) error {
+	if proxy == nil || proxy.URL == nil || proxy.Region != "" {
+		return nil
+	}
+	host := proxy.URL.Hostname()
+	if cache != nil {
+		if region, ok := cache.Get(host); ok {
+			proxy.Region = region
+			return nil
+		}
+	}
+	if lookup == nil {
+		return fmt.Errorf("cannot look up the region of '%s': %w", host, ErrGeoIPNotConfigured)
+	}
+	region, err := lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("GeoIP lookup for '%s' failed: %w", host, err)
+	}
+	if cache != nil {
+		cache.Put(host, region)
+	}
+	proxy.Region = region
 	return nil
 }
+
+// BatchGeoCheck runs GeoCheckProxy concurrently over proxies, like BatchCheckProxies does for
+// health checks: at most `concurrency` lookups run at once (at least 1), and no new lookups are
+// launched once ctx is done, which is also passed to lookup. Failed lookups are logged to standard output. It returns how many
+// proxies had their region filled in.
+func BatchGeoCheck(ctx context.Context, proxies []*ProxyInfo, concurrency int, lookup GeoLookupFunc, cache *GeoCache) int {
+	if concurrency <= 0 {
+		concurrency = 1 // Ensure at least one worker goroutine.
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex // Protects located.
+		located int
+	)
+	// Semaphore to limit the number of concurrent goroutines.
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, p := range proxies {
+		if p == nil || p.URL == nil || p.Region != "" {
+			continue
+		}
+		select {
+		case semaphore <- struct{}{}: // Acquire a slot in the semaphore.
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil { // Cancelled; launch no further lookups.
+			break
+		}
+		wg.Add(1)
+
+		go func(proxyToCheck *ProxyInfo) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // Release the slot in the semaphore.
+
+			if err := GeoCheckProxy(ctx, proxyToCheck, lookup, cache); err != nil {
+				fmt.Printf("GeoIP check for proxy '%s' failed: %v\n", proxyToCheck.URL.Host, err)
+				return
+			}
+			if proxyToCheck.Region == "" {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			located++
+		}(p)
+	}
+
+	wg.Wait()
+	return located
+}
@@ -0,0 +1,392 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProxyHealth_PinnedRetainsHealthy(t *testing.T) {
+	// The server stands in for the proxy and answers every forwarded request with a 502.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	pinned := newTestProxy(t, server.URL, "", "healthy")
+	pinned.Pinned = true
+	unpinned := newTestProxy(t, server.URL, "", "healthy")
+
+	err := CheckProxyHealth(context.Background(), pinned, 2*time.Second, "http://example.invalid/health")
+	assert.Error(t, err, "the failed check should still be reported")
+	assert.Equal(t, "healthy", pinned.HealthStatus, "pinned proxy must not be downgraded by a failing check")
+	assert.False(t, pinned.LastChecked.IsZero())
+
+	err = CheckProxyHealth(context.Background(), unpinned, 2*time.Second, "http://example.invalid/health")
+	assert.Error(t, err)
+	assert.Equal(t, "unhealthy", unpinned.HealthStatus)
+}
+
+func TestProxyManager_MinRecheckInterval(t *testing.T) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recentHealthy := newTestProxy(t, server.URL, "US", "healthy")
+	recentHealthy.LastChecked = time.Now().Add(-10 * time.Second)
+	recentHealthy.Latency = 42 * time.Millisecond
+	recentUnhealthy := newTestProxy(t, server.URL, "US", "unhealthy")
+	recentUnhealthy.LastChecked = time.Now().Add(-10 * time.Second)
+	stale := newTestProxy(t, server.URL, "US", "unhealthy")
+	stale.LastChecked = time.Now().Add(-2 * time.Minute)
+	flagged := newTestProxy(t, server.URL, "US", "unknown")
+	flagged.LastChecked = time.Now().Add(-10 * time.Second)
+	flagged.NeedsRecheck = true
+	never := newTestProxy(t, server.URL, "US", "unknown")
+	pool := []*ProxyInfo{recentHealthy, recentUnhealthy, stale, flagged, never}
+
+	pm := NewProxyManager(pool, StrategyRoundRobin, false)
+	pm.RegionHealthCheckURLs = map[string]string{"US": server.URL}
+	pm.SetMinRecheckInterval(time.Minute)
+	assert.Equal(t, time.Minute, pm.MinRecheckInterval())
+
+	result := pm.CheckProxies(context.Background(), pool, 2*time.Second, 2)
+	assert.Equal(t, int32(3), checks.Load(), "only the stale, flagged and never-checked proxies are checked")
+	assert.Equal(t, 4, result.Healthy)
+	assert.Equal(t, "healthy", recentHealthy.HealthStatus)
+	assert.Equal(t, 42*time.Millisecond, recentHealthy.Latency, "the cached result is kept")
+	assert.Equal(t, "unhealthy", recentUnhealthy.HealthStatus)
+	for _, p := range []*ProxyInfo{stale, flagged, never} {
+		assert.Equal(t, "healthy", p.HealthStatus, p.URL.String())
+	}
+
+	// Lowering the interval while the manager is in use applies to the next check.
+	pm.SetMinRecheckInterval(0)
+	pm.CheckProxies(context.Background(), []*ProxyInfo{recentUnhealthy}, 2*time.Second, 1)
+	assert.Equal(t, int32(4), checks.Load())
+	assert.Equal(t, "healthy", recentUnhealthy.HealthStatus)
+
+	// Checks outside a manager never skip a proxy.
+	require.NoError(t, CheckProxyHealth(context.Background(), recentHealthy, 2*time.Second, server.URL))
+	assert.Equal(t, int32(5), checks.Load())
+}
+
+func TestCheckedRecently(t *testing.T) {
+	p := &ProxyInfo{OriginalString: "p", HealthStatus: "unhealthy", LastChecked: time.Now().Add(-10 * time.Second)}
+	skip, err := checkedRecently(p, time.Minute)
+	assert.True(t, skip)
+	assert.ErrorIs(t, err, ErrCheckedRecently)
+
+	p.HealthStatus = "healthy"
+	skip, err = checkedRecently(p, time.Minute)
+	assert.True(t, skip)
+	assert.NoError(t, err)
+
+	skip, _ = checkedRecently(p, 5*time.Second)
+	assert.False(t, skip, "checked before the interval")
+	skip, _ = checkedRecently(p, 0)
+	assert.False(t, skip, "no interval")
+}
+
+func TestBatchCheckProxies_CancelMidBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var checks int32
+	// The server stands in for every proxy. The first check cancels the batch and hangs until
+	// its request is torn down.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&checks, 1) == 1 {
+			cancel()
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	var proxies []*ProxyInfo
+	for i := 0; i < 20; i++ {
+		proxies = append(proxies, newTestProxy(t, server.URL, "", "unknown"))
+	}
+
+	start := time.Now()
+	BatchCheckProxies(ctx, proxies, 30*time.Second, 2, "http://example.invalid/health")
+	assert.Less(t, time.Since(start), 10*time.Second, "in-flight checks should be torn down")
+	assert.Less(t, int(atomic.LoadInt32(&checks)), len(proxies), "no new checks should start once cancelled")
+	for _, p := range proxies {
+		assert.Equal(t, "unknown", p.HealthStatus, "cancelled or skipped checks must not change the status")
+	}
+}
+
+func TestBatchCheckProxies_Result(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	recovering := newTestProxy(t, ok.URL, "", "unhealthy")
+	steady := newTestProxy(t, ok.URL, "", "healthy")
+	dying := newTestProxy(t, strings.Replace(failing.URL, "http://", "http://user:secret@", 1), "", "healthy")
+
+	result := BatchCheckProxies(context.Background(), []*ProxyInfo{recovering, nil, steady, dying}, 2*time.Second, 2, "http://example.invalid/health")
+	assert.Equal(t, 3, result.Checked, "nil entries are not counted")
+	assert.Equal(t, 2, result.Healthy)
+	assert.Zero(t, result.Revived)
+	assert.Positive(t, result.Elapsed)
+	assert.ElementsMatch(t, []StatusTransition{
+		{Proxy: ok.URL, From: "unhealthy", To: "healthy"},
+		{Proxy: failing.URL, From: "healthy", To: "unhealthy"},
+	}, result.Transitions, "unchanged proxies have no transition, and credentials are left out")
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	var decoded BatchCheckResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, result, decoded, "the result survives serialization")
+}
+
+func TestFindHealthyProxies_StopsAtTarget(t *testing.T) {
+	var checks int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checks, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var proxies []*ProxyInfo
+	for i := 0; i < 50; i++ {
+		proxies = append(proxies, newTestProxy(t, server.URL, "", "unknown"))
+	}
+
+	found := FindHealthyProxies(context.Background(), proxies, 5, 2*time.Second, 2, "http://example.invalid/health")
+	assert.Len(t, found, 5)
+	for _, p := range found {
+		assert.Equal(t, "healthy", p.HealthStatus)
+	}
+	assert.LessOrEqual(t, int(atomic.LoadInt32(&checks)), 5+2, "no new checks should start once the target is met")
+
+	for _, p := range proxies {
+		assert.NotEqual(t, "unhealthy", p.HealthStatus, "cancelled or skipped checks must not mark proxies unhealthy")
+	}
+}
+
+func TestFindHealthyProxies_NoTargetChecksAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	proxies := []*ProxyInfo{
+		newTestProxy(t, server.URL, "", "unknown"),
+		newTestProxy(t, server.URL, "", "unknown"),
+		newTestProxy(t, server.URL, "", "unknown"),
+	}
+	found := FindHealthyProxies(context.Background(), proxies, 0, 2*time.Second, 2, "http://example.invalid/health")
+	assert.Len(t, found, 3)
+
+	found = FindHealthyProxies(context.Background(), proxies, 10, 2*time.Second, 2, "http://example.invalid/health?fail=1")
+	assert.Empty(t, found, "an unmet target returns whatever was found after checking every proxy")
+	for _, p := range proxies {
+		assert.Equal(t, "unhealthy", p.HealthStatus)
+	}
+}
+
+func TestFindHealthyProxies_CancelledCheckKeepsStatus(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	p := newTestProxy(t, server.URL, "", "unknown")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	found := FindHealthyProxies(ctx, []*ProxyInfo{p}, 1, 5*time.Second, 1, "http://example.invalid/health")
+	assert.Empty(t, found)
+	assert.Equal(t, "unknown", p.HealthStatus, "an abandoned check is not a failed check")
+}
+
+func TestCheckPoolHealth_RegionURLs(t *testing.T) {
+	// Each server stands in for one proxy and records the URL it was asked to fetch.
+	newRecordingProxy := func(requested chan<- string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested <- r.URL.String()
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	usRequests := make(chan string, 1)
+	otherRequests := make(chan string, 1)
+	us := newTestProxy(t, newRecordingProxy(usRequests).URL, "us", "unknown")
+	other := newTestProxy(t, newRecordingProxy(otherRequests).URL, "EU", "unknown")
+
+	pm := NewProxyManager([]*ProxyInfo{us, other}, StrategyRoundRobin, false)
+	pm.RegionHealthCheckURLs = map[string]string{"US": "http://us-check.example.invalid/health"}
+
+	assert.Equal(t, "http://us-check.example.invalid/health", pm.HealthCheckURLFor(us), "regions match case-insensitively")
+	assert.Empty(t, pm.HealthCheckURLFor(other))
+
+	result := pm.CheckPoolHealth(2*time.Second, 2)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, 2, result.Healthy)
+	assert.Equal(t, "http://us-check.example.invalid/health", <-usRequests)
+	assert.Equal(t, defaultHealthCheckURL, <-otherRequests, "proxies without a regional URL use the default")
+	assert.Equal(t, "healthy", us.HealthStatus)
+	assert.Equal(t, "healthy", other.HealthStatus)
+}
+
+func TestCheckPoolHealth_KeepsQuarantine(t *testing.T) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	quarantined := newTestProxy(t, server.URL, "", StatusQuarantined)
+	other := newTestProxy(t, server.URL+"/", "", "unknown")
+	pm := NewProxyManager([]*ProxyInfo{quarantined, other}, StrategyRoundRobin, false)
+
+	result := pm.CheckPoolHealth(2*time.Second, 2)
+	assert.Equal(t, 1, result.Checked, "quarantined proxies are skipped")
+	assert.Equal(t, int32(1), checks.Load())
+	assert.Equal(t, StatusQuarantined, quarantined.HealthStatus)
+
+	// Checked explicitly, it passes but stays quarantined.
+	checked := pm.CheckProxy(context.Background(), quarantined, 2*time.Second)
+	assert.Equal(t, int32(2), checks.Load())
+	assert.Equal(t, StatusQuarantined, checked.HealthStatus, "a check never lifts a quarantine")
+	assert.False(t, checked.LastChecked.IsZero())
+}
+
+func TestCheckProxies_ConcurrentWithSessions(t *testing.T) {
+	// The server stands in for a proxy that answers 200, once the test lets it.
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	quarantined := newTestProxy(t, server.URL, "", "unknown")
+	recovered := newTestProxy(t, server.URL+"/", "", "unhealthy")
+	pm := NewProxyManager([]*ProxyInfo{quarantined, recovered}, StrategyRoundRobin, false)
+
+	done := make(chan BatchCheckResult)
+	go func() { done <- pm.CheckPoolHealth(2*time.Second, 2) }()
+	<-started
+	<-started
+
+	// A session selecting and recording results while the checks run does not race them.
+	for i := 0; i < 10; i++ {
+		p, err := pm.GetProxy()
+		require.NoError(t, err)
+		pm.RecordResult(p.URL.String(), false)
+	}
+	require.NoError(t, pm.UpdateProxyStatus(quarantined.URL.String(), StatusQuarantined, 0))
+	close(release)
+	result := <-done
+
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, 2, result.Healthy)
+	assert.Equal(t, "healthy", recovered.HealthStatus)
+	assert.Equal(t, StatusQuarantined, quarantined.HealthStatus, "a status changed while the check ran is kept")
+	assert.False(t, quarantined.LastChecked.IsZero())
+
+	checked := pm.CheckProxy(context.Background(), recovered, 2*time.Second)
+	assert.Equal(t, "healthy", checked.HealthStatus)
+	assert.Equal(t, recovered.LastChecked, checked.LastChecked)
+}
+
+func TestRevivalCheck(t *testing.T) {
+	// The server stands in for a proxy that has come back and answers every check with a 200.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	stillDeadURL := "http://" + ln.Addr().String()
+	ln.Close() // Connections are refused.
+
+	back := newTestProxy(t, server.URL, "", StatusDead)
+	back.ConsecutiveFailures = 3
+	stillDead := newTestProxy(t, stillDeadURL, "", StatusDead)
+	pm := NewProxyManager([]*ProxyInfo{back, stillDead}, StrategyRoundRobin, false)
+
+	_, err = pm.GetProxy()
+	assert.ErrorIs(t, err, ErrNoMatchingProxies, "dead proxies are excluded even without HealthyOnly")
+	assert.Zero(t, pm.CheckPoolHealth(2*time.Second, 2).Checked, "pool health checks skip dead proxies")
+
+	revived := pm.RevivalCheck(2*time.Second, 2)
+	require.Len(t, revived, 1)
+	assert.Same(t, back, revived[0])
+	assert.Equal(t, "healthy", back.HealthStatus)
+	assert.Equal(t, 0, back.ConsecutiveFailures)
+	assert.Equal(t, StatusDead, stillDead.HealthStatus, "a failed revival check keeps the proxy dead")
+	assert.False(t, stillDead.LastChecked.IsZero())
+
+	p, err := pm.GetProxy()
+	require.NoError(t, err)
+	assert.Same(t, back, p)
+	assert.Empty(t, pm.RevivalCheck(2*time.Second, 2), "nothing left to revive")
+}
+
+func TestBatchGeoCheck(t *testing.T) {
+	cache, err := LoadGeoCache(filepath.Join(t.TempDir(), "geo.json"), 0)
+	require.NoError(t, err)
+	var lookups atomic.Int32
+	lookup := func(_ context.Context, host string) (string, error) {
+		lookups.Add(1)
+		if host == "bad.example.com" {
+			return "", ErrGeoIPNotFound
+		}
+		return "DE", nil
+	}
+	proxies := []*ProxyInfo{
+		newTestProxy(t, "http://a.example.com:8080", "", "unknown"),
+		newTestProxy(t, "http://b.example.com:8080", "", "unknown"),
+		newTestProxy(t, "http://us.example.com:8080", "US", "unknown"),
+		newTestProxy(t, "http://bad.example.com:8080", "", "unknown"),
+	}
+
+	located := BatchGeoCheck(context.Background(), proxies, 2, lookup, cache)
+	assert.Equal(t, 2, located)
+	assert.Equal(t, int32(3), lookups.Load(), "proxies with a region are not looked up")
+	assert.Equal(t, "DE", proxies[0].Region)
+	assert.Equal(t, "DE", proxies[1].Region)
+	assert.Equal(t, "US", proxies[2].Region)
+	assert.Empty(t, proxies[3].Region)
+	region, ok := cache.Get("b.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "DE", region)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Zero(t, BatchGeoCheck(ctx, []*ProxyInfo{newTestProxy(t, "http://c.example.com:8080", "", "unknown")}, 1, lookup, cache))
+}
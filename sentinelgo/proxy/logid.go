@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// Values for the mode of LogIdentifier (AppConfig.ProxyLogIdentifier).
+const (
+	LogIDHost = "host" // The proxy URL without credentials, e.g. "http://1.2.3.4:8080". The default.
+	LogIDHash = "hash" // A stable hash of the full proxy URL, e.g. "proxy-3f2a9c1b7d4e".
+	LogIDFull = "full" // The full proxy URL, credentials included.
+)
+
+// LogIdentifier returns how the proxy at proxyURL (a ProxyInfo's URL.String()) is identified in
+// logs and errors, so credentials in the URL's userinfo never reach the log file:
+//   - LogIDHost, or an empty or unrecognized mode: the URL without its credentials. Proxies that
+//     share a host and differ only in credentials get the same identifier.
+//   - LogIDHash: "proxy-" and the first 12 hex digits of the URL's SHA-256, the same across runs
+//     and distinct for different credentials, but hiding the host as well.
+//   - LogIDFull: proxyURL unchanged.
+func LogIdentifier(proxyURL, mode string) string {
+	switch strings.ToLower(mode) {
+	case LogIDFull:
+		return proxyURL
+	case LogIDHash:
+		sum := sha256.Sum256([]byte(proxyURL))
+		return "proxy-" + hex.EncodeToString(sum[:6])
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		// Not a URL whose credentials can be cut out reliably; hash it instead.
+		return LogIdentifier(proxyURL, LogIDHash)
+	}
+	u.User = nil
+	return u.String()
+}
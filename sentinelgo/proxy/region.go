@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRegion is returned by ValidateRegions when a proxy's region is not an ISO-3166 alpha-2 code.
+var ErrInvalidRegion = errors.New("unrecognized proxy region")
+
+// iso3166Alpha2 lists the officially assigned ISO-3166-1 alpha-2 codes, plus "EU" (exceptionally
+// reserved for the European Union) since it is a common region for proxy pools.
+var iso3166Alpha2 = makeRegionSet(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS
+	BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE
+	EG EH ER ES ET FI FJ FK FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM
+	HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC
+	LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA
+	NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW
+	SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO
+	TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW
+	EU`)
+
+// regionAliases maps common non-ISO spellings (uppercased) to their ISO-3166 alpha-2 code: the
+// "UK" exception, alpha-3 codes and English names of the countries most proxy providers offer.
+var regionAliases = map[string]string{
+	"UK": "GB", "GBR": "GB", "UNITED KINGDOM": "GB", "GREAT BRITAIN": "GB", "BRITAIN": "GB", "ENGLAND": "GB",
+	"USA": "US", "UNITED STATES": "US", "UNITED STATES OF AMERICA": "US", "AMERICA": "US",
+	"CAN": "CA", "CANADA": "CA",
+	"MEX": "MX", "MEXICO": "MX",
+	"BRA": "BR", "BRAZIL": "BR",
+	"ARG": "AR", "ARGENTINA": "AR",
+	"DEU": "DE", "GERMANY": "DE",
+	"FRA": "FR", "FRANCE": "FR",
+	"NLD": "NL", "NETHERLANDS": "NL", "HOLLAND": "NL",
+	"ESP": "ES", "SPAIN": "ES",
+	"ITA": "IT", "ITALY": "IT",
+	"POL": "PL", "POLAND": "PL",
+	"SWE": "SE", "SWEDEN": "SE",
+	"CHE": "CH", "SWITZERLAND": "CH",
+	"IRL": "IE", "IRELAND": "IE",
+	"UKR": "UA", "UKRAINE": "UA",
+	"RUS": "RU", "RUSSIA": "RU",
+	"TUR": "TR", "TURKEY": "TR",
+	"IND": "IN", "INDIA": "IN",
+	"CHN": "CN", "CHINA": "CN",
+	"JPN": "JP", "JAPAN": "JP",
+	"KOR": "KR", "SOUTH KOREA": "KR", "KOREA": "KR",
+	"SGP": "SG", "SINGAPORE": "SG",
+	"HKG": "HK", "HONG KONG": "HK",
+	"AUS": "AU", "AUSTRALIA": "AU",
+	"NZL": "NZ", "NEW ZEALAND": "NZ",
+	"ZAF": "ZA", "SOUTH AFRICA": "ZA",
+	"NGA": "NG", "NIGERIA": "NG",
+	"EGY": "EG", "EGYPT": "EG",
+	"ISR": "IL", "ISRAEL": "IL",
+	"ARE": "AE", "UAE": "AE", "UNITED ARAB EMIRATES": "AE",
+	"EUROPE": "EU", "EUROPEAN UNION": "EU",
+}
+
+// makeRegionSet builds a lookup set from a whitespace-separated list of codes.
+func makeRegionSet(codes string) map[string]bool {
+	set := make(map[string]bool)
+	for _, code := range strings.Fields(codes) {
+		set[code] = true
+	}
+	return set
+}
+
+// NormalizeRegion maps a region value to its ISO-3166 alpha-2 code, e.g. "usa", "United States"
+// and " us " all become "US" and "uk" becomes "GB". ok is false if the value is not recognized;
+// the trimmed input is then returned unchanged. An empty region is valid and stays empty.
+func NormalizeRegion(region string) (normalized string, ok bool) {
+	trimmed := strings.TrimSpace(region)
+	if trimmed == "" {
+		return "", true
+	}
+	key := strings.ToUpper(strings.Join(strings.FieldsFunc(trimmed, func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-' || r == '.'
+	}), " "))
+	if iso3166Alpha2[key] {
+		return key, true
+	}
+	if code, found := regionAliases[key]; found {
+		return code, true
+	}
+	return trimmed, false
+}
+
+// ValidateRegions returns an error wrapping ErrInvalidRegion listing every proxy whose region is
+// not an ISO-3166 alpha-2 code (including recognized but unnormalized values such as "usa").
+// Callers use it to enforce valid regions; the JSON loader itself only normalizes recognized
+// values and warns about the rest.
+func ValidateRegions(proxies []*ProxyInfo) error {
+	var invalid []string
+	for _, p := range proxies {
+		if p == nil || p.URL == nil || p.Region == "" {
+			continue
+		}
+		if normalized, ok := NormalizeRegion(p.Region); !ok || normalized != p.Region {
+			invalid = append(invalid, fmt.Sprintf("%q (%s)", p.Region, p.URL.Host))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidRegion, strings.Join(invalid, ", "))
+	}
+	return nil
+}
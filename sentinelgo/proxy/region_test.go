@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRegion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"US", "US", true},
+		{" us ", "US", true},
+		{"usa", "US", true},
+		{"United States", "US", true},
+		{"united_states", "US", true},
+		{"uk", "GB", true},
+		{"Great Britain", "GB", true},
+		{"DEU", "DE", true},
+		{"eu", "EU", true},
+		{"", "", true},
+		{"ZZ", "ZZ", false},
+		{"asdf", "asdf", false},
+		{" U S A!", "U S A!", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeRegion(tt.input)
+		assert.Equal(t, tt.want, got, "input %q", tt.input)
+		assert.Equal(t, tt.ok, ok, "input %q", tt.input)
+	}
+}
+
+func TestLoadProxies_JSONNormalizesRegions(t *testing.T) {
+	jsonPath := createTempJSON(t, []map[string]string{
+		{"proxy": "http://a.example.com:8080", "region": "usa"},
+		{"proxy": "http://b.example.com:8080", "region": "uk"},
+		{"proxy": "http://c.example.com:8080", "region": "Narnia"},
+		{"proxy": "http://d.example.com:8080"},
+	})
+	defer os.Remove(jsonPath)
+
+	proxies, err := LoadProxies(jsonPath)
+	require.NoError(t, err, "unrecognized regions only warn by default")
+	require.Len(t, proxies, 4)
+	assert.Equal(t, "US", proxies[0].Region)
+	assert.Equal(t, "GB", proxies[1].Region)
+	assert.Equal(t, "Narnia", proxies[2].Region, "unrecognized regions are kept as written")
+	assert.Empty(t, proxies[3].Region)
+
+	err = ValidateRegions(proxies)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidRegion))
+	assert.Contains(t, err.Error(), `"Narnia" (c.example.com:8080)`)
+	assert.NotContains(t, err.Error(), "a.example.com")
+
+	assert.NoError(t, ValidateRegions(proxies[:2]))
+}
+
+func TestValidateRegions_RejectsUnnormalizedValues(t *testing.T) {
+	// CSV regions are not normalized at load time, so strict validation also rejects aliases there.
+	proxies := []*ProxyInfo{
+		newTestProxy(t, "http://a.example.com:8080", "US", "unknown"),
+		newTestProxy(t, "http://b.example.com:8080", "usa", "unknown"),
+		newTestProxy(t, "http://c.example.com:8080", "!!", "unknown"),
+	}
+	err := ValidateRegions(proxies)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidRegion))
+	assert.Contains(t, err.Error(), `"usa"`)
+	assert.Contains(t, err.Error(), `"!!"`)
+	assert.NotContains(t, err.Error(), `"US"`)
+}
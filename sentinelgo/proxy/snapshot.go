@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PoolSnapshot is a point-in-time copy of the health status of every proxy in a pool,
+// keyed by proxy URL. Take one before and after a health check and compare them with
+// DiffSnapshots to see what the check changed.
+type PoolSnapshot map[string]string
+
+// PoolDiff describes how a proxy pool changed between two snapshots.
+// Each slice holds proxy URLs in sorted order.
+type PoolDiff struct {
+	Recovered []string // Proxies that went from "unhealthy" to "healthy".
+	Died      []string // Proxies that went from "healthy" to "unhealthy".
+	Added     []string // Proxies present only in the newer snapshot.
+	Removed   []string // Proxies present only in the older snapshot.
+}
+
+// PoolSnapshot returns the current health status of every proxy in the pool.
+// The method is thread-safe.
+func (pm *ProxyManager) PoolSnapshot() PoolSnapshot {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	snapshot := make(PoolSnapshot, len(pm.Proxies))
+	for _, p := range pm.Proxies {
+		if p == nil || p.URL == nil {
+			continue
+		}
+		snapshot[p.URL.String()] = p.HealthStatus
+	}
+	return snapshot
+}
+
+// DiffSnapshots compares an older snapshot `a` with a newer snapshot `b` and returns the
+// healthy/unhealthy transitions between them, along with proxies added or removed.
+// Other transitions (e.g., "unknown" to "healthy" on a proxy's first check) are not reported.
+func DiffSnapshots(a, b PoolSnapshot) PoolDiff {
+	var diff PoolDiff
+	for key, newStatus := range b {
+		oldStatus, ok := a[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case oldStatus == "unhealthy" && newStatus == "healthy":
+			diff.Recovered = append(diff.Recovered, key)
+		case oldStatus == "healthy" && newStatus == "unhealthy":
+			diff.Died = append(diff.Died, key)
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Recovered)
+	sort.Strings(diff.Died)
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// IsEmpty reports whether the diff contains no changes.
+func (d PoolDiff) IsEmpty() bool {
+	return len(d.Recovered) == 0 && len(d.Died) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// String summarizes the diff as counts, e.g. "5 proxies recovered, 3 died".
+// Proxy URLs are left out because they may carry credentials.
+func (d PoolDiff) String() string {
+	var parts []string
+	for _, change := range []struct {
+		n    int
+		verb string
+	}{
+		{len(d.Recovered), "recovered"},
+		{len(d.Died), "died"},
+		{len(d.Added), "added"},
+		{len(d.Removed), "removed"},
+	} {
+		if change.n == 0 {
+			continue
+		}
+		if len(parts) == 0 { // Name the noun once, on the first count.
+			noun := "proxies"
+			if change.n == 1 {
+				noun = "proxy"
+			}
+			parts = append(parts, fmt.Sprintf("%d %s %s", change.n, noun, change.verb))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", change.n, change.verb))
+	}
+	if len(parts) == 0 {
+		return "no proxy status changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HealthRecord is a proxy's entry in a file written by SaveHealth. The URL has its password redacted;
+// Key (see HealthKey) identifies the proxy, credentials included, so LoadHealth can match it.
+type HealthRecord struct {
+	URL          string    `json:"url"`
+	Key          string    `json:"key,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	HealthStatus string    `json:"health_status"`
+	LatencyMS    int64     `json:"latency_ms"`
+	LastChecked  time.Time `json:"last_checked,omitempty"`
+	Pinned       bool      `json:"pinned,omitempty"`
+}
+
+// HealthFile is the content of a file written by SaveHealth.
+type HealthFile struct {
+	SavedAt time.Time      `json:"saved_at"`
+	Healthy int            `json:"healthy"` // Proxies whose status is "healthy".
+	Proxies []HealthRecord `json:"proxies"`
+}
+
+// SaveHealth writes the health status, latency and last check time of every proxy in the pool
+// to path as an indented JSON HealthFile, e.g. to keep the results of a health check. The file
+// is created with owner-only permissions. It returns what was written. The method is thread-safe.
+func (pm *ProxyManager) SaveHealth(path string) (HealthFile, error) {
+	file := HealthFile{SavedAt: time.Now(), Proxies: []HealthRecord{}}
+	pm.mu.Lock()
+	for _, p := range pm.Proxies {
+		if p == nil || p.URL == nil {
+			continue
+		}
+		if p.HealthStatus == "healthy" {
+			file.Healthy++
+		}
+		file.Proxies = append(file.Proxies, HealthRecord{
+			URL:          p.URL.Redacted(),
+			Key:          HealthKey(p.URL.String()),
+			Region:       p.Region,
+			HealthStatus: p.HealthStatus,
+			LatencyMS:    p.Latency.Milliseconds(),
+			LastChecked:  p.LastChecked,
+			Pinned:       p.Pinned,
+		})
+	}
+	pm.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return file, fmt.Errorf("failed to marshal proxy health: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return file, fmt.Errorf("failed to write proxy health to '%s': %w", path, err)
+	}
+	return file, nil
+}
+
+// HealthKey returns the key under which SaveHealth records the proxy at proxyURL (a ProxyInfo's
+// URL.String()): the hex SHA-256 of the full URL. Proxies that differ only in credentials get
+// different keys, yet the credentials cannot be read back from the file.
+func HealthKey(proxyURL string) string {
+	sum := sha256.Sum256([]byte(proxyURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// LatestHealthFile returns the newest "proxy-health-*.json" file in dir, as named by the Proxy
+// Management tab's Ctrl+S, or an error if there is none.
+func LatestHealthFile(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "proxy-health-*.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list proxy health files in '%s': %w", dir, err)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no proxy health files in '%s'", dir)
+	}
+	sort.Strings(matches) // The names end in a sortable timestamp.
+	return matches[len(matches)-1], nil
+}
+
+// LoadHealth reads a file written by SaveHealth and restores the health status, latency and last
+// check time of the pool's proxies from it, so a restart does not have to check them all again
+// (see SetMinRecheckInterval). Records are matched by Key; records without one, or for proxies no
+// longer in the pool, are ignored, as are records older than the proxy's own LastChecked. Pinned
+// proxies are never restored to a status other than "healthy". It returns how many proxies were
+// restored. The method is thread-safe.
+func (pm *ProxyManager) LoadHealth(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read proxy health from '%s': %w", path, err)
+	}
+	var file HealthFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("failed to parse proxy health from '%s': %w", path, err)
+	}
+	records := make(map[string]HealthRecord, len(file.Proxies))
+	for _, record := range file.Proxies {
+		if record.Key != "" {
+			records[record.Key] = record
+		}
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	applied := 0
+	for _, p := range pm.Proxies {
+		if p == nil || p.URL == nil {
+			continue
+		}
+		record, ok := records[HealthKey(p.URL.String())]
+		if !ok || record.HealthStatus == "" || !record.LastChecked.After(p.LastChecked) {
+			continue
+		}
+		if !p.Pinned || record.HealthStatus == "healthy" {
+			p.HealthStatus = record.HealthStatus
+		}
+		p.Latency = time.Duration(record.LatencyMS) * time.Millisecond
+		p.LastChecked = record.LastChecked
+		applied++
+	}
+	return applied, nil
+}
@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	_ "modernc.org/sqlite" // Registers the pure-Go "sqlite" driver with database/sql.
+)
+
+// SQLiteScheme prefixes a proxy source naming a SQLite database, e.g.
+// "sqlite://config/proxies.db" or "sqlite:///var/lib/proxies.db?table=inventory".
+const SQLiteScheme = "sqlite://"
+
+// defaultSQLiteTable is the table read from a SQLite source that names neither a table nor a query.
+const defaultSQLiteTable = "proxies"
+
+// sqliteDriverNames are the database/sql driver names tried, in order, to open a SQLite source:
+// "sqlite" is registered by modernc.org/sqlite, which this package imports, and "sqlite3" by
+// github.com/mattn/go-sqlite3.
+var sqliteDriverNames = []string{"sqlite", "sqlite3"}
+
+// ErrSQLiteDriverMissing is returned when loading a SQLite source while none of
+// sqliteDriverNames is registered with database/sql.
+var ErrSQLiteDriverMissing = errors.New("no SQLite driver registered with database/sql")
+
+// sqliteTableName matches the table names accepted by the "table" parameter of a SQLite source.
+var sqliteTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqliteColumnAliases maps recognized result column names (lowercased) to the canonical names
+// used by the loader: the CSV header names plus the last-known health status and a label.
+var sqliteColumnAliases = map[string]string{
+	"health": "health", "health_status": "health", "healthstatus": "health", "status": "health",
+	"label": "label", "note": "label",
+}
+
+// IsSQLiteSource reports whether a proxy source names a SQLite database (see SQLiteScheme).
+func IsSQLiteSource(source string) bool {
+	return strings.HasPrefix(strings.ToLower(source), SQLiteScheme)
+}
+
+// sqliteDriver returns the name of the first registered driver of sqliteDriverNames.
+func sqliteDriver() (string, error) {
+	registered := make(map[string]bool)
+	for _, name := range sql.Drivers() {
+		registered[name] = true
+	}
+	for _, name := range sqliteDriverNames {
+		if registered[name] {
+			return name, nil
+		}
+	}
+	return "", ErrSQLiteDriverMissing
+}
+
+// parseSQLiteSource splits a SQLite source into the database path and the query selecting its
+// proxies: the "query" parameter if set, else every column of the "table" parameter's table
+// (default defaultSQLiteTable).
+func parseSQLiteSource(source string) (path, query string, err error) {
+	path, rawParams, _ := strings.Cut(source[len(SQLiteScheme):], "?")
+	if path == "" {
+		return "", "", fmt.Errorf("SQLite proxy source '%s' names no database file", source)
+	}
+	params, err := url.ParseQuery(rawParams)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid parameters in SQLite proxy source '%s': %w", source, err)
+	}
+	if query = strings.TrimSpace(params.Get("query")); query != "" {
+		return path, query, nil
+	}
+	table := params.Get("table")
+	if table == "" {
+		table = defaultSQLiteTable
+	}
+	if !sqliteTableName.MatchString(table) {
+		return "", "", fmt.Errorf("invalid table name '%s' in SQLite proxy source '%s'", table, source)
+	}
+	return path, "SELECT * FROM " + table, nil
+}
+
+// loadSQLiteProxies reads proxies from the SQLite source. Result columns are matched by name,
+// ignoring case, like the columns of a CSV file with a header: "host" (or "ip"/"proxy"), "port",
+// "username"/"user", "password"/"pass", "region", "protocol" and "pinned", plus "health" (or
+// "status") for the last-known health status and "label" (or "note"). Other columns are ignored.
+// A host column holding a whole proxy URL needs no port column. Rows that do not form a valid
+// proxy are skipped and logged to standard output, as for files.
+func loadSQLiteProxies(source string) ([]*ProxyInfo, error) {
+	path, query, err := parseSQLiteSource(source)
+	if err != nil {
+		return nil, err
+	}
+	driverName, err := sqliteDriver()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open SQLite proxy source '%s': %w", source, err)
+	}
+	// SQLite creates a missing database file on open; a mistyped path must fail instead.
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database '%s': %w", path, err)
+	}
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database '%s': %w", path, err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query proxies from SQLite database '%s': %w", path, err)
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns from SQLite database '%s': %w", path, err)
+	}
+	columns := make(map[string]int) // Canonical column name -> index; the first occurrence wins.
+	for idx, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		canonical, ok := csvHeaderAliases[name]
+		if !ok {
+			canonical, ok = sqliteColumnAliases[name]
+		}
+		if _, seen := columns[canonical]; ok && !seen {
+			columns[canonical] = idx
+		}
+	}
+	if _, ok := columns["host"]; !ok {
+		return nil, fmt.Errorf("query on SQLite database '%s' returned no host column (got %s)", path, strings.Join(names, ", "))
+	}
+
+	var proxies []*ProxyInfo
+	values := make([]interface{}, len(names))
+	pointers := make([]interface{}, len(names))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rowNum := 1; rows.Next(); rowNum++ {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to read row %d from SQLite database '%s': %w", rowNum, path, err)
+		}
+		row := make(map[string]string, len(columns))
+		for canonical, idx := range columns {
+			row[canonical] = sqliteString(values[idx])
+		}
+		if info := sqliteRowProxy(row, source, rowNum); info != nil {
+			proxies = append(proxies, info)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read proxies from SQLite database '%s': %w", path, err)
+	}
+	return proxies, nil
+}
+
+// sqliteRowProxy builds the proxy described by a row of canonical column values, or returns nil
+// (after logging why) if the row does not describe a valid proxy.
+func sqliteRowProxy(row map[string]string, source string, rowNum int) *ProxyInfo {
+	host := row["host"]
+	if host == "" {
+		fmt.Printf("Skipping SQLite row #%d with an empty host in '%s'\n", rowNum, source)
+		return nil
+	}
+	scheme := "http"
+	if idx := strings.Index(host, "://"); idx >= 0 { // Scheme present in the entry itself.
+		scheme, host = normalizeProxyScheme(host[:idx]), host[idx+3:]
+	}
+	if protocol := row["protocol"]; protocol != "" {
+		scheme = normalizeProxyScheme(protocol)
+	}
+	if port := row["port"]; port != "" {
+		host += ":" + port
+	}
+	proxyStr := scheme + "://" + host
+	if user := row["user"]; user != "" {
+		proxyStr = scheme + "://" + userInfoString(user, row["pass"]) + "@" + host
+	}
+	parsedURL, err := parseProxyString(proxyStr, scheme)
+	if err != nil {
+		fmt.Printf("Skipping unparseable proxy '%s' from SQLite row #%d in '%s': %v\n", scheme+"://"+host, rowNum, source, err)
+		return nil
+	}
+	if parsedURL.Port() == "" {
+		fmt.Printf("Skipping proxy '%s' without a port from SQLite row #%d in '%s'\n", parsedURL.Host, rowNum, source)
+		return nil
+	}
+	region, ok := NormalizeRegion(row["region"])
+	if !ok {
+		fmt.Printf("Warning: unrecognized region '%s' for proxy '%s' in SQLite row #%d in '%s'\n", region, parsedURL.Host, rowNum, source)
+	}
+
+	info := newProxyInfo(parsedURL, scheme+"://"+host, source, region)
+	info.Label = row["label"]
+	switch health := strings.ToLower(row["health"]); health {
+	case "", "unknown":
+	case "healthy", "unhealthy", StatusDead:
+		info.HealthStatus = health
+	default:
+		fmt.Printf("Warning: ignoring unrecognized health status '%s' for proxy '%s' in SQLite row #%d in '%s'\n", row["health"], parsedURL.Host, rowNum, source)
+	}
+	if parseCSVBool(row["pinned"]) {
+		info.pin()
+	}
+	return info
+}
+
+// sqliteString returns the text form of a value scanned from a SQLite row; NULL is "".
+func sqliteString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return strings.TrimSpace(string(v))
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
@@ -11,11 +11,32 @@ import (
 
 // Strategy constants define the available proxy selection strategies.
 const (
-	StrategyRoundRobin       = "round-robin"
-	StrategyRandom           = "random"
+	StrategyRoundRobin        = "round-robin"
+	StrategyRandom            = "random"
 	StrategyRegionPrioritized = "region-prioritized" // Note: Basic version, needs targetRegion.
+	StrategyAvoidRecent       = "avoid-recent"       // Random, but never one of the last AvoidRecentWindow proxies.
+	StrategyWeighted          = "weighted"           // Random, weighted by each proxy's success ratio (see RecordResult).
 )
 
+// DefaultAvoidRecentWindow is the number of recent selections StrategyAvoidRecent avoids
+// when ProxyManager.AvoidRecentWindow is not set.
+const DefaultAvoidRecentWindow = 3
+
+// StatusQuarantined is the HealthStatus for a proxy manually taken out of rotation.
+// Unlike the automatic "unhealthy" and "slow" downgrades, it also applies to pinned proxies.
+const StatusQuarantined = "quarantined"
+
+// StatusDead is the HealthStatus for a proxy that failed MaxConsecutiveFailures times in a row.
+// Unlike "unhealthy", it excludes the proxy from every strategy, even without HealthyOnly, and
+// health checks of the pool skip it; only RevivalCheck (or a manual status change) restores it.
+const StatusDead = "dead"
+
+// isAutomaticDowngrade reports whether status is one set automatically by health checks or
+// failed requests. Pinned proxies are exempt from these.
+func isAutomaticDowngrade(status string) bool {
+	return status == "unhealthy" || status == "slow"
+}
+
 // ErrNoHealthyProxies is returned by GetProxy when no healthy proxies are available
 // and the ProxyManager is configured to only use healthy proxies.
 var ErrNoHealthyProxies = errors.New("no healthy proxies available")
@@ -27,16 +48,79 @@ var ErrNoProxiesAvailable = errors.New("no proxies available in the manager")
 // (e.g., region, health status) and no fallback is available.
 var ErrNoMatchingProxies = errors.New("no proxies match the specified criteria")
 
+// ErrProxyNotFound is returned by RemoveProxy when no proxy with the given URL is in the pool.
+var ErrProxyNotFound = errors.New("proxy not found in manager")
+
+// ErrDuplicateProxy is returned by AddProxy when a proxy with the same URL is already in the pool.
+var ErrDuplicateProxy = errors.New("proxy already in manager")
+
 // ProxyManager manages a pool of proxies and implements various selection strategies.
 // It allows for selecting proxies based on health, region, or rotation patterns.
 // The ProxyManager is designed to be thread-safe for getting and updating proxies.
+// Every read and write of the pool goes through pm.mu, and methods that change pool membership
+// (AddProxy, RemoveProxy, ReplaceProxies) install a new slice rather than modifying the old
+// one in place, so a slice returned by GetAllProxies is never changed by later mutations.
 type ProxyManager struct {
-	Proxies      []*ProxyInfo // The pool of all available proxies.
+	Proxies      []*ProxyInfo // The pool of all available proxies. Access it through the manager's methods once in use.
 	currentIndex int          // Used by the round-robin strategy.
 	Strategy     string       // The active proxy selection strategy (e.g., "round-robin", "random").
 	HealthyOnly  bool         // If true, strategies will only consider proxies marked "healthy".
 	mu           sync.Mutex   // Protects access to currentIndex and potentially the Proxies slice if it were modified dynamically post-creation.
 	rng          *rand.Rand   // Local random number generator for random strategy.
+
+	// AllowUnchecked, if true, makes HealthyOnly treat proxies whose status is still "unknown"
+	// (not yet health checked) as selectable, so reports can be sent before the first health
+	// check finishes (a cold start). If false, they wait for a check to mark them healthy (a warm start).
+	AllowUnchecked bool
+
+	// MaxHealthyAge, if positive, is how long a "healthy" status is trusted. GetProxy downgrades
+	// a non-pinned proxy whose LastChecked is older than this to "unknown" and sets NeedsRecheck;
+	// such a proxy stays eligible until a health check (see ProxiesNeedingRecheck) re-verifies it.
+	MaxHealthyAge time.Duration
+
+	// RegionHealthCheckURLs maps a region (matched case-insensitively against ProxyInfo.Region)
+	// to the health check URL used for proxies in that region by CheckPoolHealth. Proxies
+	// without a matching entry are checked against the default URL. Set it before use.
+	RegionHealthCheckURLs map[string]string
+
+	// AvoidRecentWindow is how many of the most recent selections StrategyAvoidRecent will not
+	// repeat. Zero uses DefaultAvoidRecentWindow. With fewer candidates than the window allows,
+	// the window shrinks so one proxy always remains selectable.
+	AvoidRecentWindow int
+	recent            []string // Ring of recently selected proxy URLs for StrategyAvoidRecent.
+	recentPos         int      // Index in recent where the next selection is written.
+
+	// AuditSelections, if true, makes GetProxy count how often each proxy is returned,
+	// so the spread of load can be checked via SelectionCounts.
+	AuditSelections bool
+	selectionCounts map[string]int // Selections per proxy URL, recorded while AuditSelections is true.
+
+	// minRecheckInterval, if positive, is the least time between two health checks of the same
+	// proxy by CheckProxies, CheckPoolHealth and RevivalCheck. See SetMinRecheckInterval.
+	minRecheckInterval time.Duration
+
+	// MaxConsecutiveFailures, if positive, is how many times in a row UpdateProxyStatus may mark
+	// a non-pinned proxy "unhealthy" before it is marked StatusDead instead. A "healthy" status
+	// or a successful RecordResult resets the count. Zero never marks proxies dead.
+	MaxConsecutiveFailures int
+
+	// FallbackBelow, if positive, makes GetProxy select round-robin instead of Strategy while
+	// fewer than FallbackBelow proxies are selectable, so region-prioritized or weighted
+	// selection does not keep piling reports onto the last few proxies; Strategy is used again
+	// once enough proxies are selectable. Zero never falls back.
+	FallbackBelow  int
+	fallbackActive bool // True while GetProxy selects round-robin because of FallbackBelow.
+	lastCandidates int  // Selectable proxies at the last selection, reported to OnStrategyChange.
+
+	// OnStrategyChange, if set, is called when GetProxy falls back to round-robin (active is
+	// StrategyRoundRobin) or restores Strategy (active is Strategy), with the number of
+	// selectable proxies. It is called without pm.mu held, from the goroutine calling GetProxy.
+	OnStrategyChange func(active string, candidates int)
+
+	// OnRemove, if set, is called with the proxies RemoveProxy, ReplaceProxies or PruneUnhealthy
+	// took out of the pool, e.g. so a reporter can release their connections. It is called
+	// without pm.mu held, from the goroutine that changed the pool.
+	OnRemove func(removed []*ProxyInfo)
 }
 
 // NewProxyManager creates and returns a new ProxyManager.
@@ -78,39 +162,69 @@ func NewProxyManager(proxies []*ProxyInfo, strategy string, healthyOnly bool) *P
 // The method is thread-safe.
 func (pm *ProxyManager) GetProxy(targetRegion ...string) (*ProxyInfo, error) {
 	pm.mu.Lock() // Lock for read/write of currentIndex and for consistent view of Proxies if it were mutable.
+	wasFallback := pm.fallbackActive
+	p, err := pm.selectProxyLocked(targetRegion...)
+	if err == nil && pm.AuditSelections && p.URL != nil {
+		if pm.selectionCounts == nil {
+			pm.selectionCounts = make(map[string]int)
+		}
+		pm.selectionCounts[p.URL.String()]++
+	}
+	changed, active, candidates, onChange := pm.fallbackActive != wasFallback, pm.activeStrategyLocked(), pm.lastCandidates, pm.OnStrategyChange
+	pm.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(active, candidates)
+	}
+	return p, err
+}
+
+// ActiveStrategy returns the strategy GetProxy currently selects with: Strategy, or
+// StrategyRoundRobin while fallen back because of FallbackBelow.
+// The method is thread-safe.
+func (pm *ProxyManager) ActiveStrategy() string {
+	pm.mu.Lock()
 	defer pm.mu.Unlock()
+	return pm.activeStrategyLocked()
+}
+
+// activeStrategyLocked implements ActiveStrategy. Callers must hold pm.mu.
+func (pm *ProxyManager) activeStrategyLocked() string {
+	if pm.fallbackActive {
+		return StrategyRoundRobin
+	}
+	return pm.Strategy
+}
 
+// selectProxyLocked implements GetProxy's strategy-based selection. Callers must hold pm.mu.
+func (pm *ProxyManager) selectProxyLocked(targetRegion ...string) (*ProxyInfo, error) {
 	if len(pm.Proxies) == 0 {
 		return nil, ErrNoProxiesAvailable
 	}
+	pm.expireStaleLocked()
 
 	// Filter proxies by health status if HealthyOnly is enabled.
+	// Proxies awaiting a recheck after their healthy status expired remain eligible, as do
+	// unchecked proxies when AllowUnchecked is set.
 	var candidateProxies []*ProxyInfo
-	if pm.HealthyOnly {
-		for _, p := range pm.Proxies {
-			if p != nil && p.HealthStatus == "healthy" { // Ensure p is not nil
-				candidateProxies = append(candidateProxies, p)
-			}
-		}
-		if len(candidateProxies) == 0 {
-			return nil, ErrNoHealthyProxies
-		}
-	} else {
-		// Consider all non-nil proxies if not filtering by health.
-		for _, p := range pm.Proxies {
-			if p != nil {
-				candidateProxies = append(candidateProxies, p)
-			}
+	for _, p := range pm.Proxies {
+		if pm.isCandidateLocked(p) {
+			candidateProxies = append(candidateProxies, p)
 		}
 	}
+	if pm.HealthyOnly && len(candidateProxies) == 0 {
+		return nil, ErrNoHealthyProxies
+	}
 
 	if len(candidateProxies) == 0 {
 		// This might happen if HealthyOnly is false but all entries in pm.Proxies were nil.
 		return nil, ErrNoMatchingProxies
 	}
+	pm.lastCandidates = len(candidateProxies)
+	pm.fallbackActive = pm.FallbackBelow > 0 && len(candidateProxies) < pm.FallbackBelow
 
 	// Apply selection strategy.
-	switch pm.Strategy {
+	switch pm.activeStrategyLocked() {
 	case StrategyRandom:
 		return candidateProxies[pm.rng.Intn(len(candidateProxies))], nil
 
@@ -136,6 +250,11 @@ func (pm *ProxyManager) GetProxy(targetRegion ...string) (*ProxyInfo, error) {
 		}
 		return nil, fmt.Errorf("%w: for region '%s'", ErrNoMatchingProxies, desiredRegion)
 
+	case StrategyAvoidRecent:
+		return pm.selectAvoidingRecentLocked(candidateProxies), nil
+
+	case StrategyWeighted:
+		return pm.selectWeightedLocked(candidateProxies), nil
 
 	case StrategyRoundRobin:
 		fallthrough // Default to round-robin strategy.
@@ -147,6 +266,343 @@ func (pm *ProxyManager) GetProxy(targetRegion ...string) (*ProxyInfo, error) {
 	}
 }
 
+// isCandidateLocked reports whether GetProxy's strategies may select p: never a quarantined
+// proxy; with HealthyOnly, only healthy proxies (plus those awaiting a recheck, and unchecked
+// ones with AllowUnchecked); otherwise any proxy that is not dead. Callers must hold pm.mu.
+func (pm *ProxyManager) isCandidateLocked(p *ProxyInfo) bool {
+	if p == nil || p.HealthStatus == StatusQuarantined {
+		return false
+	}
+	if pm.HealthyOnly {
+		return p.HealthStatus == "healthy" || p.NeedsRecheck || (pm.AllowUnchecked && p.HealthStatus == "unknown")
+	}
+	return p.HealthStatus != StatusDead
+}
+
+// AddProxy appends p to the pool. It returns ErrDuplicateProxy if a proxy with the same URL is
+// already present, or an error if p has no URL. The method is thread-safe.
+func (pm *ProxyManager) AddProxy(p *ProxyInfo) error {
+	if p == nil || p.URL == nil {
+		return fmt.Errorf("cannot add a proxy without a URL")
+	}
+	key := p.URL.String()
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for _, existing := range pm.Proxies {
+		if existing != nil && existing.URL != nil && existing.URL.String() == key {
+			return fmt.Errorf("%w: %s", ErrDuplicateProxy, key)
+		}
+	}
+	// The full slice expression forces append to allocate, leaving the previous slice untouched.
+	pm.Proxies = append(pm.Proxies[:len(pm.Proxies):len(pm.Proxies)], p)
+	return nil
+}
+
+// AddProxies appends the proxies in ps to the pool, e.g. when importing more proxies while a
+// session runs, and returns how many were added. Proxies without a URL, and proxies whose URL is
+// already in the pool (or earlier in ps), are skipped. New proxies join the end of the round-robin
+// rotation. Like AddProxy, it installs a new slice. The method is thread-safe.
+func (pm *ProxyManager) AddProxies(ps []*ProxyInfo) (added int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	seen := make(map[string]bool, len(pm.Proxies)+len(ps))
+	for _, p := range pm.Proxies {
+		if p != nil && p.URL != nil {
+			seen[p.URL.String()] = true
+		}
+	}
+	var fresh []*ProxyInfo
+	for _, p := range ps {
+		if p == nil || p.URL == nil || seen[p.URL.String()] {
+			continue
+		}
+		seen[p.URL.String()] = true
+		fresh = append(fresh, p)
+	}
+	if len(fresh) == 0 {
+		return 0
+	}
+	// The full slice expression forces append to allocate, leaving the previous slice untouched.
+	pm.Proxies = append(pm.Proxies[:len(pm.Proxies):len(pm.Proxies)], fresh...)
+	return len(fresh)
+}
+
+// RemoveProxy removes the proxy with the given URL from the pool. It returns ErrProxyNotFound
+// if no such proxy exists. If the proxy was a round-robin candidate ahead of the cursor, the
+// cursor moves back one, so rotation continues from the same proxy. The method is thread-safe.
+func (pm *ProxyManager) RemoveProxy(proxyURL string) error {
+	pm.mu.Lock()
+	candidateIndex := 0 // Position of p among the round-robin candidates.
+	for i, p := range pm.Proxies {
+		if p != nil && p.URL != nil && p.URL.String() == proxyURL {
+			if pm.isCandidateLocked(p) && candidateIndex < pm.currentIndex {
+				pm.currentIndex--
+			}
+			remaining := make([]*ProxyInfo, 0, len(pm.Proxies)-1)
+			remaining = append(remaining, pm.Proxies[:i]...)
+			remaining = append(remaining, pm.Proxies[i+1:]...)
+			pm.Proxies = remaining
+			if pm.currentIndex >= len(remaining) {
+				pm.currentIndex = 0
+			}
+			onRemove := pm.OnRemove
+			pm.mu.Unlock()
+			if onRemove != nil {
+				onRemove([]*ProxyInfo{p})
+			}
+			return nil
+		}
+		if pm.isCandidateLocked(p) {
+			candidateIndex++
+		}
+	}
+	pm.mu.Unlock()
+	return fmt.Errorf("%w: %s", ErrProxyNotFound, proxyURL)
+}
+
+// ResetCursor rewinds the round-robin cursor so the next GetProxy call starts from the first
+// candidate proxy. Useful after the pool is reordered and for reproducible tests.
+// The method is thread-safe.
+func (pm *ProxyManager) ResetCursor() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.currentIndex = 0
+}
+
+// ReplaceProxies swaps the whole proxy pool (e.g., after reloading the proxy file) and resets
+// the round-robin cursor, so rotation over the new pool starts from its first proxy.
+// The method is thread-safe.
+func (pm *ProxyManager) ReplaceProxies(proxies []*ProxyInfo) {
+	kept := make(map[string]bool, len(proxies))
+	for _, p := range proxies {
+		if p != nil && p.URL != nil {
+			kept[p.URL.String()] = true
+		}
+	}
+
+	pm.mu.Lock()
+	var removed []*ProxyInfo // Old proxies whose URL is not in the new pool.
+	for _, p := range pm.Proxies {
+		if p != nil && p.URL != nil && !kept[p.URL.String()] {
+			removed = append(removed, p)
+		}
+	}
+	pm.Proxies = proxies
+	pm.currentIndex = 0
+	onRemove := pm.OnRemove
+	pm.mu.Unlock()
+
+	if len(removed) > 0 && onRemove != nil {
+		onRemove(removed)
+	}
+}
+
+// PruneUnhealthy removes proxies marked "unhealthy", StatusDead or StatusQuarantined from the pool, e.g.
+// after a health pass, and returns how many were removed. Proxies not yet checked ("unknown")
+// and "slow" proxies are kept. The round-robin cursor is adjusted so rotation continues from
+// the same proxy: like RemoveProxy, it moves back by the number of pruned round-robin candidates
+// (see isCandidateLocked) that preceded it. Pruned proxies that were never candidates, such as
+// dead or quarantined ones, leave it unchanged.
+// Like RemoveProxy, it installs a new slice. The method is thread-safe.
+func (pm *ProxyManager) PruneUnhealthy() (removed int) {
+	pm.mu.Lock()
+	var pruned []*ProxyInfo
+	remaining := make([]*ProxyInfo, 0, len(pm.Proxies))
+	cursor := pm.currentIndex
+	candidateIndex := 0 // Position of p among the round-robin candidates.
+	for _, p := range pm.Proxies {
+		if p == nil {
+			remaining = append(remaining, p)
+			continue
+		}
+		candidate := pm.isCandidateLocked(p)
+		if p.HealthStatus == "unhealthy" || p.HealthStatus == StatusDead || p.HealthStatus == StatusQuarantined {
+			removed++
+			pruned = append(pruned, p)
+			if candidate && candidateIndex < pm.currentIndex {
+				cursor--
+			}
+		} else {
+			remaining = append(remaining, p)
+		}
+		if candidate {
+			candidateIndex++
+		}
+	}
+	if removed == 0 {
+		pm.mu.Unlock()
+		return 0
+	}
+	pm.Proxies = remaining
+	if cursor >= len(remaining) {
+		cursor = 0
+	}
+	pm.currentIndex = cursor
+	onRemove := pm.OnRemove
+	pm.mu.Unlock()
+
+	if onRemove != nil {
+		onRemove(pruned)
+	}
+	return removed
+}
+
+// ResetHealth sets every proxy back to "unknown" so the next health check re-evaluates the pool
+// from scratch, e.g. after a network outage falsely marked it unhealthy. Latency, last checked
+// time and the consecutive failure count are cleared, which also revives dead proxies. Proxies
+// marked StatusQuarantined were taken out of rotation by hand and keep their status. It returns
+// how many proxies were reset. The method is thread-safe.
+func (pm *ProxyManager) ResetHealth() (reset int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, p := range pm.Proxies {
+		if p == nil || p.HealthStatus == StatusQuarantined {
+			continue
+		}
+		p.HealthStatus = "unknown"
+		p.Latency = 0
+		p.LastChecked = time.Time{}
+		p.NeedsRecheck = false
+		p.ConsecutiveFailures = 0
+		reset++
+	}
+	return reset
+}
+
+// selectAvoidingRecentLocked picks a random candidate that is not among the last window
+// selections and records it in the ring. The window is capped at len(candidates)-1, so a pool
+// smaller than the window still yields a proxy, used as long ago as possible. Callers must hold pm.mu.
+func (pm *ProxyManager) selectAvoidingRecentLocked(candidates []*ProxyInfo) *ProxyInfo {
+	size := pm.AvoidRecentWindow
+	if size <= 0 {
+		size = DefaultAvoidRecentWindow
+	}
+	if len(pm.recent) != size { // First use, or the window was resized.
+		pm.recent = make([]string, size)
+		pm.recentPos = 0
+	}
+	window := size
+	if window > len(candidates)-1 {
+		window = len(candidates) - 1
+	}
+
+	avoid := make(map[string]bool, window)
+	for i := 1; i <= window; i++ {
+		if key := pm.recent[(pm.recentPos-i+size)%size]; key != "" {
+			avoid[key] = true
+		}
+	}
+	var eligible []*ProxyInfo
+	for _, p := range candidates {
+		if p.URL == nil || !avoid[p.URL.String()] {
+			eligible = append(eligible, p)
+		}
+	}
+	if len(eligible) == 0 { // Only possible if candidates share URLs; fall back to any candidate.
+		eligible = candidates
+	}
+
+	selected := eligible[pm.rng.Intn(len(eligible))]
+	if selected.URL != nil {
+		pm.recent[pm.recentPos] = selected.URL.String()
+		pm.recentPos = (pm.recentPos + 1) % size
+	}
+	return selected
+}
+
+// successWeight is the selection weight StrategyWeighted gives p: its success ratio with one
+// success and one failure added, (success+1)/(success+failure+2), so a proxy without recorded
+// outcomes weighs 0.5 and a failing proxy keeps a small chance of being tried again.
+func successWeight(p *ProxyInfo) float64 {
+	return float64(p.SuccessCount+1) / float64(p.SuccessCount+p.FailureCount+2)
+}
+
+// selectWeightedLocked picks a random candidate with a probability proportional to its
+// successWeight. Callers must hold pm.mu.
+func (pm *ProxyManager) selectWeightedLocked(candidates []*ProxyInfo) *ProxyInfo {
+	total := 0.0
+	for _, p := range candidates {
+		total += successWeight(p)
+	}
+	pick := pm.rng.Float64() * total
+	for _, p := range candidates {
+		pick -= successWeight(p)
+		if pick < 0 {
+			return p
+		}
+	}
+	return candidates[len(candidates)-1] // Only reached through floating-point rounding.
+}
+
+// RecordResult counts the outcome of a report attempt sent through the proxy with the given URL,
+// for StrategyWeighted. A success also resets the proxy's ConsecutiveFailures. Outcomes for proxies not in the pool are ignored. The method is thread-safe.
+func (pm *ProxyManager) RecordResult(proxyURL string, success bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, p := range pm.Proxies {
+		if p != nil && p.URL != nil && p.URL.String() == proxyURL {
+			if success {
+				p.SuccessCount++
+				p.ConsecutiveFailures = 0
+			} else {
+				p.FailureCount++
+			}
+			return
+		}
+	}
+}
+
+// expireStaleLocked downgrades "healthy" proxies last checked more than MaxHealthyAge ago to
+// "unknown" and flags them for a recheck. Pinned proxies and proxies never checked are left
+// alone. Callers must hold pm.mu.
+func (pm *ProxyManager) expireStaleLocked() {
+	if pm.MaxHealthyAge <= 0 {
+		return
+	}
+	for _, p := range pm.Proxies {
+		if p == nil || p.Pinned || p.HealthStatus != "healthy" || p.LastChecked.IsZero() {
+			continue
+		}
+		if time.Since(p.LastChecked) > pm.MaxHealthyAge {
+			p.HealthStatus = "unknown"
+			p.NeedsRecheck = true
+		}
+	}
+}
+
+// ProxiesNeedingRecheck returns the proxies whose healthy status has expired (see MaxHealthyAge),
+// for a background loop to pass to CheckProxies. The method is thread-safe.
+func (pm *ProxyManager) ProxiesNeedingRecheck() []*ProxyInfo {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.expireStaleLocked()
+	var stale []*ProxyInfo
+	for _, p := range pm.Proxies {
+		if p != nil && p.NeedsRecheck {
+			stale = append(stale, p)
+		}
+	}
+	return stale
+}
+
+// SelectionCounts returns how many times GetProxy has returned each proxy, keyed by proxy URL,
+// while AuditSelections was enabled. The returned map is a copy. The method is thread-safe.
+func (pm *ProxyManager) SelectionCounts() map[string]int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	counts := make(map[string]int, len(pm.selectionCounts))
+	for url, n := range pm.selectionCounts {
+		counts[url] = n
+	}
+	return counts
+}
+
 // UpdateProxyStatus updates the health status, latency, and last checked time
 // of a specific proxy in the manager's list.
 // The proxy is identified by its URL string.
@@ -156,8 +612,15 @@ func (pm *ProxyManager) GetProxy(targetRegion ...string) (*ProxyInfo, error) {
 //   - newStatus: The new health status string (e.g., "healthy", "unhealthy").
 //   - latency: The latency recorded for the operation that determined this new status.
 //
+// Pinned proxies keep their status when newStatus is an automatic downgrade ("unhealthy", "slow");
+// their latency and last checked time are still updated. StatusQuarantined always applies.
+//
+// Each "unhealthy" status counts as a consecutive failure of a non-pinned proxy, and a "healthy"
+// status resets the count. Once MaxConsecutiveFailures is reached, the proxy is marked StatusDead.
+// A dead proxy stays dead through further automatic downgrades.
+//
 // Returns:
-//   - `nil` if the proxy was found and updated.
+//   - `nil` if the proxy was found (even if a pinned proxy's status was left unchanged).
 //   - An error if a proxy with the given URL string is not found in the manager.
 //
 // The method is thread-safe.
@@ -169,7 +632,22 @@ func (pm *ProxyManager) UpdateProxyStatus(proxyURL string, newStatus string, lat
 	for _, p := range pm.Proxies {
 		// Ensure p and p.URL are not nil before calling String()
 		if p != nil && p.URL != nil && p.URL.String() == proxyURL {
-			p.HealthStatus = newStatus
+			switch {
+			case isAutomaticDowngrade(newStatus) && (p.Pinned || p.HealthStatus == StatusDead):
+				// Keep the current status.
+			case newStatus == "unhealthy":
+				p.ConsecutiveFailures++
+				p.HealthStatus = newStatus
+				if pm.MaxConsecutiveFailures > 0 && p.ConsecutiveFailures >= pm.MaxConsecutiveFailures {
+					p.HealthStatus = StatusDead
+				}
+			default:
+				if newStatus == "healthy" {
+					p.ConsecutiveFailures = 0
+				}
+				p.HealthStatus = newStatus
+			}
+			p.NeedsRecheck = false
 			p.Latency = latency
 			p.LastChecked = time.Now()
 			found = true
@@ -182,9 +660,86 @@ func (pm *ProxyManager) UpdateProxyStatus(proxyURL string, newStatus string, lat
 	return nil
 }
 
+// ApplyRegions sets the Region of pool proxies that have none from regions, keyed by the proxy's
+// URL.String(), e.g. with the results of a BatchGeoCheck run on copies of the proxies. It returns
+// how many proxies were given a region. The method is thread-safe.
+func (pm *ProxyManager) ApplyRegions(regions map[string]string) (applied int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, p := range pm.Proxies {
+		if p == nil || p.URL == nil || p.Region != "" {
+			continue
+		}
+		if region := regions[p.URL.String()]; region != "" {
+			p.Region = region
+			applied++
+		}
+	}
+	return applied
+}
+
+// SetMinRecheckInterval sets the least time between two health checks of the same proxy. With
+// a positive d, the manager's checks skip a proxy whose LastChecked is more recent and keep its
+// cached status, so overlapping startup, periodic and manual checks do not hammer it; proxies
+// flagged with NeedsRecheck, or never checked, are always checked. Zero or less checks every
+// proxy. It can be changed while the manager is in use, e.g. on a config reload. The method is
+// thread-safe.
+func (pm *ProxyManager) SetMinRecheckInterval(d time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.minRecheckInterval = d
+}
+
+// MinRecheckInterval returns the interval set by SetMinRecheckInterval.
+// The method is thread-safe.
+func (pm *ProxyManager) MinRecheckInterval() time.Duration {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.minRecheckInterval
+}
+
+// SetHealthyOnly switches selection between healthy proxies only (true) and any proxy in the
+// pool (false) while the manager is in use, e.g. to keep a session going when the pool degrades.
+// The method is thread-safe.
+func (pm *ProxyManager) SetHealthyOnly(healthyOnly bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.HealthyOnly = healthyOnly
+}
+
+// IsHealthyOnly reports whether selection is currently limited to healthy proxies.
+// The method is thread-safe.
+func (pm *ProxyManager) IsHealthyOnly() bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.HealthyOnly
+}
+
+// IsDegraded reports whether the pool is in a degraded state where GetProxy cannot return
+// anything: HealthyOnly is enabled, the pool is non-empty, and every proxy has been checked
+// and none is healthy. Proxies still awaiting their first check ("unknown") keep the pool
+// out of the degraded state. The method is thread-safe.
+func (pm *ProxyManager) IsDegraded() bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if !pm.HealthyOnly || len(pm.Proxies) == 0 {
+		return false
+	}
+	for _, p := range pm.Proxies {
+		if p != nil && (p.HealthStatus == "healthy" || p.HealthStatus == "unknown") {
+			return false
+		}
+	}
+	return true
+}
+
 // GetAllProxies returns a new slice containing all proxies currently managed by the ProxyManager.
 // This is useful for operations like batch health checks that need to iterate over all proxies.
-// Returns a copy to prevent external modification of the manager's internal proxy slice.
+// Returns a copy to prevent external modification of the manager's internal proxy slice; later
+// AddProxy/RemoveProxy calls do not change it. The *ProxyInfo values are shared with the pool,
+// so status updates made through the manager remain visible through them.
 // The method is thread-safe.
 func (pm *ProxyManager) GetAllProxies() []*ProxyInfo {
 	pm.mu.Lock()
@@ -198,3 +753,49 @@ func (pm *ProxyManager) GetAllProxies() []*ProxyInfo {
 	copy(proxiesCopy, pm.Proxies)
 	return proxiesCopy
 }
+
+// ProxySnapshots returns a copy of every non-nil proxy in the pool, taken under pm.mu, so their
+// fields can be read while health checks and sessions keep updating the pool's entries (which
+// reading through GetAllProxies' shared pointers would race with). The method is thread-safe.
+func (pm *ProxyManager) ProxySnapshots() []ProxyInfo {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	snapshots := make([]ProxyInfo, 0, len(pm.Proxies))
+	for _, p := range pm.Proxies {
+		if p != nil {
+			snapshots = append(snapshots, *p)
+		}
+	}
+	return snapshots
+}
+
+// GetProxyByRegion returns a healthy proxy from the given region, ignoring the configured
+// Strategy and HealthyOnly settings. It is intended for one-off requests (e.g., a manual test
+// against a specific region) where the normal rotation should not be consulted or advanced.
+//
+// Returns:
+//   - A randomly chosen healthy proxy whose Region matches `region` (case-insensitive).
+//   - `ErrNoProxiesAvailable` if the pool is empty, or an error wrapping `ErrNoMatchingProxies`
+//     if no healthy proxy exists in that region.
+//
+// The method is thread-safe.
+func (pm *ProxyManager) GetProxyByRegion(region string) (*ProxyInfo, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if len(pm.Proxies) == 0 {
+		return nil, ErrNoProxiesAvailable
+	}
+
+	var matches []*ProxyInfo
+	for _, p := range pm.Proxies {
+		if p != nil && p.HealthStatus == "healthy" && strings.EqualFold(p.Region, region) {
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: no healthy proxy for region '%s'", ErrNoMatchingProxies, region)
+	}
+	return matches[pm.rng.Intn(len(matches))], nil
+}
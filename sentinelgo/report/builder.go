@@ -0,0 +1,137 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"sentinelgo/sentinelgo/config"
+)
+
+// RequestBuilder builds the HTTP request for a single report attempt.
+// Supporting a platform that needs a different request shape (form or JSON body,
+// specific headers, signed parameters) only requires implementing this interface
+// and setting it as Reporter.RequestBuilder.
+//
+// Build is called once per attempt, so retries get a fresh request. The returned request
+// must carry ctx; the Reporter takes care of the proxy, retries and logging.
+type RequestBuilder interface {
+	Build(ctx context.Context, targetURL string, sessionID string) (*http.Request, error)
+}
+
+// DefaultRequestBuilder builds the standard report request: by default a POST with a nil body
+// (or an explicit empty body when Config.SendEmptyBody is set) carrying the configured headers and
+// cookies. Config.RequestMethod changes the method, and Config.RequestBodyTemplate gives it a body.
+type DefaultRequestBuilder struct {
+	Config *config.AppConfig
+}
+
+// Build implements RequestBuilder.
+func (b *DefaultRequestBuilder) Build(ctx context.Context, targetURL string, sessionID string) (*http.Request, error) {
+	method := b.Config.RequestMethod
+	if method == "" {
+		method = "POST"
+	}
+
+	// By default the request has a nil body; with SendEmptyBody an explicit empty body
+	// is sent so Content-Length/Content-Type are always present.
+	var reqBody io.Reader = nil // Explicitly nil for a request with no body.
+	contentType := ""
+	switch {
+	case b.Config.RequestBodyTemplate != "":
+		contentType = b.Config.RequestContentType
+		if contentType == "" {
+			contentType = defaultBodyContentType
+		}
+		reqBody = strings.NewReader(renderBodyTemplate(b.Config.RequestBodyTemplate, contentType, targetURL, sessionID))
+	case b.Config.SendEmptyBody:
+		contentType = b.Config.EmptyBodyContentType
+		if contentType == "" {
+			contentType = defaultBodyContentType
+		}
+		reqBody = strings.NewReader("")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		if req.ContentLength == 0 {
+			req.Header.Set("Content-Length", "0") // Make the header explicit for logging; the transport sends it either way.
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	ApplyConfigHeaders(req, b.Config)
+	return req, nil
+}
+
+// renderBodyTemplate replaces "{{target}}" and "{{sessionID}}" in tmpl with targetURL and
+// sessionID. For a JSON contentType they are escaped for use inside a JSON string, and for a
+// form-encoded one they are query-escaped; other content types get them as is.
+func renderBodyTemplate(tmpl, contentType, targetURL, sessionID string) string {
+	escape := func(s string) string { return s }
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		escape = url.QueryEscape
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		escape = func(s string) string {
+			var buf strings.Builder
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false) // Keep URLs readable: "&" rather than "\u0026".
+			enc.Encode(s)            // Encoding a string cannot fail.
+			quoted := strings.TrimSuffix(buf.String(), "\n")
+			return quoted[1 : len(quoted)-1] // Drop the quotes; the template has its own.
+		}
+	}
+	return strings.NewReplacer("{{target}}", escape(targetURL), "{{sessionID}}", escape(sessionID)).Replace(tmpl)
+}
+
+// ApplyConfigHeaders sets the User-Agent (from cfg.DefaultHeaders, or a random default),
+// the remaining cfg.DefaultHeaders and cfg.CustomCookies on req.
+// Custom RequestBuilders can call it to keep the configured headers and cookies.
+// The headers are read with DefaultHeadersSnapshot, so the Settings tab can edit them meanwhile.
+func ApplyConfigHeaders(req *http.Request, cfg *config.AppConfig) {
+	// Set headers from AppConfig and a fallback default user agent list.
+	headers := cfg.DefaultHeadersSnapshot()
+	userAgent := headers["User-Agent"]
+	if userAgent == "" && len(defaultUserAgents) > 0 {
+		userAgent = defaultUserAgents[rand.Intn(len(defaultUserAgents))]
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range headers {
+		if key != "User-Agent" { // Avoid setting User-Agent twice.
+			req.Header.Set(key, value)
+		}
+	}
+
+	// Add custom cookies from AppConfig.
+	for _, cookie := range cfg.CustomCookies {
+		req.AddCookie(&cookie)
+	}
+}
+
+// requestBodyString returns a copy of req's body for logging without consuming it.
+// It returns "" if the request has no body or its body cannot be replayed (no GetBody).
+func requestBodyString(req *http.Request) string {
+	if req.Body == nil || req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// jsonRequestBuilder is a custom builder for a platform that expects a JSON report body.
+type jsonRequestBuilder struct {
+	cfg   *config.AppConfig
+	calls int
+}
+
+func (b *jsonRequestBuilder) Build(ctx context.Context, targetURL string, sessionID string) (*http.Request, error) {
+	b.calls++
+	payload, err := json.Marshal(map[string]string{"session": sessionID, "reason": "spam"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	ApplyConfigHeaders(req, b.cfg)
+	return req, nil
+}
+
+func TestSendReport_CustomRequestBuilder(t *testing.T) {
+	var gotBody map[string]string
+	var gotContentType, gotUserAgent string
+	cfg := &config.AppConfig{MaxRetries: 1, DefaultHeaders: map[string]string{"User-Agent": "builder-test"}}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		gotContentType = req.Header.Get("Content-Type")
+		gotUserAgent = req.Header.Get("User-Agent")
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&gotBody))
+		w.WriteHeader(http.StatusOK)
+	})
+	var logBuf bytes.Buffer
+	r.Logger = utils.NewLogger(&logBuf, "INFO")
+	builder := &jsonRequestBuilder{cfg: cfg}
+	r.RequestBuilder = builder
+
+	_, err := r.SendReport(context.Background(), target, "session-42")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, builder.calls)
+	assert.Equal(t, "application/json", gotContentType)
+	assert.Equal(t, "builder-test", gotUserAgent, "configured headers should be applied via ApplyConfigHeaders")
+	assert.Equal(t, map[string]string{"session": "session-42", "reason": "spam"}, gotBody)
+	assert.Contains(t, logBuf.String(), `"request_body":"{\"reason\":\"spam\",\"session\":\"session-42\"}"`, "the custom body should be logged")
+}
+
+func TestSendOnce_CountsRequestBody(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	r.RequestBuilder = &jsonRequestBuilder{cfg: cfg}
+	payload, err := json.Marshal(map[string]string{"session": "", "reason": "spam"})
+	require.NoError(t, err)
+
+	_, err = r.SendOnce(target)
+	require.NoError(t, err)
+	_, sent := r.BudgetUsage()
+	assert.Equal(t, int64(len(payload)+len("ok")), sent, "the request body is charged like in SendReport")
+}
+
+func TestDefaultRequestBuilder(t *testing.T) {
+	cfg := &config.AppConfig{DefaultHeaders: map[string]string{"X-Test": "1"}, CustomCookies: []http.Cookie{{Name: "c", Value: "v"}}}
+	req, err := (&DefaultRequestBuilder{Config: cfg}).Build(context.Background(), "http://example.com/report", "s1")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Nil(t, req.Body, "the default request has no body")
+	assert.Equal(t, "1", req.Header.Get("X-Test"))
+	assert.NotEmpty(t, req.Header.Get("User-Agent"), "a default User-Agent should be chosen")
+	cookie, err := req.Cookie("c")
+	require.NoError(t, err)
+	assert.Equal(t, "v", cookie.Value)
+}
+
+func TestRenderBodyTemplate(t *testing.T) {
+	const target = `http://example.com/p?a=1&b="x"`
+	tests := []struct {
+		contentType, tmpl, want string
+	}{
+		{"application/json", `{"u":"{{target}}","s":"{{sessionID}}"}`, `{"u":"http://example.com/p?a=1&b=\"x\"","s":"s 1"}`},
+		{"application/problem+json; charset=utf-8", `"{{target}}"`, `"http://example.com/p?a=1&b=\"x\""`},
+		{"application/x-www-form-urlencoded", "u={{target}}&s={{sessionID}}", "u=http%3A%2F%2Fexample.com%2Fp%3Fa%3D1%26b%3D%22x%22&s=s+1"},
+		{"text/plain", "{{target}} {{sessionID}} {{other}}", target + " s 1 {{other}}"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, renderBodyTemplate(tt.tmpl, tt.contentType, target, "s 1"), tt.contentType)
+	}
+}
+
+func TestRequestBodyString(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", strings.NewReader("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, "payload", requestBodyString(req))
+
+	data, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(data), "reading the body for logging must not consume it")
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// CurlCommand renders req as a curl command that reproduces it: the method, the headers (sorted
+// by name), body as --data-raw (when not empty), proxyURL as -x (when not nil) and the URL. Every
+// argument is quoted for a POSIX shell.
+//
+// With redact set, the values of credential-bearing headers (those matched by
+// config.IsSensitiveHeader) are replaced by utils.RedactedHeaderValue and the proxy's
+// password is masked, so the command can be shared; without it the command carries the
+// credentials it needs to run as is.
+func CurlCommand(req *http.Request, body string, proxyURL *url.URL, redact bool) string {
+	args := []string{"curl", "-X", shellQuote(req.Method)}
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H", shellQuote("Host: "+req.Host))
+	}
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			if redact && config.IsSensitiveHeader(name) {
+				value = utils.RedactedHeaderValue
+			}
+			args = append(args, "-H", shellQuote(name+": "+value))
+		}
+	}
+
+	if body != "" {
+		args = append(args, "--data-raw", shellQuote(body))
+	}
+	if proxyURL != nil {
+		proxyArg := proxyURL.String()
+		if redact {
+			proxyArg = proxyURL.Redacted()
+		}
+		args = append(args, "-x", shellQuote(proxyArg))
+	}
+	args = append(args, shellQuote(req.URL.String()))
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// failedAttempt is the request of the most recent failed report attempt, kept for
+// LastFailedCurl. req is a clone taken after the attempt, so its body is already consumed;
+// body holds a copy.
+type failedAttempt struct {
+	req   *http.Request
+	body  string
+	proxy *url.URL
+}
+
+// rememberFailure keeps req, sent with body through p, as the most recent failed attempt.
+func (r *Reporter) rememberFailure(req *http.Request, body string, p *proxy.ProxyInfo) {
+	attempt := &failedAttempt{req: req.Clone(context.Background()), body: body}
+	if p != nil && p.URL != nil {
+		proxyURL := *p.URL
+		attempt.proxy = &proxyURL
+	}
+	r.failureMu.Lock()
+	defer r.failureMu.Unlock()
+	r.lastFailure = attempt
+}
+
+// LastFailedCurl returns the most recent failed report attempt rendered by CurlCommand, with the
+// proxy it was sent through, so it can be reproduced by hand. ok is false if no attempt has
+// failed yet.
+func (r *Reporter) LastFailedCurl(redact bool) (cmd string, ok bool) {
+	r.failureMu.Lock()
+	attempt := r.lastFailure
+	r.failureMu.Unlock()
+	if attempt == nil {
+		return "", false
+	}
+	return CurlCommand(attempt.req, attempt.body, attempt.proxy, redact), true
+}
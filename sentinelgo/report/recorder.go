@@ -0,0 +1,179 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRecordedInteraction is returned by ReplayTransport when a request has no remaining
+// recorded interaction with the same method and URL.
+var ErrNoRecordedInteraction = errors.New("no recorded interaction for request")
+
+// RecordedRequest is the serialized form of a report attempt's request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the serialized form of the response to a report attempt.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is one recorded report attempt: the request sent, and either the response
+// received or the transport error that prevented one.
+type Interaction struct {
+	Timestamp time.Time         `json:"timestamp"`
+	SessionID string            `json:"session_id,omitempty"`
+	Proxy     string            `json:"proxy,omitempty"`
+	LatencyMS int64             `json:"latency_ms"`
+	Request   RecordedRequest   `json:"request"`
+	Response  *RecordedResponse `json:"response,omitempty"` // Nil if the attempt failed before a response arrived.
+	Error     string            `json:"error,omitempty"`    // Transport error, if any.
+}
+
+// Recorder writes each report attempt as one JSON line, so failing interactions can be
+// inspected or replayed later with a ReplayTransport. It is safe for concurrent use.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a Recorder that writes interactions to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// OpenRecorder returns a Recorder appending to the file at path, creating it if needed.
+// The file is created with owner-only permissions because recordings contain full
+// request and response headers and bodies. The caller should close the returned file.
+func OpenRecorder(path string) (*Recorder, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open recording file '%s': %w", path, err)
+	}
+	return NewRecorder(f), f, nil
+}
+
+// Record writes one interaction as a JSON line.
+func (rec *Recorder) Record(interaction Interaction) error {
+	data, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recorded interaction: %w", err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if _, err := rec.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write recorded interaction: %w", err)
+	}
+	return nil
+}
+
+// LoadInteractions reads interactions written by a Recorder. Blank lines are skipped.
+func LoadInteractions(r io.Reader) ([]Interaction, error) {
+	var interactions []Interaction
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // Recorded bodies can exceed the default line limit.
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal([]byte(text), &interaction); err != nil {
+			return nil, fmt.Errorf("failed to parse recorded interaction on line %d: %w", line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recorded interactions: %w", err)
+	}
+	return interactions, nil
+}
+
+// ReplayTransport is an http.RoundTripper that answers requests from recorded interactions
+// instead of the network. Requests are matched by method and URL; interactions with the same
+// method and URL are replayed in the order they were recorded. It is safe for concurrent use.
+type ReplayTransport struct {
+	mu      sync.Mutex
+	pending map[string][]Interaction
+}
+
+// NewReplayTransport returns a ReplayTransport serving the given interactions.
+func NewReplayTransport(interactions []Interaction) *ReplayTransport {
+	pending := make(map[string][]Interaction)
+	for _, interaction := range interactions {
+		key := replayKey(interaction.Request.Method, interaction.Request.URL)
+		pending[key] = append(pending[key], interaction)
+	}
+	return &ReplayTransport{pending: pending}
+}
+
+// replayKey identifies the interactions that can answer a request.
+func replayKey(method, rawURL string) string {
+	return strings.ToUpper(method) + " " + rawURL
+}
+
+// RoundTrip returns the next recorded response for req's method and URL. A recorded transport
+// error is returned as an error. ErrNoRecordedInteraction is returned once no interaction is left.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close() // RoundTrippers must close the request body.
+	}
+
+	key := replayKey(req.Method, req.URL.String())
+	t.mu.Lock()
+	queue := t.pending[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrNoRecordedInteraction, key)
+	}
+	interaction := queue[0]
+	t.pending[key] = queue[1:]
+	t.mu.Unlock()
+
+	if interaction.Response == nil {
+		if interaction.Error == "" {
+			return nil, fmt.Errorf("recorded interaction for %s has no response", key)
+		}
+		return nil, errors.New(interaction.Error)
+	}
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of recorded interactions not yet replayed.
+func (t *ReplayTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, queue := range t.pending {
+		n += len(queue)
+	}
+	return n
+}
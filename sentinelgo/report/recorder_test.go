@@ -0,0 +1,102 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+)
+
+func TestRecorder_ReplayRoundTrip(t *testing.T) {
+	hits := 0
+	r, target := newTestReporter(t, &config.AppConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}, func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		if hits == 1 {
+			http.Error(w, "upstream busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Tt-Logid", "log-123")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	var recording bytes.Buffer
+	r.Recorder = NewRecorder(&recording)
+
+	result, err := r.SendReport(context.Background(), target, "s1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+
+	interactions, err := LoadInteractions(&recording)
+	require.NoError(t, err)
+	require.Len(t, interactions, 2, "each attempt should be recorded")
+	assert.Equal(t, "s1", interactions[0].SessionID)
+	assert.Equal(t, http.MethodPost, interactions[0].Request.Method)
+	assert.Equal(t, target, interactions[0].Request.URL)
+	assert.NotEmpty(t, interactions[0].Request.Header.Get("User-Agent"))
+	assert.Equal(t, http.StatusServiceUnavailable, interactions[0].Response.StatusCode)
+	assert.Equal(t, `{"status":"ok"}`, interactions[1].Response.Body)
+	assert.Equal(t, "log-123", interactions[1].Response.Header.Get("X-Tt-Logid"))
+
+	// Replaying the recording reproduces the same outcome without touching the network.
+	replay := NewReplayTransport(interactions)
+	replayed, _ := newTestReporter(t, &config.AppConfig{MaxRetries: 2}, func(w http.ResponseWriter, req *http.Request) {
+		t.Error("replayed requests must not reach the network")
+	})
+	replayed.Transport = replay
+	var rerecording bytes.Buffer
+	replayed.Recorder = NewRecorder(&rerecording)
+
+	result, err = replayed.SendReport(context.Background(), target, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.Zero(t, replay.Remaining())
+
+	reinteractions, err := LoadInteractions(&rerecording)
+	require.NoError(t, err)
+	require.Len(t, reinteractions, 2)
+	for i := range interactions {
+		assert.Equal(t, interactions[i].Request.URL, reinteractions[i].Request.URL)
+		assert.Equal(t, interactions[i].Response.StatusCode, reinteractions[i].Response.StatusCode)
+		assert.Equal(t, interactions[i].Response.Body, reinteractions[i].Response.Body)
+	}
+}
+
+func TestReplayTransport(t *testing.T) {
+	replay := NewReplayTransport([]Interaction{
+		{Request: RecordedRequest{Method: "POST", URL: "http://example.com/report"}, Error: "proxyconnect tcp: connection refused"},
+		{Request: RecordedRequest{Method: "POST", URL: "http://example.com/report"}, Response: &RecordedResponse{StatusCode: http.StatusOK, Body: "done"}},
+	})
+	client := &http.Client{Transport: replay}
+
+	_, err := client.Post("http://example.com/report", "text/plain", strings.NewReader("x"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused", "a recorded transport error is replayed as an error")
+
+	resp, err := client.Post("http://example.com/report", "text/plain", nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "done", string(body))
+
+	_, err = client.Post("http://example.com/report", "text/plain", nil)
+	assert.True(t, errors.Is(err, ErrNoRecordedInteraction), "interactions are consumed once")
+	_, err = client.Get("http://example.com/other")
+	assert.True(t, errors.Is(err, ErrNoRecordedInteraction))
+}
+
+func TestLoadInteractions_InvalidLine(t *testing.T) {
+	_, err := LoadInteractions(strings.NewReader("{\"request\":{}}\n\nnot json\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+}
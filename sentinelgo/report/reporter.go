@@ -1,16 +1,21 @@
 package report
 
 import (
-	// "bytes" // No longer needed if not constructing specific body like form data
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url" // Required for url.Error
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"sentinelgo/sentinelgo/ai"
 	"sentinelgo/sentinelgo/config"
 	"sentinelgo/sentinelgo/proxy"
@@ -26,6 +31,99 @@ var defaultUserAgents = []string{
 	"Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
 }
 
+// defaultBodyContentType is the Content-Type used for request bodies when
+// AppConfig.RequestContentType (or, for explicit empty bodies, AppConfig.EmptyBodyContentType) is unset.
+const defaultBodyContentType = "application/x-www-form-urlencoded"
+
+// Default retry backoff used when AppConfig.BackoffBase / AppConfig.BackoffMax are unset. They keep
+// the delays close to the flat 1-2s pause SendReport used before backoff was configurable.
+const (
+	defaultBackoffBase = 1 * time.Second
+	defaultBackoffMax  = 2 * time.Second
+)
+
+// Default timeouts of a report attempt, used when AppConfig.RequestTimeout,
+// AppConfig.ResponseHeaderTimeout and AppConfig.ExpectContinueTimeout are unset.
+const (
+	defaultRequestTimeout        = 30 * time.Second
+	defaultResponseHeaderTimeout = 20 * time.Second
+	defaultExpectContinueTimeout = 5 * time.Second
+)
+
+// idleConnTimeout is how long a proxy transport keeps an idle connection open, as in
+// http.DefaultTransport.
+const idleConnTimeout = 90 * time.Second
+
+// durationOr returns d, or def if d is not positive.
+func durationOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// backoffJitterFraction is the largest share of the computed delay added as random jitter.
+const backoffJitterFraction = 0.25
+
+// backoffDelay returns how long to wait after the given failed attempt (0-based) before retrying:
+// min(maxDelay, base * 2^attempt) plus up to 25% jitter, scaled by jitter in [0, 1). A zero base or
+// maxDelay uses the package default.
+func backoffDelay(attempt int, base, maxDelay time.Duration, jitter float64) time.Duration {
+	if base <= 0 {
+		base = defaultBackoffBase
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultBackoffMax
+	}
+	delay := base
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay + time.Duration(float64(delay)*backoffJitterFraction*jitter)
+}
+
+// decorrelatedBackoffDelay returns how long to wait before the next retry with decorrelated
+// jitter: a delay between base and three times the previous delay prev (base before the first
+// retry), placed by jitter in [0, 1) and capped at maxDelay. Zero base or maxDelay use the
+// package defaults, as for backoffDelay.
+func decorrelatedBackoffDelay(prev, base, maxDelay time.Duration, jitter float64) time.Duration {
+	if base <= 0 {
+		base = defaultBackoffBase
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultBackoffMax
+	}
+	if prev < base {
+		prev = base
+	}
+	delay := base + time.Duration(float64(3*prev-base)*jitter)
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// retryDelay returns how long to wait after the given failed attempt (0-based), whose preceding
+// wait was prev, following Config.BackoffStrategy.
+func (r *Reporter) retryDelay(attempt int, prev time.Duration) time.Duration {
+	if strings.EqualFold(r.Config.BackoffStrategy, config.BackoffDecorrelated) {
+		return decorrelatedBackoffDelay(prev, r.Config.BackoffBase, r.Config.BackoffMax, rand.Float64())
+	}
+	return backoffDelay(attempt, r.Config.BackoffBase, r.Config.BackoffMax, rand.Float64())
+}
+
+// LogIDHeader is the response header carrying the target platform's log identifier (TikTok's
+// X-Tt-Logid), returned in ReportResult.LogID so reports can be correlated with the upstream service.
+const LogIDHeader = "X-Tt-Logid"
+
+// ErrBudgetExhausted is returned by SendReport and SendOnce once the Reporter's request or byte
+// budget, configured via AppConfig.MaxTotalRequests / AppConfig.MaxTotalBytes, has been used up.
+// The budget is counted per Reporter; the TUI shares a single Reporter across all its sessions.
+var ErrBudgetExhausted = errors.New("report budget exhausted")
+
 // Reporter encapsulates the logic for sending a single report, including handling proxies,
 // retries, configuration, logging, and optional AI analysis.
 type Reporter struct {
@@ -33,7 +131,33 @@ type Reporter struct {
 	ProxyMgr   *proxy.ProxyManager // Manages proxy selection and status.
 	Logger     *utils.Logger       // Structured logger for recording events.
 	AIAnalyzer ai.ContentAnalyzer  // Optional content analyzer.
-	HTTPClient *http.Client        // HTTP client used for sending requests.
+	HTTPClient *http.Client        // HTTP client settings used for sending requests; copied per attempt with the proxy's transport.
+
+	// RequestBuilder builds each report request. If nil, a DefaultRequestBuilder using Config is used.
+	RequestBuilder RequestBuilder
+
+	// Recorder, if set, records every report attempt's request and response for debugging.
+	Recorder *Recorder
+
+	// Transport, if set, is used for every attempt instead of a transport routed through the
+	// selected proxy. Tests use it with a ReplayTransport to replay recorded interactions.
+	Transport http.RoundTripper
+
+	// HeaderFunc, if set, is called before every attempt (numbered from 1) and returns headers
+	// computed at request time, such as a timestamped token. They are set over the static
+	// DefaultHeaders, the RequestBuilder's headers and the correlation header. It may be called
+	// from several goroutines at once.
+	HeaderFunc func(target, sessionID string, attempt int) map[string]string
+
+	budgetMu     sync.Mutex // Protects the budget counters below, which belong to this Reporter.
+	requestsSent int        // Number of requests sent so far, counted against Config.MaxTotalRequests.
+	bytesSent    int64      // Number of body bytes transferred so far, counted against Config.MaxTotalBytes.
+
+	failureMu   sync.Mutex     // Protects lastFailure.
+	lastFailure *failedAttempt // The most recent failed attempt, rendered by LastFailedCurl.
+
+	transportsMu sync.Mutex                 // Protects transports.
+	transports   map[string]*http.Transport // Transports by proxy URL, built on first use by transportFor.
 }
 
 // NewReporter creates and returns a new Reporter instance.
@@ -44,7 +168,9 @@ type Reporter struct {
 //   - logger: A pointer to the Logger for structured logging.
 //   - analyzer: An implementation of the ai.ContentAnalyzer interface for content analysis (can be nil).
 //
-// The HTTPClient is initialized here but its transport (including proxy) is configured per request attempt.
+// The HTTPClient is initialized here; each attempt sends through a copy of it whose transport
+// routes through the selected proxy. Transports are kept per proxy, so attempts through the same
+// proxy reuse its connections.
 func NewReporter(cfg *config.AppConfig, pm *proxy.ProxyManager, logger *utils.Logger, analyzer ai.ContentAnalyzer) *Reporter {
 	return &Reporter{
 		Config:     cfg,
@@ -58,134 +184,559 @@ func NewReporter(cfg *config.AppConfig, pm *proxy.ProxyManager, logger *utils.Lo
 	}
 }
 
+// reserveRequest claims one request from the budget before an attempt is sent.
+// It returns ErrBudgetExhausted if either the request or byte budget has been used up.
+func (r *Reporter) reserveRequest() error {
+	r.budgetMu.Lock()
+	defer r.budgetMu.Unlock()
+	if r.Config.MaxTotalRequests > 0 && r.requestsSent >= r.Config.MaxTotalRequests {
+		return ErrBudgetExhausted
+	}
+	if r.Config.MaxTotalBytes > 0 && r.bytesSent >= r.Config.MaxTotalBytes {
+		return ErrBudgetExhausted
+	}
+	r.requestsSent++
+	return nil
+}
+
+// recordBytes adds n transferred body bytes to the byte budget counter.
+func (r *Reporter) recordBytes(n int) {
+	r.budgetMu.Lock()
+	defer r.budgetMu.Unlock()
+	r.bytesSent += int64(n)
+}
+
+// BudgetUsage returns the number of requests sent and body bytes transferred so far (thread-safe).
+func (r *Reporter) BudgetUsage() (requests int, bytes int64) {
+	r.budgetMu.Lock()
+	defer r.budgetMu.Unlock()
+	return r.requestsSent, r.bytesSent
+}
+
+// transportFor returns the RoundTripper for an attempt through p: r.Transport if set,
+// otherwise the transport routed through the proxy, built by newTransport on the proxy's first
+// attempt and shared by every later one so its idle connections are reused. It is safe for
+// concurrent use. A transport keeps the settings of the Config it was built with.
+func (r *Reporter) transportFor(p *proxy.ProxyInfo) http.RoundTripper {
+	if r.Transport != nil {
+		return r.Transport
+	}
+	key := p.URL.String()
+	r.transportsMu.Lock()
+	defer r.transportsMu.Unlock()
+	if transport, ok := r.transports[key]; ok {
+		return transport
+	}
+	if r.transports == nil {
+		r.transports = make(map[string]*http.Transport)
+	}
+	transport := r.newTransport(p)
+	r.transports[key] = transport
+	return transport
+}
+
+// CloseIdleConnections closes the idle connections of every proxy transport and forgets them, so
+// the next attempt through each proxy starts with a transport built from the current Config.
+// Connections in use are closed once their request finishes.
+func (r *Reporter) CloseIdleConnections() {
+	r.transportsMu.Lock()
+	transports := r.transports
+	r.transports = nil
+	r.transportsMu.Unlock()
+	for _, transport := range transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// ForgetProxy closes the idle connections of the transport through the proxy with the given URL
+// and forgets it, e.g. once the proxy has left the pool (see proxy.ProxyManager.OnRemove).
+// Connections in use are closed once their request finishes.
+func (r *Reporter) ForgetProxy(proxyURL string) {
+	r.transportsMu.Lock()
+	transport := r.transports[proxyURL]
+	delete(r.transports, proxyURL)
+	r.transportsMu.Unlock()
+	if transport != nil {
+		transport.CloseIdleConnections()
+	}
+}
+
+// proxyID returns the identifier p is logged as, chosen by Config.ProxyLogIdentifier (see
+// proxy.LogIdentifier): by default its URL without credentials.
+func (r *Reporter) proxyID(p *proxy.ProxyInfo) string {
+	mode := ""
+	if r.Config != nil {
+		mode = r.Config.ProxyLogIdentifier
+	}
+	return proxy.LogIdentifier(p.URL.String(), mode)
+}
+
+// record writes one attempt to r.Recorder, if set. resp is nil when the request failed with
+// err before a response arrived. Recording failures are logged but never fail the report.
+func (r *Reporter) record(sessionID string, p *proxy.ProxyInfo, latency time.Duration, req *http.Request, reqBody string, resp *http.Response, respBody string, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	interaction := Interaction{
+		Timestamp: time.Now().UTC(),
+		SessionID: sessionID,
+		Proxy:     r.proxyID(p),
+		LatencyMS: latency.Milliseconds(),
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   reqBody,
+		},
+	}
+	if resp != nil {
+		interaction.Response = &RecordedResponse{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: respBody}
+	}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	if recErr := r.Recorder.Record(interaction); recErr != nil && r.Logger != nil {
+		r.Logger.Warn(utils.LogEntry{SessionID: sessionID, Message: "Failed to record report attempt", ReportURL: req.URL.String(), Error: recErr.Error()})
+	}
+}
+
+// newTransport returns the HTTP transport used to route report attempts through the given proxy.
+// With Config.DisableKeepAlives set, connections are never reused across requests. The header and
+// 100-continue timeouts come from Config.ResponseHeaderTimeout and Config.ExpectContinueTimeout.
+// Idle connections are closed after idleConnTimeout, so proxies that leave the pool do not hold
+// connections open.
+func (r *Reporter) newTransport(p *proxy.ProxyInfo) *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyURL(p.URL),
+		DisableKeepAlives:     r.Config.DisableKeepAlives,
+		IdleConnTimeout:       idleConnTimeout,
+		ResponseHeaderTimeout: durationOr(r.Config.ResponseHeaderTimeout, defaultResponseHeaderTimeout),
+		ExpectContinueTimeout: durationOr(r.Config.ExpectContinueTimeout, defaultExpectContinueTimeout),
+	}
+}
+
+// buildRequest builds the report request for targetURL using the configured RequestBuilder
+// (DefaultRequestBuilder if none is set). It also returns the request body as a string for logging.
+func (r *Reporter) buildRequest(ctx context.Context, targetURL, sessionID string) (*http.Request, string, error) {
+	builder := r.RequestBuilder
+	if builder == nil {
+		builder = &DefaultRequestBuilder{Config: r.Config}
+	}
+	req, err := builder.Build(ctx, targetURL, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	if req == nil {
+		return nil, "", fmt.Errorf("request builder returned a nil request")
+	}
+	return req, requestBodyString(req), nil
+}
+
+// setCorrelationHeader sets Config.CorrelationHeader on req to id, if the header is configured.
+func (r *Reporter) setCorrelationHeader(req *http.Request, id string) {
+	if r.Config != nil && r.Config.CorrelationHeader != "" {
+		req.Header.Set(r.Config.CorrelationHeader, id)
+	}
+}
+
+// setAttemptHeaders sets the headers returned by r.HeaderFunc, if set, for the given attempt
+// (numbered from 1) on req.
+func (r *Reporter) setAttemptHeaders(req *http.Request, targetURL, sessionID string, attempt int) {
+	if r.HeaderFunc == nil {
+		return
+	}
+	for key, value := range r.HeaderFunc(targetURL, sessionID, attempt) {
+		req.Header.Set(key, value)
+	}
+}
+
+// jobIDKey is the context key under which WithJobID stores a job ID.
+type jobIDKey struct{}
+
+// WithJobID returns a copy of ctx carrying jobID, which SendReport uses for the correlation header.
+func WithJobID(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobIDKey{}, jobID)
+}
+
+// JobIDFromContext returns the job ID stored in ctx by WithJobID, or "" if there is none.
+func JobIDFromContext(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobIDKey{}).(string)
+	return jobID
+}
+
+// labelKey is the context key under which WithLabel stores a session label.
+type labelKey struct{}
+
+// WithLabel returns a copy of ctx carrying label, which SendReport adds to its log entries so the
+// attempts of a labelled session can be filtered together. Sessions use it with their Session.Label.
+func WithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+// LabelFromContext returns the label stored in ctx by WithLabel, or "" if there is none.
+func LabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(labelKey{}).(string)
+	return label
+}
+
+// dryRunKey is the context key under which WithDryRun stores the dry-run setting.
+type dryRunKey struct{}
+
+// WithDryRun returns a copy of ctx that makes SendReport rehearse the report (dryRun true) or
+// send it (false), whatever Config.DryRun says. Sessions use it with their Session.DryRun.
+func WithDryRun(ctx context.Context, dryRun bool) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dryRun)
+}
+
+// DryRunFromContext returns the setting stored in ctx by WithDryRun. ok is false if there is none.
+func DryRunFromContext(ctx context.Context) (dryRun, ok bool) {
+	dryRun, ok = ctx.Value(dryRunKey{}).(bool)
+	return dryRun, ok
+}
+
+// pinnedProxyKey is the context key under which WithProxy stores a proxy.
+type pinnedProxyKey struct{}
+
+// WithProxy returns a copy of ctx carrying p, which SendReport then uses for its attempts instead
+// of asking the ProxyManager for a proxy per attempt. Sessions use it to send several reports
+// through the same proxy (see AppConfig.ReportsPerProxy).
+func WithProxy(ctx context.Context, p *proxy.ProxyInfo) context.Context {
+	return context.WithValue(ctx, pinnedProxyKey{}, p)
+}
+
+// ProxyFromContext returns the proxy stored in ctx by WithProxy, or nil if there is none.
+func ProxyFromContext(ctx context.Context) *proxy.ProxyInfo {
+	p, _ := ctx.Value(pinnedProxyKey{}).(*proxy.ProxyInfo)
+	return p
+}
+
+// headersKey is the context key under which WithHeaders stores header overrides.
+type headersKey struct{}
+
+// WithHeaders returns a copy of ctx carrying headers, which SendReport sets on every attempt over
+// the configured DefaultHeaders. They are merged over any headers ctx already carries, so an inner
+// WithHeaders wins: sessions wrap each report's context with their Session.Headers, and a caller
+// can add per-call headers on top.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	merged := make(map[string]string, len(headers))
+	for key, value := range HeadersFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromContext returns the headers stored in ctx by WithHeaders, or nil if there are none.
+// The map must not be modified.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+// responseSnippetBytes caps ReportResult.ResponseSnippet.
+const responseSnippetBytes = 256
+
+// ReportResult describes the outcome of a single report request.
+type ReportResult struct {
+	StatusCode int           // HTTP status code of the response (0 if no response was received).
+	Latency    time.Duration // Time taken for the request to complete.
+	Proxy      string        // Identifier of the proxy used for the request, as logged (see Reporter.proxyID).
+	LogID      string        // Log identifier from the LogIDHeader response header, if the target sent one.
+
+	// ResponseSnippet is the start of the response body, cut to responseSnippetBytes, kept so
+	// callers can audit what came back without the log file.
+	ResponseSnippet string
+
+	AIResult *ai.AnalysisResult // Analysis of the response body, or nil if no analyzer ran or it failed.
+
+	DryRun bool // True if the attempt was only rehearsed (see dryRunAttempt) and nothing was sent.
+}
+
+// dryRunAttempt stands in for sending a report attempt through p in dry-run mode: it waits
+// Config.DryRunLatency (or until ctx is done), logs entry, the attempt as it would have been sent,
+// with outcome "dry_run", and returns a successful result with no status code. The proxy's health
+// and statistics are left alone, since nothing was learned about it.
+func (r *Reporter) dryRunAttempt(ctx context.Context, entry utils.LogEntry, p *proxy.ProxyInfo) (*ReportResult, error) {
+	start := time.Now()
+	if r.Config.DryRunLatency > 0 {
+		timer := time.NewTimer(r.Config.DryRunLatency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	entry.Message = "Dry run: report not sent"
+	entry.Outcome = "dry_run"
+	r.Logger.Info(entry)
+	return &ReportResult{Latency: time.Since(start), Proxy: r.proxyID(p), DryRun: true}, nil
+}
+
+// SendOnce sends a single report request to targetURL without retries, AI analysis or session context.
+// It is intended as a quick connectivity check (e.g., to catch typos or dead targets before a session).
+// The request counts against the configured budget like any other report request.
+// With Config.DryRun set, the request is built and logged through dryRunAttempt like a dry-run
+// report, but not sent, and no budget is used.
+//
+// Returns the result of the attempt, or an error if no proxy was available, the request could not be
+// sent, or the budget is exhausted. A non-2xx status is not treated as an error; inspect StatusCode.
+func (r *Reporter) SendOnce(targetURL string) (*ReportResult, error) {
+	if !r.Config.DryRun {
+		if err := r.reserveRequest(); err != nil {
+			return nil, err
+		}
+	}
+	selectedProxy, err := r.ProxyMgr.GetProxy()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), durationOr(r.Config.RequestTimeout, defaultRequestTimeout))
+	defer cancel()
+	req, reqBodyStr, err := r.buildRequest(ctx, targetURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	r.setCorrelationHeader(req, uuid.NewString())
+	if r.Config.DryRun {
+		return r.dryRunAttempt(ctx, utils.LogEntry{
+			ReportURL: targetURL, Proxy: r.proxyID(selectedProxy), UserAgent: req.Header.Get("User-Agent"),
+			RequestMethod: req.Method, RequestHeaders: req.Header.Clone(), RequestBody: reqBodyStr,
+		}, selectedProxy)
+	}
+
+	// A dedicated client avoids mutating the shared HTTPClient used by running sessions.
+	client := &http.Client{Transport: r.transportFor(selectedProxy)}
+	result := &ReportResult{Proxy: r.proxyID(selectedProxy)}
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	result.Latency = time.Since(startTime)
+	if err != nil {
+		r.Logger.Error(utils.LogEntry{Message: "Test connection failed", ReportURL: targetURL, Proxy: result.Proxy, Error: err.Error(), Outcome: "test_failed"})
+		return result, fmt.Errorf("test request to %s via %s failed: %w", targetURL, result.Proxy, err)
+	}
+	defer resp.Body.Close()
+	n, _ := io.Copy(io.Discard, resp.Body)
+	r.recordBytes(len(reqBodyStr) + int(n)) // Request and response body, as SendReport counts them.
+
+	result.StatusCode = resp.StatusCode
+	r.Logger.Info(utils.LogEntry{Message: "Test connection completed", ReportURL: targetURL, Proxy: result.Proxy, ResponseStatus: resp.StatusCode, Outcome: "test_completed"})
+	return result, nil
+}
+
 // SendReport attempts to send a single "report" to the specified targetURL.
 // This method manages the entire lifecycle of a single report transmission, including:
 //   - Selecting a proxy via the ProxyManager.
-//   - Constructing and sending an HTTP POST request (currently with a nil body).
+//   - Building the request with the configured RequestBuilder (by default a POST with a nil body) and sending it.
 //   - Applying headers and cookies from AppConfig.
-//   - Retrying the request up to Config.MaxRetries times on failure.
+//   - Retrying the request up to Config.MaxRetries times on failure, backing off before each retry (see retryDelay).
+//   - Following redirects, with Config.RedirectRules deciding the outcome by where they lead.
+//   - With Config.TraceConnections, logging each attempt's connection reuse and TLS details.
 //   - Performing AI content analysis on the response if an AIAnalyzer is configured and the request is successful.
 //   - Logging all significant events (attempts, successes, failures, AI results) using the structured logger.
+//   - Recording each attempt's outcome against its proxy with ProxyManager.RecordResult.
+//   - Treating soft blocks (Config.SoftBlockStatusCodes and Config.SoftBlockBodyMarkers) as
+//     failures that mark the proxy unhealthy and retry through another proxy.
+//   - Keeping the most recent failed attempt, which LastFailedCurl renders as a curl command.
 //
 // Parameters:
+//   - ctx: Parent context for every attempt; once it is done, no further attempt is made. If it
+//     carries a job ID (see WithJobID), that ID is used for the optional correlation header; if
+//     it carries a label (see WithLabel), the label is added to every log entry. It may also
+//     override Config.DryRun (see WithDryRun).
 //   - targetURL: The URL to which the report request will be sent.
 //   - sessionID: A unique identifier for the current reporting session, used for logging context.
 //
 // Returns:
-//   - `nil` if the report is considered successfully sent (e.g., HTTP 2xx response) after any retries.
+//   - A ReportResult describing the successful attempt (status, latency, proxy, response snippet) and a
+//     nil error if the report is considered successfully sent (e.g., HTTP 2xx response) after any retries.
 //   - An error if the report fails after all retry attempts, or if a non-retryable error occurs
-//     (e.g., failure to get a proxy, request creation failure).
+//     (e.g., failure to get a proxy, request creation failure). The result is then nil, unless an
+//     attempt received a response: it then describes the last response received, for auditing.
+//   - ErrBudgetExhausted if the configured request/byte budget has been used up.
 //
 // Note: The "reportReason" parameter was removed as the request body is currently nil.
 // The actual nature of the "report" is implicit in the targetURL and the POST request method.
-func (r *Reporter) SendReport(targetURL string, sessionID string) error {
-	var lastErr error // Stores the error from the last attempt.
+//
+// If Config.CorrelationHeader is set, every attempt carries that header with the value
+// "<jobID>-<attempt>" (attempts numbered from 1), so server logs can be matched to the job and
+// attempt. Without a job ID in ctx, a random one is used.
+//
+// Headers are applied in this order, each overriding the ones before it:
+//  1. Config.DefaultHeaders and those of the RequestBuilder (such as the body's Content-Type).
+//  2. Headers carried by ctx (see WithHeaders): a session's Session.Headers, then per-call headers.
+//  3. The correlation header, if Config.CorrelationHeader is set.
+//  4. The headers returned by HeaderFunc, if set, for that attempt.
+//
+// In dry-run mode (Config.DryRun, or WithDryRun), the first attempt selects a proxy, builds the
+// request and logs it as usual, then returns a successful result after Config.DryRunLatency
+// without sending anything; see dryRunAttempt.
+//
+// If ctx carries a proxy (see WithProxy), attempts go through it instead of a proxy chosen by the
+// ProxyManager, until it fails in a way that points at the proxy itself; the remaining attempts
+// then select proxies as usual.
+func (r *Reporter) SendReport(parent context.Context, targetURL string, sessionID string) (*ReportResult, error) {
+	var lastErr error              // Stores the error from the last attempt.
+	var lastResponse *ReportResult // The last response received, returned with a failure for auditing.
+	label := LabelFromContext(parent)
+	jobID := JobIDFromContext(parent)
+	if jobID == "" {
+		jobID = uuid.NewString()
+	}
+	pinnedProxy := ProxyFromContext(parent)
+	dryRun, ok := DryRunFromContext(parent)
+	if !ok {
+		dryRun = r.Config.DryRun
+	}
+	var retryWait time.Duration // The last wait between attempts, for decorrelated backoff.
 
 	// Retry loop based on MaxRetries from configuration.
 	for attempt := 0; attempt < r.Config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			// Back off before every retry, whatever failed the previous attempt, so retries do
+			// not hammer a target that is rate limiting (e.g. with 429 soft blocks).
+			retryWait = r.retryDelay(attempt-1, retryWait)
+			select {
+			case <-time.After(retryWait):
+			case <-parent.Done(): // Checked just below.
+			}
+		}
+		if err := parent.Err(); err != nil {
+			return lastResponse, err // The caller gave up; don't start another attempt.
+		}
 		// Context for per-attempt timeout and potential cancellation.
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30) // Overall timeout for one attempt.
-		defer cancel()                                                           // Ensure cancel is called to free resources.
+		ctx, cancel := context.WithTimeout(parent, durationOr(r.Config.RequestTimeout, defaultRequestTimeout)) // Overall timeout for one attempt.
+		defer cancel()                                                                                         // Ensure cancel is called to free resources.
+
+		// Stop before sending anything once the cost budget has been used up. Dry runs send nothing.
+		if !dryRun {
+			if err := r.reserveRequest(); err != nil {
+				r.Logger.Warn(utils.LogEntry{
+					SessionID: sessionID, Label: label, Message: "Report budget exhausted; not sending", ReportURL: targetURL,
+					Error: err.Error(), Outcome: "budget_exhausted",
+				})
+				return lastResponse, err
+			}
+		}
 
-		// Select a proxy for this attempt.
-		selectedProxy, err := r.ProxyMgr.GetProxy() // TODO: Future: pass targetRegion if strategy needs it.
+		// Select a proxy for this attempt, unless the caller pinned one.
+		selectedProxy := pinnedProxy
+		var err error
+		if selectedProxy == nil {
+			selectedProxy, err = r.ProxyMgr.GetProxy() // TODO: Future: pass targetRegion if strategy needs it.
+		}
 		if err != nil {
 			// Log and return if no proxy is available, as this is a prerequisite.
 			r.Logger.Error(utils.LogEntry{
-				SessionID: sessionID, Message: "Failed to get proxy for report attempt", ReportURL: targetURL,
+				SessionID: sessionID, Label: label, Message: "Failed to get proxy for report attempt", ReportURL: targetURL,
 				Error: err.Error(), Outcome: "failed_prereq",
 			})
-			return fmt.Errorf("failed to get proxy: %w", err)
+			return lastResponse, fmt.Errorf("failed to get proxy: %w", err)
 		}
 
-		// Configure HTTP client transport for this attempt with the selected proxy.
-		transport := &http.Transport{
-			Proxy:                 http.ProxyURL(selectedProxy.URL),
-			ResponseHeaderTimeout: 20 * time.Second, // Specific timeout for receiving headers.
-			ExpectContinueTimeout: 5 * time.Second,  // Timeout for 100-continue responses.
-		}
-		r.HTTPClient.Transport = transport
-		// r.HTTPClient.Timeout can be set here too for the entire Do call, if preferred over context.
-
-		// Create the HTTP request. Currently, it's a POST with a nil body.
-		// If a request body is needed in the future, it would be constructed here.
-		var reqBody io.Reader = nil // Explicitly nil for POST with no body.
-		reqBodyStr := ""            // For logging; empty as there's no body.
+		// Route this attempt through the selected proxy's shared transport. The client is copied
+		// rather than mutated, since sessions with Concurrency above 1 call SendReport from several
+		// goroutines at once.
+		client := *r.HTTPClient
+		client.Transport = r.transportFor(selectedProxy)
 
-		req, err := http.NewRequestWithContext(ctx, "POST", targetURL, reqBody)
+		req, reqBodyStr, err := r.buildRequest(ctx, targetURL, sessionID)
 		if err != nil {
 			// Log and return if request creation fails (should not be retried).
-			r.Logger.Error(utils.LogEntry{SessionID: sessionID, Message: "Failed to create request", ReportURL: targetURL, Error: err.Error()})
-			return fmt.Errorf("failed to create request: %w", err) // Critical failure for this attempt.
-		}
-
-		// Set headers from AppConfig and a fallback default user agent list.
-		userAgent := r.Config.DefaultHeaders["User-Agent"]
-		if userAgent == "" && len(defaultUserAgents) > 0 {
-			userAgent = defaultUserAgents[rand.Intn(len(defaultUserAgents))]
+			r.Logger.Error(utils.LogEntry{SessionID: sessionID, Label: label, Message: "Failed to create request", ReportURL: targetURL, Error: err.Error()})
+			return lastResponse, fmt.Errorf("failed to create request: %w", err) // Critical failure for this attempt.
 		}
-		req.Header.Set("User-Agent", userAgent)
-		for key, value := range r.Config.DefaultHeaders {
-			if key != "User-Agent" { // Avoid setting User-Agent twice.
-				req.Header.Set(key, value)
-			}
+		for key, value := range HeadersFromContext(parent) {
+			req.Header.Set(key, value)
 		}
-
-		// Add custom cookies from AppConfig.
-		for _, cookie := range r.Config.CustomCookies {
-			req.AddCookie(&cookie)
+		r.setCorrelationHeader(req, fmt.Sprintf("%s-%d", jobID, attempt+1))
+		r.setAttemptHeaders(req, targetURL, sessionID, attempt+1)
+		var trace *connTrace
+		if r.Config.TraceConnections {
+			trace = &connTrace{}
+			req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
 		}
 
 		// Log before sending the request.
 		preReqLogEntry := utils.LogEntry{
 			SessionID:      sessionID,
+			Label:          label,
 			Message:        fmt.Sprintf("Attempting report (attempt %d/%d)", attempt+1, r.Config.MaxRetries),
 			ReportURL:      targetURL,
-			Proxy:          selectedProxy.URL.String(),
+			Proxy:          r.proxyID(selectedProxy),
 			UserAgent:      req.Header.Get("User-Agent"),
 			RequestMethod:  req.Method,
 			RequestHeaders: req.Header.Clone(), // Clone to log headers as prepared.
-			RequestBody:    reqBodyStr,         // Empty as reqBody is nil.
+			RequestBody:    reqBodyStr,         // Empty for the default nil-body request.
 		}
 		r.Logger.Info(preReqLogEntry)
+		if dryRun {
+			return r.dryRunAttempt(ctx, preReqLogEntry, selectedProxy)
+		}
 
 		// Execute the request.
 		startTime := time.Now()
-		resp, err := r.HTTPClient.Do(req)
+		resp, err := client.Do(req)
 		latency := time.Since(startTime)
 
 		// Prepare a log entry for the outcome, to be filled as details emerge.
 		logEntry := utils.LogEntry{
-			SessionID: sessionID, Message: "Report attempt completed", ReportURL: targetURL,
-			Proxy: selectedProxy.URL.String(), UserAgent: req.Header.Get("User-Agent"),
+			SessionID: sessionID, Label: label, Message: "Report attempt completed", ReportURL: targetURL,
+			Proxy: r.proxyID(selectedProxy), UserAgent: req.Header.Get("User-Agent"),
 			RequestMethod: req.Method, RequestHeaders: preReqLogEntry.RequestHeaders, RequestBody: reqBodyStr,
 		}
+		if trace != nil {
+			trace.addTo(&logEntry)
+		}
 
 		if err != nil { // Network error or client-side error (e.g., timeout).
-			lastErr = fmt.Errorf("attempt %d/%d to %s via %s failed: %w", attempt+1, r.Config.MaxRetries, targetURL, selectedProxy.URL.String(), err)
+			r.record(sessionID, selectedProxy, latency, req, reqBodyStr, nil, "", err)
+			lastErr = fmt.Errorf("attempt %d/%d to %s via %s failed: %w", attempt+1, r.Config.MaxRetries, targetURL, r.proxyID(selectedProxy), err)
 			logEntry.Error = err.Error()
 			logEntry.Outcome = "failed_request_error"
+			proxyFault := isProxyFault(err)
+			if logEntry.AdditionalData == nil {
+				logEntry.AdditionalData = make(map[string]interface{})
+			}
+			if proxyFault {
+				logEntry.AdditionalData["error_source"] = "proxy"
+			} else {
+				logEntry.AdditionalData["error_source"] = "target"
+			}
 			r.Logger.Error(logEntry)
+			r.rememberFailure(req, reqBodyStr, selectedProxy)
+			r.ProxyMgr.RecordResult(selectedProxy.URL.String(), false)
 
-			// Heuristically update proxy status if the error seems proxy-related.
-			if urlErr, ok := err.(*url.Error); ok && (urlErr.Timeout() || urlErr.Temporary()) {
-				r.ProxyMgr.UpdateProxyStatus(selectedProxy.URL.String(), "unhealthy", latency)
-			} else if strings.Contains(err.Error(), "connect: connection refused") || strings.Contains(err.Error(), "proxyconnect") {
+			// Only penalize the proxy for failures of the proxy itself; a target that refuses or
+			// resets the connection says nothing about the proxy's health.
+			if proxyFault {
 				r.ProxyMgr.UpdateProxyStatus(selectedProxy.URL.String(), "unhealthy", latency)
+				pinnedProxy = nil // Retry through another proxy.
 			}
 
-			// If not the last attempt, sleep and continue to the next retry.
+			// If not the last attempt, continue to the next retry after the backoff.
 			if attempt < r.Config.MaxRetries-1 {
-				time.Sleep(time.Duration(rand.Intn(2)+1) * time.Second) // Simple random backoff.
 				continue
 			}
-			return lastErr // All retries exhausted for this specific error type.
+			return lastResponse, lastErr // All retries exhausted for this specific error type.
 		}
 		defer resp.Body.Close() // Ensure response body is closed for this successful attempt.
 
 		// Read response body.
 		bodyBytes, readErr := io.ReadAll(resp.Body)
 		responseBodyStr := string(bodyBytes)
+		r.recordBytes(len(reqBodyStr) + len(bodyBytes))
+		r.record(sessionID, selectedProxy, latency, req, reqBodyStr, resp, responseBodyStr, readErr)
+		lastResponse = &ReportResult{
+			StatusCode: resp.StatusCode, Latency: latency, Proxy: r.proxyID(selectedProxy),
+			LogID: resp.Header.Get(LogIDHeader), ResponseSnippet: utils.TruncateUTF8(responseBodyStr, responseSnippetBytes),
+		}
 
 		if readErr != nil { // Error reading response body.
 			lastErr = fmt.Errorf("attempt %d/%d to %s: failed to read response body: %w", attempt+1, r.Config.MaxRetries, targetURL, readErr)
@@ -194,21 +745,85 @@ func (r *Reporter) SendReport(targetURL string, sessionID string) error {
 			logEntry.ResponseStatus = resp.StatusCode // Log status code even if body read fails.
 			logEntry.ResponseHeaders = resp.Header.Clone()
 			r.Logger.Error(logEntry)
+			r.rememberFailure(req, reqBodyStr, selectedProxy)
+			r.ProxyMgr.RecordResult(selectedProxy.URL.String(), false)
 
 			if attempt < r.Config.MaxRetries-1 {
 				continue
 			}
-			return lastErr
+			return lastResponse, lastErr
 		}
 
 		// Populate remaining fields in the log entry.
 		logEntry.ResponseStatus = resp.StatusCode
 		logEntry.ResponseHeaders = resp.Header.Clone()
-		logEntry.ResponseBody = responseBodyStr        // Caution: can be large.
-		logEntry.LogID = resp.Header.Get("X-Tt-Logid") // Example TikTok log ID header.
+		logEntry.ResponseBody = responseBodyStr // Caution: can be large.
+		logEntry.LogID = resp.Header.Get(LogIDHeader)
+
+		// A soft block (a rate limit, or a CAPTCHA page often served with status 200) means the
+		// target refuses this proxy: fail the attempt, take the proxy out of rotation and retry
+		// through another one.
+		if reason, ok := r.softBlock(resp.StatusCode, responseBodyStr); ok {
+			lastErr = fmt.Errorf("attempt %d/%d to %s via %s: soft-blocked (%s)", attempt+1, r.Config.MaxRetries, targetURL, r.proxyID(selectedProxy), reason)
+			logEntry.Error = "soft-blocked: " + reason
+			logEntry.Outcome = "soft_blocked"
+			r.Logger.Warn(logEntry)
+			r.rememberFailure(req, reqBodyStr, selectedProxy)
+			r.ProxyMgr.RecordResult(selectedProxy.URL.String(), false)
+			r.ProxyMgr.UpdateProxyStatus(selectedProxy.URL.String(), "unhealthy", latency)
+			pinnedProxy = nil // Retry through another proxy.
+			if attempt < r.Config.MaxRetries-1 {
+				continue
+			}
+			return lastResponse, lastErr
+		}
+
+		// If the client followed redirects, a matching redirect rule decides the outcome
+		// regardless of the final status (e.g., a login page served with 200 is a failure).
+		accepted := resp.StatusCode >= 200 && resp.StatusCode < 300
+		redirectOutcome, redirectURL := r.redirectOutcome(req, resp)
+		if redirectURL != "" {
+			if logEntry.AdditionalData == nil {
+				logEntry.AdditionalData = make(map[string]interface{})
+			}
+			logEntry.AdditionalData["redirect_url"] = redirectURL
+		}
+		switch redirectOutcome {
+		case config.RedirectOutcomeFailure:
+			lastErr = fmt.Errorf("attempt %d/%d to %s: redirected to failure page %s", attempt+1, r.Config.MaxRetries, targetURL, redirectURL)
+			logEntry.Error = fmt.Sprintf("redirected to failure page %s", redirectURL)
+			logEntry.Outcome = "redirect_failure"
+			r.Logger.Error(logEntry)
+			r.rememberFailure(req, reqBodyStr, selectedProxy)
+			r.ProxyMgr.RecordResult(selectedProxy.URL.String(), false)
+			if attempt < r.Config.MaxRetries-1 {
+				continue
+			}
+			return lastResponse, lastErr
+		case config.RedirectOutcomeSuccess:
+			accepted = true
+		}
+
+		// A 2xx response whose body matches a configured retry trigger (e.g., a captcha page)
+		// is a soft failure: retry with the next proxy rather than accepting it.
+		if accepted && redirectOutcome == "" {
+			if trigger, ok := r.retryBodyTrigger(responseBodyStr); ok {
+				lastErr = fmt.Errorf("attempt %d/%d to %s: response body contains retry trigger %q", attempt+1, r.Config.MaxRetries, targetURL, trigger)
+				logEntry.Error = fmt.Sprintf("response body contains retry trigger %q", trigger)
+				logEntry.Outcome = "retry_body_match"
+				r.Logger.Warn(logEntry)
+				r.rememberFailure(req, reqBodyStr, selectedProxy)
+				r.ProxyMgr.RecordResult(selectedProxy.URL.String(), false)
+				if attempt < r.Config.MaxRetries-1 {
+					continue
+				}
+				return lastResponse, lastErr
+			}
+		}
 
 		// AI Analysis Hook (if analyzer is configured and request was successful so far).
-		if r.AIAnalyzer != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		var analysis *ai.AnalysisResult
+		if r.AIAnalyzer != nil && accepted {
 			simulatedPostID := "post123_" + targetURL // Simplified post ID.
 			analysisText := responseBodyStr
 			if len(analysisText) > 500 {
@@ -220,8 +835,9 @@ func (r *Reporter) SendReport(targetURL string, sessionID string) error {
 
 			aiResult, aiErr := r.AIAnalyzer.Analyze(sessionID, simulatedPostID, analysisText)
 			if aiErr != nil {
-				r.Logger.Error(utils.LogEntry{SessionID: sessionID, Message: "AI analysis failed", ReportURL: targetURL, Error: aiErr.Error(), AdditionalData: map[string]interface{}{"post_id": simulatedPostID}})
+				r.Logger.Error(utils.LogEntry{SessionID: sessionID, Label: label, Message: "AI analysis failed", ReportURL: targetURL, Error: aiErr.Error(), AdditionalData: map[string]interface{}{"post_id": simulatedPostID}})
 			} else if aiResult != nil {
+				analysis = aiResult
 				if logEntry.AdditionalData == nil {
 					logEntry.AdditionalData = make(map[string]interface{})
 				}
@@ -231,18 +847,21 @@ func (r *Reporter) SendReport(targetURL string, sessionID string) error {
 					logEntry.AdditionalData["AIDetails"] = aiResult.Details
 				}
 
-				r.Logger.Info(utils.LogEntry{SessionID: sessionID, Message: "AI Analysis Result", ReportURL: targetURL, AdditionalData: map[string]interface{}{"post_id": simulatedPostID, "threat_score": aiResult.ThreatScore, "category": aiResult.Category}})
+				r.Logger.Info(utils.LogEntry{SessionID: sessionID, Label: label, Message: "AI Analysis Result", ReportURL: targetURL, AdditionalData: map[string]interface{}{"post_id": simulatedPostID, "threat_score": aiResult.ThreatScore, "category": aiResult.Category}})
 				if aiResult.ThreatScore > r.Config.RiskThreshold {
-					r.Logger.Warn(utils.LogEntry{SessionID: sessionID, Message: "AI detected high risk content!", ReportURL: targetURL, AdditionalData: map[string]interface{}{"post_id": simulatedPostID, "threat_score": aiResult.ThreatScore, "category": aiResult.Category, "threshold": r.Config.RiskThreshold}, Outcome: "high_risk_detected"})
+					r.Logger.Warn(utils.LogEntry{SessionID: sessionID, Label: label, Message: "AI detected high risk content!", ReportURL: targetURL, AdditionalData: map[string]interface{}{"post_id": simulatedPostID, "threat_score": aiResult.ThreatScore, "category": aiResult.Category, "threshold": r.Config.RiskThreshold}, Outcome: "high_risk_detected"})
 				}
 			}
 		}
 
 		// Final outcome based on status code.
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 { // Successful response.
+		if accepted { // Successful response, or a redirect to a success page.
 			logEntry.Outcome = "accepted"
 			r.Logger.Info(logEntry)
-			return nil // Report successful, exit retry loop.
+			r.ProxyMgr.RecordResult(selectedProxy.URL.String(), true)
+			// Report successful, exit retry loop.
+			lastResponse.AIResult = analysis
+			return lastResponse, nil
 		}
 
 		// Non-2xx status code is considered a failure for this attempt.
@@ -252,6 +871,8 @@ func (r *Reporter) SendReport(targetURL string, sessionID string) error {
 		}
 		logEntry.Outcome = "failed_status_code"
 		r.Logger.Error(logEntry)
+		r.rememberFailure(req, reqBodyStr, selectedProxy)
+		r.ProxyMgr.RecordResult(selectedProxy.URL.String(), false)
 
 		if resp.StatusCode == http.StatusProxyAuthRequired || resp.StatusCode == http.StatusForbidden {
 			r.ProxyMgr.UpdateProxyStatus(selectedProxy.URL.String(), "unhealthy", latency)
@@ -260,7 +881,86 @@ func (r *Reporter) SendReport(targetURL string, sessionID string) error {
 		if attempt < r.Config.MaxRetries-1 {
 			continue
 		} // Go to next retry if not last attempt.
-		return lastErr // All retries failed for non-2xx status.
+		return lastResponse, lastErr // All retries failed for non-2xx status.
+	}
+	return lastResponse, lastErr // Should only be reached if MaxRetries is 0 or less (loop doesn't run).
+}
+
+// isProxyFault reports whether a failed attempt's transport error points at the proxy rather
+// than the target. Failures to connect or authenticate to the proxy ("proxyconnect" errors, or a
+// CONNECT rejected with 407) and timeouts count against the proxy. Errors after the proxy
+// connection was established, such as the target refusing or resetting the connection, do not.
+func isProxyFault(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return true
+		}
+		// The transport reports a rejected CONNECT by its status text alone.
+		if urlErr.Err != nil && urlErr.Err.Error() == http.StatusText(http.StatusProxyAuthRequired) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectOutcome applies Config.RedirectRules to a response reached by following redirects.
+// It returns the outcome of the first rule whose Match is found, case-insensitively, in the final
+// URL's host and path (empty if none matched), and the final URL (empty if there was no redirect).
+// Rules with an outcome other than RedirectOutcomeSuccess or RedirectOutcomeFailure are ignored.
+func (r *Reporter) redirectOutcome(req *http.Request, resp *http.Response) (outcome, finalURL string) {
+	if resp.Request == nil || resp.Request.URL == nil || resp.Request.URL.String() == req.URL.String() {
+		return "", ""
+	}
+	finalURL = resp.Request.URL.String()
+	hostPath := strings.ToLower(resp.Request.URL.Host + resp.Request.URL.Path)
+	for _, rule := range r.Config.RedirectRules {
+		if rule.Match == "" || (rule.Outcome != config.RedirectOutcomeSuccess && rule.Outcome != config.RedirectOutcomeFailure) {
+			continue
+		}
+		if strings.Contains(hostPath, strings.ToLower(rule.Match)) {
+			return rule.Outcome, finalURL
+		}
+	}
+	return "", finalURL
+}
+
+// softBlock reports whether a response with the given status and body is a soft block (see
+// Config.SoftBlockStatusCodes and Config.SoftBlockBodyMarkers), and why: the status, or the first
+// marker found in body, matched case-insensitively. Empty markers are ignored.
+func (r *Reporter) softBlock(status int, body string) (string, bool) {
+	for _, code := range r.Config.SoftBlockStatusCodes {
+		if status == code {
+			return fmt.Sprintf("status %d", status), true
+		}
+	}
+	if len(r.Config.SoftBlockBodyMarkers) == 0 || body == "" {
+		return "", false
+	}
+	lowerBody := strings.ToLower(body)
+	for _, marker := range r.Config.SoftBlockBodyMarkers {
+		if marker != "" && strings.Contains(lowerBody, strings.ToLower(marker)) {
+			return fmt.Sprintf("body contains %q", marker), true
+		}
+	}
+	return "", false
+}
+
+// retryBodyTrigger returns the first of Config.RetryBodySubstrings found in body, matched
+// case-insensitively, and whether any was found. Empty substrings are ignored.
+func (r *Reporter) retryBodyTrigger(body string) (string, bool) {
+	if len(r.Config.RetryBodySubstrings) == 0 || body == "" {
+		return "", false
+	}
+	lowerBody := strings.ToLower(body)
+	for _, trigger := range r.Config.RetryBodySubstrings {
+		if trigger != "" && strings.Contains(lowerBody, strings.ToLower(trigger)) {
+			return trigger, true
+		}
 	}
-	return lastErr // Should only be reached if MaxRetries is 0 or less (loop doesn't run).
+	return "", false
 }
@@ -0,0 +1,861 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// newTestReporter starts an httptest server that acts as both the proxy and the report target
+// (plain HTTP proxying simply forwards the absolute-URI request to the server) and returns a
+// Reporter wired to it along with the target URL.
+func newTestReporter(t *testing.T, cfg *config.AppConfig, handler http.HandlerFunc) (*Reporter, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	proxyURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	pm := proxy.NewProxyManager([]*proxy.ProxyInfo{{URL: proxyURL, HealthStatus: "healthy"}}, proxy.StrategyRoundRobin, false)
+
+	if cfg == nil {
+		cfg = &config.AppConfig{MaxRetries: 1}
+	}
+	logger := utils.NewLogger(io.Discard, "INFO")
+	return NewReporter(cfg, pm, logger, nil), server.URL + "/report"
+}
+
+// sendReport sends a report for a fixed session ID and returns only the error.
+func sendReport(r *Reporter, target string) error {
+	_, err := r.SendReport(context.Background(), target, "s1")
+	return err
+}
+
+func TestSendReport_ResultOnSuccess(t *testing.T) {
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(LogIDHeader, "log-123")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	result, err := r.SendReport(context.Background(), target, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Greater(t, result.Latency, time.Duration(0))
+	assert.NotEmpty(t, result.Proxy)
+	assert.Equal(t, "log-123", result.LogID)
+}
+
+func TestSendReport_FailureReturnsLastResponse(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}
+	attempt := 0
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		attempt++
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "server error %d", attempt)
+	})
+
+	result, err := r.SendReport(context.Background(), target, "s1")
+	require.Error(t, err)
+	require.NotNil(t, result, "the last response is returned with the error")
+	assert.Equal(t, http.StatusInternalServerError, result.StatusCode)
+	assert.Equal(t, "server error 2", result.ResponseSnippet)
+}
+
+func TestSendReport_RequestBudget(t *testing.T) {
+	hits := 0
+	cfg := &config.AppConfig{MaxRetries: 1, MaxTotalRequests: 2}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	})
+
+	require.NoError(t, sendReport(r, target))
+	require.NoError(t, sendReport(r, target))
+
+	_, err := r.SendReport(context.Background(), target, "s1")
+	assert.True(t, errors.Is(err, ErrBudgetExhausted), "third report should exceed the request budget")
+	assert.Equal(t, 2, hits, "no request should reach the server once the budget is exhausted")
+
+	requests, _ := r.BudgetUsage()
+	assert.Equal(t, 2, requests)
+}
+
+func TestSendReport_ByteBudget(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, MaxTotalBytes: 10}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("0123456789abcdef")) // 16 bytes, over the budget in one response.
+	})
+
+	require.NoError(t, sendReport(r, target), "the request that crosses the limit is still allowed")
+	_, err := r.SendReport(context.Background(), target, "s1")
+	assert.True(t, errors.Is(err, ErrBudgetExhausted))
+
+	_, bytes := r.BudgetUsage()
+	assert.Equal(t, int64(16), bytes)
+}
+
+func TestSendReport_EmptyBodyHeaders(t *testing.T) {
+	var gotContentLength, gotContentType string
+	var gotLength int64
+	cfg := &config.AppConfig{MaxRetries: 1, SendEmptyBody: true, EmptyBodyContentType: "application/json"}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		gotContentLength = req.Header.Get("Content-Length")
+		gotContentType = req.Header.Get("Content-Type")
+		gotLength = req.ContentLength
+		w.WriteHeader(http.StatusOK)
+	})
+
+	require.NoError(t, sendReport(r, target))
+	assert.Equal(t, "0", gotContentLength)
+	assert.Equal(t, int64(0), gotLength)
+	assert.Equal(t, "application/json", gotContentType)
+
+	// Without a configured type the form-encoded default is used.
+	cfg.EmptyBodyContentType = ""
+	require.NoError(t, sendReport(r, target))
+	assert.Equal(t, "0", gotContentLength)
+	assert.Equal(t, defaultBodyContentType, gotContentType)
+
+	// The default nil-body form sends no Content-Type.
+	cfg.SendEmptyBody = false
+	require.NoError(t, sendReport(r, target))
+	assert.Empty(t, gotContentType)
+}
+
+func TestSendReport_RequestBodyTemplate(t *testing.T) {
+	var gotMethod, gotContentType, gotBody string
+	cfg := &config.AppConfig{
+		MaxRetries:          1,
+		RequestMethod:       http.MethodPut,
+		RequestBodyTemplate: `{"url":"{{target}}","session":"{{sessionID}}"}`,
+		RequestContentType:  "application/json",
+		SendEmptyBody:       true, // The template takes precedence.
+	}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		gotMethod = req.Method
+		gotContentType = req.Header.Get("Content-Type")
+		body, _ := io.ReadAll(req.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	require.NoError(t, sendReport(r, target))
+	assert.Equal(t, http.MethodPut, gotMethod)
+	assert.Equal(t, "application/json", gotContentType)
+	assert.JSONEq(t, `{"url":"`+target+`","session":"s1"}`, gotBody)
+
+	// Form bodies get the form-encoded default and query-escaped values.
+	cfg.RequestMethod = ""
+	cfg.RequestContentType = ""
+	cfg.RequestBodyTemplate = "url={{target}}&reason=spam"
+	require.NoError(t, sendReport(r, target))
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, defaultBodyContentType, gotContentType)
+	assert.Equal(t, "url="+url.QueryEscape(target)+"&reason=spam", gotBody)
+
+	// Without a template the default body-less POST is sent.
+	cfg.RequestBodyTemplate = ""
+	cfg.SendEmptyBody = false
+	require.NoError(t, sendReport(r, target))
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Empty(t, gotContentType)
+	assert.Empty(t, gotBody)
+}
+
+func TestSendOnce(t *testing.T) {
+	hits := 0
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	result, err := r.SendOnce(target)
+	require.NoError(t, err, "a non-2xx status is reported via StatusCode, not as an error")
+	assert.Equal(t, http.StatusTeapot, result.StatusCode)
+	assert.NotEmpty(t, result.Proxy)
+	assert.Greater(t, result.Latency, time.Duration(0))
+	assert.Equal(t, 1, hits, "SendOnce must not retry")
+}
+
+func TestNewTransport_DisableKeepAlives(t *testing.T) {
+	p := &proxy.ProxyInfo{URL: &url.URL{Scheme: "http", Host: "proxy.example.com:8080"}}
+
+	r := NewReporter(&config.AppConfig{}, nil, nil, nil)
+	assert.False(t, r.newTransport(p).DisableKeepAlives, "connections are reused by default")
+
+	r.Config.DisableKeepAlives = true
+	assert.True(t, r.newTransport(p).DisableKeepAlives)
+}
+
+func TestNewTransport_Timeouts(t *testing.T) {
+	p := &proxy.ProxyInfo{URL: &url.URL{Scheme: "http", Host: "proxy.example.com:8080"}}
+
+	r := NewReporter(&config.AppConfig{}, nil, nil, nil)
+	transport := r.newTransport(p)
+	assert.Equal(t, 20*time.Second, transport.ResponseHeaderTimeout)
+	assert.Equal(t, 5*time.Second, transport.ExpectContinueTimeout)
+
+	r.Config.ResponseHeaderTimeout = time.Minute
+	r.Config.ExpectContinueTimeout = time.Second
+	transport = r.newTransport(p)
+	assert.Equal(t, time.Minute, transport.ResponseHeaderTimeout)
+	assert.Equal(t, time.Second, transport.ExpectContinueTimeout)
+}
+
+func TestTransportFor_ReusedPerProxy(t *testing.T) {
+	a := &proxy.ProxyInfo{URL: &url.URL{Scheme: "http", Host: "a.example.com:8080"}}
+	b := &proxy.ProxyInfo{URL: &url.URL{Scheme: "http", Host: "b.example.com:8080"}}
+	r := NewReporter(&config.AppConfig{}, nil, nil, nil)
+
+	first := r.transportFor(a)
+	assert.Same(t, first, r.transportFor(a), "attempts through the same proxy share its transport")
+	assert.NotSame(t, first, r.transportFor(b))
+
+	r.CloseIdleConnections()
+	assert.NotSame(t, first, r.transportFor(a), "a new transport is built after CloseIdleConnections")
+
+	second, other := r.transportFor(a), r.transportFor(b)
+	r.ForgetProxy(a.URL.String())
+	assert.NotSame(t, second, r.transportFor(a), "a forgotten proxy gets a new transport")
+	assert.Same(t, other, r.transportFor(b), "other proxies keep theirs")
+	r.ForgetProxy("http://unknown.example.com:8080") // No transport; nothing to do.
+}
+
+func TestSendReport_ConcurrentReusesConnections(t *testing.T) {
+	var newConns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	proxyURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	pm := proxy.NewProxyManager([]*proxy.ProxyInfo{{URL: proxyURL, HealthStatus: "healthy"}}, proxy.StrategyRoundRobin, false)
+	r := NewReporter(&config.AppConfig{MaxRetries: 1}, pm, utils.NewLogger(io.Discard, "INFO"), nil)
+
+	const workers, reportsPerWorker = 2, 10
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < reportsPerWorker; j++ {
+				assert.NoError(t, sendReport(r, server.URL+"/report"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, r.transports, 1)
+	assert.LessOrEqual(t, int(newConns.Load()), workers, "sequential reports reuse their worker's connection")
+}
+
+func TestSendReport_Timeouts(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  *config.AppConfig
+	}{
+		{"request timeout", &config.AppConfig{MaxRetries: 1, RequestTimeout: 100 * time.Millisecond}},
+		{"response header timeout", &config.AppConfig{MaxRetries: 1, ResponseHeaderTimeout: 100 * time.Millisecond}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			release := make(chan struct{})
+			r, target := newTestReporter(t, tc.cfg, func(w http.ResponseWriter, req *http.Request) {
+				select {
+				case <-release:
+				case <-req.Context().Done():
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			defer close(release)
+
+			start := time.Now()
+			assert.Error(t, sendReport(r, target))
+			assert.Less(t, time.Since(start), 5*time.Second, "the slow response is abandoned at the configured timeout")
+		})
+	}
+}
+
+// failingTransport fails the test if a request is sent through it.
+type failingTransport struct{ t *testing.T }
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.t.Errorf("unexpected request to %s", req.URL)
+	return nil, errors.New("no requests expected")
+}
+
+func TestSendReport_DryRun(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 3, MaxTotalRequests: 1, DryRun: true, DryRunLatency: 20 * time.Millisecond}
+	r, target := newTestReporter(t, cfg, nil)
+	r.Transport = failingTransport{t}
+	var buf bytes.Buffer
+	r.Logger = utils.NewLogger(&buf, "INFO")
+
+	for i := 0; i < 2; i++ {
+		result, err := r.SendReport(context.Background(), target, "s1")
+		require.NoError(t, err)
+		assert.Zero(t, result.StatusCode, "nothing was received")
+		assert.GreaterOrEqual(t, result.Latency, 20*time.Millisecond)
+		assert.Equal(t, r.ProxyMgr.GetAllProxies()[0].URL.String(), result.Proxy)
+	}
+	requests, _ := r.BudgetUsage()
+	assert.Zero(t, requests, "dry runs do not use the request budget")
+
+	var outcomes []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry.Outcome != "" {
+			outcomes = append(outcomes, entry.Outcome)
+		}
+	}
+	assert.Equal(t, []string{"dry_run", "dry_run"}, outcomes, "one attempt per report, without retries")
+
+	// The context overrides the config either way.
+	r.Config.DryRun = false
+	_, err := r.SendReport(WithDryRun(context.Background(), true), target, "s1")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.SendReport(WithDryRun(ctx, true), target, "s1")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSendOnce_DryRun(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, MaxTotalRequests: 1, DryRun: true}
+	r, target := newTestReporter(t, cfg, nil)
+	r.Transport = failingTransport{t}
+	var buf bytes.Buffer
+	r.Logger = utils.NewLogger(&buf, "INFO")
+
+	for i := 0; i < 2; i++ {
+		result, err := r.SendOnce(target)
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Zero(t, result.StatusCode, "nothing was received")
+		assert.Equal(t, r.ProxyMgr.GetAllProxies()[0].URL.String(), result.Proxy)
+	}
+	requests, _ := r.BudgetUsage()
+	assert.Zero(t, requests, "dry runs do not use the request budget")
+
+	var entry utils.LogEntry
+	require.NoError(t, json.Unmarshal([]byte(strings.SplitN(buf.String(), "\n", 2)[0]), &entry))
+	assert.Equal(t, "dry_run", entry.Outcome)
+	assert.Equal(t, target, entry.ReportURL)
+}
+
+func TestSendReport_RetryBodySubstrings(t *testing.T) {
+	captcha := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("<html>Please solve the CAPTCHA</html>"))
+	}))
+	t.Cleanup(captcha.Close)
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("report received"))
+	}))
+	t.Cleanup(good.Close)
+
+	captchaURL, err := url.Parse(captcha.URL)
+	require.NoError(t, err)
+	goodURL, err := url.Parse(good.URL)
+	require.NoError(t, err)
+	pm := proxy.NewProxyManager([]*proxy.ProxyInfo{
+		{URL: captchaURL, HealthStatus: "healthy"},
+		{URL: goodURL, HealthStatus: "healthy"},
+	}, proxy.StrategyRoundRobin, false)
+
+	cfg := &config.AppConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, RetryBodySubstrings: []string{"captcha", "try again"}}
+	r := NewReporter(cfg, pm, utils.NewLogger(io.Discard, "INFO"), nil)
+
+	// The captcha proxy answers first; the 200 must be retried through the next proxy.
+	result, err := r.SendReport(context.Background(), good.URL+"/report", "s1")
+	require.NoError(t, err)
+	assert.Equal(t, goodURL.String(), result.Proxy)
+
+	requests, _ := r.BudgetUsage()
+	assert.Equal(t, 2, requests, "the soft failure should cost one extra attempt")
+
+	// Each attempt's outcome is recorded against its proxy.
+	proxies := pm.GetAllProxies()
+	assert.Equal(t, []int{0, 1}, []int{proxies[0].SuccessCount, proxies[0].FailureCount})
+	assert.Equal(t, []int{1, 0}, []int{proxies[1].SuccessCount, proxies[1].FailureCount})
+}
+
+func TestSendReport_SoftBlock(t *testing.T) {
+	captcha := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("<html>Please solve the CAPTCHA</html>"))
+	}))
+	t.Cleanup(captcha.Close)
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("report received"))
+	}))
+	t.Cleanup(good.Close)
+
+	captchaURL, err := url.Parse(captcha.URL)
+	require.NoError(t, err)
+	goodURL, err := url.Parse(good.URL)
+	require.NoError(t, err)
+	pm := proxy.NewProxyManager([]*proxy.ProxyInfo{
+		{URL: captchaURL, HealthStatus: "healthy"},
+		{URL: goodURL, HealthStatus: "healthy"},
+	}, proxy.StrategyRoundRobin, true)
+	cfg := &config.AppConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, SoftBlockBodyMarkers: []string{"captcha"}}
+	r := NewReporter(cfg, pm, utils.NewLogger(io.Discard, "INFO"), nil)
+
+	// Pin the captcha proxy: the soft block must still move the retry to another proxy.
+	ctx := WithProxy(context.Background(), pm.GetAllProxies()[0])
+	result, err := r.SendReport(ctx, good.URL+"/report", "s1")
+	require.NoError(t, err)
+	assert.Equal(t, goodURL.String(), result.Proxy)
+	proxies := pm.GetAllProxies()
+	assert.Equal(t, "unhealthy", proxies[0].HealthStatus, "the soft-blocked proxy is taken out of rotation")
+	assert.Equal(t, []int{0, 1}, []int{proxies[0].SuccessCount, proxies[0].FailureCount})
+	assert.Equal(t, "healthy", proxies[1].HealthStatus)
+
+	// With no retry left, the 200 with a CAPTCHA body is a failure.
+	cfg.MaxRetries = 1
+	proxies[0].HealthStatus = "healthy"
+	result, err = r.SendReport(WithProxy(context.Background(), proxies[0]), good.URL+"/report", "s1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `soft-blocked (body contains "captcha")`)
+	require.NotNil(t, result)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+}
+
+func TestSendReport_SoftBlockStatusCode(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, SoftBlockStatusCodes: []int{http.StatusTooManyRequests}}
+	status := http.StatusTooManyRequests
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	err := sendReport(r, target)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "soft-blocked (status 429)")
+	assert.Equal(t, "unhealthy", r.ProxyMgr.GetAllProxies()[0].HealthStatus)
+
+	// Other failing statuses leave the proxy's health alone.
+	r.ProxyMgr.GetAllProxies()[0].HealthStatus = "healthy"
+	status = http.StatusInternalServerError
+	require.Error(t, sendReport(r, target))
+	assert.Equal(t, "healthy", r.ProxyMgr.GetAllProxies()[0].HealthStatus)
+}
+
+func TestSendReport_RetryBodySubstringsExhausted(t *testing.T) {
+	hits := 0
+	cfg := &config.AppConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, RetryBodySubstrings: []string{"Try Again"}}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("rate limited, try again later"))
+	})
+
+	_, err := r.SendReport(context.Background(), target, "s1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `retry trigger "Try Again"`)
+	assert.Equal(t, 3, hits, "every attempt should be made before giving up")
+
+	// Without triggers the same body is accepted.
+	cfg.RetryBodySubstrings = nil
+	require.NoError(t, sendReport(r, target))
+}
+
+func TestSendReport_CorrelationHeader(t *testing.T) {
+	var ids []string
+	failures := 2 // Fail the first two requests so one report makes three attempts.
+	cfg := &config.AppConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, CorrelationHeader: "X-Request-ID"}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		ids = append(ids, req.Header.Get("X-Request-ID"))
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	_, err := r.SendReport(WithJobID(context.Background(), "job-7"), target, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job-7-1", "job-7-2", "job-7-3"}, ids, "each attempt carries the job ID and its attempt number")
+
+	// Without a job ID, each report still gets unique values.
+	ids = nil
+	require.NoError(t, sendReport(r, target))
+	require.NoError(t, sendReport(r, target))
+	require.Len(t, ids, 2)
+	assert.NotEmpty(t, ids[0])
+	assert.NotEqual(t, ids[0], ids[1])
+
+	// The header is not sent unless configured.
+	cfg.CorrelationHeader = ""
+	ids = nil
+	require.NoError(t, sendReport(r, target))
+	assert.Equal(t, []string{""}, ids)
+}
+
+func TestSendReport_HeaderFunc(t *testing.T) {
+	var tokens, agents []string
+	failures := 2 // Fail the first two requests so one report makes three attempts.
+	cfg := &config.AppConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, DefaultHeaders: map[string]string{"User-Agent": "static-agent", "X-Token": "static"}}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		tokens = append(tokens, req.Header.Get("X-Token"))
+		agents = append(agents, req.Header.Get("User-Agent"))
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	var calls []string
+	r.HeaderFunc = func(gotTarget, sessionID string, attempt int) map[string]string {
+		calls = append(calls, fmt.Sprintf("%s %s %d", gotTarget, sessionID, attempt))
+		return map[string]string{"X-Token": fmt.Sprintf("token-%d", attempt)}
+	}
+
+	_, err := r.SendReport(context.Background(), target, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"token-1", "token-2", "token-3"}, tokens, "each attempt gets a fresh token over the static header")
+	assert.Equal(t, []string{"static-agent", "static-agent", "static-agent"}, agents, "other static headers are kept")
+	assert.Equal(t, []string{target + " s1 1", target + " s1 2", target + " s1 3"}, calls)
+}
+
+func TestSendReport_HeaderPrecedence(t *testing.T) {
+	var got http.Header
+	cfg := &config.AppConfig{MaxRetries: 1, DefaultHeaders: map[string]string{"Referer": "default", "Origin": "default", "X-Trace": "default", "X-Keep": "default"}}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	})
+	r.HeaderFunc = func(target, sessionID string, attempt int) map[string]string {
+		return map[string]string{"X-Trace": "attempt"}
+	}
+
+	sessionCtx := WithHeaders(context.Background(), map[string]string{"Referer": "session", "Origin": "session", "X-Trace": "session"})
+	callCtx := WithHeaders(sessionCtx, map[string]string{"Origin": "call"})
+	_, err := r.SendReport(callCtx, target, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "session", got.Get("Referer"), "session headers win over defaults")
+	assert.Equal(t, "call", got.Get("Origin"), "per-call headers win over session headers")
+	assert.Equal(t, "attempt", got.Get("X-Trace"), "HeaderFunc wins over every static header")
+	assert.Equal(t, "default", got.Get("X-Keep"))
+	assert.Equal(t, map[string]string{"Referer": "session", "Origin": "session", "X-Trace": "session"}, HeadersFromContext(sessionCtx), "the outer headers are not modified")
+}
+
+func TestIsProxyFault(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: connection refused")}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"proxy connect refused", &url.Error{Op: "Post", URL: "http://t", Err: &net.OpError{Op: "proxyconnect", Net: "tcp", Err: refused}}, true},
+		{"proxy auth rejected", &url.Error{Op: "Post", URL: "https://t", Err: errors.New("Proxy Authentication Required")}, true},
+		{"timeout", &url.Error{Op: "Post", URL: "http://t", Err: timeoutError{}}, true},
+		{"target refused", &url.Error{Op: "Post", URL: "http://t", Err: refused}, false},
+		{"target reset", &url.Error{Op: "Post", URL: "http://t", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}, false},
+		{"target closed", &url.Error{Op: "Post", URL: "http://t", Err: io.EOF}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isProxyFault(tt.err))
+		})
+	}
+}
+
+// timeoutError is a net.Error that reports a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempt   int
+		base, max time.Duration
+		jitter    float64
+		want      time.Duration
+	}{
+		{"first retry", 0, 100 * time.Millisecond, time.Second, 0, 100 * time.Millisecond},
+		{"doubles", 1, 100 * time.Millisecond, time.Second, 0, 200 * time.Millisecond},
+		{"doubles again", 3, 100 * time.Millisecond, time.Second, 0, 800 * time.Millisecond},
+		{"capped", 4, 100 * time.Millisecond, time.Second, 0, time.Second},
+		{"capped for large attempts", 200, 100 * time.Millisecond, time.Second, 0, time.Second},
+		{"full jitter adds 25%", 1, 100 * time.Millisecond, time.Second, 1, 250 * time.Millisecond},
+		{"half jitter", 4, 100 * time.Millisecond, time.Second, 0.5, 1125 * time.Millisecond},
+		{"defaults", 0, 0, 0, 0, time.Second},
+		{"default cap", 5, 0, 0, 0, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, backoffDelay(tt.attempt, tt.base, tt.max, tt.jitter))
+		})
+	}
+}
+
+func TestDecorrelatedBackoffDelay(t *testing.T) {
+	base, maxDelay := 100*time.Millisecond, 5*time.Second
+	assert.Equal(t, base, decorrelatedBackoffDelay(0, base, maxDelay, 0), "the first retry waits at least base")
+	assert.Equal(t, 300*time.Millisecond, decorrelatedBackoffDelay(0, base, maxDelay, 1), "and at most three times base")
+	assert.Equal(t, 1550*time.Millisecond, decorrelatedBackoffDelay(time.Second, base, maxDelay, 0.5), "halfway between base and 3s")
+	assert.Equal(t, maxDelay, decorrelatedBackoffDelay(4*time.Second, base, maxDelay, 0.9), "capped")
+	assert.Equal(t, defaultBackoffBase, decorrelatedBackoffDelay(0, 0, 0, 0), "defaults")
+
+	// Each delay of a long sequence lies between base and min(maxDelay, 3 * the previous delay).
+	rng := rand.New(rand.NewSource(1))
+	prev := time.Duration(0)
+	for i := 0; i < 100; i++ {
+		delay := decorrelatedBackoffDelay(prev, base, maxDelay, rng.Float64())
+		upper := 3 * prev
+		if upper < 3*base {
+			upper = 3 * base
+		}
+		if upper > maxDelay {
+			upper = maxDelay
+		}
+		require.GreaterOrEqual(t, delay, base, "retry %d", i)
+		require.LessOrEqual(t, delay, upper, "retry %d", i)
+		prev = delay
+	}
+}
+
+func TestSendReport_DecorrelatedBackoff(t *testing.T) {
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+	r.Config.MaxRetries = 3
+	r.Config.BackoffStrategy = "Decorrelated"
+	r.Config.BackoffBase = 20 * time.Millisecond
+	r.Config.BackoffMax = 30 * time.Millisecond
+
+	start := time.Now()
+	require.Error(t, sendReport(r, target))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "two retries wait at least base each")
+}
+
+func TestSendReport_BackoffBeforeStatusRetries(t *testing.T) {
+	var mu sync.Mutex
+	var attempts []time.Time
+	r, target := newTestReporter(t, &config.AppConfig{
+		MaxRetries: 3, BackoffBase: 50 * time.Millisecond, BackoffMax: 50 * time.Millisecond,
+		SoftBlockStatusCodes: []int{http.StatusTooManyRequests},
+	}, func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		attempts = append(attempts, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	require.Error(t, sendReport(r, target))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, attempts, 3)
+	for i := 1; i < len(attempts); i++ {
+		assert.GreaterOrEqual(t, attempts[i].Sub(attempts[i-1]), 50*time.Millisecond, "retry %d is spaced by the backoff", i)
+	}
+}
+
+func TestSendReport_TargetResetKeepsProxyHealthy(t *testing.T) {
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close() // The target drops the connection without responding.
+	})
+
+	require.Error(t, sendReport(r, target))
+	assert.Equal(t, "healthy", r.ProxyMgr.GetAllProxies()[0].HealthStatus, "a target-side failure must not penalize the proxy")
+}
+
+func TestSendReport_ProxyConnectFailureMarksProxyUnhealthy(t *testing.T) {
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	// A listener closed straight away leaves a port that refuses connections.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadURL, err := url.Parse("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	ln.Close()
+	r.ProxyMgr = proxy.NewProxyManager([]*proxy.ProxyInfo{{URL: deadURL, HealthStatus: "healthy"}}, proxy.StrategyRoundRobin, false)
+
+	require.Error(t, sendReport(r, target))
+	assert.Equal(t, "unhealthy", r.ProxyMgr.GetAllProxies()[0].HealthStatus)
+}
+
+func TestSendReport_ProxyCredentialsNotLogged(t *testing.T) {
+	for _, mode := range []string{"", proxy.LogIDHash} {
+		calls := 0
+		r, target := newTestReporter(t, &config.AppConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, ProxyLogIdentifier: mode}, func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		var buf bytes.Buffer
+		r.Logger = utils.NewLogger(&buf, "DEBUG")
+		proxyURL := r.ProxyMgr.GetAllProxies()[0].URL
+		proxyURL.User = url.UserPassword("alice", "s3cret")
+		want := proxy.LogIdentifier(proxyURL.String(), mode)
+
+		result, err := r.SendReport(context.Background(), target, "s1")
+		require.NoError(t, err, "mode %q", mode)
+		assert.Equal(t, want, result.Proxy)
+		_, err = r.SendOnce(target)
+		require.NoError(t, err)
+
+		// A proxy that refuses connections puts its identifier in the returned error.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		deadURL, err := url.Parse("http://alice:s3cret@" + ln.Addr().String())
+		require.NoError(t, err)
+		ln.Close()
+		r.ProxyMgr = proxy.NewProxyManager([]*proxy.ProxyInfo{{URL: deadURL, HealthStatus: "healthy"}}, proxy.StrategyRoundRobin, false)
+		_, err = r.SendReport(context.Background(), target, "s2")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "s3cret")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.GreaterOrEqual(t, len(lines), 4)
+		for _, line := range lines {
+			var entry utils.LogEntry
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			if entry.Proxy != "" {
+				assert.NotContains(t, entry.Proxy, "alice", "mode %q: %s", mode, entry.Message)
+				assert.NotContains(t, entry.Proxy, "s3cret", "mode %q: %s", mode, entry.Message)
+			}
+		}
+		assert.NotContains(t, buf.String(), "s3cret", "mode %q", mode)
+		assert.Contains(t, buf.String(), `"proxy":"`+want+`"`)
+	}
+
+	// The old behavior stays available.
+	r, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1, ProxyLogIdentifier: proxy.LogIDFull}, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.ProxyMgr.GetAllProxies()[0].URL.User = url.UserPassword("alice", "s3cret")
+	result, err := r.SendReport(context.Background(), target, "s1")
+	require.NoError(t, err)
+	assert.Contains(t, result.Proxy, "alice:s3cret@")
+}
+
+func TestSendReport_PinnedProxy(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	good := r.ProxyMgr.GetAllProxies()[0]
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadURL, err := url.Parse("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	ln.Close()
+	dead := &proxy.ProxyInfo{URL: deadURL, HealthStatus: "healthy"}
+
+	// The pinned proxy is used even though the pool would hand out another one.
+	r.ProxyMgr = proxy.NewProxyManager([]*proxy.ProxyInfo{dead}, proxy.StrategyRoundRobin, false)
+	result, err := r.SendReport(WithProxy(context.Background(), good), target, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, good.URL.String(), result.Proxy)
+
+	// A pinned proxy that fails at the proxy level is abandoned for the remaining attempts.
+	r.ProxyMgr = proxy.NewProxyManager([]*proxy.ProxyInfo{good}, proxy.StrategyRoundRobin, false)
+	result, err = r.SendReport(WithProxy(context.Background(), dead), target, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, good.URL.String(), result.Proxy)
+}
+
+func TestSendReport_RedirectRules(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, RedirectRules: []config.RedirectRule{
+		{Match: "confirm.example.com", Outcome: config.RedirectOutcomeSuccess},
+		{Match: "/LOGIN", Outcome: config.RedirectOutcomeFailure},
+	}}
+	// The test server is also the proxy, so redirects to other hosts are served by this handler.
+	r, target := newTestReporter(t, cfg, func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/report":
+			http.Redirect(w, req, req.URL.Query().Get("to"), http.StatusFound)
+		case "/done":
+			w.WriteHeader(http.StatusNotFound) // A confirmation page with an unhelpful status.
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	result, err := r.SendReport(context.Background(), target+"?to=http://confirm.example.com/done", "s1")
+	require.NoError(t, err, "a redirect to the confirmation host counts as success")
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+
+	_, err = r.SendReport(context.Background(), target+"?to=http://accounts.example.com/login?next=report", "s1")
+	require.Error(t, err, "a redirect to a login page counts as failure despite the 200")
+	assert.Contains(t, err.Error(), "redirected to failure page http://accounts.example.com/login")
+
+	// Redirects matching no rule fall back to the final status.
+	require.NoError(t, sendReport(r, target+"?to=http://other.example.com/thanks"))
+	assert.Error(t, sendReport(r, target+"?to=http://other.example.com/done"))
+}
+
+func TestSendReport_CanceledContext(t *testing.T) {
+	hits := 0
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := r.SendReport(ctx, target, "s1")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Zero(t, hits, "no attempt is made once the caller's context is done")
+}
+
+func TestSendReport_BackoffStopsOnCancel(t *testing.T) {
+	// Both bounds are an hour, so only the cancellation can end the backoff in time.
+	r, target := newTestReporter(t, &config.AppConfig{MaxRetries: 3, BackoffBase: time.Hour, BackoffMax: time.Hour}, func(w http.ResponseWriter, req *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := r.SendReport(ctx, target, "session")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second, "canceling the context cuts the backoff short")
+}
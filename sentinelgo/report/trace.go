@@ -0,0 +1,86 @@
+package report
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+
+	"sentinelgo/sentinelgo/utils"
+)
+
+// connTrace captures connection-level details of one report attempt via httptrace: whether
+// the connection was reused and, for HTTPS targets, the negotiated TLS version and cipher suite
+// or the handshake error. It is only installed when Config.TraceConnections is set.
+type connTrace struct {
+	mu           sync.Mutex // Trace hooks may run on the transport's goroutines.
+	gotConn      bool
+	reused       bool
+	wasIdle      bool
+	tlsDone      bool
+	tlsVersion   uint16
+	cipherSuite  uint16
+	handshakeErr error
+}
+
+// clientTrace returns the httptrace hooks that fill in c.
+func (c *connTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.gotConn = true
+			c.reused = info.Reused
+			c.wasIdle = info.WasIdle
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.tlsDone = true
+			c.tlsVersion = state.Version
+			c.cipherSuite = state.CipherSuite
+			c.handshakeErr = err
+		},
+	}
+}
+
+// addTo adds the captured details to entry.AdditionalData. A reused connection performs no
+// handshake, so the TLS fields are only present on attempts that opened a new TLS connection.
+func (c *connTrace) addTo(entry *utils.LogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.gotConn && !c.tlsDone {
+		return
+	}
+	if entry.AdditionalData == nil {
+		entry.AdditionalData = make(map[string]interface{})
+	}
+	if c.gotConn {
+		entry.AdditionalData["conn_reused"] = c.reused
+		entry.AdditionalData["conn_was_idle"] = c.wasIdle
+	}
+	if c.tlsDone {
+		if c.handshakeErr != nil {
+			entry.AdditionalData["tls_handshake_error"] = c.handshakeErr.Error()
+			return
+		}
+		entry.AdditionalData["tls_version"] = tlsVersionName(c.tlsVersion)
+		entry.AdditionalData["tls_cipher_suite"] = tls.CipherSuiteName(c.cipherSuite)
+	}
+}
+
+// tlsVersionName returns a readable name for a TLS protocol version, e.g. "TLS 1.3".
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", version)
+	}
+}
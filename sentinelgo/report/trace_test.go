@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// completedAttempts returns the "Report attempt completed" entries written to logBuf.
+func completedAttempts(t *testing.T, logBuf *bytes.Buffer) []utils.LogEntry {
+	t.Helper()
+	var entries []utils.LogEntry
+	scanner := bufio.NewScanner(logBuf)
+	for scanner.Scan() {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		if entry.Message == "Report attempt completed" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestSendReport_TraceConnections(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	// Route attempts straight to the TLS server; the test reporter's own server is unused.
+	r, _ := newTestReporter(t, &config.AppConfig{MaxRetries: 1, TraceConnections: true}, func(w http.ResponseWriter, req *http.Request) {})
+	r.Transport = server.Client().Transport
+	var logBuf bytes.Buffer
+	r.Logger = utils.NewLogger(&logBuf, "INFO")
+
+	require.NoError(t, sendReport(r, server.URL+"/report"))
+	require.NoError(t, sendReport(r, server.URL+"/report"))
+
+	entries := completedAttempts(t, &logBuf)
+	require.Len(t, entries, 2)
+	first := entries[0].AdditionalData
+	assert.Equal(t, false, first["conn_reused"])
+	assert.Equal(t, "TLS 1.3", first["tls_version"])
+	assert.NotEmpty(t, first["tls_cipher_suite"])
+	assert.NotContains(t, first, "tls_handshake_error")
+
+	second := entries[1].AdditionalData
+	assert.Equal(t, true, second["conn_reused"], "the second report should reuse the kept-alive connection")
+	assert.NotContains(t, second, "tls_version", "a reused connection performs no handshake")
+}
+
+func TestSendReport_TraceConnectionsDisabled(t *testing.T) {
+	r, target := newTestReporter(t, nil, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	var logBuf bytes.Buffer
+	r.Logger = utils.NewLogger(&logBuf, "INFO")
+
+	require.NoError(t, sendReport(r, target))
+	entries := completedAttempts(t, &logBuf)
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0].AdditionalData, "conn_reused")
+}
@@ -0,0 +1,133 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sentinelgo/sentinelgo/config"
+)
+
+// Files written by ExportRunBundle.
+const (
+	BundleConfigFile  = "config.yaml"  // The reporter's config, with secrets redacted.
+	BundleProxiesFile = "proxies.json" // Every proxy in the pool with its final health.
+	BundleResultsFile = "results.json" // The session's progress and per-job results.
+)
+
+// BundleProxy is a proxy's entry in a run bundle. The URL has its password redacted.
+type BundleProxy struct {
+	URL          string    `json:"url"`
+	Region       string    `json:"region,omitempty"`
+	Label        string    `json:"label,omitempty"`
+	HealthStatus string    `json:"health_status"`
+	LatencyMS    int64     `json:"latency_ms"`
+	LastChecked  time.Time `json:"last_checked,omitempty"`
+	Pinned       bool      `json:"pinned,omitempty"`
+	Selections   int       `json:"selections,omitempty"` // Times the proxy was selected, if selection auditing was enabled.
+}
+
+// BundleResults is the results file of a run bundle: the session's saved state plus its summary figures.
+type BundleResults struct {
+	SavedState
+	LatencyP50MS int64                     `json:"latency_p50_ms"`
+	LatencyP90MS int64                     `json:"latency_p90_ms"`
+	LatencyP99MS int64                     `json:"latency_p99_ms"`
+	AICategories map[string]AICategoryStat `json:"ai_categories,omitempty"`
+}
+
+// ExportRunBundle writes everything needed to understand a run into dir, creating it if needed:
+// the redacted config (BundleConfigFile), the proxy pool with each proxy's final health
+// (BundleProxiesFile) and the session's results (BundleResultsFile). Files are written with
+// owner-only permissions. The config and proxy files are skipped when the session has no Config
+// or ProxyMgr respectively (e.g., when driven by a mock Reporter).
+func (s *Session) ExportRunBundle(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create bundle directory '%s': %w", dir, err)
+	}
+
+	s.mu.Lock()
+	p50, p90, p99 := s.latencyPercentilesLocked()
+	results := BundleResults{
+		SavedState:   s.snapshotLocked(),
+		LatencyP50MS: p50.Milliseconds(),
+		LatencyP90MS: p90.Milliseconds(),
+		LatencyP99MS: p99.Milliseconds(),
+		AICategories: s.aiSummaryLocked(),
+	}
+	s.mu.Unlock()
+	results.Headers = redactHeaders(results.Headers)
+	if err := writeBundleJSON(filepath.Join(dir, BundleResultsFile), results); err != nil {
+		return err
+	}
+
+	if s.Config != nil {
+		data, err := yaml.Marshal(s.Config.Redacted())
+		if err != nil {
+			return fmt.Errorf("failed to marshal bundle config: %w", err)
+		}
+		if err := writeBundleFile(filepath.Join(dir, BundleConfigFile), data); err != nil {
+			return err
+		}
+	}
+	if pm := s.ProxyMgr; pm != nil {
+		counts := pm.SelectionCounts()
+		var proxies []BundleProxy
+		for _, p := range pm.ProxySnapshots() {
+			if p.URL == nil {
+				continue
+			}
+			proxies = append(proxies, BundleProxy{
+				URL:          p.URL.Redacted(),
+				Region:       p.Region,
+				Label:        p.Label,
+				HealthStatus: p.HealthStatus,
+				LatencyMS:    p.Latency.Milliseconds(),
+				LastChecked:  p.LastChecked,
+				Pinned:       p.Pinned,
+				Selections:   counts[p.URL.String()],
+			})
+		}
+		if err := writeBundleJSON(filepath.Join(dir, BundleProxiesFile), proxies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactHeaders returns a copy of headers with the values of credential-bearing headers (see
+// config.IsSensitiveHeader) replaced by config.RedactedValue.
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if config.IsSensitiveHeader(name) {
+			value = config.RedactedValue
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// writeBundleJSON writes v to path as indented JSON.
+func writeBundleJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle file '%s': %w", filepath.Base(path), err)
+	}
+	return writeBundleFile(path, data)
+}
+
+// writeBundleFile writes data to path with owner-only permissions.
+func writeBundleFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle file '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrCampaignAborted is returned by Campaign.Run when the campaign was aborted.
+var ErrCampaignAborted = errors.New("campaign aborted")
+
+// Campaign runs a sequence of sessions that share a Reporter (and so its config, proxy pool and
+// budget), either one after another or all at once, and aggregates their progress. Pause, Resume
+// and Abort apply to every session of the campaign. A Campaign runs once.
+type Campaign struct {
+	ID       string
+	Reporter Reporter // Shared by every session added to the campaign.
+
+	// Parallel runs all sessions at the same time instead of one after another.
+	Parallel bool
+
+	// OnLog, if set, receives every session's log updates. The campaign drains each session's
+	// LogChannel itself, so updates are dropped if OnLog is nil. It may be called concurrently
+	// when Parallel is set.
+	OnLog func(sessionID string, update LogUpdate)
+
+	mu       sync.Mutex
+	state    SessionState
+	sessions []*Session
+	changed  chan struct{} // Closed and replaced whenever state changes, to wake a paused Run.
+}
+
+// CampaignSummary aggregates the progress of a campaign's sessions.
+type CampaignSummary struct {
+	ID                string
+	State             SessionState      // Idle, Running, Paused, Completed or Aborted.
+	Sessions          []SessionProgress // Progress of each session, in the order they were added.
+	SessionsCompleted int               // Sessions that finished all their reports.
+	Total             int               // Reports across all sessions.
+	Attempted         int
+	Successful        int
+	Failed            int
+}
+
+// NewCampaign creates an empty campaign whose sessions send reports through reporter.
+func NewCampaign(reporter Reporter, parallel bool) *Campaign {
+	return &Campaign{
+		ID:       uuid.NewString(),
+		Reporter: reporter,
+		Parallel: parallel,
+		state:    Idle,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Add appends a session sending numReports reports to targetURL and returns it, so per-target
+// settings (e.g. Concurrency) can be adjusted before Run. Sessions can only be added before Run.
+func (c *Campaign) Add(targetURL string, numReports int) (*Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != Idle {
+		return nil, fmt.Errorf("cannot add a session to a campaign in state %s", c.state)
+	}
+	s := NewSession(c.Reporter, targetURL, numReports)
+	c.sessions = append(c.sessions, s)
+	return s, nil
+}
+
+// Run starts the campaign's sessions and blocks until they have all ended. It returns an error
+// wrapping ErrCampaignAborted if the campaign was aborted, ctx.Err() if ctx was canceled (which
+// aborts the running sessions), or the error of a session that failed to start. Sessions that end
+// in a failed or aborted state of their own do not stop the campaign.
+func (c *Campaign) Run(ctx context.Context) error {
+	c.mu.Lock()
+	if c.state != Idle {
+		c.mu.Unlock()
+		return fmt.Errorf("campaign cannot be run from its current state: %s", c.state)
+	}
+	c.setStateLocked(Running)
+	sessions := append([]*Session(nil), c.sessions...)
+	c.mu.Unlock()
+
+	var err error
+	if c.Parallel {
+		err = c.runParallel(ctx, sessions)
+	} else {
+		err = c.runSequential(ctx, sessions)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == Aborted {
+		if err == nil {
+			err = fmt.Errorf("%w: %s", ErrCampaignAborted, c.ID)
+		}
+		return err
+	}
+	if err != nil {
+		c.setStateLocked(Aborted)
+		return err
+	}
+	c.setStateLocked(Completed)
+	return nil
+}
+
+// runSequential runs each session to its end before starting the next, waiting while the
+// campaign is paused.
+func (c *Campaign) runSequential(ctx context.Context, sessions []*Session) error {
+	for _, s := range sessions {
+		if err := c.startWhenRunning(ctx, s); err != nil {
+			return err
+		}
+		if err := c.wait(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runParallel starts every session and waits for all of them.
+func (c *Campaign) runParallel(ctx context.Context, sessions []*Session) error {
+	var started []*Session
+	var err error
+	for _, s := range sessions {
+		if err = c.startWhenRunning(ctx, s); err != nil {
+			break
+		}
+		started = append(started, s)
+	}
+	for _, s := range started {
+		if waitErr := c.wait(ctx, s); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return err
+}
+
+// startWhenRunning starts s once the campaign is not paused. It returns nil without starting s
+// if the campaign has been aborted, and ctx.Err() if ctx is canceled first.
+func (c *Campaign) startWhenRunning(ctx context.Context, s *Session) error {
+	for {
+		c.mu.Lock()
+		switch c.state {
+		case Aborted:
+			c.mu.Unlock()
+			return nil
+		case Paused:
+			changed := c.changed
+			c.mu.Unlock()
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				c.Abort()
+				return ctx.Err()
+			}
+		}
+		// Start under the lock so a concurrent Pause or Abort sees the session as started.
+		err := s.StartContext(ctx)
+		c.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to start session for %s: %w", s.TargetURL, err)
+		}
+		go c.forwardLogs(s)
+		return nil
+	}
+}
+
+// wait blocks until s has ended, aborting the campaign if ctx is canceled first. A session that
+// never started returns immediately.
+func (c *Campaign) wait(ctx context.Context, s *Session) error {
+	err := s.Wait(ctx)
+	if ctx.Err() != nil {
+		c.Abort()
+		return ctx.Err()
+	}
+	if err != nil && !errors.Is(err, ErrSessionAborted) && !errors.Is(err, ErrSessionFailed) {
+		return err
+	}
+	return nil
+}
+
+// forwardLogs drains s's LogChannel, passing each update to OnLog.
+func (c *Campaign) forwardLogs(s *Session) {
+	for update := range s.LogChannel {
+		if c.OnLog != nil {
+			c.OnLog(s.ID, update)
+		}
+	}
+}
+
+// Pause pauses every running session and, for a sequential campaign, holds back the next one
+// until Resume. It returns an error if the campaign is not running.
+func (c *Campaign) Pause() error {
+	c.mu.Lock()
+	if c.state != Running {
+		c.mu.Unlock()
+		return fmt.Errorf("campaign is not running, cannot pause (current state: %s)", c.state)
+	}
+	c.setStateLocked(Paused)
+	sessions := append([]*Session(nil), c.sessions...)
+	c.mu.Unlock()
+
+	for _, s := range sessions {
+		if s.GetStateValue() == Running {
+			_ = s.Pause() // The session may finish on its own in the meantime.
+		}
+	}
+	return nil
+}
+
+// Resume resumes the sessions paused by Pause (or auto-paused) and lets a sequential campaign
+// move on. It returns an error if the campaign is not paused.
+func (c *Campaign) Resume() error {
+	c.mu.Lock()
+	if c.state != Paused {
+		c.mu.Unlock()
+		return fmt.Errorf("campaign is not paused, cannot resume (current state: %s)", c.state)
+	}
+	c.setStateLocked(Running)
+	sessions := append([]*Session(nil), c.sessions...)
+	c.mu.Unlock()
+
+	for _, s := range sessions {
+		if s.GetStateValue() == Paused {
+			_ = s.Resume()
+		}
+	}
+	return nil
+}
+
+// Abort stops the campaign: sessions not yet started never start, and running ones are aborted.
+// It returns the first error from aborting a session, e.g. one wrapping ErrAbortTimeout.
+func (c *Campaign) Abort() error {
+	c.mu.Lock()
+	if c.state == Aborted || c.state == Completed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.setStateLocked(Aborted)
+	sessions := append([]*Session(nil), c.sessions...)
+	c.mu.Unlock()
+
+	var firstErr error
+	for _, s := range sessions {
+		if err := s.Abort(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Summary returns the campaign's state and the progress of its sessions, with report counts
+// summed across them.
+func (c *Campaign) Summary() CampaignSummary {
+	c.mu.Lock()
+	summary := CampaignSummary{ID: c.ID, State: c.state}
+	sessions := append([]*Session(nil), c.sessions...)
+	c.mu.Unlock()
+
+	for _, s := range sessions {
+		p := s.Progress()
+		summary.Sessions = append(summary.Sessions, p)
+		if p.State == Completed {
+			summary.SessionsCompleted++
+		}
+		summary.Total += p.Total
+		summary.Attempted += p.Attempted
+		summary.Successful += p.Successful
+		summary.Failed += p.Failed
+	}
+	return summary
+}
+
+// setStateLocked changes the campaign state and wakes anything waiting on a change.
+// Callers must hold c.mu.
+func (c *Campaign) setStateLocked(state SessionState) {
+	c.state = state
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/report"
+)
+
+func TestCampaign_RunTwoSessions(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+				if call == 0 {
+					return nil, errors.New("rejected")
+				}
+				return &report.ReportResult{StatusCode: 200}, nil
+			}}
+			c := NewCampaign(mock, parallel)
+			var logMu sync.Mutex
+			logged := make(map[string]int)
+			c.OnLog = func(sessionID string, update LogUpdate) {
+				logMu.Lock()
+				defer logMu.Unlock()
+				logged[sessionID]++
+			}
+			first, err := c.Add("http://one.example.com/report", 2)
+			require.NoError(t, err)
+			second, err := c.Add("http://two.example.com/report", 3)
+			require.NoError(t, err)
+
+			require.NoError(t, c.Run(context.Background()))
+
+			summary := c.Summary()
+			assert.Equal(t, Completed, summary.State)
+			require.Len(t, summary.Sessions, 2)
+			assert.Equal(t, "http://one.example.com/report", summary.Sessions[0].Target)
+			assert.Equal(t, "http://two.example.com/report", summary.Sessions[1].Target)
+			assert.Equal(t, 2, summary.SessionsCompleted)
+			assert.Equal(t, 5, summary.Total)
+			assert.Equal(t, 5, summary.Attempted)
+			assert.Equal(t, 4, summary.Successful)
+			assert.Equal(t, 1, summary.Failed)
+			assert.Equal(t, 5, mock.calls())
+
+			require.Eventually(t, func() bool {
+				logMu.Lock()
+				defer logMu.Unlock()
+				return logged[first.ID] > 0 && logged[second.ID] > 0
+			}, 5*time.Second, time.Millisecond, "each session's logs are forwarded")
+
+			_, err = c.Add("http://three.example.com/report", 1)
+			assert.Error(t, err, "sessions cannot be added once the campaign has run")
+			assert.Error(t, c.Run(context.Background()), "a campaign runs once")
+		})
+	}
+}
+
+func TestCampaign_PauseHoldsNextSessionAndAbort(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call == 0 {
+			close(started)
+			<-release
+		}
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	c := NewCampaign(mock, false)
+	_, err := c.Add("http://one.example.com/report", 1)
+	require.NoError(t, err)
+	second, err := c.Add("http://two.example.com/report", 1)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background()) }()
+	<-started
+	require.NoError(t, c.Pause())
+	close(release) // The first session finishes, but the second must not start while paused.
+	require.Eventually(t, func() bool { return c.Summary().Sessions[0].State == Completed }, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, Idle, second.GetStateValue())
+	assert.Equal(t, Paused, c.Summary().State)
+
+	require.NoError(t, c.Abort())
+	err = <-done
+	assert.True(t, errors.Is(err, ErrCampaignAborted))
+	assert.Equal(t, Idle, second.GetStateValue(), "an aborted campaign starts no further sessions")
+	assert.Equal(t, 1, mock.calls())
+	assert.Equal(t, Aborted, c.Summary().State)
+}
+
+func TestCampaign_ContextCancelAbortsSessions(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		time.Sleep(5 * time.Millisecond)
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	c := NewCampaign(mock, true)
+	for i := 0; i < 2; i++ {
+		_, err := c.Add(fmt.Sprintf("http://%d.example.com/report", i), 1000)
+		require.NoError(t, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(30*time.Millisecond, cancel)
+
+	err := c.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	summary := c.Summary()
+	assert.Equal(t, Aborted, summary.State)
+	for _, p := range summary.Sessions {
+		assert.Equal(t, Aborted, p.State)
+		assert.Less(t, p.Attempted, 1000)
+	}
+}
@@ -0,0 +1,331 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/report"
+)
+
+// mockReporter is a Reporter whose outcomes are scripted by send, called with the 0-based
+// index of each call. A nil send succeeds every report. It records the job ID of each call,
+// and the host of the proxy pinned by the session, if any.
+type mockReporter struct {
+	mu      sync.Mutex
+	jobIDs  []string
+	proxies []string
+	send    func(call int) (*report.ReportResult, error)
+}
+
+func (m *mockReporter) SendReport(ctx context.Context, targetURL, sessionID string) (*report.ReportResult, error) {
+	m.mu.Lock()
+	call := len(m.jobIDs)
+	m.jobIDs = append(m.jobIDs, report.JobIDFromContext(ctx))
+	pinned := ""
+	if p := report.ProxyFromContext(ctx); p != nil {
+		pinned = p.URL.Host
+	}
+	m.proxies = append(m.proxies, pinned)
+	m.mu.Unlock()
+	if m.send == nil {
+		return &report.ReportResult{StatusCode: 200, Latency: time.Millisecond}, nil
+	}
+	return m.send(call)
+}
+
+// calls returns how many reports have been sent so far.
+func (m *mockReporter) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.jobIDs)
+}
+
+// waitFor waits up to 5s for s to finish and fails the test otherwise.
+func waitFor(t *testing.T, s *Session) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Wait(ctx))
+}
+
+func TestSession_MockReporterSuccessAndFailure(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call%2 == 1 {
+			return nil, fmt.Errorf("attempt %d rejected", call)
+		}
+		return &report.ReportResult{StatusCode: 200, Latency: time.Duration(call+1) * time.Millisecond}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 4)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	waitFor(t, s)
+
+	p := s.Progress()
+	assert.Equal(t, Completed, p.State)
+	assert.Equal(t, 4, p.Attempted)
+	assert.Equal(t, 2, p.Successful)
+	assert.Equal(t, 2, p.Failed)
+	for i, job := range s.Jobs {
+		assert.Equal(t, job.ID, mock.jobIDs[i], "each report carries its job ID")
+	}
+	assert.Equal(t, "success", s.Jobs[0].Status)
+	assert.Equal(t, "failed", s.Jobs[1].Status)
+	assert.Equal(t, "attempt 1 rejected", s.Jobs[1].Error)
+	assert.Equal(t, 3*time.Millisecond, s.Jobs[2].Latency)
+}
+
+func TestSession_MockReporterPauseAndResume(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call == 0 {
+			close(started)
+			<-release // Hold the first report so the pause lands mid-session.
+		}
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 3)
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	<-started
+	require.NoError(t, s.Pause())
+	close(release)
+	require.Eventually(t, func() bool { return s.GetStateValue() == Paused }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 1, mock.calls(), "no report is sent while paused")
+
+	require.NoError(t, s.Resume())
+	waitFor(t, s)
+	assert.Equal(t, 3, mock.calls())
+	assert.Equal(t, Completed, s.GetStateValue())
+}
+
+func TestSession_MockReporterBudgetExhausted(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call == 1 {
+			return nil, report.ErrBudgetExhausted
+		}
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 5)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	waitFor(t, s)
+
+	p := s.Progress()
+	assert.Equal(t, Stopped, p.State)
+	assert.Equal(t, 2, mock.calls(), "the session stops once the budget is exhausted")
+	assert.Equal(t, 1, p.Successful)
+	assert.Equal(t, 1, p.Failed)
+}
+
+func TestSession_MockReporterAutoPause(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		return nil, fmt.Errorf("failed to get proxy: %w", proxy.ErrNoHealthyProxies)
+	}}
+	s := NewSession(mock, "http://example.com/report", 3)
+	s.Config = &config.AppConfig{AutoPauseOnDegraded: true}
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool { return s.GetStateValue() == Paused }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 1, mock.calls())
+
+	require.NoError(t, s.Abort())
+	assert.True(t, errors.Is(s.Wait(context.Background()), ErrSessionAborted))
+}
+
+func TestSession_DelayBetweenReports(t *testing.T) {
+	elapsed := func(delay time.Duration) time.Duration {
+		s := NewSession(&mockReporter{}, "http://example.com/report", 3)
+		s.DelayBetweenReports = delay
+		start := time.Now()
+		require.NoError(t, s.Start())
+		drainLogs(s)
+		waitFor(t, s)
+		return time.Since(start)
+	}
+
+	// Three reports wait twice: after the first and the second, but not after the last.
+	fast := elapsed(0)
+	slow := elapsed(100 * time.Millisecond)
+	assert.GreaterOrEqual(t, slow, 200*time.Millisecond)
+	assert.Greater(t, slow-fast, 150*time.Millisecond, "elapsed time scales with the delay")
+}
+
+func TestSession_AbortCutsDelayShort(t *testing.T) {
+	mock := &mockReporter{}
+	s := NewSession(mock, "http://example.com/report", 3)
+	s.DelayBetweenReports = time.Hour
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool { return mock.calls() == 1 }, 5*time.Second, time.Millisecond)
+	start := time.Now()
+	require.NoError(t, s.Abort())
+	assert.Less(t, time.Since(start), time.Second, "abort must not wait for the delay to elapse")
+	assert.Equal(t, Aborted, s.GetStateValue())
+	assert.Equal(t, 1, mock.calls())
+}
+
+func TestSession_PauseDuringDelay(t *testing.T) {
+	mock := &mockReporter{}
+	s := NewSession(mock, "http://example.com/report", 2)
+	s.DelayBetweenReports = time.Hour
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool { return mock.calls() == 1 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, s.Pause())
+	require.Eventually(t, func() bool { return s.GetStateValue() == Paused }, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, s.Resume())
+	waitFor(t, s)
+	assert.Equal(t, 2, mock.calls(), "resuming sends the next report without waiting out the delay")
+}
+
+func TestJitteredDelay(t *testing.T) {
+	assert.Equal(t, time.Second, jitteredDelay(time.Second, 0, 0.9))
+	assert.Equal(t, time.Second, jitteredDelay(time.Second, 200*time.Millisecond, 0.5))
+	assert.Equal(t, 800*time.Millisecond, jitteredDelay(time.Second, 200*time.Millisecond, 0))
+	assert.Equal(t, 1150*time.Millisecond, jitteredDelay(time.Second, 200*time.Millisecond, 0.875))
+	assert.Equal(t, time.Duration(0), jitteredDelay(100*time.Millisecond, time.Second, 0), "never negative")
+}
+
+func TestSession_ConcurrentWorkers(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond) // Keep reports overlapping.
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if call%5 == 0 {
+			return nil, fmt.Errorf("attempt %d rejected", call)
+		}
+		return &report.ReportResult{StatusCode: 200, Latency: time.Millisecond}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 50)
+	s.Concurrency = 5
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+	waitFor(t, s)
+
+	p := s.Progress()
+	assert.Equal(t, Completed, p.State)
+	assert.Equal(t, 50, p.Attempted)
+	assert.Equal(t, 40, p.Successful)
+	assert.Equal(t, 10, p.Failed)
+	assert.Equal(t, 50, mock.calls())
+	assert.LessOrEqual(t, maxInFlight, 5, "no more than Concurrency reports run at once")
+	assert.Greater(t, maxInFlight, 1, "reports run in parallel")
+
+	seen := make(map[string]bool)
+	for _, id := range mock.jobIDs {
+		assert.False(t, seen[id], "job %s was sent twice", id)
+		seen[id] = true
+	}
+	for _, job := range s.Jobs {
+		assert.True(t, seen[job.ID], "job %d was never sent", job.ReportNumber)
+		assert.Contains(t, []string{"success", "failed"}, job.Status)
+	}
+}
+
+func TestSession_ConcurrentPauseResumeAndAbort(t *testing.T) {
+	release := make(chan struct{})
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		<-release
+		if call >= 3 {
+			time.Sleep(20 * time.Millisecond) // Slow enough that the abort lands mid-session.
+		}
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 20)
+	s.Concurrency = 3
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool { return mock.calls() == 3 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, s.Pause())
+	require.Eventually(t, func() bool { return s.GetStateValue() == Paused }, 5*time.Second, time.Millisecond)
+	close(release) // In-flight reports finish, but no new ones start while paused.
+	require.Eventually(t, func() bool { return s.Progress().Attempted == 3 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 3, mock.calls())
+
+	require.NoError(t, s.Resume())
+	require.Eventually(t, func() bool { return mock.calls() > 3 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, s.Abort())
+	assert.Equal(t, Aborted, s.GetStateValue())
+	p := s.Progress()
+	assert.Equal(t, p.Attempted, p.Successful+p.Failed)
+	assert.Equal(t, mock.calls(), p.Attempted, "every report handed to a worker finishes before the session ends")
+	assert.Less(t, p.Attempted, 20)
+}
+
+// newMockProxyManager returns a round-robin pool of healthy proxies named p0, p1, ... .
+func newMockProxyManager(t *testing.T, n int) *proxy.ProxyManager {
+	t.Helper()
+	var proxies []*proxy.ProxyInfo
+	for i := 0; i < n; i++ {
+		u, err := url.Parse(fmt.Sprintf("http://p%d:8080", i))
+		require.NoError(t, err)
+		proxies = append(proxies, &proxy.ProxyInfo{URL: u, HealthStatus: "healthy"})
+	}
+	return proxy.NewProxyManager(proxies, proxy.StrategyRoundRobin, true)
+}
+
+func TestSession_ReportsPerProxy(t *testing.T) {
+	mock := &mockReporter{}
+	s := NewSession(mock, "http://example.com/report", 7)
+	s.ProxyMgr = newMockProxyManager(t, 3)
+	s.ReportsPerProxy = 2
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+	waitFor(t, s)
+
+	assert.Equal(t, []string{"p0:8080", "p0:8080", "p1:8080", "p1:8080", "p2:8080", "p2:8080", "p0:8080"}, mock.proxies)
+}
+
+func TestSession_ReportsPerProxyRotatesAfterFailure(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call == 0 {
+			return nil, errors.New("rejected")
+		}
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 4)
+	s.ProxyMgr = newMockProxyManager(t, 3)
+	s.ReportsPerProxy = 3
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+	waitFor(t, s)
+
+	assert.Equal(t, []string{"p0:8080", "p1:8080", "p1:8080", "p1:8080"}, mock.proxies)
+}
+
+func TestSession_ReportsPerProxyZeroLeavesSelectionToReporter(t *testing.T) {
+	mock := &mockReporter{}
+	s := NewSession(mock, "http://example.com/report", 3)
+	s.ProxyMgr = newMockProxyManager(t, 3)
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+	waitFor(t, s)
+
+	assert.Equal(t, []string{"", "", ""}, mock.proxies)
+}
@@ -1,13 +1,24 @@
 package session
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 
+	"sentinelgo/sentinelgo/ai"
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
 	"sentinelgo/sentinelgo/report"
+	"sentinelgo/sentinelgo/utils"
 )
 
 // LogLevelUpdate defines the severity level for LogUpdate messages sent to the TUI.
@@ -24,8 +35,35 @@ type LogUpdate struct {
 	Level     string    // Severity level of the log update (e.g., LogLevelUpdateInfo).
 	Message   string    // The content of the log message.
 	Timestamp time.Time // Timestamp when the log update was generated.
+	// Terminal marks the last update a listener will receive, e.g. the notice a listener makes
+	// up once LogChannel is closed. Sessions never send terminal updates themselves.
+	Terminal bool
 }
 
+// Backpressure policies for LogChannel: what a session does with a log update when the
+// channel's buffer is full because the listener is slow.
+const (
+	LogPolicyDropNewest = "drop-newest" // Wait up to logSendTimeout for room, then drop the new update (the default).
+	LogPolicyDropOldest = "drop-oldest" // Discard the oldest buffered update to make room, without waiting.
+	LogPolicyBlock      = "block"       // Wait for room for as long as it takes; nothing is dropped.
+)
+
+// logSendTimeout is how long LogPolicyDropNewest waits for room in LogChannel.
+const logSendTimeout = time.Second
+
+// Errors returned by Wait when a session ends in a non-successful terminal state.
+var (
+	ErrSessionAborted = errors.New("session aborted")
+	ErrSessionFailed  = errors.New("session failed")
+)
+
+// ErrAbortTimeout is returned by Abort when runLoop does not exit within the session's AbortTimeout.
+var ErrAbortTimeout = errors.New("timeout waiting for session to abort")
+
+// DefaultAbortTimeout is how long Abort waits for runLoop to exit when neither the session's
+// AbortTimeout nor the config's AbortTimeoutSeconds is set.
+const DefaultAbortTimeout = 10 * time.Second
+
 // SessionState defines the possible operational states of a reporting session.
 type SessionState int
 
@@ -68,46 +106,153 @@ func (s SessionState) String() string {
 // ReportJob represents a single report attempt within a session.
 // Since a session now targets one URL for N reports, each of these N reports is a ReportJob.
 type ReportJob struct {
-	ID           string    // Unique identifier for this specific report job.
-	ReportNumber int       // 1-based sequence number of this report within the session (e.g., 1 of N).
-	Status       string    // Current status of this job (e.g., "pending", "processing", "success", "failed").
-	LogID        string    // Log identifier received from the target platform's response (if any). TODO: reporter.SendReport needs to return this.
-	Error        string    // Error message if this specific report job failed.
-	StartTime    time.Time // Timestamp when processing for this job started.
-	EndTime      time.Time // Timestamp when processing for this job ended.
+	ID           string        // Unique identifier for this specific report job.
+	ReportNumber int           // 1-based sequence number of this report within the session (e.g., 1 of N).
+	Status       string        // Current status of this job (e.g., "pending", "processing", "success", "failed").
+	LogID        string        // Log identifier received from the target platform's response (if any), from ReportResult.LogID.
+	Error        string        // Error message if this specific report job failed.
+	Latency      time.Duration // Latency of the successful request, taken from the reporter's ReportResult.
+	StartTime    time.Time     // Timestamp when processing for this job started.
+	EndTime      time.Time     // Timestamp when processing for this job ended.
+
+	// ResponseStatus and ResponseSnippet record the last response received for this job, from the
+	// reporter's ReportResult, whether the job succeeded or failed: its HTTP status and the start
+	// of its body. They are zero if no response was received (e.g., every attempt hit a network error).
+	ResponseStatus  int
+	ResponseSnippet string
+
+	// DryRun is true if the job succeeded in a dry run (see Session.DryRun), so nothing was sent.
+	// A resumed session that is not a dry run sends such jobs again.
+	DryRun bool
+
+	proxy *proxy.ProxyInfo // Proxy pinned for this job under ReportsPerProxy; nil lets the reporter pick per attempt.
+}
+
+// AICategoryStat summarizes the AI analysis results of one content category across a session.
+type AICategoryStat struct {
+	Count          int     `json:"count"`            // Number of analyzed reports in this category.
+	MaxThreatScore float64 `json:"max_threat_score"` // Highest threat score seen in this category.
+	AvgThreatScore float64 `json:"avg_threat_score"` // Mean threat score across the category's reports.
+}
+
+// Reporter sends a single report, handling its own retries. *report.Reporter implements it;
+// tests can substitute a mock to drive a session without an HTTP stack.
+type Reporter interface {
+	SendReport(ctx context.Context, targetURL, sessionID string) (*report.ReportResult, error)
 }
 
 // Session manages the overall process of sending a configured number of reports
 // to a single target URL. It handles state (running, paused, etc.), tracks progress,
 // and communicates updates via its LogChannel.
 type Session struct {
-	ID       string           // Unique identifier for the session.
-	State    SessionState     // Current operational state of the session.
-	Reporter *report.Reporter // The reporter instance used to send individual reports.
+	ID       string              // Unique identifier for the session.
+	State    SessionState        // Current operational state of the session.
+	Reporter Reporter            // Sends the individual reports.
+	Logger   *utils.Logger       // Structured file logger for lifecycle events (defaults to the reporter's logger; may be nil).
+	Config   *config.AppConfig   // Settings for auto-pause, auto-save and the kill switch (defaults to the reporter's config; may be nil).
+	ProxyMgr *proxy.ProxyManager // Proxy pool for the summary and run bundles (defaults to the reporter's; may be nil).
 
 	TargetURL        string       // The URL targeted by this session.
+	Label            string       // Optional user-provided name (e.g. "evening-batch") added to log entries and the summary; set it before Start.
 	NumReportsToSend int          // Total number of reports to send in this session.
 	Jobs             []*ReportJob // Slice holding each of the N report jobs.
 
-	ReportsAttemptedCount int // How many reports (0 to N-1 index) have begun processing.
-	SuccessfulReports     int // Count of successfully sent reports.
-	FailedReports         int // Count of failed report attempts.
+	// Headers are set on every report of the session over the reporter's DefaultHeaders, e.g. a
+	// Referer or Origin that differs between concurrent sessions. Per-call headers added with
+	// report.WithHeaders win over them (see Reporter.SendReport for the full precedence). Set it
+	// before Start.
+	Headers map[string]string
+
+	// DryRun makes the session rehearse its reports: each one selects a proxy and is logged with
+	// outcome "dry_run", but nothing is sent and it succeeds (see report.WithDryRun). It defaults
+	// to the config's DryRun. Set it before Start.
+	DryRun bool
 
-	StartTime   time.Time      // Timestamp when the session was started.
-	EndTime     time.Time      // Timestamp when the session concluded (completed, aborted, or failed).
-	ProxiesUsed map[string]int // TODO: Track proxy usage statistics.
+	ReportsAttemptedCount int  // How many reports have finished processing, successfully or not.
+	SuccessfulReports     int  // Count of successfully sent reports.
+	FailedReports         int  // Count of failed report attempts.
+	dispatched            int  // How many jobs runLoop has handed to workers; the index of the next job.
+	resumed               bool // Set by ResumeSession so the next Start keeps jobs that already succeeded.
+
+	StartTime   time.Time                 // Timestamp when the session was started.
+	EndTime     time.Time                 // Timestamp when the session concluded (completed, aborted, or failed).
+	ProxiesUsed map[string]int            // TODO: Track proxy usage statistics.
+	latencies   []time.Duration           // Latencies of successful reports, used for LatencyPercentiles.
+	aiStats     map[string]AICategoryStat // AI analysis rollup per category, used for AISummary.
+
+	AbortTimeout time.Duration // How long Abort waits for runLoop to exit; zero means DefaultAbortTimeout.
+
+	// StallTimeout, if positive, starts a watchdog that detects a silent stall: reports are in
+	// flight but none has been handed out or finished for this long, e.g. because the reporter
+	// hangs. A stall is logged as a warning with the in-flight reports. With FailOnStall set, the
+	// in-flight reports are also marked failed and the session ends in the Failed state without
+	// waiting for them; results that arrive later are discarded. Set them before Start, and keep
+	// StallTimeout above DelayBetweenReports plus the slowest expected report.
+	StallTimeout time.Duration
+	FailOnStall  bool
+	lastProgress time.Time     // When a job was last handed out or finished, or the session last started running.
+	stallWarned  time.Time     // lastProgress at the last stall warning, so each stall is reported once.
+	run          int           // Incremented by each Start; identifies the current runLoop and its workers.
+	cancelRun    func()        // Cancels the context of the current run's reports; called by Abort.
+	abandonedRun int           // A run failed by the stall watchdog; its workers discard their results.
+	watchdogDone chan struct{} // Closed by runLoop on exit to stop the stall watchdog.
+
+	// DelayBetweenReports spaces out reports: after each attempted report the session waits this
+	// long, shifted randomly by up to ±DelayJitter, before sending the next one. Pause and abort
+	// take effect immediately during the wait. Set them before Start.
+	DelayBetweenReports time.Duration
+	DelayJitter         time.Duration
+
+	// Concurrency is how many reports may be in flight at once, each sent by its own worker.
+	// Values below 2 send reports one at a time. Set it before Start.
+	Concurrency int
+
+	// ReportsPerProxy, if positive, sends that many consecutive reports through the same proxy
+	// from ProxyMgr before selecting the next one; a report failure rotates early. Zero lets the
+	// reporter select a proxy for every attempt. Set it before Start.
+	ReportsPerProxy int
+	pinnedProxy     *proxy.ProxyInfo // Proxy handed to the current run of ReportsPerProxy jobs.
+	pinnedUses      int              // Jobs handed pinnedProxy so far.
+
+	// OnJobComplete, if set, is called with a copy of each job once it has finished (success or failure).
+	// It runs in its own goroutine so a slow integration (webhook, notification) never stalls the session;
+	// calls may therefore arrive out of order. Set it before Start.
+	OnJobComplete func(ReportJob)
+
+	// OnStateChange, if set, is called on every state transition with the previous state and a
+	// snapshot of the progress, whose State is the new state. Unlike OnJobComplete it is called
+	// synchronously, while the session's lock is held, so transitions arrive in order; it must
+	// return quickly and must not call the session's methods. Set it before Start.
+	OnStateChange func(from SessionState, progress SessionProgress)
+
+	// AutoSaveInterval and AutoSavePath, if both set, make the session write its progress to
+	// AutoSavePath via SaveState on every tick while it runs, and once more when it ends.
+	AutoSaveInterval time.Duration
+	AutoSavePath     string
+	autoSaveDone     chan struct{} // Closed by runLoop on exit to stop the auto-save goroutine.
 
 	LogChannel     chan LogUpdate // Channel for sending LogUpdate messages to listeners (e.g., TUI).
 	controlChannel chan string    // Internal channel for control commands (pause, resume, abort).
 
+	// LogPolicy is the backpressure policy applied when LogChannel is full: LogPolicyDropNewest
+	// (used when empty or unknown), LogPolicyDropOldest or LogPolicyBlock. With LogPolicyBlock the
+	// session stalls until the listener catches up, so it suits only listeners that always drain
+	// LogChannel. Updates are sent while the session's lock is held, so such a listener must not
+	// call the session's methods (e.g. Progress) between two receives. Dropped updates are counted
+	// (see DroppedLogs) and written to Logger. Set it before Start.
+	LogPolicy   string
+	droppedLogs atomic.Int64 // Updates dropped from LogChannel under LogPolicy.
+
 	wg sync.WaitGroup // Used to wait for the main runLoop goroutine to finish.
 	mu sync.Mutex     // Protects concurrent access to shared fields (State, counts, etc.).
 }
 
 // NewSession creates a new reporting session configured to send `numReportsToSend`
 // reports to the specified `targetURL` using the provided `reporter`.
-// The session starts in the Idle state.
-func NewSession(reporter *report.Reporter, targetURL string, numReportsToSend int) *Session {
+// If reporter is a *report.Reporter, the session's Logger, Config and ProxyMgr are taken from it.
+// The optional headers become the session's Headers; with several maps, later ones override
+// earlier ones. The session starts in the Idle state.
+func NewSession(reporter Reporter, targetURL string, numReportsToSend int, headers ...map[string]string) *Session {
 	if numReportsToSend <= 0 {
 		numReportsToSend = 1 // Ensure at least one report is attempted.
 	}
@@ -120,34 +265,214 @@ func NewSession(reporter *report.Reporter, targetURL string, numReportsToSend in
 		}
 	}
 
+	var logger *utils.Logger
+	var cfg *config.AppConfig
+	var pm *proxy.ProxyManager
+	if r, ok := reporter.(*report.Reporter); ok && r != nil {
+		logger, cfg, pm = r.Logger, r.Config, r.ProxyMgr
+	}
+	abortTimeout := DefaultAbortTimeout
+	if cfg != nil && cfg.AbortTimeoutSeconds > 0 {
+		abortTimeout = time.Duration(cfg.AbortTimeoutSeconds * float64(time.Second))
+	}
+	var delayBetweenReports, delayJitter time.Duration
+	concurrency := 1
+	var reportsPerProxy int
+	if cfg != nil {
+		if cfg.ReportConcurrency > 1 {
+			concurrency = cfg.ReportConcurrency
+		}
+		reportsPerProxy = cfg.ReportsPerProxy
+		delayBetweenReports = time.Duration(cfg.DelayBetweenReportsSeconds * float64(time.Second))
+		delayJitter = time.Duration(cfg.DelayJitterSeconds * float64(time.Second))
+	}
+	var logPolicy string
+	if cfg != nil {
+		logPolicy = strings.ToLower(cfg.LogChannelPolicy)
+	}
+	var stallTimeout time.Duration
+	var failOnStall bool
+	if cfg != nil {
+		stallTimeout = time.Duration(cfg.StallTimeoutSeconds * float64(time.Second))
+		failOnStall = cfg.FailOnStall
+	}
+	var sessionHeaders map[string]string
+	for _, h := range headers {
+		for key, value := range h {
+			if sessionHeaders == nil {
+				sessionHeaders = make(map[string]string)
+			}
+			sessionHeaders[key] = value
+		}
+	}
+	var dryRun bool
+	if cfg != nil {
+		dryRun = cfg.DryRun
+	}
+	var autoSaveInterval time.Duration
+	var autoSavePath string
+	if cfg != nil && cfg.AutoSaveIntervalSeconds > 0 {
+		autoSaveInterval = time.Duration(cfg.AutoSaveIntervalSeconds * float64(time.Second))
+		autoSavePath = cfg.AutoSavePath
+	}
+
 	return &Session{
-		ID:               uuid.NewString(),
-		State:            Idle,
-		Reporter:         reporter,
-		TargetURL:        targetURL,
-		NumReportsToSend: numReportsToSend,
-		Jobs:             jobs,
-		ProxiesUsed:      make(map[string]int),
-		LogChannel:       make(chan LogUpdate, 100), // Buffered channel for TUI updates.
-		controlChannel:   make(chan string, 10),     // Buffered for control commands.
+		ID:                  uuid.NewString(),
+		State:               Idle,
+		Reporter:            reporter,
+		Logger:              logger,
+		Config:              cfg,
+		ProxyMgr:            pm,
+		TargetURL:           targetURL,
+		NumReportsToSend:    numReportsToSend,
+		Headers:             sessionHeaders,
+		DryRun:              dryRun,
+		Jobs:                jobs,
+		ProxiesUsed:         make(map[string]int),
+		AbortTimeout:        abortTimeout,
+		StallTimeout:        stallTimeout,
+		FailOnStall:         failOnStall,
+		DelayBetweenReports: delayBetweenReports,
+		DelayJitter:         delayJitter,
+		Concurrency:         concurrency,
+		ReportsPerProxy:     reportsPerProxy,
+		AutoSaveInterval:    autoSaveInterval,
+		AutoSavePath:        autoSavePath,
+		LogPolicy:           logPolicy,
+		LogChannel:          make(chan LogUpdate, 100), // Buffered channel for TUI updates.
+		controlChannel:      make(chan string, 10),     // Buffered for control commands.
 	}
 }
 
-// sendLog is an internal helper to send a LogUpdate to the LogChannel.
-// It includes a timeout to prevent blocking indefinitely if the channel is full.
+// sendLog is an internal helper to send a LogUpdate to the LogChannel. If the channel is full,
+// the session's LogPolicy decides whether to wait, drop the new update or drop the oldest one.
 func (s *Session) sendLog(level string, message string) {
-	select {
-	case s.LogChannel <- LogUpdate{Level: level, Message: message, Timestamp: time.Now()}:
-	case <-time.After(1 * time.Second): // Timeout to prevent deadlock.
-		// Fallback if TUI is not consuming logs (e.g., during shutdown or if TUI is frozen).
-		fmt.Printf("LogChannel Send Timeout: [%s] %s\n", level, message)
+	update := LogUpdate{Level: level, Message: message, Timestamp: time.Now()}
+	switch s.LogPolicy {
+	case LogPolicyBlock:
+		s.LogChannel <- update
+	case LogPolicyDropOldest:
+		for {
+			select {
+			case s.LogChannel <- update:
+				return
+			default:
+			}
+			select { // Full: make room, unless the listener just did.
+			case oldest := <-s.LogChannel:
+				s.dropLog(oldest)
+			default:
+			}
+		}
+	default: // LogPolicyDropNewest
+		select {
+		case s.LogChannel <- update:
+		case <-time.After(logSendTimeout): // Timeout to prevent deadlock if the listener is frozen or gone.
+			s.dropLog(update)
+		}
+	}
+}
+
+// dropLog counts an update dropped from LogChannel and records it in the file logger instead,
+// so the file keeps the full timeline. Without a logger it is printed to stdout.
+func (s *Session) dropLog(update LogUpdate) {
+	s.droppedLogs.Add(1)
+	if s.Logger == nil {
+		fmt.Printf("LogChannel full, dropped: [%s] %s\n", update.Level, update.Message)
+		return
+	}
+	level, ok := utils.ParseLevel(update.Level)
+	if !ok {
+		level = utils.LevelInfo
 	}
+	s.Logger.Log(level, utils.LogEntry{
+		SessionID:      s.ID,
+		Label:          s.Label,
+		Message:        update.Message,
+		ReportURL:      s.TargetURL,
+		Outcome:        "session_update_dropped",
+		AdditionalData: map[string]interface{}{"update_time": update.Timestamp.UTC().Format(time.RFC3339Nano), "log_policy": s.logPolicyName()},
+	})
+}
+
+// logPolicyName returns the effective LogPolicy.
+func (s *Session) logPolicyName() string {
+	switch s.LogPolicy {
+	case LogPolicyBlock, LogPolicyDropOldest:
+		return s.LogPolicy
+	}
+	return LogPolicyDropNewest
+}
+
+// DroppedLogs returns how many log updates have been dropped from LogChannel because it was
+// full (thread-safe).
+func (s *Session) DroppedLogs() int {
+	return int(s.droppedLogs.Load())
+}
+
+// setState transitions the session to newState and records the transition as a structured
+// LogEntry in the file logger (old/new state plus progress counts), so the session timeline
+// can be reconstructed after the fact, and calls OnStateChange. Nothing is recorded if the state
+// does not change.
+// Callers must hold s.mu.
+func (s *Session) setState(newState SessionState) {
+	oldState := s.State
+	s.State = newState
+	if oldState == newState {
+		return
+	}
+	if newState == Running {
+		s.lastProgress = time.Now() // Time spent idle or paused is not a stall.
+	}
+	if s.OnStateChange != nil {
+		s.OnStateChange(oldState, s.progressLocked(time.Now()))
+	}
+	if s.Logger == nil {
+		return
+	}
+	s.Logger.Info(utils.LogEntry{
+		SessionID: s.ID,
+		Label:     s.Label,
+		Message:   fmt.Sprintf("Session state changed: %s -> %s", oldState, newState),
+		ReportURL: s.TargetURL,
+		Outcome:   "state_transition",
+		AdditionalData: map[string]interface{}{
+			"old_state":  oldState.String(),
+			"new_state":  newState.String(),
+			"total":      s.NumReportsToSend,
+			"attempted":  s.ReportsAttemptedCount,
+			"successful": s.SuccessfulReports,
+			"failed":     s.FailedReports,
+		},
+	})
 }
 
 // Start initiates the session's reporting process in a new goroutine.
-// It returns an error if the session is not in a startable state (Idle, Stopped, Completed, Aborted).
-// If restarting a session, its progress counters and job statuses are reset.
+// It returns an error if the session is not in a startable state (Idle, Stopped, Completed, Aborted),
+// one wrapping config.ErrDisabled if the operator kill switch is active, or one wrapping
+// config.ErrTargetNotAllowed if the config's target policy refuses TargetURL.
+// If restarting a session, its progress counters and job statuses are reset. The first Start of a
+// session built by ResumeSession keeps the jobs that already succeeded and only sends the rest.
 func (s *Session) Start() error {
+	return s.StartContext(context.Background())
+}
+
+// StartContext is like Start, but sends the session's reports with a context derived from ctx.
+// Cancelling ctx cancels the reports in flight and aborts the session, like Abort.
+func (s *Session) StartContext(ctx context.Context) error {
+	if err := s.Config.CheckKillSwitch(); err != nil {
+		if s.Logger != nil {
+			s.Logger.Error(utils.LogEntry{SessionID: s.ID, Label: s.Label, Message: "Session refused to start", ReportURL: s.TargetURL, Error: err.Error(), Outcome: "kill_switch"})
+		}
+		return err
+	}
+	if err := s.Config.CheckTarget(s.TargetURL); err != nil {
+		if s.Logger != nil {
+			s.Logger.Error(utils.LogEntry{SessionID: s.ID, Label: s.Label, Message: "Session refused to start", ReportURL: s.TargetURL, Error: err.Error(), Outcome: "target_not_allowed"})
+		}
+		return err
+	}
+
 	s.mu.Lock()
 	// Allow starting from Idle or any terminal/stopped state (which implies a restart).
 	if s.State != Idle && s.State != Stopped && s.State != Completed && s.State != Aborted && s.State != Failed {
@@ -155,50 +480,120 @@ func (s *Session) Start() error {
 		return fmt.Errorf("session cannot be started from its current state: %s", s.State)
 	}
 
-	s.State = Running
 	s.StartTime = time.Now()
 	s.EndTime = time.Time{} // Clear EndTime if this is a restart.
 
 	// Reset counters and job statuses if this is a fresh start or a restart.
 	s.ReportsAttemptedCount = 0
+	s.dispatched = 0
+	s.pinnedProxy, s.pinnedUses = nil, 0
 	s.SuccessfulReports = 0
 	s.FailedReports = 0
+	s.latencies = nil
+	s.aiStats = nil
+	keepSucceeded := s.resumed
+	s.resumed = false // A later restart starts from scratch.
 	for i := 0; i < s.NumReportsToSend; i++ {
 		// Ensure Jobs slice is not nil and element exists (should be guaranteed by NewSession)
 		if i < len(s.Jobs) && s.Jobs[i] != nil {
+			if keepSucceeded && s.Jobs[i].Status == "success" && (!s.Jobs[i].DryRun || s.DryRun) {
+				// Counted as done up front; runLoop skips it when handing out jobs. A rehearsed
+				// job is only done if this run is a rehearsal too.
+				s.ReportsAttemptedCount++
+				s.SuccessfulReports++
+				if s.Jobs[i].Latency > 0 {
+					s.latencies = append(s.latencies, s.Jobs[i].Latency)
+				}
+				continue
+			}
 			s.Jobs[i].Status = "pending"
 			s.Jobs[i].Error = ""
 			s.Jobs[i].LogID = ""
+			s.Jobs[i].Latency = 0
+			s.Jobs[i].ResponseStatus = 0
+			s.Jobs[i].ResponseSnippet = ""
+			s.Jobs[i].DryRun = false
 		}
 	}
+	s.setState(Running) // After the reset, so the transition entry records the fresh counts.
+	alreadySucceeded := s.SuccessfulReports
+	autoSave := s.AutoSaveInterval > 0 && s.AutoSavePath != ""
+	if autoSave {
+		s.autoSaveDone = make(chan struct{})
+	} else {
+		s.autoSaveDone = nil
+	}
+	s.run++
+	run := s.run
+	var watchdogDone chan struct{}
+	if s.StallTimeout > 0 {
+		watchdogDone = make(chan struct{})
+	}
+	s.watchdogDone = watchdogDone
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancelRun = cancel
 	s.mu.Unlock()
 
+	// Log before launching runLoop: it closes LogChannel on exit, which may happen before a later send.
+	if alreadySucceeded > 0 {
+		s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Session %s resumed from checkpoint: %d of %d reports left to %s.", s.ID, s.NumReportsToSend-alreadySucceeded, s.NumReportsToSend, s.TargetURL))
+	} else {
+		s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Session %s started: %d reports to %s.", s.ID, s.NumReportsToSend, s.TargetURL))
+	}
+	if autoSave {
+		s.wg.Add(1) // Wait and Abort also wait for the final save.
+		go s.autoSaveLoop(s.autoSaveDone)
+	}
+	if watchdogDone != nil {
+		go s.stallWatchdog(run, watchdogDone)
+	}
+	if ctx.Done() != nil {
+		go func() {
+			<-runCtx.Done() // Cancelled by ctx, by Abort, or by runLoop on exit.
+			if ctx.Err() != nil {
+				s.Abort()
+			}
+		}()
+	}
 	s.wg.Add(1)
-	go s.runLoop()
-	s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Session %s started: %d reports to %s.", s.ID, s.NumReportsToSend, s.TargetURL))
+	go s.runLoop(runCtx, cancel, run)
 	return nil
 }
 
 // runLoop is the core goroutine where reports are sent one by one.
 // It handles state changes, control commands, and updates progress.
 // This function calls `defer s.wg.Done()` and `defer close(s.LogChannel)`.
-func (s *Session) runLoop() {
+// Reports are sent with ctx, which runLoop cancels on exit.
+// run identifies this Start of the session (see Session.run).
+func (s *Session) runLoop(ctx context.Context, cancel context.CancelFunc, run int) {
 	defer s.wg.Done() // Signal that this goroutine has finished.
-	defer func() {    // This deferred function handles cleanup and final state setting.
+	defer cancel()
+	defer func() { // This deferred function handles cleanup and final state setting.
 		s.mu.Lock()
 		if r := recover(); r != nil { // Panic recovery.
 			s.sendLog(LogLevelUpdateError, fmt.Sprintf("FATAL: Session runLoop panicked: %v", r))
-			s.State = Failed
+			s.setState(Failed)
 		}
 
 		// Determine final state if not already Aborted or Failed.
 		currentLockedState := s.State
+		if currentLockedState == Stopping {
+			// Abort was requested (and may have timed out waiting) while a report was in flight.
+			s.setState(Aborted)
+			currentLockedState = Aborted
+		}
 		if currentLockedState != Aborted && currentLockedState != Failed {
 			if s.ReportsAttemptedCount >= s.NumReportsToSend {
-				s.State = Completed
-				s.sendLog(LogLevelUpdateInfo, "Session completed: All reports processed.")
+				s.setState(Completed)
+				banner := "Session completed: All reports processed."
+				if len(s.latencies) > 0 {
+					p50, p90, p99 := s.latencyPercentilesLocked()
+					banner += fmt.Sprintf(" Latency p50: %s, p90: %s, p99: %s.",
+						p50.Round(time.Millisecond), p90.Round(time.Millisecond), p99.Round(time.Millisecond))
+				}
+				s.sendLog(LogLevelUpdateInfo, banner)
 			} else if currentLockedState != Paused { // Not all jobs done, not paused -> implies stopped early.
-				s.State = Stopped
+				s.setState(Stopped)
 				s.sendLog(LogLevelUpdateWarn, "Session stopped before completing all reports.")
 			}
 			// If it was Paused and the loop exited (e.g., control channel closed externally), it remains Paused.
@@ -210,29 +605,83 @@ func (s *Session) runLoop() {
 		if s.EndTime.IsZero() {
 			s.EndTime = time.Now()
 		} // Set end time if not already set (e.g., by Abort).
+		s.logSummary()
+		autoSaveDone, watchdogDone := s.autoSaveDone, s.watchdogDone
 		s.mu.Unlock()
+		if autoSaveDone != nil {
+			close(autoSaveDone) // Triggers the final auto-save with the terminal state.
+		}
+		if watchdogDone != nil {
+			close(watchdogDone)
+		}
 		close(s.LogChannel) // Signal to listeners that no more logs will come from this session.
 	}()
 
-	for { // Loop for each report to be sent.
+	// Reports are sent by a pool of workers pulling jobs from queue. runLoop only hands out a job
+	// once a worker slot is free, so pause, abort and budget or auto-pause stops take effect
+	// before the next job starts. With one worker, reports are sent strictly one at a time.
+	workers := s.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	queue := make(chan *ReportJob)
+	slots := make(chan struct{}, workers) // Holds one token per job handed out and not yet finished.
+	var workerWG sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		workerWG.Add(1)
+		go func() {
+			defer workerWG.Done()
+			for job := range queue {
+				s.runJob(ctx, job, slots, run)
+			}
+		}()
+	}
+	defer func() { // Runs before the cleanup above, so in-flight reports finish before LogChannel closes.
+		close(queue)
+		s.mu.Lock()
+		abandoned := s.abandonedRun == run
+		s.mu.Unlock()
+		if !abandoned { // Workers stuck in a stalled report are left behind; they discard their results.
+			workerWG.Wait()
+		}
+	}()
+
+	// pendingCmd holds a control command received while waiting for a worker or between reports,
+	// handled like any other. delayDue is set once a job is handed out, so the next one waits
+	// DelayBetweenReports first; an interrupted wait is not repeated.
+	var pendingCmd string
+	delayDue := false
+	for { // Loop for each report to be handed out.
 		s.mu.Lock()
-		// Check if all reports have been attempted or if a terminal state was reached.
-		if s.ReportsAttemptedCount >= s.NumReportsToSend || (s.State != Running && s.State != Paused) {
+		// Skip jobs restored as already successful by ResumeSession.
+		for s.dispatched < s.NumReportsToSend && s.Jobs[s.dispatched].Status == "success" {
+			s.dispatched++
+		}
+		// Check if all reports have been handed out or if a terminal state was reached.
+		if s.dispatched >= s.NumReportsToSend || (s.State != Running && s.State != Paused) {
 			s.mu.Unlock()
 			break
 		}
 		currentState := s.State // Capture current state under lock.
-		currentJob := s.Jobs[s.ReportsAttemptedCount]
 		s.mu.Unlock()
 
 		// Handle control commands (Pause, Resume, Abort).
-		select {
-		case cmd := <-s.controlChannel:
+		cmd, hasCmd := pendingCmd, pendingCmd != ""
+		pendingCmd = ""
+		if !hasCmd {
+			select {
+			case cmd = <-s.controlChannel:
+				hasCmd = true
+			default:
+				// No control message, proceed.
+			}
+		}
+		if hasCmd {
 			s.mu.Lock()
 			switch cmd {
 			case "pause":
 				if s.State == Running {
-					s.State = Paused
+					s.setState(Paused)
 					s.sendLog(LogLevelUpdateWarn, "Session paused.")
 				}
 				s.mu.Unlock()
@@ -241,15 +690,18 @@ func (s *Session) runLoop() {
 					s.mu.Lock()
 					if pausedCmd == "resume" {
 						if s.State == Paused {
-							s.State = Running
+							s.setState(Running)
 							s.sendLog(LogLevelUpdateWarn, "Session resumed.")
 						}
 						s.mu.Unlock()
 						break // Exit pause-wait loop, continue outer report loop.
 					} else if pausedCmd == "abort" {
-						s.State = Aborted // Set final state.
+						s.setState(Aborted) // Set final state.
 						s.mu.Unlock()
 						return // Exit runLoop entirely.
+					} else if pausedCmd == "stalled" { // The session failed before the pause took effect.
+						s.mu.Unlock()
+						return
 					}
 					s.sendLog(LogLevelUpdateWarn, fmt.Sprintf("Invalid command '%s' while session is paused.", pausedCmd))
 					s.mu.Unlock()
@@ -257,24 +709,30 @@ func (s *Session) runLoop() {
 				// If controlChannel was closed while paused.
 				s.mu.Lock()
 				if s.State == Paused { // If still paused (e.g., channel closed externally).
-					s.State = Aborted // Treat as an abort.
+					s.setState(Aborted) // Treat as an abort.
 				}
 				s.mu.Unlock()
 				if s.GetStateValue() == Aborted {
 					return
 				} // Exit runLoop if aborted.
 				continue // Re-evaluate main loop condition.
+			case "resume": // Resuming a session that was auto-paused by runLoop itself.
+				if s.State == Paused {
+					s.setState(Running)
+					s.sendLog(LogLevelUpdateWarn, "Session resumed.")
+				}
+				s.mu.Unlock()
 			case "abort":
-				s.State = Aborted // Set final state.
+				s.setState(Aborted) // Set final state.
 				s.mu.Unlock()
 				return // Exit runLoop entirely.
+			case "stalled": // Sent by the stall watchdog after failing the session; the loop ends below.
+				s.mu.Unlock()
 			default: // Unknown command.
 				s.sendLog(LogLevelUpdateWarn, fmt.Sprintf("Unknown control command received: %s", cmd))
 				s.mu.Unlock()
 			}
 			continue // After handling a control command, re-evaluate main loop.
-		default:
-			// No control message, proceed.
 		}
 
 		s.mu.Lock()
@@ -286,32 +744,286 @@ func (s *Session) runLoop() {
 			}
 			continue // Re-evaluate main loop condition (e.g. might be paused or aborted).
 		}
-		s.mu.Unlock() // Unlock before blocking on SendReport.
+		s.mu.Unlock()
+
+		// Wait for a free worker, then space this report out from the previous one. A control
+		// command ends either wait early and is handled at the top of the loop.
+		select {
+		case slots <- struct{}{}:
+		case cmd := <-s.controlChannel:
+			pendingCmd = cmd
+			continue
+		}
+		if delayDue && s.GetStateValue() == Running {
+			delayDue = false
+			if pendingCmd = s.waitBetweenReports(jitteredDelay(s.DelayBetweenReports, s.DelayJitter, rand.Float64())); pendingCmd != "" {
+				<-slots
+				continue
+			}
+		}
 
-		// Process the current report job.
+		s.mu.Lock()
+		if ctx.Err() != nil && s.State == Running { // The caller's context was cancelled: abort.
+			s.sendLog(LogLevelUpdateWarn, "Session context cancelled.")
+			s.setState(Stopping)
+		}
+		// A finished report may have stopped or paused the session while we waited.
+		if s.State != Running || s.dispatched >= s.NumReportsToSend {
+			s.mu.Unlock()
+			<-slots
+			continue
+		}
+		// Mark the job under the lock so Abort can report it if a worker gets stuck.
+		currentJob := s.Jobs[s.dispatched]
+		s.dispatched++
 		currentJob.Status = "processing"
 		currentJob.StartTime = time.Now()
-		s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Report %d/%d to %s -> Sending...", currentJob.ReportNumber, s.NumReportsToSend, s.TargetURL))
+		s.lastProgress = currentJob.StartTime
+		currentJob.proxy = s.nextPinnedProxyLocked()
+		s.mu.Unlock()
+		delayDue = true
+		queue <- currentJob
+	}
+}
 
-		// This is a blocking call. Reporter.SendReport handles its own retries.
-		reportErr := s.Reporter.SendReport(s.TargetURL, s.ID) // Reason is no longer passed.
-		currentJob.EndTime = time.Now()
+// runJob sends one report of run with ctx on a worker goroutine and records its outcome. It
+// releases the job's worker slot when done. A panic while sending fails the session instead of crashing the
+// process. If the stall watchdog has failed run, the job was already recorded as failed and
+// runLoop may have closed LogChannel, so the outcome is discarded.
+func (s *Session) runJob(ctx context.Context, currentJob *ReportJob, slots chan struct{}, run int) {
+	defer func() { <-slots }()
+	defer func() {
+		if r := recover(); r != nil {
+			s.mu.Lock()
+			if s.abandonedRun != run {
+				s.sendLog(LogLevelUpdateError, fmt.Sprintf("FATAL: Session worker panicked: %v", r))
+				s.setState(Failed)
+			}
+			s.mu.Unlock()
+		}
+	}()
+	s.mu.Lock()
+	if s.abandonedRun == run {
+		s.mu.Unlock()
+		return
+	}
+	s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Report %d/%d to %s -> Sending...", currentJob.ReportNumber, s.NumReportsToSend, s.TargetURL))
+	s.mu.Unlock()
 
-		s.mu.Lock()
-		if reportErr != nil {
-			currentJob.Status = "failed"
-			currentJob.Error = reportErr.Error()
-			s.FailedReports++
-			s.sendLog(LogLevelUpdateError, fmt.Sprintf("Report %d/%d to %s -> Failed: %s", currentJob.ReportNumber, s.NumReportsToSend, s.TargetURL, reportErr.Error()))
+	// This is a blocking call. The reporter handles its own retries; the job ID feeds the optional correlation header.
+	ctx = report.WithJobID(ctx, currentJob.ID)
+	if currentJob.proxy != nil {
+		ctx = report.WithProxy(ctx, currentJob.proxy)
+	}
+	ctx = report.WithHeaders(ctx, s.Headers)
+	ctx = report.WithLabel(ctx, s.Label)
+	ctx = report.WithDryRun(ctx, s.DryRun)
+	result, reportErr := s.Reporter.SendReport(ctx, s.TargetURL, s.ID)
+
+	s.mu.Lock()
+	if s.abandonedRun == run {
+		s.mu.Unlock()
+		return
+	}
+	currentJob.EndTime = time.Now()
+	s.lastProgress = currentJob.EndTime
+	if result != nil {
+		currentJob.ResponseStatus = result.StatusCode
+		currentJob.ResponseSnippet = result.ResponseSnippet
+	}
+	if reportErr != nil {
+		currentJob.Status = "failed"
+		currentJob.Error = reportErr.Error()
+		s.FailedReports++
+		if currentJob.proxy != nil && currentJob.proxy == s.pinnedProxy {
+			s.pinnedProxy = nil // Don't keep sending through a proxy that just failed a report.
+		}
+		s.sendLog(LogLevelUpdateError, fmt.Sprintf("Report %d/%d to %s -> Failed: %s", currentJob.ReportNumber, s.NumReportsToSend, s.TargetURL, reportErr.Error()))
+		if errors.Is(reportErr, report.ErrBudgetExhausted) {
+			// No further report can be sent by this process; stop instead of failing every remaining job.
+			if s.State == Running || s.State == Paused {
+				s.setState(Stopped)
+				s.sendLog(LogLevelUpdateWarn, "Report budget exhausted; stopping session.")
+			}
+		} else if s.State == Running && s.shouldAutoPause(reportErr) {
+			// Every proxy is unhealthy, so the remaining reports would fail the same way.
+			s.setState(Paused)
+			s.sendLog(LogLevelUpdateWarn, "All proxies are unhealthy; session auto-paused. Recheck proxies or disable HealthyOnly, then resume.")
+		}
+	} else {
+		currentJob.Status = "success"
+		currentJob.DryRun = s.DryRun
+		s.SuccessfulReports++
+		if result != nil {
+			currentJob.Latency = result.Latency
+			currentJob.LogID = result.LogID
+			s.latencies = append(s.latencies, result.Latency)
+			s.recordAIResultLocked(result.AIResult)
+		}
+		if currentJob.LogID != "" {
+			s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Report %d/%d to %s -> Success (log ID %s).", currentJob.ReportNumber, s.NumReportsToSend, s.TargetURL, currentJob.LogID))
 		} else {
-			currentJob.Status = "success"
-			s.SuccessfulReports++
 			s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("Report %d/%d to %s -> Success.", currentJob.ReportNumber, s.NumReportsToSend, s.TargetURL))
-			// TODO: currentJob.LogID = ... // Reporter.SendReport needs to return this.
 		}
+	}
+	s.ReportsAttemptedCount++
+	completed := *currentJob // Copy under the lock; the callback runs without it.
+	s.mu.Unlock()
+	s.notifyJobComplete(completed)
+}
+
+// nextPinnedProxyLocked returns the proxy for the next job under ReportsPerProxy, selecting a new
+// one from ProxyMgr every ReportsPerProxy jobs. It returns nil, leaving the choice to the
+// reporter, if ReportsPerProxy is not set, there is no ProxyMgr or no proxy is available.
+// Callers must hold s.mu.
+func (s *Session) nextPinnedProxyLocked() *proxy.ProxyInfo {
+	if s.ReportsPerProxy <= 0 || s.ProxyMgr == nil {
+		return nil
+	}
+	if s.pinnedProxy == nil || s.pinnedUses >= s.ReportsPerProxy {
+		p, err := s.ProxyMgr.GetProxy()
+		if err != nil {
+			s.pinnedProxy = nil
+			return nil // The reporter reports the same error for the job.
+		}
+		s.pinnedProxy, s.pinnedUses = p, 0
+	}
+	s.pinnedUses++
+	return s.pinnedProxy
+}
+
+// waitBetweenReports sleeps for delay before the next report is sent. A control command
+// (pause, resume, abort) ends the wait early and is returned so runLoop handles it; otherwise
+// the result is "".
+func (s *Session) waitBetweenReports(delay time.Duration) string {
+	if delay <= 0 {
+		return ""
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case cmd := <-s.controlChannel:
+		return cmd
+	case <-timer.C:
+		return ""
+	}
+}
+
+// jitteredDelay returns base shifted by a random amount within ±jitter, scaled by r in [0, 1)
+// (r = 0.5 gives base exactly). The result is never negative.
+func jitteredDelay(base, jitter time.Duration, r float64) time.Duration {
+	delay := base
+	if jitter > 0 {
+		delay += time.Duration(float64(jitter) * (2*r - 1))
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// stallWatchdog runs checkStall for run periodically until done is closed.
+func (s *Session) stallWatchdog(run int, done <-chan struct{}) {
+	interval := s.StallTimeout / 4
+	if interval > time.Second {
+		interval = time.Second
+	}
+	if interval <= 0 {
+		interval = s.StallTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			s.checkStall(run, now)
+		}
+	}
+}
+
+// checkStall reports a stall of run as of now: the session is running with reports in flight,
+// but none has been handed out or finished for StallTimeout. The stall is logged once, with the
+// in-flight reports. With FailOnStall, they are marked failed, the session moves to Failed and
+// runLoop is woken to end it without waiting for them.
+func (s *Session) checkStall(run int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.run != run || s.State != Running || now.Sub(s.lastProgress) < s.StallTimeout || s.stallWarned.Equal(s.lastProgress) {
+		return
+	}
+	var inFlight []*ReportJob
+	for _, job := range s.Jobs {
+		if job.Status == "processing" {
+			inFlight = append(inFlight, job)
+		}
+	}
+	if len(inFlight) == 0 {
+		return // Waiting between reports is not a stall.
+	}
+	s.stallWarned = s.lastProgress
+	stalledFor := now.Sub(s.lastProgress).Round(time.Millisecond)
+	details := make([]string, len(inFlight))
+	for i, job := range inFlight {
+		details[i] = fmt.Sprintf("report %d/%d started %s ago", job.ReportNumber, s.NumReportsToSend, now.Sub(job.StartTime).Round(time.Millisecond))
+		if job.proxy != nil && job.proxy.URL != nil {
+			details[i] += " via " + job.proxy.URL.Host
+		}
+	}
+	s.sendLog(LogLevelUpdateWarn, fmt.Sprintf("Session stalled: no report has finished or started in %s. In flight: %s.", stalledFor, strings.Join(details, "; ")))
+	if s.Logger != nil {
+		s.Logger.Warn(utils.LogEntry{
+			SessionID: s.ID,
+			Label:     s.Label,
+			Message:   "Session stalled",
+			ReportURL: s.TargetURL,
+			Outcome:   "session_stalled",
+			AdditionalData: map[string]interface{}{
+				"stalled_ms":       stalledFor.Milliseconds(),
+				"stall_timeout_ms": s.StallTimeout.Milliseconds(),
+				"in_flight":        details,
+				"fail_on_stall":    s.FailOnStall,
+			},
+		})
+	}
+	if !s.FailOnStall {
+		return
+	}
+
+	s.abandonedRun = run
+	for _, job := range inFlight {
+		job.Status = "failed"
+		job.Error = fmt.Sprintf("stalled: no response after %s", now.Sub(job.StartTime).Round(time.Millisecond))
+		job.EndTime = now
+		s.FailedReports++
 		s.ReportsAttemptedCount++
-		s.mu.Unlock()
+		s.notifyJobComplete(*job)
 	}
+	s.sendLog(LogLevelUpdateError, fmt.Sprintf("Session failed: %d reports stalled for longer than %s.", len(inFlight), s.StallTimeout))
+	s.setState(Failed)
+	select {
+	case s.controlChannel <- "stalled": // Wake runLoop if it is waiting for a worker.
+	default:
+	}
+}
+
+// notifyJobComplete hands a finished job to OnJobComplete, if set, without blocking runLoop.
+func (s *Session) notifyJobComplete(job ReportJob) {
+	if s.OnJobComplete == nil {
+		return
+	}
+	go s.OnJobComplete(job)
+}
+
+// shouldAutoPause reports whether a failed report should pause the session: auto-pause is
+// enabled in the session's config and the failure was caused by the proxy pool having no
+// healthy proxy left.
+func (s *Session) shouldAutoPause(reportErr error) bool {
+	if s.Config == nil || !s.Config.AutoPauseOnDegraded {
+		return false
+	}
+	return errors.Is(reportErr, proxy.ErrNoHealthyProxies)
 }
 
 // Pause sends a command to the runLoop to pause the session.
@@ -338,28 +1050,39 @@ func (s *Session) Resume() error {
 	return nil
 }
 
-// Abort signals the session to stop processing reports and clean up.
-// It waits for the runLoop goroutine to complete, with a timeout.
+// Abort signals the session to stop processing reports and clean up. Reports in flight are
+// cancelled. It waits for the runLoop goroutine to complete, with a timeout.
 // Returns an error if the session is in a state that cannot be aborted or if timeout occurs.
 func (s *Session) Abort() error {
 	s.mu.Lock()
 	// Check if session is in a state where abort is meaningful or possible.
-	if s.State == Completed || s.State == Aborted || s.State == Failed || s.State == Idle {
+	// Stopped means runLoop has already exited (and closed LogChannel).
+	if s.State == Completed || s.State == Aborted || s.State == Failed || s.State == Idle || s.State == Stopped {
 		s.mu.Unlock()
 		return nil // Nothing to abort or already done.
 	}
 
 	isAlreadyStopping := (s.State == Stopping || s.State == Aborted) // Aborted also implies stopping is done.
-	s.State = Stopping                                               // Indicate intent to stop. runLoop will set final Aborted state.
+	if !isAlreadyStopping {
+		// Log while holding the lock: once the state leaves Running/Paused, runLoop may exit and close LogChannel.
+		s.sendLog(LogLevelUpdateWarn, "Abort signal sent to session.")
+	}
+	s.setState(Stopping) // Indicate intent to stop. runLoop will set final Aborted state.
+	if s.cancelRun != nil {
+		s.cancelRun() // Tear down reports in flight rather than waiting for them.
+	}
 	s.mu.Unlock()
 
 	if !isAlreadyStopping {
-		s.sendLog(LogLevelUpdateWarn, "Abort signal sent to session.")
 		s.controlChannel <- "abort"
 	}
 
 	// Wait for runLoop goroutine to finish, with a timeout.
-	waitTimeout := time.NewTimer(10 * time.Second)
+	timeout := s.AbortTimeout
+	if timeout <= 0 {
+		timeout = DefaultAbortTimeout
+	}
+	waitTimeout := time.NewTimer(timeout)
 	defer waitTimeout.Stop()
 	done := make(chan struct{})
 	go func() {
@@ -370,19 +1093,202 @@ func (s *Session) Abort() error {
 	select {
 	case <-done: // runLoop completed.
 		s.mu.Lock()
-		s.State = Aborted // Ensure final state is Aborted.
+		s.setState(Aborted) // Ensure final state is Aborted.
 		if s.EndTime.IsZero() {
 			s.EndTime = time.Now()
 		}
 		s.mu.Unlock()
 	case <-waitTimeout.C: // Timeout waiting for runLoop.
-		s.sendLog(LogLevelUpdateError, "Timeout waiting for session to abort; runLoop may be stuck.")
+		s.mu.Lock()
+		lastJob := s.lastJobStatusLocked()
+		s.sendLog(LogLevelUpdateError, fmt.Sprintf("Timeout after %s waiting for session to abort; runLoop may be stuck. Last job: %s", timeout, lastJob))
+		if s.Logger != nil {
+			s.Logger.Error(utils.LogEntry{
+				SessionID: s.ID,
+				Label:     s.Label,
+				Message:   "Timeout waiting for session to abort",
+				ReportURL: s.TargetURL,
+				Outcome:   "abort_timeout",
+				AdditionalData: map[string]interface{}{
+					"timeout_ms": timeout.Milliseconds(),
+					"last_job":   lastJob,
+				},
+			})
+		}
+		s.mu.Unlock()
 		// State remains Stopping if runLoop is stuck.
-		return fmt.Errorf("timeout waiting for session to abort")
+		return fmt.Errorf("%w after %s", ErrAbortTimeout, timeout)
 	}
 	return nil
 }
 
+// lastJobStatusLocked describes the most recent job that began processing, to help diagnose
+// a runLoop that does not respond to abort. Callers must hold s.mu.
+func (s *Session) lastJobStatusLocked() string {
+	for i := len(s.Jobs) - 1; i >= 0; i-- {
+		job := s.Jobs[i]
+		if job.StartTime.IsZero() {
+			continue
+		}
+		desc := fmt.Sprintf("report %d/%d %s, started %s ago", job.ReportNumber, s.NumReportsToSend, job.Status, time.Since(job.StartTime).Round(time.Millisecond))
+		if job.Error != "" {
+			desc += " (error: " + job.Error + ")"
+		}
+		return desc
+	}
+	return "none started"
+}
+
+// Wait blocks until the session's runLoop goroutine exits or ctx is done, so headless callers
+// do not need to poll GetStats. It returns ctx.Err() if the context is done first,
+// ErrSessionAborted or ErrSessionFailed if the session ended in that state, and nil otherwise.
+// Calling Wait on a session that was never started returns immediately.
+func (s *Session) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	switch state := s.GetStateValue(); state {
+	case Aborted:
+		return fmt.Errorf("%w: %s", ErrSessionAborted, s.ID)
+	case Failed:
+		return fmt.Errorf("%w: %s", ErrSessionFailed, s.ID)
+	default:
+		return nil
+	}
+}
+
+// LatencyPercentiles returns the 50th, 90th and 99th percentile latencies of the session's
+// successful reports, using the nearest-rank method. All values are zero if no report has succeeded.
+func (s *Session) LatencyPercentiles() (p50, p90, p99 time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latencyPercentilesLocked()
+}
+
+// latencyPercentilesLocked computes LatencyPercentiles. Callers must hold s.mu.
+func (s *Session) latencyPercentilesLocked() (p50, p90, p99 time.Duration) {
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99)
+}
+
+// percentile returns the nearest-rank p-th percentile (0-100) of sorted, or 0 if it is empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// logSummary writes a structured end-of-session summary (final state, counts, duration and
+// latency percentiles) to the file logger. Callers must hold s.mu.
+func (s *Session) logSummary() {
+	if s.Logger == nil {
+		return
+	}
+	p50, p90, p99 := s.latencyPercentilesLocked()
+	data := map[string]interface{}{
+		"state":          s.State.String(),
+		"total":          s.NumReportsToSend,
+		"attempted":      s.ReportsAttemptedCount,
+		"successful":     s.SuccessfulReports,
+		"failed":         s.FailedReports,
+		"duration_ms":    s.EndTime.Sub(s.StartTime).Milliseconds(),
+		"latency_p50_ms": p50.Milliseconds(),
+		"latency_p90_ms": p90.Milliseconds(),
+		"latency_p99_ms": p99.Milliseconds(),
+		"dropped_logs":   s.DroppedLogs(),
+	}
+	if s.DryRun {
+		data["dry_run"] = true
+	}
+	if len(s.aiStats) > 0 {
+		data["ai_categories"] = s.aiSummaryLocked()
+	}
+	if s.ProxyMgr != nil && s.ProxyMgr.AuditSelections {
+		// Keyed by proxy URL; log them under their identifiers so credentials stay out of the log.
+		mode := ""
+		if s.Config != nil {
+			mode = s.Config.ProxyLogIdentifier
+		}
+		selections := make(map[string]int)
+		for proxyURL, count := range s.ProxyMgr.SelectionCounts() {
+			selections[proxy.LogIdentifier(proxyURL, mode)] += count
+		}
+		data["proxy_selections"] = selections
+	}
+	logIDs := make(map[string]string) // Job ID -> platform log ID, for correlating reports upstream.
+	for _, job := range s.Jobs {
+		if job.LogID != "" {
+			logIDs[job.ID] = job.LogID
+		}
+	}
+	if len(logIDs) > 0 {
+		data["log_ids"] = logIDs
+	}
+	s.Logger.Info(utils.LogEntry{
+		SessionID:      s.ID,
+		Label:          s.Label,
+		Message:        "Session summary",
+		ReportURL:      s.TargetURL,
+		Outcome:        "session_summary",
+		AdditionalData: data,
+	})
+}
+
+// AISummary returns the AI analysis results of the session's successful reports rolled up by
+// category. It is empty if no report was analyzed. The returned map is a copy (thread-safe).
+func (s *Session) AISummary() map[string]AICategoryStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aiSummaryLocked()
+}
+
+// aiSummaryLocked copies aiStats. Callers must hold s.mu.
+func (s *Session) aiSummaryLocked() map[string]AICategoryStat {
+	summary := make(map[string]AICategoryStat, len(s.aiStats))
+	for category, stat := range s.aiStats {
+		summary[category] = stat
+	}
+	return summary
+}
+
+// recordAIResultLocked adds one analysis result to the per-category rollup. Results without a
+// category are counted under "Uncategorized". Callers must hold s.mu.
+func (s *Session) recordAIResultLocked(result *ai.AnalysisResult) {
+	if result == nil {
+		return
+	}
+	category := result.Category
+	if category == "" {
+		category = "Uncategorized"
+	}
+	if s.aiStats == nil {
+		s.aiStats = make(map[string]AICategoryStat)
+	}
+	stat := s.aiStats[category]
+	stat.Count++
+	if stat.Count == 1 || result.ThreatScore > stat.MaxThreatScore {
+		stat.MaxThreatScore = result.ThreatScore
+	}
+	stat.AvgThreatScore += (result.ThreatScore - stat.AvgThreatScore) / float64(stat.Count) // Running mean.
+	s.aiStats[category] = stat
+}
+
 // GetStateValue returns the current operational state of the session (thread-safe).
 func (s *Session) GetStateValue() SessionState {
 	s.mu.Lock()
@@ -416,6 +1322,64 @@ func (s *Session) GetStats() (currentState SessionState, targetURL string, numTo
 	return s.State, s.TargetURL, s.NumReportsToSend, s.ReportsAttemptedCount, s.SuccessfulReports, s.FailedReports
 }
 
+// SessionProgress is a snapshot of a session's progress, as returned by Progress.
+type SessionProgress struct {
+	State       SessionState  // Current operational state.
+	Target      string        // The URL targeted by the session.
+	Label       string        // The session's Label, if any.
+	DryRun      bool          // Whether the session only rehearses its reports (see Session.DryRun).
+	Total       int           // Total number of reports to send.
+	Attempted   int           // Reports that have finished processing, successfully or not.
+	Successful  int           // Reports sent successfully.
+	Failed      int           // Reports that failed.
+	Percent     float64       // Finished (successful or failed) reports as a percentage of Total, 0-100.
+	ETA         time.Duration // Estimated time until the remaining reports finish; zero if unknown or the session is not running.
+	Throughput  float64       // Finished reports per second since the session started.
+	DroppedLogs int           // Log updates dropped because LogChannel was full (see Session.LogPolicy).
+}
+
+// Progress returns a snapshot of the session's state, counts and derived rates (thread-safe).
+// Unlike GetStats, it can be extended without changing every caller.
+func (s *Session) Progress() SessionProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progressLocked(time.Now())
+}
+
+// progressLocked computes Progress as of now. ETA extrapolates the throughput so far to the
+// remaining reports. Callers must hold s.mu.
+func (s *Session) progressLocked(now time.Time) SessionProgress {
+	p := SessionProgress{
+		State:       s.State,
+		Target:      s.TargetURL,
+		Label:       s.Label,
+		DryRun:      s.DryRun,
+		Total:       s.NumReportsToSend,
+		Attempted:   s.ReportsAttemptedCount,
+		Successful:  s.SuccessfulReports,
+		Failed:      s.FailedReports,
+		DroppedLogs: s.DroppedLogs(),
+	}
+	finished := s.SuccessfulReports + s.FailedReports
+	if s.NumReportsToSend > 0 {
+		p.Percent = float64(finished) / float64(s.NumReportsToSend) * 100
+	}
+	if s.StartTime.IsZero() {
+		return p
+	}
+	elapsed := now.Sub(s.StartTime)
+	if !s.EndTime.IsZero() && s.State != Running && s.State != Paused {
+		elapsed = s.EndTime.Sub(s.StartTime)
+	}
+	if elapsed > 0 && finished > 0 {
+		p.Throughput = float64(finished) / elapsed.Seconds()
+		if remaining := s.NumReportsToSend - finished; remaining > 0 && (s.State == Running || s.State == Paused) {
+			p.ETA = time.Duration(float64(remaining) / p.Throughput * float64(time.Second))
+		}
+	}
+	return p
+}
+
 // EnsureLogChannelClosed can be called by the TUI if it needs to signal it's done with this session object
 // particularly if the session was never started. The primary mechanism for channel closure is the
 // defer function in runLoop.
@@ -0,0 +1,1073 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/ai"
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/report"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// newTestReporter returns a Reporter whose proxy and target are both served by an httptest server.
+func newTestReporter(t *testing.T, cfg *config.AppConfig, handler http.HandlerFunc) (*report.Reporter, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	proxyURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	pm := proxy.NewProxyManager([]*proxy.ProxyInfo{{URL: proxyURL, HealthStatus: "healthy"}}, proxy.StrategyRoundRobin, false)
+	return report.NewReporter(cfg, pm, utils.NewLogger(io.Discard, "INFO"), nil), server.URL + "/report"
+}
+
+// drainLogs consumes the session's LogChannel until runLoop closes it and returns the messages.
+func drainLogs(s *Session) []LogUpdate {
+	var updates []LogUpdate
+	for u := range s.LogChannel {
+		updates = append(updates, u)
+	}
+	return updates
+}
+
+func TestSession_StopsWhenBudgetExhausted(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, MaxTotalRequests: 3}
+	reporter, target := newTestReporter(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 10)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	state, _, _, attempted, successful, failed := s.GetStats()
+	assert.Equal(t, Stopped, state)
+	assert.Equal(t, 3, successful, "reports within the budget should succeed")
+	assert.Equal(t, 1, failed, "the first report over budget fails")
+	assert.Equal(t, 4, attempted, "the session must not keep attempting reports after the budget is exhausted")
+}
+
+func TestSession_StateTransitionsAreLogged(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 2)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	var transitions []utils.LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry.Outcome == "state_transition" {
+			transitions = append(transitions, entry)
+		}
+	}
+	require.Len(t, transitions, 2, "expected Idle->Running and Running->Completed")
+
+	start := transitions[0]
+	assert.Equal(t, s.ID, start.SessionID)
+	assert.Equal(t, "Idle", start.AdditionalData["old_state"])
+	assert.Equal(t, "Running", start.AdditionalData["new_state"])
+	assert.Equal(t, float64(0), start.AdditionalData["attempted"])
+
+	end := transitions[1]
+	assert.Equal(t, "Running", end.AdditionalData["old_state"])
+	assert.Equal(t, "Completed", end.AdditionalData["new_state"])
+	assert.Equal(t, float64(2), end.AdditionalData["total"])
+	assert.Equal(t, float64(2), end.AdditionalData["attempted"])
+	assert.Equal(t, float64(2), end.AdditionalData["successful"])
+	assert.Equal(t, float64(0), end.AdditionalData["failed"])
+}
+
+func TestSession_WaitReturnsAfterCompletion(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 3)
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Wait(ctx))
+
+	state, _, _, attempted, successful, _ := s.GetStats()
+	assert.Equal(t, Completed, state)
+	assert.Equal(t, 3, attempted)
+	assert.Equal(t, 3, successful)
+}
+
+func TestSession_WaitReturnsAbortedError(t *testing.T) {
+	release := make(chan struct{})
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 5)
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	// Pause first so runLoop is parked waiting for a control command when Abort is sent.
+	require.NoError(t, s.Pause())
+	close(release)
+	require.Eventually(t, func() bool { return s.GetStateValue() == Paused }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, s.Abort())
+
+	err := s.Wait(context.Background())
+	assert.ErrorIs(t, err, ErrSessionAborted)
+}
+
+func TestSession_WaitRespectsContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 1)
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := s.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second, "Wait should return promptly once the context is done")
+
+	close(release)
+	require.NoError(t, s.Wait(context.Background()), "Wait should succeed once the session completes")
+}
+
+func TestSession_LatencyPercentiles(t *testing.T) {
+	s := NewSession(nil, "http://example.com", 1)
+
+	p50, p90, p99 := s.LatencyPercentiles()
+	assert.Zero(t, p50, "no successful reports means no latency data")
+	assert.Zero(t, p90)
+	assert.Zero(t, p99)
+
+	// 1ms..100ms in shuffled order; nearest-rank percentiles are exact on this set.
+	for i := 100; i >= 1; i-- {
+		s.latencies = append(s.latencies, time.Duration(i)*time.Millisecond)
+	}
+	p50, p90, p99 = s.LatencyPercentiles()
+	assert.Equal(t, 50*time.Millisecond, p50)
+	assert.Equal(t, 90*time.Millisecond, p90)
+	assert.Equal(t, 99*time.Millisecond, p99)
+	assert.Equal(t, 100*time.Millisecond, s.latencies[0], "computing percentiles must not reorder the recorded latencies")
+
+	s.latencies = []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}
+	p50, p90, p99 = s.LatencyPercentiles()
+	assert.Equal(t, 20*time.Millisecond, p50)
+	assert.Equal(t, 30*time.Millisecond, p90)
+	assert.Equal(t, 30*time.Millisecond, p99)
+}
+
+func TestSession_SummaryIncludesLatencies(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 3)
+	require.NoError(t, s.Start())
+	updates := drainLogs(s)
+
+	p50, _, p99 := s.LatencyPercentiles()
+	assert.GreaterOrEqual(t, p50, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, p99, p50)
+	for _, job := range s.Jobs {
+		assert.Greater(t, job.Latency, time.Duration(0), "each successful job should record its latency")
+	}
+
+	var banner string
+	for _, u := range updates {
+		if strings.HasPrefix(u.Message, "Session completed") {
+			banner = u.Message
+		}
+	}
+	assert.Contains(t, banner, "Latency p50:")
+	assert.Contains(t, banner, "p99:")
+
+	var summary *utils.LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry.Outcome == "session_summary" {
+			summary = &entry
+		}
+	}
+	require.NotNil(t, summary, "a session summary entry should be logged")
+	assert.Equal(t, "Completed", summary.AdditionalData["state"])
+	assert.EqualValues(t, 3, summary.AdditionalData["successful"])
+	assert.EqualValues(t, p50.Milliseconds(), summary.AdditionalData["latency_p50_ms"])
+	assert.Contains(t, summary.AdditionalData, "latency_p90_ms")
+	assert.Contains(t, summary.AdditionalData, "latency_p99_ms")
+}
+
+func TestSession_LabelInLogsAndSummary(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 2)
+	s.Label = "evening-batch"
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	assert.Equal(t, "evening-batch", s.Progress().Label)
+
+	outcomes := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "evening-batch", entry.Label, "entry %q", entry.Message)
+		outcomes[entry.Outcome] = true
+	}
+	assert.True(t, outcomes["state_transition"])
+	assert.True(t, outcomes["accepted"], "the reporter's attempt entries carry the label")
+	assert.True(t, outcomes["session_summary"])
+}
+
+func TestSession_DryRun(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1, DryRun: true}, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	})
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 3)
+	require.True(t, s.DryRun, "the session defaults to the config's DryRun")
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	p := s.Progress()
+	assert.Equal(t, Completed, p.State)
+	assert.Equal(t, 3, p.Successful)
+	assert.True(t, p.DryRun)
+	dryRuns := 0
+	var summary *utils.LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		switch entry.Outcome {
+		case "dry_run":
+			dryRuns++
+		case "session_summary":
+			summary = &entry
+		}
+	}
+	assert.Equal(t, 3, dryRuns)
+	require.NotNil(t, summary)
+	assert.Equal(t, true, summary.AdditionalData["dry_run"])
+
+	// A session can opt out of the config's dry run.
+	hits := 0
+	reporter, target = newTestReporter(t, &config.AppConfig{MaxRetries: 1, DryRun: true}, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	})
+	s = NewSession(reporter, target, 1)
+	s.DryRun = false
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	assert.Equal(t, 1, hits)
+}
+
+func TestSession_ShouldAutoPause(t *testing.T) {
+	wrapped := fmt.Errorf("failed to get proxy: %w", proxy.ErrNoHealthyProxies)
+	tests := []struct {
+		name    string
+		enabled bool
+		err     error
+		want    bool
+	}{
+		{"enabled and no healthy proxies", true, wrapped, true},
+		{"disabled", false, wrapped, false},
+		{"other error", true, errors.New("status code 500"), false},
+		{"empty pool", true, proxy.ErrNoProxiesAvailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.AppConfig{MaxRetries: 1, AutoPauseOnDegraded: tt.enabled}
+			s := NewSession(report.NewReporter(cfg, nil, nil, nil), "http://example.com", 1)
+			assert.Equal(t, tt.want, s.shouldAutoPause(tt.err))
+		})
+	}
+}
+
+func TestSession_AutoPausesWhenAllProxiesUnhealthy(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1, AutoPauseOnDegraded: true}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	pm := reporter.ProxyMgr
+	pm.HealthyOnly = true
+	proxyURL := pm.GetAllProxies()[0].URL.String()
+	require.NoError(t, pm.UpdateProxyStatus(proxyURL, "unhealthy", 0))
+
+	s := NewSession(reporter, target, 5)
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool { return s.GetStateValue() == Paused }, 5*time.Second, 10*time.Millisecond)
+	_, _, _, attempted, _, failed := s.GetStats()
+	assert.Equal(t, 1, attempted, "the session should pause after the first failure instead of failing every report")
+	assert.Equal(t, 1, failed)
+
+	// Once proxies recover the session can be resumed and completes.
+	require.NoError(t, pm.UpdateProxyStatus(proxyURL, "healthy", 0))
+	require.NoError(t, s.Resume())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Wait(ctx))
+
+	state, _, _, attempted, successful, _ := s.GetStats()
+	assert.Equal(t, Completed, state)
+	assert.Equal(t, 5, attempted)
+	assert.Equal(t, 4, successful)
+}
+
+func TestNewSession_AbortTimeout(t *testing.T) {
+	s := NewSession(report.NewReporter(&config.AppConfig{MaxRetries: 1}, nil, nil, nil), "http://example.com", 1)
+	assert.Equal(t, DefaultAbortTimeout, s.AbortTimeout)
+
+	cfg := &config.AppConfig{MaxRetries: 1, AbortTimeoutSeconds: 0.5}
+	s = NewSession(report.NewReporter(cfg, nil, nil, nil), "http://example.com", 1)
+	assert.Equal(t, 500*time.Millisecond, s.AbortTimeout)
+}
+
+func TestSession_AbortShortTimeoutReportsLastJob(t *testing.T) {
+	// The reporter ignores its context, so Abort cannot cancel the report in flight.
+	release := make(chan struct{})
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		<-release
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	var buf bytes.Buffer
+
+	s := NewSession(mock, "http://example.com/report", 2)
+	s.Logger = utils.NewLogger(&buf, "INFO")
+	s.AbortTimeout = 50 * time.Millisecond
+	require.NoError(t, s.Start())
+	logs := make(chan []LogUpdate, 1)
+	go func() { logs <- drainLogs(s) }()
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.Jobs[0].Status == "processing"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	err := s.Abort()
+	assert.ErrorIs(t, err, ErrAbortTimeout)
+	assert.Less(t, time.Since(start), 2*time.Second, "Abort should give up after the configured timeout")
+	assert.Equal(t, Stopping, s.GetStateValue(), "a stuck runLoop leaves the session Stopping")
+
+	close(release)
+	assert.ErrorIs(t, s.Wait(context.Background()), ErrSessionAborted)
+
+	var timeoutMsg string
+	for _, u := range <-logs {
+		if strings.HasPrefix(u.Message, "Timeout after") {
+			timeoutMsg = u.Message
+		}
+	}
+	assert.Contains(t, timeoutMsg, "Last job: report 1/2 processing")
+	assert.Contains(t, buf.String(), `"outcome":"abort_timeout"`)
+}
+
+func TestSession_StallWatchdogWarns(t *testing.T) {
+	release := make(chan struct{})
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call == 0 {
+			<-release
+		}
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 2)
+	s.StallTimeout = 50 * time.Millisecond
+	require.NoError(t, s.Start())
+
+	var warning string
+	for u := range s.LogChannel {
+		if strings.HasPrefix(u.Message, "Session stalled") {
+			warning = u.Message
+			break
+		}
+	}
+	assert.Contains(t, warning, "In flight: report 1/2 started")
+	assert.Equal(t, Running, s.GetStateValue(), "without FailOnStall the session keeps waiting")
+
+	close(release)
+	logs := drainLogs(s)
+	waitFor(t, s)
+	assert.Equal(t, Completed, s.GetStateValue())
+	for _, u := range logs {
+		assert.NotContains(t, u.Message, "Session stalled", "a stall is reported once")
+	}
+}
+
+func TestSession_StallWatchdogFailsSession(t *testing.T) {
+	release := make(chan struct{})
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		<-release // Never responds until the test is done.
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	var buf bytes.Buffer
+	s := NewSession(mock, "http://example.com/report", 3)
+	s.Logger = utils.NewLogger(&buf, "INFO")
+	s.Concurrency = 2
+	s.StallTimeout = 50 * time.Millisecond
+	s.FailOnStall = true
+	completed := make(chan ReportJob, 3)
+	s.OnJobComplete = func(job ReportJob) { completed <- job }
+	require.NoError(t, s.Start())
+	logs := make(chan []LogUpdate, 1)
+	go func() { logs <- drainLogs(s) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, s.Wait(ctx), ErrSessionFailed, "the session ends without waiting for the hung reports")
+	close(release) // The late results are discarded.
+	time.Sleep(20 * time.Millisecond)
+
+	_, _, _, attempted, successful, failed := s.GetStats()
+	assert.Equal(t, 2, attempted)
+	assert.Equal(t, 0, successful)
+	assert.Equal(t, 2, failed)
+	s.mu.Lock()
+	for _, job := range s.Jobs[:2] {
+		assert.Equal(t, "failed", job.Status)
+		assert.Contains(t, job.Error, "stalled: no response after")
+	}
+	assert.Equal(t, "pending", s.Jobs[2].Status, "no further report is sent")
+	s.mu.Unlock()
+	assert.Equal(t, 2, mock.calls())
+	for i := 0; i < 2; i++ {
+		select {
+		case job := <-completed:
+			assert.Equal(t, "failed", job.Status)
+		case <-time.After(5 * time.Second):
+			t.Fatal("OnJobComplete was not called for a stalled report")
+		}
+	}
+
+	var messages []string
+	for _, u := range <-logs {
+		messages = append(messages, u.Message)
+	}
+	assert.Contains(t, strings.Join(messages, "\n"), "Session failed: 2 reports stalled")
+	assert.Contains(t, buf.String(), `"outcome":"session_stalled"`)
+	assert.Contains(t, buf.String(), `"fail_on_stall":true`)
+}
+
+func TestSession_AbortLongTimeoutWaitsForRunLoop(t *testing.T) {
+	release := make(chan struct{})
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 2)
+	s.AbortTimeout = 5 * time.Second
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.Jobs[0].Status == "processing"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The in-flight report finishes well within the timeout, so Abort succeeds.
+	time.AfterFunc(100*time.Millisecond, func() { close(release) })
+	require.NoError(t, s.Abort())
+	assert.Equal(t, Aborted, s.GetStateValue())
+}
+
+func TestSession_AbortCancelsInFlightReport(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 2)
+	s.AbortTimeout = 5 * time.Second
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.Jobs[0].Status == "processing"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, s.Abort())
+	assert.Less(t, time.Since(start), 2*time.Second, "Abort cancels the report instead of waiting for it")
+	assert.Equal(t, Aborted, s.GetStateValue())
+	assert.Equal(t, "failed", s.Jobs[0].Status)
+	assert.Contains(t, s.Jobs[0].Error, context.Canceled.Error())
+}
+
+// ctxReporter is a Reporter whose reports only end when their context is cancelled. Each call
+// sends on started first.
+type ctxReporter struct{ started chan struct{} }
+
+func (r ctxReporter) SendReport(ctx context.Context, targetURL, sessionID string) (*report.ReportResult, error) {
+	r.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSession_StartContextCancelAborts(t *testing.T) {
+	reporter := ctxReporter{started: make(chan struct{}, 3)}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSession(reporter, "http://example.com/report", 3)
+	require.NoError(t, s.StartContext(ctx))
+	go drainLogs(s)
+	<-reporter.started
+
+	cancel()
+	assert.ErrorIs(t, s.Wait(context.Background()), ErrSessionAborted)
+	assert.Equal(t, Aborted, s.GetStateValue())
+	assert.Equal(t, 1, s.Progress().Failed, "only the report in flight was sent")
+}
+
+func TestSession_OnJobCompleteFiresPerJob(t *testing.T) {
+	hits := 0
+	var hitsMu sync.Mutex
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		hitsMu.Lock()
+		defer hitsMu.Unlock()
+		hits++
+		if hits == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 3)
+	completed := make(chan ReportJob, 3)
+	s.OnJobComplete = func(job ReportJob) { completed <- job }
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	byNumber := make(map[int]ReportJob)
+	for i := 0; i < 3; i++ {
+		select {
+		case job := <-completed:
+			_, dup := byNumber[job.ReportNumber]
+			assert.False(t, dup, "report %d completed more than once", job.ReportNumber)
+			byNumber[job.ReportNumber] = job
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of 3 job callbacks fired", i)
+		}
+	}
+	select {
+	case job := <-completed:
+		t.Fatalf("unexpected extra callback for report %d", job.ReportNumber)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, "success", byNumber[1].Status)
+	assert.Equal(t, "failed", byNumber[2].Status)
+	assert.Contains(t, byNumber[2].Error, "status 500")
+	assert.Equal(t, "success", byNumber[3].Status)
+	for _, job := range byNumber {
+		assert.False(t, job.EndTime.IsZero(), "the callback receives the finished job")
+	}
+}
+
+func TestSession_OnJobCompleteDoesNotBlockSession(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	block := make(chan struct{})
+	defer close(block)
+	s := NewSession(reporter, target, 2)
+	s.OnJobComplete = func(ReportJob) { <-block }
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Wait(ctx), "a stalled callback must not stall the session")
+}
+
+func TestSession_OnStateChangeReportsTransitionsInOrder(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		return &report.ReportResult{StatusCode: 200}, nil
+	}}
+	s := NewSession(mock, "http://example.com/report", 2)
+	var transitions []string
+	var final SessionProgress
+	s.OnStateChange = func(from SessionState, progress SessionProgress) {
+		transitions = append(transitions, from.String()+"->"+progress.State.String())
+		final = progress
+	}
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	require.NoError(t, s.Wait(context.Background()))
+
+	assert.Equal(t, []string{"Idle->Running", "Running->Completed"}, transitions)
+	assert.Equal(t, 2, final.Attempted, "the snapshot is taken after the last report")
+	assert.Equal(t, 2, final.Successful)
+}
+
+func TestSession_SummaryIncludesProxySelections(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	reporter.ProxyMgr.AuditSelections = true
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 3)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	var selections map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry.Outcome == "session_summary" {
+			selections, _ = entry.AdditionalData["proxy_selections"].(map[string]interface{})
+		}
+	}
+	require.Len(t, selections, 1)
+	proxyURL := reporter.ProxyMgr.GetAllProxies()[0].URL.String()
+	assert.EqualValues(t, 3, selections[proxyURL])
+}
+
+func TestSession_RecordsLogID(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			w.Header().Set(report.LogIDHeader, "20240101120000ABCDEF")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 2)
+	require.NoError(t, s.Start())
+	updates := drainLogs(s)
+	require.NoError(t, s.Wait(context.Background()))
+
+	assert.Equal(t, "20240101120000ABCDEF", s.Jobs[0].LogID)
+	assert.Empty(t, s.Jobs[1].LogID, "no header, no log ID")
+	var messages []string
+	for _, u := range updates {
+		messages = append(messages, u.Message)
+	}
+	assert.Contains(t, strings.Join(messages, "\n"), "Success (log ID 20240101120000ABCDEF).")
+
+	var logIDs map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry.Outcome == "session_summary" {
+			logIDs, _ = entry.AdditionalData["log_ids"].(map[string]interface{})
+		}
+	}
+	assert.Equal(t, map[string]interface{}{s.Jobs[0].ID: "20240101120000ABCDEF"}, logIDs)
+}
+
+func TestSession_RecordsFinalResponse(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("report received"))
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(strings.Repeat("slow down ", 100)))
+	})
+
+	s := NewSession(reporter, target, 2)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	require.NoError(t, s.Wait(context.Background()))
+
+	assert.Equal(t, "success", s.Jobs[0].Status)
+	assert.Equal(t, http.StatusOK, s.Jobs[0].ResponseStatus)
+	assert.Equal(t, "report received", s.Jobs[0].ResponseSnippet)
+
+	assert.Equal(t, "failed", s.Jobs[1].Status)
+	assert.Equal(t, http.StatusTooManyRequests, s.Jobs[1].ResponseStatus, "a failed job keeps the response that failed it")
+	assert.True(t, strings.HasPrefix(s.Jobs[1].ResponseSnippet, "slow down slow down"))
+	assert.Contains(t, s.Jobs[1].ResponseSnippet, "...[truncated", "long bodies are cut")
+	assert.Less(t, len(s.Jobs[1].ResponseSnippet), 300)
+}
+
+func TestSession_NoResponseLeavesResponseFieldsEmpty(t *testing.T) {
+	mock := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		return nil, errors.New("connection refused")
+	}}
+	s := NewSession(mock, "http://example.com/report", 1)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	waitFor(t, s)
+
+	assert.Equal(t, "failed", s.Jobs[0].Status)
+	assert.Zero(t, s.Jobs[0].ResponseStatus)
+	assert.Empty(t, s.Jobs[0].ResponseSnippet)
+}
+
+func TestSession_AISummaryRollup(t *testing.T) {
+	s := NewSession(nil, "http://example.com", 1)
+	assert.Empty(t, s.AISummary())
+
+	for _, r := range []*ai.AnalysisResult{
+		{Category: "Misinformation", ThreatScore: 80},
+		{Category: "Misinformation", ThreatScore: 90},
+		{Category: "Misinformation", ThreatScore: 40},
+		{Category: "Benign", ThreatScore: 5},
+		{ThreatScore: 20},
+		nil,
+	} {
+		s.recordAIResultLocked(r)
+	}
+
+	summary := s.AISummary()
+	require.Len(t, summary, 3)
+	assert.Equal(t, 3, summary["Misinformation"].Count)
+	assert.Equal(t, 90.0, summary["Misinformation"].MaxThreatScore)
+	assert.InDelta(t, 70.0, summary["Misinformation"].AvgThreatScore, 1e-9)
+	assert.Equal(t, AICategoryStat{Count: 1, MaxThreatScore: 5, AvgThreatScore: 5}, summary["Benign"])
+	assert.Equal(t, 1, summary["Uncategorized"].Count, "results without a category are still counted")
+
+	summary["Benign"] = AICategoryStat{}
+	assert.Equal(t, 1, s.AISummary()["Benign"].Count, "the returned map must be a copy")
+}
+
+// sequenceAnalyzer returns the given results in order, one per Analyze call.
+type sequenceAnalyzer struct {
+	mu      sync.Mutex
+	results []*ai.AnalysisResult
+}
+
+func (a *sequenceAnalyzer) Analyze(sessionID, postID, contentText string) (*ai.AnalysisResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := a.results[0]
+	a.results = a.results[1:]
+	return result, nil
+}
+
+func TestSession_AISummaryFromReports(t *testing.T) {
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1, RiskThreshold: 75}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	reporter.AIAnalyzer = &sequenceAnalyzer{results: []*ai.AnalysisResult{
+		{Category: "Incitement", ThreatScore: 95},
+		{Category: "Benign", ThreatScore: 10},
+		{Category: "Incitement", ThreatScore: 85},
+	}}
+	var buf bytes.Buffer
+	reporter.Logger = utils.NewLogger(&buf, "INFO")
+
+	s := NewSession(reporter, target, 3)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	summary := s.AISummary()
+	assert.Equal(t, AICategoryStat{Count: 2, MaxThreatScore: 95, AvgThreatScore: 90}, summary["Incitement"])
+	assert.Equal(t, 1, summary["Benign"].Count)
+
+	var categories map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry utils.LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry.Outcome == "session_summary" {
+			categories, _ = entry.AdditionalData["ai_categories"].(map[string]interface{})
+		}
+	}
+	require.Contains(t, categories, "Incitement", "the rollup should be part of the session summary entry")
+	assert.EqualValues(t, 95, categories["Incitement"].(map[string]interface{})["max_threat_score"])
+}
+
+func TestSession_CorrelationHeaderCarriesJobID(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	cfg := &config.AppConfig{MaxRetries: 1, CorrelationHeader: "X-Request-ID"}
+	reporter, target := newTestReporter(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 2)
+	require.NoError(t, s.Start())
+	drainLogs(s)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{s.Jobs[0].ID + "-1", s.Jobs[1].ID + "-1"}, ids)
+}
+
+func TestSession_HeadersOverrideDefaults(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string][]string{}
+	cfg := &config.AppConfig{MaxRetries: 1, DefaultHeaders: map[string]string{"Referer": "https://default.example/", "Origin": "https://default.example"}}
+	reporter, target := newTestReporter(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got[r.URL.Query().Get("campaign")] = []string{r.Header.Get("Referer"), r.Header.Get("Origin")}
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Two concurrent sessions with their own Referer; Origin falls back to the default.
+	a := NewSession(reporter, target+"?campaign=a", 1, map[string]string{"Referer": "https://a.example/"})
+	b := NewSession(reporter, target+"?campaign=b", 1, map[string]string{"Referer": "https://b.example/"}, map[string]string{"Origin": "https://b.example"})
+	require.NoError(t, a.Start())
+	require.NoError(t, b.Start())
+	drainLogs(a)
+	drainLogs(b)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"https://a.example/", "https://default.example"}, got["a"])
+	assert.Equal(t, []string{"https://b.example/", "https://b.example"}, got["b"], "later header maps are merged over earlier ones")
+}
+
+func TestSession_Progress(t *testing.T) {
+	s := NewSession(nil, "http://example.com/report", 10)
+
+	p := s.Progress()
+	assert.Equal(t, Idle, p.State)
+	assert.Equal(t, "http://example.com/report", p.Target)
+	assert.Equal(t, 10, p.Total)
+	assert.Zero(t, p.Percent)
+	assert.Zero(t, p.ETA, "no ETA before the session starts")
+	assert.Zero(t, p.Throughput)
+
+	// 4 of 10 reports finished in 10s: 0.4 reports/s leaves 15s for the remaining 6.
+	now := time.Now()
+	s.State = Running
+	s.StartTime = now.Add(-10 * time.Second)
+	s.ReportsAttemptedCount = 5
+	s.SuccessfulReports = 3
+	s.FailedReports = 1
+	p = s.progressLocked(now)
+	assert.Equal(t, 5, p.Attempted)
+	assert.InDelta(t, 40.0, p.Percent, 1e-9)
+	assert.InDelta(t, 0.4, p.Throughput, 1e-9)
+	assert.Equal(t, 15*time.Second, p.ETA)
+
+	// A finished session has no ETA, and its throughput is measured up to EndTime.
+	s.State = Completed
+	s.SuccessfulReports, s.FailedReports = 8, 2
+	s.EndTime = s.StartTime.Add(20 * time.Second)
+	p = s.progressLocked(now.Add(time.Hour))
+	assert.InDelta(t, 100.0, p.Percent, 1e-9)
+	assert.InDelta(t, 0.5, p.Throughput, 1e-9)
+	assert.Zero(t, p.ETA)
+}
+
+func TestSession_StartRefusedByKillSwitch(t *testing.T) {
+	switchFile := filepath.Join(t.TempDir(), "disabled")
+	cfg := &config.AppConfig{MaxRetries: 1, KillSwitchFile: switchFile}
+	hits := 0
+	reporter, target := newTestReporter(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Setenv(config.KillSwitchEnvVar, "1")
+	s := NewSession(reporter, target, 1)
+	assert.ErrorIs(t, s.Start(), config.ErrDisabled)
+	assert.Equal(t, Idle, s.GetStateValue())
+
+	t.Setenv(config.KillSwitchEnvVar, "")
+	require.NoError(t, os.WriteFile(switchFile, nil, 0600))
+	assert.ErrorIs(t, s.Start(), config.ErrDisabled)
+	assert.Equal(t, Idle, s.GetStateValue())
+
+	// Clearing the switch lets the same session start.
+	require.NoError(t, os.Remove(switchFile))
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	assert.Equal(t, 1, hits, "no report is sent while the switch is active")
+}
+
+func TestSession_ConcurrentRealReporter(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, ReportConcurrency: 4}
+	reporter, target := newTestReporter(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 20)
+	require.Equal(t, 4, s.Concurrency, "the worker count comes from the config")
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	require.NoError(t, s.Wait(context.Background()))
+
+	p := s.Progress()
+	assert.Equal(t, Completed, p.State)
+	assert.Equal(t, 20, p.Successful)
+}
+
+func TestSession_StartRefusedByTargetPolicy(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, TargetBlocklist: []string{"127.0.0.1"}}
+	hits := 0
+	reporter, target := newTestReporter(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := NewSession(reporter, target, 1)
+	assert.ErrorIs(t, s.Start(), config.ErrTargetNotAllowed)
+	assert.Equal(t, Idle, s.GetStateValue())
+	assert.Zero(t, hits)
+
+	cfg.TargetBlocklist = nil
+	cfg.TargetAllowlist = []string{"127.0.0.1"}
+	require.NoError(t, s.Start())
+	drainLogs(s)
+	assert.Equal(t, 1, hits)
+}
+
+// newLogPolicySession returns an idle session of numReports reports with the given LogPolicy,
+// a LogChannel holding two updates and a file logger writing to the returned buffer.
+func newLogPolicySession(policy string, numReports int) (*Session, *bytes.Buffer) {
+	s := NewSession(&mockReporter{}, "http://example.com/report", numReports)
+	s.LogPolicy = policy
+	s.LogChannel = make(chan LogUpdate, 2)
+	var buf bytes.Buffer
+	s.Logger = utils.NewLogger(&buf, "INFO")
+	return s, &buf
+}
+
+// bufferedMessages drains what is buffered in ch without waiting for more.
+func bufferedMessages(ch chan LogUpdate) []string {
+	var messages []string
+	for {
+		select {
+		case u := <-ch:
+			messages = append(messages, u.Message)
+		default:
+			return messages
+		}
+	}
+}
+
+func TestSendLog_DropOldest(t *testing.T) {
+	s, buf := newLogPolicySession(LogPolicyDropOldest, 1)
+	for i := 1; i <= 5; i++ {
+		s.sendLog(LogLevelUpdateInfo, fmt.Sprintf("update %d", i)) // Nobody reads: never waits.
+	}
+	assert.Equal(t, []string{"update 4", "update 5"}, bufferedMessages(s.LogChannel), "the newest updates are kept")
+	assert.Equal(t, 3, s.DroppedLogs())
+	assert.Equal(t, 3, strings.Count(buf.String(), `"outcome":"session_update_dropped"`), "dropped updates go to the file logger")
+	assert.Contains(t, buf.String(), "update 1")
+}
+
+func TestSendLog_DropNewest(t *testing.T) {
+	s, buf := newLogPolicySession("", 1) // The default.
+	s.sendLog(LogLevelUpdateInfo, "update 1")
+	s.sendLog(LogLevelUpdateInfo, "update 2")
+	start := time.Now()
+	s.sendLog(LogLevelUpdateWarn, "update 3")
+	assert.GreaterOrEqual(t, time.Since(start), logSendTimeout, "waits for the listener before dropping")
+	assert.Equal(t, []string{"update 1", "update 2"}, bufferedMessages(s.LogChannel))
+	assert.Equal(t, 1, s.DroppedLogs())
+	assert.Contains(t, buf.String(), `"level":"WARN"`)
+	assert.Contains(t, buf.String(), "update 3")
+
+	// A slow listener that catches up within the timeout loses nothing.
+	s.sendLog(LogLevelUpdateInfo, "update 4")
+	s.sendLog(LogLevelUpdateInfo, "update 5")
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		<-s.LogChannel
+	}()
+	s.sendLog(LogLevelUpdateInfo, "update 6")
+	assert.Equal(t, []string{"update 5", "update 6"}, bufferedMessages(s.LogChannel))
+	assert.Equal(t, 1, s.DroppedLogs())
+}
+
+func TestSendLog_Block(t *testing.T) {
+	s, _ := newLogPolicySession(LogPolicyBlock, 1)
+	s.sendLog(LogLevelUpdateInfo, "update 1")
+	s.sendLog(LogLevelUpdateInfo, "update 2")
+	sent := make(chan struct{})
+	go func() {
+		s.sendLog(LogLevelUpdateInfo, "update 3")
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("sendLog returned while LogChannel was full")
+	case <-time.After(logSendTimeout + 200*time.Millisecond): // Longer than drop-newest would wait.
+	}
+	assert.Equal(t, "update 1", (<-s.LogChannel).Message) // The slow listener finally reads.
+	<-sent
+	assert.Equal(t, []string{"update 2", "update 3"}, bufferedMessages(s.LogChannel))
+	assert.Zero(t, s.DroppedLogs())
+}
+
+func TestSession_DroppedLogsInProgressAndSummary(t *testing.T) {
+	s, buf := newLogPolicySession(LogPolicyDropOldest, 20)
+	require.NoError(t, s.Start())
+	waitFor(t, s) // Nobody reads LogChannel until the session has ended.
+	updates := drainLogs(s)
+
+	assert.Len(t, updates, 2)
+	p := s.Progress()
+	assert.Equal(t, 20, p.Successful)
+	assert.Greater(t, p.DroppedLogs, 20)
+	assert.Equal(t, s.DroppedLogs(), p.DroppedLogs)
+	assert.Contains(t, buf.String(), `"dropped_logs":`)
+}
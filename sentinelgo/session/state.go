@@ -0,0 +1,162 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sentinelgo/sentinelgo/utils"
+)
+
+// ErrInvalidCheckpoint is returned by ResumeSession when a state file cannot describe a session.
+var ErrInvalidCheckpoint = errors.New("invalid session checkpoint")
+
+// SavedState is the on-disk form of a session's progress, written by SaveState.
+type SavedState struct {
+	SessionID        string      `json:"session_id"`
+	TargetURL        string      `json:"target_url"`
+	State            string      `json:"state"`
+	NumReportsToSend int         `json:"num_reports_to_send"`
+	Attempted        int         `json:"attempted"`
+	Successful       int         `json:"successful"`
+	Failed           int         `json:"failed"`
+	StartTime        time.Time   `json:"start_time"`
+	EndTime          time.Time   `json:"end_time,omitempty"`
+	SavedAt          time.Time   `json:"saved_at"`
+	Jobs             []ReportJob `json:"jobs"`
+
+	Headers map[string]string `json:"headers,omitempty"` // The session's Headers, restored by ResumeSession.
+	Label   string            `json:"label,omitempty"`   // The session's Label, restored by ResumeSession.
+
+	// DryRun records whether the session was a dry run. It is not restored: a resumed session
+	// follows the current config, and Start sends the jobs rehearsed earlier (see ReportJob.DryRun)
+	// unless it is a dry run too.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// snapshotLocked captures the session's progress. Callers must hold s.mu.
+func (s *Session) snapshotLocked() SavedState {
+	jobs := make([]ReportJob, 0, len(s.Jobs))
+	for _, job := range s.Jobs {
+		if job != nil {
+			jobs = append(jobs, *job)
+		}
+	}
+	return SavedState{
+		SessionID:        s.ID,
+		TargetURL:        s.TargetURL,
+		State:            s.State.String(),
+		NumReportsToSend: s.NumReportsToSend,
+		Attempted:        s.ReportsAttemptedCount,
+		Successful:       s.SuccessfulReports,
+		Failed:           s.FailedReports,
+		StartTime:        s.StartTime,
+		EndTime:          s.EndTime,
+		SavedAt:          time.Now().UTC(),
+		Jobs:             jobs,
+		Headers:          s.Headers,
+		Label:            s.Label,
+		DryRun:           s.DryRun,
+	}
+}
+
+// SaveState writes the session's current progress (counts and per-job statuses) to path as JSON.
+// The file is written to a temporary file and renamed into place, so a crash mid-write never
+// leaves a truncated state file behind. It is safe to call while the session is running.
+func (s *Session) SaveState(path string) error {
+	s.mu.Lock()
+	state := s.snapshotLocked()
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file for '%s': %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename has succeeded.
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session state to '%s': %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close session state file '%s': %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move session state into '%s': %w", path, err)
+	}
+	return nil
+}
+
+// ResumeSession rebuilds a session from a state file written by SaveState (e.g. an auto-save
+// checkpoint of a session that crashed or was aborted). The session keeps the checkpoint's ID,
+// target, label, headers and jobs; jobs that had succeeded stay done, and every other job is sent again once the
+// session is started. Jobs that only succeeded in a dry run stay done only if the resumed session
+// is a dry run too. Like NewSession, it takes its Logger, Config and ProxyMgr from reporter.
+func ResumeSession(path string, reporter Reporter) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session checkpoint '%s': %w", path, err)
+	}
+	var state SavedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse '%s': %v", ErrInvalidCheckpoint, path, err)
+	}
+	if state.TargetURL == "" || state.NumReportsToSend <= 0 || len(state.Jobs) != state.NumReportsToSend {
+		return nil, fmt.Errorf("%w: '%s' has target %q, %d reports and %d jobs", ErrInvalidCheckpoint, path, state.TargetURL, state.NumReportsToSend, len(state.Jobs))
+	}
+
+	s := NewSession(reporter, state.TargetURL, state.NumReportsToSend, state.Headers)
+	if state.SessionID != "" {
+		s.ID = state.SessionID
+	}
+	s.Label = state.Label
+	for i, saved := range state.Jobs {
+		job := s.Jobs[i]
+		if saved.ID != "" {
+			job.ID = saved.ID
+		}
+		if saved.Status == "success" {
+			job.Status = "success"
+			job.LogID = saved.LogID
+			job.Latency = saved.Latency
+			job.ResponseStatus = saved.ResponseStatus
+			job.ResponseSnippet = saved.ResponseSnippet
+			job.StartTime = saved.StartTime
+			job.EndTime = saved.EndTime
+			job.DryRun = saved.DryRun
+		}
+	}
+	s.resumed = true
+	return s, nil
+}
+
+// autoSaveLoop saves the session state to AutoSavePath every AutoSaveInterval until done is
+// closed, then saves once more so the file records the final state. Errors are logged to the
+// file logger only, since LogChannel may already be closed.
+func (s *Session) autoSaveLoop(done <-chan struct{}) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.AutoSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.autoSave()
+		case <-done:
+			s.autoSave()
+			return
+		}
+	}
+}
+
+// autoSave writes the state to AutoSavePath, logging any failure.
+func (s *Session) autoSave() {
+	if err := s.SaveState(s.AutoSavePath); err != nil && s.Logger != nil {
+		s.Logger.Error(utils.LogEntry{SessionID: s.ID, Label: s.Label, Message: "Failed to auto-save session state", ReportURL: s.TargetURL, Error: err.Error(), Outcome: "autosave_failed"})
+	}
+}
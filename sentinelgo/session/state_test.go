@@ -0,0 +1,198 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/report"
+)
+
+// readSavedState loads a state file written by SaveState.
+func readSavedState(t *testing.T, path string) (SavedState, error) {
+	t.Helper()
+	var state SavedState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+func TestSession_SaveState(t *testing.T) {
+	s := NewSession(nil, "http://example.com/report", 2)
+	s.Jobs[0].Status = "success"
+	s.SuccessfulReports = 1
+	s.ReportsAttemptedCount = 1
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, s.SaveState(path))
+
+	state, err := readSavedState(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, s.ID, state.SessionID)
+	assert.Equal(t, "Idle", state.State)
+	assert.Equal(t, 1, state.Successful)
+	require.Len(t, state.Jobs, 2)
+	assert.Equal(t, "success", state.Jobs[0].Status)
+	assert.Equal(t, "pending", state.Jobs[1].Status)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file should be left behind")
+}
+
+func TestSession_AutoSaveDuringRun(t *testing.T) {
+	requests := make(chan struct{})
+	reporter, target := newTestReporter(t, &config.AppConfig{MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		<-requests // Each report waits for the test to let it through.
+		w.WriteHeader(http.StatusOK)
+	})
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := NewSession(reporter, target, 3)
+	s.AutoSaveInterval = 10 * time.Millisecond
+	s.AutoSavePath = path
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+
+	requests <- struct{}{} // Let the first report succeed; the second then blocks.
+	require.Eventually(t, func() bool {
+		state, err := readSavedState(t, path)
+		return err == nil && state.Successful == 1 && state.Jobs[1].Status == "processing"
+	}, 5*time.Second, 10*time.Millisecond, "the state file should be updated while the session runs")
+
+	state, err := readSavedState(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, "Running", state.State)
+	assert.Equal(t, "success", state.Jobs[0].Status)
+	assert.Equal(t, "pending", state.Jobs[2].Status)
+
+	requests <- struct{}{}
+	requests <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Wait(ctx))
+
+	state, err = readSavedState(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, "Completed", state.State, "a final save should record the terminal state")
+	assert.Equal(t, 3, state.Successful)
+}
+
+func TestNewSession_AutoSaveFromConfig(t *testing.T) {
+	cfg := &config.AppConfig{MaxRetries: 1, AutoSaveIntervalSeconds: 30, AutoSavePath: "session_state.json"}
+	s := NewSession(report.NewReporter(cfg, nil, nil, nil), "http://example.com", 1)
+	assert.Equal(t, 30*time.Second, s.AutoSaveInterval)
+	assert.Equal(t, "session_state.json", s.AutoSavePath)
+
+	s = NewSession(report.NewReporter(&config.AppConfig{MaxRetries: 1}, nil, nil, nil), "http://example.com", 1)
+	assert.Zero(t, s.AutoSaveInterval, "auto-save is off by default")
+}
+
+func TestResumeSession_OnlySendsPendingJobs(t *testing.T) {
+	first := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call%2 == 1 {
+			return nil, errors.New("rejected")
+		}
+		return &report.ReportResult{StatusCode: 200, Latency: time.Millisecond, LogID: fmt.Sprintf("log-%d", call)}, nil
+	}}
+	s := NewSession(first, "http://example.com/report", 4, map[string]string{"Referer": "https://example.com/"})
+	s.Label = "evening-batch"
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+	waitFor(t, s)
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, s.SaveState(path))
+
+	second := &mockReporter{}
+	resumed, err := ResumeSession(path, second)
+	require.NoError(t, err)
+	assert.Equal(t, s.ID, resumed.ID)
+	assert.Equal(t, "http://example.com/report", resumed.TargetURL)
+	assert.Equal(t, map[string]string{"Referer": "https://example.com/"}, resumed.Headers)
+	assert.Equal(t, "evening-batch", resumed.Label)
+	require.NoError(t, resumed.Start())
+	go drainLogs(resumed)
+	waitFor(t, resumed)
+
+	assert.Equal(t, []string{s.Jobs[1].ID, s.Jobs[3].ID}, second.jobIDs, "only the failed jobs are sent again")
+	p := resumed.Progress()
+	assert.Equal(t, Completed, p.State)
+	assert.Equal(t, 4, p.Attempted)
+	assert.Equal(t, 4, p.Successful)
+	assert.Zero(t, p.Failed)
+	assert.Equal(t, "log-0", resumed.Jobs[0].LogID, "restored jobs keep their results")
+}
+
+func TestResumeSession_SendsDryRunJobs(t *testing.T) {
+	// Rehearse three reports, the last of which fails, then checkpoint.
+	first := &mockReporter{send: func(call int) (*report.ReportResult, error) {
+		if call == 2 {
+			return nil, errors.New("rejected")
+		}
+		return &report.ReportResult{DryRun: true}, nil
+	}}
+	s := NewSession(first, "http://example.com/report", 3)
+	s.DryRun = true
+	require.NoError(t, s.Start())
+	go drainLogs(s)
+	waitFor(t, s)
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, s.SaveState(path))
+	state, err := readSavedState(t, path)
+	require.NoError(t, err)
+	assert.True(t, state.DryRun)
+	require.Equal(t, "success", state.Jobs[0].Status)
+	assert.True(t, state.Jobs[0].DryRun)
+
+	// Resumed as a dry run, the rehearsed jobs stay done.
+	rehearsal := &mockReporter{}
+	resumed, err := ResumeSession(path, rehearsal)
+	require.NoError(t, err)
+	resumed.DryRun = true
+	require.NoError(t, resumed.Start())
+	go drainLogs(resumed)
+	waitFor(t, resumed)
+	assert.Equal(t, 1, rehearsal.calls(), "only the failed job is rehearsed again")
+
+	// Resumed for real, every job is sent.
+	real := &mockReporter{}
+	resumed, err = ResumeSession(path, real)
+	require.NoError(t, err)
+	require.False(t, resumed.DryRun)
+	require.NoError(t, resumed.Start())
+	go drainLogs(resumed)
+	waitFor(t, resumed)
+	assert.Equal(t, 3, real.calls(), "rehearsed reports were never sent")
+	p := resumed.Progress()
+	assert.Equal(t, 3, p.Successful)
+	for _, job := range resumed.Jobs {
+		assert.False(t, job.DryRun)
+	}
+}
+
+func TestResumeSession_InvalidCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	_, err := ResumeSession(filepath.Join(dir, "missing.json"), nil)
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = ResumeSession(path, nil)
+	assert.True(t, errors.Is(err, ErrInvalidCheckpoint))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"target_url":"http://example.com","num_reports_to_send":2,"jobs":[{}]}`), 0600))
+	_, err = ResumeSession(path, nil)
+	assert.True(t, errors.Is(err, ErrInvalidCheckpoint))
+}
@@ -0,0 +1,20 @@
+package tui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// clipboardOutput is the terminal the OSC 52 sequence of copyToClipboard is written to. Tests
+// replace it to capture the sequence.
+var clipboardOutput io.Writer = os.Stdout
+
+// copyToClipboard asks the terminal to put text on the system clipboard with an OSC 52 escape
+// sequence, which also works over SSH. Terminals without OSC 52 support ignore it, so the
+// caller should show text as well.
+func copyToClipboard(text string) error {
+	_, err := fmt.Fprintf(clipboardOutput, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
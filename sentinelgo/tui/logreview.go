@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"sentinelgo/sentinelgo/utils"
+)
+
+// defaultSessionLogPath is the JSON-lines log written by cmd/sentinelgo and reviewed on the
+// Log Review & Export tab.
+const defaultSessionLogPath = "sentinelgo_session.log"
+
+// defaultLogExportPath is the initial value of the Log Review & Export tab's export path field.
+const defaultLogExportPath = "log_export.csv"
+
+// maxLogReviewEntries caps how many matching entries the Log Review & Export tab keeps for
+// display. Only the most recent matches are kept; exports stream every match from the file.
+const maxLogReviewEntries = 2000
+
+// Indices of the Log Review & Export tab's input fields, in focus order.
+const (
+	logReviewLevelField = iota
+	logReviewOutcomeField
+	logReviewSessionField
+	logReviewLabelField
+	logReviewExportField
+	numLogReviewFields
+)
+
+// logReviewFieldLabels are the labels of the Log Review & Export tab's input fields.
+var logReviewFieldLabels = [numLogReviewFields]string{"Level", "Outcome", "Session ID", "Session Label", "Export to"}
+
+// logReviewState holds the Log Review & Export tab's inputs and the entries of the last load.
+type logReviewState struct {
+	path    string                     // Log file to review; defaultSessionLogPath if empty.
+	inputs  [numLogReviewFields]string // Filter fields and the export path, indexed by the logReview*Field constants.
+	focus   int                        // Index of the focused input field.
+	entries []utils.LogEntry           // The most recent matching entries, oldest first.
+	matched int                        // Entries in the file matching the filter, including those not kept.
+	skipped int                        // Malformed lines skipped by the last load.
+	scroll  int                        // Entries hidden below the list; 0 shows the newest entries.
+	loaded  bool                       // True once a load has finished.
+	loading bool                       // True while a load started from the tab is in progress.
+}
+
+// logPath returns the log file to review.
+func (s logReviewState) logPath() string {
+	if s.path == "" {
+		return defaultSessionLogPath
+	}
+	return s.path
+}
+
+// filter returns the entry filter described by the tab's filter fields.
+func (s logReviewState) filter() utils.LogFilter {
+	return utils.LogFilter{
+		Level:     s.inputs[logReviewLevelField],
+		Outcome:   s.inputs[logReviewOutcomeField],
+		SessionID: s.inputs[logReviewSessionField],
+		Label:     s.inputs[logReviewLabelField],
+	}
+}
+
+// logReviewLoadedMsg is a tea.Msg carrying the result of loadLogReviewCmd.
+type logReviewLoadedMsg struct {
+	entries []utils.LogEntry // The most recent matching entries, at most maxLogReviewEntries.
+	matched int              // All matching entries in the file.
+	skipped int              // Malformed lines skipped.
+	err     error
+}
+
+// logReviewExportedMsg is a tea.Msg carrying the result of exportLogReviewCmd.
+type logReviewExportedMsg struct {
+	path     string
+	exported int
+	skipped  int
+	err      error
+}
+
+// loadLogReviewCmd returns a tea.Cmd that streams the log at path and reports the entries
+// matching filter as a logReviewLoadedMsg, keeping only the newest maxLogReviewEntries.
+// A missing log file yields no entries rather than an error, since nothing has been logged yet.
+func loadLogReviewCmd(path string, filter utils.LogFilter) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			return logReviewLoadedMsg{}
+		}
+		if err != nil {
+			return logReviewLoadedMsg{err: fmt.Errorf("failed to open log file '%s': %w", path, err)}
+		}
+		defer f.Close()
+		var msg logReviewLoadedMsg
+		msg.skipped, msg.err = utils.ScanLogEntries(f, filter, func(entry utils.LogEntry) error {
+			msg.matched++
+			msg.entries = append(msg.entries, entry)
+			if len(msg.entries) > maxLogReviewEntries {
+				msg.entries = msg.entries[1:] // Drop the oldest; append reallocates, so memory stays bounded.
+			}
+			return nil
+		})
+		return msg
+	}
+}
+
+// exportLogReviewCmd returns a tea.Cmd that writes the entries of the log at path matching
+// filter to outPath via utils.ExportLogEntries and reports the outcome as a logReviewExportedMsg.
+func exportLogReviewCmd(path string, filter utils.LogFilter, outPath string) tea.Cmd {
+	return func() tea.Msg {
+		exported, skipped, err := utils.ExportLogEntries(path, filter, outPath)
+		return logReviewExportedMsg{path: outPath, exported: exported, skipped: skipped, err: err}
+	}
+}
+
+// scrollBy moves the entry list n entries back in time (or forward for a negative n), staying
+// within the loaded entries.
+func (s *logReviewState) scrollBy(n int) {
+	s.scroll += n
+	if s.scroll > len(s.entries)-1 {
+		s.scroll = len(s.entries) - 1
+	}
+	if s.scroll < 0 {
+		s.scroll = 0
+	}
+}
+
+// reloadLogReview starts loading the log with the tab's current filter, unless a load is
+// already in progress.
+func (m *Model) reloadLogReview() tea.Cmd {
+	if m.logReview.loading {
+		return nil
+	}
+	m.logReview.loading = true
+	return loadLogReviewCmd(m.logReview.logPath(), m.logReview.filter())
+}
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strconv" // For parsing numReportsInput & settings
 	"strings"
 	"time" // For BatchCheckProxies delay example
@@ -13,6 +15,7 @@ import (
 
 	"sentinelgo/sentinelgo/ai" // Import AI package
 	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/events"
 	"sentinelgo/sentinelgo/proxy"   // Import proxy package
 	"sentinelgo/sentinelgo/report"  // Import report package
 	"sentinelgo/sentinelgo/session" // Import session package
@@ -30,7 +33,7 @@ const (
 	ProxyMgmtTab                  // Tab for managing and viewing proxy status.
 	SettingsTab                   // Tab for viewing and editing application settings.
 	LiveSessionLogsTab            // Tab for viewing live logs from an active reporting session.
-	LogReviewTab                  // Tab for reviewing past logs (placeholder).
+	LogReviewTab                  // Tab for reviewing and exporting the structured session log.
 	numTabs                       // Internal counter for the number of tabs.
 )
 
@@ -44,8 +47,89 @@ var tabNames = []string{
 }
 
 // sessionLogMsg is a tea.Msg used to send log updates from a running session.Session
-// to the TUI's Update method. It wraps a session.LogUpdate struct.
-type sessionLogMsg struct{ update session.LogUpdate }
+// to the TUI's Update method. It wraps a session.LogUpdate struct with the ID of the session
+// that sent it, so updates from concurrent sessions are routed to the right one.
+type sessionLogMsg struct {
+	sessionID string
+	update    session.LogUpdate
+}
+
+// testConnectionMsg is a tea.Msg carrying the outcome of a single test request
+// fired from the Target Input tab via testConnectionCmd.
+type testConnectionMsg struct {
+	targetURL string
+	result    *report.ReportResult
+	err       error
+}
+
+// healthCheckDoneMsg is a tea.Msg wrapping the result of a health check of the whole pool
+// started via healthCheckCmd: the initial check from Init or a re-run from the Proxy
+// Management tab.
+type healthCheckDoneMsg struct {
+	initial bool                   // True for the initial check started by Init.
+	recheck bool                   // True for a periodic recheck of stale proxies started by staleRecheckCmd.
+	result  proxy.BatchCheckResult // Counts, per-proxy transitions and elapsed time, including the revival check.
+	diff    proxy.PoolDiff         // Changes between the pool before and after the check, including proxies added meanwhile.
+}
+
+// geoIPDoneMsg is a tea.Msg carrying the regions found by the startup GeoIP lookup (geoIPCmd).
+type geoIPDoneMsg struct {
+	regions  map[string]string // Region per proxy URL.String(), for proxies that were located.
+	source   string            // The GeoIP database the regions came from.
+	cacheErr error             // Set if the GeoIP cache could not be saved.
+}
+
+// geoIPLookup is the startup GeoIP lookup prepared by applyGeoIP and run by geoIPCmd.
+type geoIPLookup struct {
+	proxies []*proxy.ProxyInfo // Copies of the proxies loaded without a region.
+	lookup  proxy.GeoLookupFunc
+	cache   *proxy.GeoCache // May be nil.
+	source  string          // The GeoIP database, for the log.
+}
+
+// proxyCheckDoneMsg is a tea.Msg carrying the outcome of a single proxy's health check
+// started from the Proxy Management tab via proxyCheckCmd.
+type proxyCheckDoneMsg struct {
+	host    string        // Host of the checked proxy, shown instead of its URL.
+	status  string        // Health status after the check.
+	latency time.Duration // Duration of the check request.
+}
+
+// spinnerTickMsg advances the activity spinner shown while health checks run.
+type spinnerTickMsg struct{}
+
+// staleRecheckTickMsg triggers the periodic recheck of proxies whose "healthy" status has
+// expired (see proxy.ProxyManager.MaxHealthyAge).
+type staleRecheckTickMsg struct{}
+
+// proxyImportDoneMsg is a tea.Msg carrying the outcome of a proxy import started from the
+// Proxy Management tab via proxyImportCmd.
+type proxyImportDoneMsg struct {
+	source string // Proxy file or API URL imported from.
+	loaded int    // Proxies read from source.
+	added  int    // Proxies added to the pool; the rest were already in it.
+	err    error
+}
+
+// Default health check parameters, used for the initial background check and as the
+// starting values of the Proxy Management tab fields.
+const (
+	defaultHealthCheckTimeout     = 10 * time.Second
+	defaultHealthCheckConcurrency = 5
+	maxHealthCheckTimeout         = 2 * time.Minute // Upper bound accepted from the Proxy Management tab.
+	maxHealthCheckConcurrency     = 100             // Upper bound accepted from the Proxy Management tab.
+	minStaleRecheckInterval       = time.Second     // Least time between two periodic rechecks of stale proxies.
+)
+
+// GeoIP lookups made at startup for proxies loaded without a region (see applyGeoIP and
+// geoIPCmd). The timeout bounds the whole batch, mostly spent resolving proxy host names.
+const (
+	geoIPConcurrency    = 10
+	geoIPStartupTimeout = 30 * time.Second
+)
+
+// maxSettingRetries is the largest MaxRetries accepted from the Settings tab.
+const maxSettingRetries = 20
 
 // EditableSettingEntry defines the structure for a setting that can be
 // displayed and potentially edited in the Settings tab.
@@ -55,6 +139,22 @@ type EditableSettingEntry struct {
 	Type         string      // Data type of the setting ("int", "float", "string"), used for validation and input handling.
 	CurrentValue interface{} // The current value of the setting, retrieved from AppConfig.
 	IsSensitive  bool        // Flag indicating if the value should be masked when displayed (e.g., API keys).
+
+	// Min and Max are the inclusive bounds of "int" and "float" settings. An edit outside them is
+	// rejected rather than applied. When both are zero the setting is unbounded.
+	Min, Max float64
+}
+
+// checkBounds returns an error if value lies outside the entry's Min and Max. NaN lies
+// outside any bounds.
+func (e EditableSettingEntry) checkBounds(value float64) error {
+	if e.Min == 0 && e.Max == 0 {
+		return nil
+	}
+	if !(value >= e.Min && value <= e.Max) {
+		return fmt.Errorf("%s must be between %g and %g (got %g)", e.Name, e.Min, e.Max, value)
+	}
+	return nil
 }
 
 // Model is the central struct for the Bubble Tea TUI application.
@@ -71,14 +171,36 @@ type Model struct {
 	logger       *utils.Logger       // Pointer to the global structured logger.
 	proxyManager *proxy.ProxyManager // Manages the pool of proxies.
 	reporter     *report.Reporter    // Handles sending individual reports.
-	session      *session.Session    // Pointer to the currently active reporting session (nil if no session is active).
+	session      *session.Session    // The focused session: shown in the status line, controlled with P/R/A and exported with Ctrl+E (nil before the first session).
+	sessions     []*session.Session  // Sessions started from the TUI whose log channels are still open, in start order (see maxSessions).
+	events       *events.Server      // Publishes the events of sessions started from the TUI (nil if eventsaddr is not set).
 
 	// Fields for the "Target Input" tab
-	targetURLInput  string // Buffer for the target URL input.
-	numReportsInput string // Buffer for the number of reports input (stored as string for text input).
+	targetURLInput       string // Buffer for the target URL input.
+	numReportsInput      string // Buffer for the number of reports input (stored as string for text input).
+	sessionLabelInput    string // Buffer for the optional label of the sessions started from the tab.
+	testConnectionStatus string // Styled outcome of the last "test connection" action, shown on the Target Input tab.
+
+	// Fields for the "Proxy Management" tab
+	healthCheckTimeoutInput     string       // Buffer for the per-proxy health check timeout, in seconds.
+	healthCheckConcurrencyInput string       // Buffer for the number of concurrent health checks.
+	proxyImportInput            string       // Buffer for the proxy file or API URL to import more proxies from.
+	proxyInputFocus             int          // 0 for the timeout field, 1 for the concurrency field, 2 for the import field.
+	healthCheckRunning          bool         // True while a health check of the whole pool is in progress.
+	proxyCheckHost              string       // Host of the proxy being checked on its own; empty if none.
+	proxyListCursor             int          // Index in the pool of the proxy selected on the tab.
+	spinnerFrame                int          // Current frame of spinnerFrames.
+	spinnerActive               bool         // True while spinnerTickMsgs are scheduled.
+	staleRecheckActive          bool         // True while staleRecheckTickMsgs are scheduled.
+	geoIP                       *geoIPLookup // Startup GeoIP lookup for Init to start; nil if none.
+
+	logReview logReviewState // State of the "Log Review & Export" tab.
+
+	targetQueue targetQueue    // Targets submitted while a session was active, started in order as sessions finish.
+	breaker     sessionBreaker // Holds back queued targets after AppConfig.MaxConsecutiveSessionFailures failed sessions in a row.
 
 	logMessages   []string // Slice of styled strings for display in the "Live Session Logs" tab.
-	inputFocus    int      // Determines which input field has focus (0 for URL, 1 for NumReports on TargetInputTab; index on SettingsTab).
+	inputFocus    int      // Determines which input field has focus (0 for URL, 1 for NumReports, 2 for the session label on TargetInputTab; index on SettingsTab).
 	sessionStatus string   // A styled string representing the current session status, displayed below the tab bar.
 
 	// State fields for the "Settings" tab
@@ -88,6 +210,28 @@ type Model struct {
 	currentEditValue   string                 // Buffer for the value being typed during a setting edit.
 	originalEditValue  interface{}            // Stores the original value of a setting before editing, for cancellation.
 	editingSettingPath string                 // The 'Path' of the setting currently being edited.
+
+	proxyLoadWarning string // Set when the proxy file failed to load; shown as a banner above every tab.
+	startupErr       error  // Fatal startup error (see StartupError), e.g. a missing proxy file with RequireProxyFile set.
+}
+
+// loadHealthSnapshot restores the proxies' health from path (see AppConfig.HealthSnapshot), a file
+// saved with Ctrl+S or a directory holding such files, logging the outcome. Failures are warnings.
+func (m *Model) loadHealthSnapshot(path string) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		latest, err := proxy.LatestHealthFile(path)
+		if err != nil {
+			m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(LogPrefixWarn+fmt.Sprintf(" Proxy health not restored: %v", err)))
+			return
+		}
+		path = latest
+	}
+	applied, err := m.proxyManager.LoadHealth(path)
+	if err != nil {
+		m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(LogPrefixWarn+fmt.Sprintf(" Proxy health not restored: %v", err)))
+		return
+	}
+	m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+fmt.Sprintf(" Restored the health of %d proxies from %s.", applied, path)))
 }
 
 // NewInitialModel creates the initial state of the TUI Model.
@@ -112,52 +256,200 @@ func NewInitialModel(cfg *config.AppConfig, logger *utils.Logger) Model {
 		logMessages:      []string{LogTimestampStyle.Render(time.Now().Format("15:04:05.000")) + " " + LogLevelInfoStyle.Render(LogPrefixInfo+" TUI Initialized. Welcome to SentinelGo!")},
 		inputFocus:       0,   // Default focus to the first input field on the active tab.
 		numReportsInput:  "1", // Default value for number of reports.
+
+		healthCheckTimeoutInput:     strconv.Itoa(int(defaultHealthCheckTimeout / time.Second)),
+		healthCheckConcurrencyInput: strconv.Itoa(defaultHealthCheckConcurrency),
 	}
+	m.logReview.inputs[logReviewExportField] = defaultLogExportPath
 
 	m.populateEditableSettings() // Initialize the list of editable settings.
 
 	// Initialize proxy manager
-	proxySourcePath := "config/proxies.csv"                  // Default path
-	if cfg != nil && cfg.DefaultHeaders["ProxyFile"] != "" { // Allow overriding via app config
-		proxySourcePath = cfg.DefaultHeaders["ProxyFile"]
+	proxySourcePath := cfg.ProxySource() // DefaultHeaders["ProxyFile"] or the default path.
+	var initialProxies []*proxy.ProxyInfo
+	var err error
+	if proxy.IsAPISource(proxySourcePath) && cfg != nil && cfg.ProxyAPITimeoutSeconds > 0 {
+		initialProxies, err = proxy.LoadProxiesFromAPI(proxySourcePath, time.Duration(cfg.ProxyAPITimeoutSeconds*float64(time.Second)))
+	} else {
+		initialProxies, err = proxy.LoadProxies(proxySourcePath)
+	}
+	if err == nil && cfg != nil && cfg.StrictProxyRegions {
+		err = proxy.ValidateRegions(initialProxies)
 	}
-	initialProxies, err := proxy.LoadProxies(proxySourcePath)
 	if err != nil {
 		// Log error to TUI and potentially to file logger via m.err or direct log
 		m.logMessages = append(m.logMessages, LogLevelErrorStyle.Render(LogPrefixError+fmt.Sprintf(" Error loading proxies from %s: %v", proxySourcePath, err)))
 		m.err = fmt.Errorf("failed to load proxies: %w", err) // Set error for display in footer
 		initialProxies = []*proxy.ProxyInfo{}                 // Proceed with empty list
+		if cfg != nil && cfg.RequireProxyFile {
+			m.startupErr = fmt.Errorf("failed to load proxies from %s (requireproxyfile is set): %w", proxySourcePath, err)
+		} else {
+			m.proxyLoadWarning = fmt.Sprintf("No proxies loaded from %s: reports will fail until a proxy file is available.", proxySourcePath)
+		}
 	} else {
 		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+fmt.Sprintf(" Loaded %d proxies from %s.", len(initialProxies), proxySourcePath)))
+		if cfg != nil && (cfg.GeoCacheFile != "" || cfg.GeoIPDatabase != "") {
+			m.applyGeoIP(initialProxies, cfg)
+		}
+	}
+	strategy := proxy.StrategyRoundRobin // Default strategy
+	if cfg.ProxyStrategy != "" {
+		strategy = cfg.ProxyStrategy
+	}
+	m.proxyManager = proxy.NewProxyManager(initialProxies, strategy, true)
+	m.proxyManager.AvoidRecentWindow = cfg.AvoidRecentWindow
+	m.proxyManager.MaxConsecutiveFailures = cfg.MaxConsecutiveFailures
+	m.proxyManager.AllowUnchecked = !strings.EqualFold(cfg.ProxyStartMode, config.ProxyStartModeWarm)
+	m.proxyManager.RegionHealthCheckURLs = cfg.RegionHealthCheckURLs
+	m.proxyManager.AuditSelections = cfg.AuditProxySelection
+	m.proxyManager.MaxHealthyAge = time.Duration(cfg.HealthyMaxAgeSeconds * float64(time.Second))
+	m.proxyManager.SetMinRecheckInterval(time.Duration(cfg.MinRecheckIntervalSeconds * float64(time.Second)))
+	m.proxyManager.FallbackBelow = cfg.StrategyFallbackBelow
+	if cfg.HealthSnapshot != "" && len(initialProxies) > 0 {
+		m.loadHealthSnapshot(cfg.HealthSnapshot)
+	}
+	if logger != nil {
+		preferred := m.proxyManager.Strategy
+		m.proxyManager.OnStrategyChange = func(active string, candidates int) {
+			if active == preferred {
+				logger.Info(utils.LogEntry{Message: "Proxy strategy restored", Outcome: "strategy_restored", AdditionalData: map[string]interface{}{"strategy": active, "candidates": candidates}})
+				return
+			}
+			logger.Warn(utils.LogEntry{Message: "Proxy pool shrank: falling back to round-robin", Outcome: "strategy_fallback", AdditionalData: map[string]interface{}{"strategy": preferred, "candidates": candidates, "threshold": cfg.StrategyFallbackBelow}})
+		}
 	}
-	m.proxyManager = proxy.NewProxyManager(initialProxies, proxy.StrategyRoundRobin, true) // Default strategy
 	m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+" Proxy manager initialized."))
 
-	// Asynchronously start initial proxy health check if proxies are loaded.
+	// Init starts the initial proxy health check if proxies are loaded. It runs in the
+	// background; its healthCheckDoneMsg refreshes the Proxy Management tab when it finishes.
 	if len(m.proxyManager.GetAllProxies()) > 0 {
 		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+" Starting initial proxy health check (background)..."))
-		// Launch a background goroutine to perform an initial health check on all proxies.
-		// This is a "fire-and-forget" operation: results update the proxy pool and log to file, but do not block the UI.
-		go func() {
-			// TODO: Consider a mechanism (tea.Cmd) to send a message back to TUI upon completion for status update.
-			checkTimeout := 10 * time.Second // Timeout for each proxy health check (could be made configurable).
-			concurrency := 5                 // Number of concurrent health checks (could be made configurable).
-			// Perform the batch health check. This updates each ProxyInfo and logs results to stdout.
-			proxy.BatchCheckProxies(m.proxyManager.GetAllProxies(), checkTimeout, concurrency)
-			// Log completion to the file logger for audit/debug purposes.
-			m.logger.Info(utils.LogEntry{Message: "Initial batch proxy health check completed."})
-		}()
+		m.healthCheckRunning = true
+		m.spinnerActive = true // Init schedules the first tick.
 	}
+	// With expiring health results, Init also starts the periodic recheck of stale proxies.
+	m.staleRecheckActive = m.proxyManager.MaxHealthyAge > 0
 
 	// Initialize AI Analyzer (dummy for now) and Reporter.
 	dummyAnalyzer := ai.NewDummyAnalyzer(logger)
 	m.reporter = report.NewReporter(cfg, m.proxyManager, logger, dummyAnalyzer)
+	reporter := m.reporter
+	m.proxyManager.OnRemove = func(removed []*proxy.ProxyInfo) {
+		for _, p := range removed {
+			reporter.ForgetProxy(p.URL.String()) // Release the connections of proxies that left the pool.
+		}
+	}
 	m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+" Reporter initialized with Dummy AI Analyzer."))
 	m.sessionStatus = SubtleTextStyle.Render("Session: Idle") // Initial session status.
 
 	return m
 }
 
+// mirrorSessionLog writes a LogUpdate from s shown in the Live Session Logs tab to the file
+// logger as a structured entry, so the file holds the same timeline as the screen.
+func (m Model) mirrorSessionLog(s *session.Session, update session.LogUpdate) {
+	if m.logger == nil {
+		return
+	}
+	level, ok := utils.ParseLevel(update.Level)
+	if !ok {
+		level = utils.LevelInfo
+	}
+	entry := utils.LogEntry{
+		Message:        update.Message,
+		Outcome:        "session_update",
+		AdditionalData: map[string]interface{}{"update_time": update.Timestamp.UTC().Format(time.RFC3339Nano)},
+	}
+	if s != nil {
+		entry.SessionID = s.ID
+		entry.ReportURL = s.TargetURL
+	}
+	m.logger.Log(level, entry)
+}
+
+// StartupError returns the error that should stop the application before the TUI runs, or nil.
+// It is set when the proxy file fails to load and AppConfig.RequireProxyFile is enabled;
+// otherwise such a failure only produces a warning banner.
+func (m Model) StartupError() error {
+	return m.startupErr
+}
+
+// SetRecorder makes the model's reporter record every report attempt to rec.
+func (m *Model) SetRecorder(rec *report.Recorder) {
+	m.reporter.Recorder = rec
+}
+
+// SetEventServer makes every session started from the TUI publish its events to srv.
+func (m *Model) SetEventServer(srv *events.Server) {
+	m.events = srv
+}
+
+// applyGeoIP fills in the region of loaded proxies that have none from the GeoIP cache at
+// cfg.GeoCacheFile, if set. If cfg.GeoIPDatabase is set, the proxies the cache does not cover are
+// left to geoIPCmd, which Init starts in the background so DNS lookups do not delay startup.
+func (m *Model) applyGeoIP(proxies []*proxy.ProxyInfo, cfg *config.AppConfig) {
+	var cache *proxy.GeoCache
+	if cfg.GeoCacheFile != "" {
+		var err error
+		if cache, err = proxy.LoadGeoCache(cfg.GeoCacheFile, time.Duration(cfg.GeoCacheTTLHours*float64(time.Hour))); err != nil {
+			m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(LogPrefixWarn+fmt.Sprintf(" GeoIP cache not used: %v", err)))
+		}
+	}
+	var lookup proxy.GeoLookupFunc
+	if cfg.GeoIPDatabase != "" {
+		db, err := proxy.OpenGeoIPDB(cfg.GeoIPDatabase)
+		if err != nil {
+			m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(LogPrefixWarn+fmt.Sprintf(" GeoIP database not used: %v", err)))
+		} else {
+			lookup = db.Lookup
+		}
+	}
+	if cache == nil && lookup == nil {
+		return
+	}
+
+	applied := 0
+	var unlocated []*proxy.ProxyInfo
+	for _, p := range proxies {
+		if p.Region != "" {
+			continue
+		}
+		if proxy.GeoCheckProxy(context.Background(), p, nil, cache) == nil { // Without a lookup, only cached regions apply.
+			applied++
+		} else if lookup != nil {
+			cp := *p
+			unlocated = append(unlocated, &cp)
+		}
+	}
+	if cache != nil {
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+fmt.Sprintf(" Applied %d cached GeoIP regions from %s.", applied, cfg.GeoCacheFile)))
+	}
+	if len(unlocated) > 0 {
+		m.geoIP = &geoIPLookup{proxies: unlocated, lookup: lookup, cache: cache, source: cfg.GeoIPDatabase}
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogPrefixInfo+fmt.Sprintf(" Looking up the regions of %d proxies in %s (background)...", len(unlocated), cfg.GeoIPDatabase)))
+	}
+}
+
+// geoIPCmd returns a tea.Cmd that runs the startup GeoIP lookup on its copies of the proxies,
+// saves the cache and reports the regions found in a geoIPDoneMsg, for Update to apply to the pool.
+func geoIPCmd(job *geoIPLookup) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), geoIPStartupTimeout)
+		defer cancel()
+		proxy.BatchGeoCheck(ctx, job.proxies, geoIPConcurrency, job.lookup, job.cache)
+		msg := geoIPDoneMsg{regions: make(map[string]string), source: job.source}
+		for _, p := range job.proxies {
+			if p.Region != "" {
+				msg.regions[p.URL.String()] = p.Region
+			}
+		}
+		if job.cache != nil {
+			msg.cacheErr = job.cache.Save()
+		}
+		return msg
+	}
+}
+
 // populateEditableSettings initializes or refreshes the list of settings that can be edited in the UI.
 // It reads directly from `m.appConfig`. This should be called when `m.appConfig` is loaded or reloaded.
 func (m *Model) populateEditableSettings() {
@@ -167,36 +459,364 @@ func (m *Model) populateEditableSettings() {
 		return
 	}
 	m.editableSettings = []EditableSettingEntry{
-		{Name: "Max Retries", Path: "MaxRetries", Type: "int", CurrentValue: m.appConfig.MaxRetries},
-		{Name: "Risk Threshold (%)", Path: "RiskThreshold", Type: "float", CurrentValue: m.appConfig.RiskThreshold},
-		// Example for a string setting (if one were added to AppConfig directly)
-		// {Name: "Default User Agent", Path: "DefaultHeaders.User-Agent", Type: "string", CurrentValue: m.appConfig.DefaultHeaders["User-Agent"]},
-		// Note: Editing nested map/slice values like DefaultHeaders or APIKeys directly through this simple list
-		// would require more complex Path handling and reflection/type assertions in Update logic.
-		// For now, only top-level simple fields are handled.
+		// Zero retries would send nothing yet count every report as done.
+		{Name: "Max Retries", Path: "MaxRetries", Type: "int", CurrentValue: m.appConfig.MaxRetries, Min: 1, Max: maxSettingRetries},
+		{Name: "Risk Threshold (%)", Path: "RiskThreshold", Type: "float", CurrentValue: m.appConfig.RiskThreshold, Min: 0, Max: 100},
+		{Name: "Log Level", Path: "LogLevel", Type: "string", CurrentValue: m.logLevel()},
 	}
+	// Each DefaultHeaders and APIKeys entry, e.g. Path "DefaultHeaders.User-Agent" (see applyMapSetting).
+	m.editableSettings = append(m.editableSettings, mapSettingEntries(m.appConfig)...)
+}
+
+// focusSetting moves the Settings tab selection to the entry with the given Path, if any.
+func (m *Model) focusSetting(path string) {
+	for i, setting := range m.editableSettings {
+		if setting.Path == path {
+			m.settingsFocusIndex = i
+			return
+		}
+	}
+	if m.settingsFocusIndex >= len(m.editableSettings) {
+		m.settingsFocusIndex = len(m.editableSettings) - 1
+	}
+}
+
+// warnIfPoolTooSmall logs a warning if the pool has too few healthy proxies for the planned send
+// rate (targetrps at perproxyrps each). It does nothing when targetrps is not set.
+func (m *Model) warnIfPoolTooSmall() {
+	if m.appConfig == nil || m.appConfig.TargetRPS <= 0 || m.proxyManager == nil {
+		return
+	}
+	estimate, err := m.proxyManager.EstimateCapacity(m.appConfig.TargetRPS, m.appConfig.PerProxyRPS)
+	if err != nil || estimate.Sufficient() {
+		return // The config's validation reports unusable rates.
+	}
+	m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(logTimestamp()+" "+LogPrefixWarn+fmt.Sprintf(" Pool too small for requested rate: %g req/s at %g req/s per proxy needs %d healthy proxies, %d are healthy.", estimate.TargetRPS, estimate.PerProxyRPS, estimate.Needed, estimate.Healthy)))
+	if m.logger != nil {
+		m.logger.Warn(utils.LogEntry{Message: "Proxy pool too small for requested rate", Outcome: "pool_too_small", AdditionalData: map[string]interface{}{
+			"target_rps":    estimate.TargetRPS,
+			"per_proxy_rps": estimate.PerProxyRPS,
+			"needed":        estimate.Needed,
+			"healthy":       estimate.Healthy,
+		}})
+	}
+}
+
+// logLevel returns the file logger's current level, or the configured one without a logger.
+func (m *Model) logLevel() string {
+	if m.logger != nil {
+		return m.logger.Level().String()
+	}
+	if m.appConfig.LogLevel == "" {
+		return utils.LevelInfo.String()
+	}
+	return strings.ToUpper(m.appConfig.LogLevel)
+}
+
+// startSession creates and starts a session for targetURL labelled label (which may be empty),
+// focuses it and logs the outcome. It returns the command that listens for the session's log
+// updates, or nil if the session failed to start (the error is left in m.err).
+func (m *Model) startSession(targetURL string, numReports int, label string) tea.Cmd {
+	s := session.NewSession(m.reporter, targetURL, numReports)
+	s.Label = label
+	m.err = m.startTUISession(s)
+	if m.err != nil {
+		m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixError+fmt.Sprintf(" Error starting session: %v", m.err)))
+		return nil
+	}
+	m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixInfo+fmt.Sprintf(" New session %s%s started for %d reports to %s.", shortSessionID(s.ID), labelSuffix(label), numReports, targetURL)))
+	return m.listenForSessionLogsCmd(s)
+}
+
+// labelSuffix returns label formatted to follow a session ID in log lines, or "" if it is empty.
+func labelSuffix(label string) string {
+	if label == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", label)
+}
+
+// startTUISession starts s with the TUI's LogChannel backpressure policy: unless the config
+// chose one, the oldest updates are dropped when the Live Session Logs tab falls behind, so the
+// newest progress stays visible and the session never waits on the screen. The "block" policy is
+// replaced by "drop-newest": Update reads the session's progress between two log updates, which
+// would deadlock against a session blocked on a full LogChannel. With an event server set, s
+// publishes its events to it. Once started, s is tracked in m.sessions and focused.
+func (m *Model) startTUISession(s *session.Session) error {
+	switch s.LogPolicy {
+	case "":
+		s.LogPolicy = session.LogPolicyDropOldest
+	case session.LogPolicyBlock:
+		s.LogPolicy = session.LogPolicyDropNewest
+		m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(logTimestamp()+" "+LogPrefixWarn+" Log channel policy \"block\" is not supported by the TUI; using \"drop-newest\"."))
+	}
+	if m.events != nil {
+		m.events.Watch(s)
+	}
+	if err := s.Start(); err != nil {
+		return err
+	}
+	m.sessions = append(m.sessions, s)
+	m.session = s
+	return nil
+}
+
+// maxSessions returns how many sessions may run at once: AppConfig.MaxConcurrentSessions, or 1
+// if it is not set.
+func (m Model) maxSessions() int {
+	if m.appConfig == nil || m.appConfig.MaxConcurrentSessions < 1 {
+		return 1
+	}
+	return m.appConfig.MaxConcurrentSessions
+}
+
+// activeSessions returns the number of tracked sessions that are running or paused.
+func (m Model) activeSessions() int {
+	active := 0
+	for _, s := range m.sessions {
+		if state := s.GetStateValue(); state == session.Running || state == session.Paused {
+			active++
+		}
+	}
+	return active
+}
+
+// sessionLimitError returns the error for a session that cannot start because maxSessions are
+// already active.
+func (m Model) sessionLimitError() error {
+	if m.maxSessions() == 1 {
+		return fmt.Errorf("session already active")
+	}
+	return fmt.Errorf("session limit reached: %d sessions active", m.activeSessions())
+}
+
+// sessionByID returns the tracked session with the given ID, or nil if there is none.
+func (m Model) sessionByID(id string) *session.Session {
+	for _, s := range m.sessions {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// removeSession stops tracking s, e.g. once its log channel has closed.
+func (m *Model) removeSession(s *session.Session) {
+	remaining := make([]*session.Session, 0, len(m.sessions))
+	for _, tracked := range m.sessions {
+		if tracked != s {
+			remaining = append(remaining, tracked)
+		}
+	}
+	m.sessions = remaining
+}
+
+// focusNextSession moves the focus to the tracked session after the focused one, wrapping
+// around, or to the first tracked session if the focused one has finished. It reports whether
+// there was a session to focus.
+func (m *Model) focusNextSession() bool {
+	if len(m.sessions) == 0 {
+		return false
+	}
+	next := 0
+	for i, s := range m.sessions {
+		if s == m.session {
+			next = (i + 1) % len(m.sessions)
+			break
+		}
+	}
+	m.session = m.sessions[next]
+	return true
+}
+
+// shortSessionID returns the first 8 characters of a session ID, used to tell concurrent
+// sessions apart in log lines.
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// resumeSession rebuilds the session checkpointed at the configured autosavepath and starts it,
+// so only the reports that had not succeeded are sent. Like startSession, it returns the log
+// listener command, or nil with the error left in m.err.
+func (m *Model) resumeSession() tea.Cmd {
+	if m.appConfig == nil || m.appConfig.AutoSavePath == "" {
+		m.err = fmt.Errorf("no checkpoint to resume: autosavepath is not configured")
+	} else if resumed, err := session.ResumeSession(m.appConfig.AutoSavePath, m.reporter); err != nil {
+		m.err = fmt.Errorf("failed to resume session: %w", err)
+	} else if m.err = m.startTUISession(resumed); m.err == nil {
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Resumed session %s from %s.", resumed.ID, m.appConfig.AutoSavePath)))
+		return m.listenForSessionLogsCmd(resumed)
+	}
+	m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+fmt.Sprintf(" Error resuming session: %v", m.err)))
+	return nil
 }
 
 // listenForSessionLogsCmd returns a tea.Cmd that listens for the next LogUpdate
-// from the active session's LogChannel. If the channel is closed or the session is nil,
-// it sends a specific sessionLogMsg to indicate this.
-func (m *Model) listenForSessionLogsCmd() tea.Cmd {
+// from s's LogChannel. If the channel is closed or s is nil, it sends a terminal LogUpdate
+// (see LogUpdate.Terminal) so Update stops listening to s.
+func (m *Model) listenForSessionLogsCmd(s *session.Session) tea.Cmd {
 	return func() tea.Msg {
-		if m.session == nil || m.session.LogChannel == nil {
+		if s == nil || s.LogChannel == nil {
 			// This indicates an issue, possibly session ended abruptly or was not set up.
-			return sessionLogMsg{update: session.LogUpdate{Level: session.LogLevelUpdateError, Message: "TUI Error: Session or its LogChannel is nil.", Timestamp: time.Now()}}
+			return sessionLogMsg{update: session.LogUpdate{Level: session.LogLevelUpdateError, Message: "TUI Error: Session or its LogChannel is nil.", Timestamp: time.Now(), Terminal: true}}
 		}
-		logUpdate, ok := <-m.session.LogChannel // Blocking read from the channel.
-		if !ok {                                // Channel has been closed by the sender (session.runLoop's defer).
-			return sessionLogMsg{update: session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session log channel closed by sender.", Timestamp: time.Now()}}
+		logUpdate, ok := <-s.LogChannel // Blocking read from the channel.
+		if !ok {                        // Channel has been closed by the sender (session.runLoop's defer).
+			return sessionLogMsg{sessionID: s.ID, update: session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session log channel closed by sender.", Timestamp: time.Now(), Terminal: true}}
 		}
-		return sessionLogMsg{update: logUpdate} // Send the received LogUpdate.
+		return sessionLogMsg{sessionID: s.ID, update: logUpdate} // Send the received LogUpdate.
 	}
 }
 
-// Init is called by Bubble Tea when the program starts.
-// It can return an initial command to be executed.
-func (m Model) Init() tea.Cmd { return nil } // No initial command needed for now.
+// testConnectionCmd returns a tea.Cmd that sends a single test request to targetURL via
+// Reporter.SendOnce and reports the outcome as a testConnectionMsg. No session is started.
+func testConnectionCmd(reporter *report.Reporter, targetURL string) tea.Cmd {
+	return func() tea.Msg {
+		if reporter == nil {
+			return testConnectionMsg{targetURL: targetURL, err: fmt.Errorf("reporter not initialized")}
+		}
+		result, err := reporter.SendOnce(targetURL)
+		return testConnectionMsg{targetURL: targetURL, result: result, err: err}
+	}
+}
+
+// parseHealthCheckParams validates the Proxy Management tab inputs. The timeout is given in
+// seconds (fractions allowed) and must be in (0, maxHealthCheckTimeout]; the concurrency must
+// be an integer in [1, maxHealthCheckConcurrency].
+func parseHealthCheckParams(timeoutInput, concurrencyInput string) (time.Duration, int, error) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(timeoutInput), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid health check timeout '%s': must be a number of seconds", timeoutInput)
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout <= 0 || timeout > maxHealthCheckTimeout {
+		return 0, 0, fmt.Errorf("health check timeout must be greater than 0 and at most %s", maxHealthCheckTimeout)
+	}
+
+	concurrency, err := strconv.Atoi(strings.TrimSpace(concurrencyInput))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid health check concurrency '%s': must be a whole number", concurrencyInput)
+	}
+	if concurrency < 1 || concurrency > maxHealthCheckConcurrency {
+		return 0, 0, fmt.Errorf("health check concurrency must be between 1 and %d", maxHealthCheckConcurrency)
+	}
+	return timeout, concurrency, nil
+}
+
+// healthCheckCmd returns a tea.Cmd that re-runs the health check over the whole pool with the
+// given parameters, then re-tests dead proxies with RevivalCheck, and reports the combined result
+// as a healthCheckDoneMsg. Revived proxies count as checked and healthy.
+func healthCheckCmd(pm *proxy.ProxyManager, timeout time.Duration, concurrency int) tea.Cmd {
+	return func() tea.Msg {
+		start := time.Now()
+		before := pm.PoolSnapshot()
+		result := pm.CheckPoolHealth(timeout, concurrency)
+		revived := pm.RevivalCheck(timeout, concurrency)
+		result.Revived = len(revived)
+		result.Checked += len(revived)
+		result.Healthy += len(revived)
+		result.Elapsed = time.Since(start)
+		return healthCheckDoneMsg{result: result, diff: proxy.DiffSnapshots(before, pm.PoolSnapshot())}
+	}
+}
+
+// proxyImportCmd returns a tea.Cmd that loads proxies from source, a proxy file or API URL (the
+// latter bounded by apiTimeout if positive, like at startup), adds those not already in the pool
+// to pm, and reports the outcome as a proxyImportDoneMsg. A running session can use the new
+// proxies from its next report.
+func proxyImportCmd(pm *proxy.ProxyManager, source string, apiTimeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		var proxies []*proxy.ProxyInfo
+		var err error
+		if proxy.IsAPISource(source) && apiTimeout > 0 {
+			proxies, err = proxy.LoadProxiesFromAPI(source, apiTimeout)
+		} else {
+			proxies, err = proxy.LoadProxies(source)
+		}
+		if err != nil {
+			return proxyImportDoneMsg{source: source, err: err}
+		}
+		return proxyImportDoneMsg{source: source, loaded: len(proxies), added: pm.AddProxies(proxies)}
+	}
+}
+
+// proxyCheckCmd returns a tea.Cmd that health checks p alone, against the URL for its region,
+// and reports the result as a proxyCheckDoneMsg.
+func proxyCheckCmd(pm *proxy.ProxyManager, p *proxy.ProxyInfo, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		checked := pm.CheckProxy(context.Background(), p, timeout)
+		return proxyCheckDoneMsg{host: checked.URL.Host, status: checked.HealthStatus, latency: checked.Latency}
+	}
+}
+
+// staleRecheckCmd returns a tea.Cmd that health checks the given stale proxies (see
+// ProxiesNeedingRecheck) and reports the result as a healthCheckDoneMsg with recheck set.
+func staleRecheckCmd(pm *proxy.ProxyManager, stale []*proxy.ProxyInfo, timeout time.Duration, concurrency int) tea.Cmd {
+	return func() tea.Msg {
+		before := pm.PoolSnapshot()
+		result := pm.CheckProxies(context.Background(), stale, timeout, concurrency)
+		return healthCheckDoneMsg{recheck: true, result: result, diff: proxy.DiffSnapshots(before, pm.PoolSnapshot())}
+	}
+}
+
+// staleRecheckTickCmd returns a tea.Cmd that sends a staleRecheckTickMsg after half of maxAge,
+// but at least minStaleRecheckInterval, so stale proxies are rechecked soon after they expire.
+func staleRecheckTickCmd(maxAge time.Duration) tea.Cmd {
+	interval := maxAge / 2
+	if interval < minStaleRecheckInterval {
+		interval = minStaleRecheckInterval
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return staleRecheckTickMsg{} })
+}
+
+// spinnerFrames are the frames of the spinner shown on the Proxy Management tab while health
+// checks run.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner advances.
+const spinnerInterval = 100 * time.Millisecond
+
+// spinnerTickCmd returns a tea.Cmd that sends a spinnerTickMsg after spinnerInterval.
+func spinnerTickCmd() tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg { return spinnerTickMsg{} })
+}
+
+// startSpinner schedules spinner ticks unless they already are. The ticks stop by themselves
+// once no health check is running.
+func (m *Model) startSpinner() tea.Cmd {
+	if m.spinnerActive {
+		return nil
+	}
+	m.spinnerActive = true
+	return spinnerTickCmd()
+}
+
+// Init is called by Bubble Tea when the program starts. It runs the initial proxy health check
+// set up by NewInitialModel, if any, and starts the periodic recheck of stale proxies.
+func (m Model) Init() tea.Cmd {
+	if m.proxyManager == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	if m.healthCheckRunning {
+		check := healthCheckCmd(m.proxyManager, defaultHealthCheckTimeout, defaultHealthCheckConcurrency)
+		cmds = append(cmds, func() tea.Msg {
+			msg := check().(healthCheckDoneMsg)
+			msg.initial = true
+			return msg
+		}, spinnerTickCmd())
+	}
+	if m.staleRecheckActive {
+		cmds = append(cmds, staleRecheckTickCmd(m.proxyManager.MaxHealthyAge))
+	}
+	if m.geoIP != nil {
+		cmds = append(cmds, geoIPCmd(m.geoIP))
+	}
+	return tea.Batch(cmds...)
+}
 
 // Update is the main message handling function for the TUI.
 // It processes incoming tea.Msg types (like key presses, window size changes, custom messages)
@@ -210,7 +830,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-	case sessionLogMsg: // Handle log updates from the active session.
+	case sessionLogMsg: // Handle log updates from a running session.
+		s := m.sessionByID(msg.sessionID)
 		logEntry := msg.update
 		var styledLog string
 		var logStyle lipgloss.Style
@@ -230,90 +851,200 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			logStyle, prefix = NormalTextStyle, "[???]" // Fallback for unknown levels.
 		}
 
+		if s != nil && m.maxSessions() > 1 { // Tell concurrent sessions apart.
+			prefix += " [" + shortSessionID(s.ID) + "]"
+		}
+
 		timestampStr := LogTimestampStyle.Render(logEntry.Timestamp.Format("15:04:05.000")) // Format timestamp.
 		styledMsgPart := logStyle.Render(prefix + " " + logEntry.Message)                   // Style prefix and message.
 		styledLog = fmt.Sprintf("%s %s", timestampStr, styledMsgPart)                       // Combine parts.
 
 		m.logMessages = append(m.logMessages, styledLog) // Add to TUI log display buffer.
+		m.mirrorSessionLog(s, logEntry)                  // Keep the file log consistent with the on-screen log.
 
-		// If the message indicates the log channel was closed, stop listening.
-		if logEntry.Message == "Session log channel closed by sender." {
-			if m.session != nil { // Update final session status.
-				sState, _, numToSend, attempted, ok, fail := m.session.GetStats()
-				m.sessionStatus = fmt.Sprintf("Session: %s | Target: %s | Reports: %d/%d | OK: %s | Fail: %s",
-					sState.String(), m.session.TargetURL, attempted, numToSend,
-					SuccessTextStyle.Render(fmt.Sprintf("%d", ok)), ErrorTextStyle.Render(fmt.Sprintf("%d", fail)))
+		// A terminal update means the log channel was closed; stop listening to this session.
+		if logEntry.Terminal {
+			if s != nil { // Record the finished session's outcome.
+				m.removeSession(s)
+				progress := s.Progress()
+				if m.breaker.Record(progress, m.appConfig.MaxConsecutiveSessionFailures) {
+					m.err = fmt.Errorf("%d consecutive sessions failed; queued targets will not start automatically", m.breaker.ConsecutiveFailures())
+					m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+fmt.Sprintf(" %d consecutive sessions failed: not starting queued targets (%d held). Check credentials and proxies; a successful session resumes the queue.", m.breaker.ConsecutiveFailures(), m.targetQueue.Len())))
+					if m.logger != nil {
+						m.logger.Error(utils.LogEntry{Message: "Session circuit breaker tripped", Error: m.err.Error(), Outcome: "breaker_tripped", AdditionalData: map[string]interface{}{"consecutive_failures": m.breaker.ConsecutiveFailures(), "queued": m.targetQueue.Len()}})
+					}
+				}
 			} else { // Should ideally not happen if channel belonged to a session.
 				m.sessionStatus = ErrorTextStyle.Render("Session: ERROR - Log channel closed but session is nil")
+				return m, nil
+			}
+			m.updateSessionStatus()
+			// Start the next queued target, if any, a session slot is free and the breaker allows
+			// it; otherwise stop listening. Other sessions keep their own listeners.
+			if m.breaker.Tripped() || m.activeSessions() >= m.maxSessions() {
+				return m, nil
+			}
+			if next, ok := m.targetQueue.Pop(); ok {
+				m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixInfo+fmt.Sprintf(" Starting queued session (%d more queued).", m.targetQueue.Len())))
+				cmd := m.startSession(next.targetURL, next.numReports, next.label)
+				m.updateSessionStatus()
+				return m, cmd
 			}
 			return m, nil // No further command; stop listening.
 		}
 		// Continue listening for more log messages from the session.
-		cmds = append(cmds, m.listenForSessionLogsCmd())
+		cmds = append(cmds, m.listenForSessionLogsCmd(s))
+
+	case testConnectionMsg: // Handle the outcome of a "test connection" action.
+		ts := LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))
+		if msg.err != nil {
+			m.testConnectionStatus = ErrorTextStyle.Render(fmt.Sprintf("%s Test failed: %v", SymbolFailure, msg.err))
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+fmt.Sprintf(" Test connection to %s failed: %v", msg.targetURL, msg.err)))
+		} else {
+			summary := fmt.Sprintf("Status: %d | Latency: %s | Proxy: %s", msg.result.StatusCode, msg.result.Latency.Round(time.Millisecond), msg.result.Proxy)
+			if msg.result.DryRun {
+				summary = fmt.Sprintf("Dry run, nothing sent | Proxy: %s", msg.result.Proxy)
+			}
+			if msg.result.DryRun || msg.result.StatusCode >= 200 && msg.result.StatusCode < 300 {
+				m.testConnectionStatus = SuccessTextStyle.Render(SymbolSuccess + " " + summary)
+			} else {
+				m.testConnectionStatus = WarningTextStyle.Render(SymbolWarning + " " + summary)
+			}
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(ts+" "+LogPrefixInfo+fmt.Sprintf(" Test connection to %s -> %s", msg.targetURL, summary)))
+		}
+
+	case geoIPDoneMsg: // Apply the regions found by the startup GeoIP lookup.
+		applied := 0
+		if m.proxyManager != nil {
+			applied = m.proxyManager.ApplyRegions(msg.regions)
+		}
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Applied %d GeoIP regions from %s.", applied, msg.source)))
+		if msg.cacheErr != nil {
+			m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(logTimestamp()+" "+LogPrefixWarn+fmt.Sprintf(" GeoIP cache not saved: %v", msg.cacheErr)))
+		}
+
+	case healthCheckDoneMsg: // Handle completion of a health check of the whole pool.
+		m.healthCheckRunning = false
+		ts := LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))
+		kind, logMessage := "Health check", "Manual proxy health check completed."
+		if msg.initial {
+			kind, logMessage = "Initial proxy health check", "Initial batch proxy health check completed."
+		} else if msg.recheck {
+			kind, logMessage = "Stale proxy recheck", "Periodic recheck of stale proxies completed."
+		}
+		result := msg.result
+		summary := fmt.Sprintf(" %s completed in %s: %d/%d proxies healthy.", kind, result.Elapsed.Round(time.Millisecond), result.Healthy, result.Checked)
+		if result.Revived > 0 {
+			summary += fmt.Sprintf(" Revived %d dead proxies.", result.Revived)
+		}
+		if !msg.diff.IsEmpty() {
+			summary += " Since last check: " + msg.diff.String() + "."
+		}
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(ts+" "+LogPrefixInfo+summary))
+		if m.logger != nil {
+			m.logger.Info(utils.LogEntry{Message: logMessage, AdditionalData: map[string]interface{}{
+				"healthy":     result.Healthy,
+				"total":       result.Checked,
+				"revived":     result.Revived,
+				"recovered":   len(msg.diff.Recovered),
+				"died":        len(msg.diff.Died),
+				"transitions": result.Transitions,
+				"elapsed_ms":  result.Elapsed.Milliseconds(),
+			}})
+		}
+		m.warnIfPoolTooSmall()
+
+	case proxyCheckDoneMsg: // Handle the outcome of a single proxy's health check.
+		m.proxyCheckHost = ""
+		if msg.status != "healthy" {
+			m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(logTimestamp()+" "+LogPrefixWarn+fmt.Sprintf(" Proxy %s is %s after %s.", msg.host, msg.status, msg.latency.Round(time.Millisecond))))
+		} else {
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Proxy %s is healthy (latency %s).", msg.host, msg.latency.Round(time.Millisecond))))
+		}
+		if m.logger != nil {
+			m.logger.Info(utils.LogEntry{Message: "Proxy health check completed.", Proxy: msg.host, AdditionalData: map[string]interface{}{
+				"status":     msg.status,
+				"latency_ms": msg.latency.Milliseconds(),
+			}})
+		}
+
+	case staleRecheckTickMsg: // Recheck proxies whose healthy status expired; stop ticking once expiry is off.
+		if m.proxyManager == nil || m.proxyManager.MaxHealthyAge <= 0 {
+			m.staleRecheckActive = false
+			break
+		}
+		cmds = append(cmds, staleRecheckTickCmd(m.proxyManager.MaxHealthyAge))
+		if m.healthCheckRunning {
+			break // Stale proxies stay flagged, so the next tick picks them up.
+		}
+		if stale := m.proxyManager.ProxiesNeedingRecheck(); len(stale) > 0 {
+			m.healthCheckRunning = true
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Rechecking %d proxies whose healthy status expired...", len(stale))))
+			cmds = append(cmds, staleRecheckCmd(m.proxyManager, stale, defaultHealthCheckTimeout, defaultHealthCheckConcurrency), m.startSpinner())
+		}
+
+	case spinnerTickMsg: // Advance the spinner while a health check runs; stop ticking otherwise.
+		if !m.healthCheckRunning && m.proxyCheckHost == "" {
+			m.spinnerActive = false
+			break
+		}
+		m.spinnerActive = true
+		m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
+		cmds = append(cmds, spinnerTickCmd())
+
+	case proxyImportDoneMsg: // Handle the outcome of a proxy import.
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to import proxies from %s: %w", msg.source, msg.err)
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" "+m.err.Error()))
+			break
+		}
+		summary := fmt.Sprintf(" Imported %d new proxies from %s (%d already in the pool).", msg.added, msg.source, msg.loaded-msg.added)
+		if msg.added > 0 {
+			summary += " Press Ctrl+R to health check them."
+		}
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+summary))
+		if m.logger != nil {
+			m.logger.Info(utils.LogEntry{Message: "Proxies imported", Outcome: "proxies_imported", AdditionalData: map[string]interface{}{
+				"source": msg.source,
+				"loaded": msg.loaded,
+				"added":  msg.added,
+			}})
+		}
+
+	case logReviewLoadedMsg: // Handle the entries loaded for the Log Review & Export tab.
+		m.logReview.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			break
+		}
+		m.logReview.entries = msg.entries
+		m.logReview.matched = msg.matched
+		m.logReview.skipped = msg.skipped
+		m.logReview.scroll = 0
+		m.logReview.loaded = true
+
+	case logReviewExportedMsg: // Handle the outcome of a log export.
+		ts := logTimestamp()
+		if msg.err != nil {
+			m.err = msg.err
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+" Log export failed: "+msg.err.Error()))
+			break
+		}
+		summary := fmt.Sprintf(" Exported %d log entries to %s.", msg.exported, msg.path)
+		if msg.skipped > 0 {
+			summary += fmt.Sprintf(" %d malformed lines skipped.", msg.skipped)
+		}
+		m.logMessages = append(m.logMessages, SuccessTextStyle.Render(ts+" "+LogPrefixInfo+summary))
 
 	case tea.KeyMsg: // Handle keyboard input.
 		// Settings tab edit mode has priority for key handling.
 		if m.activeTab == SettingsTab && m.editingSetting {
-			switch msg.String() {
-			case "enter": // Confirm edit.
-				if m.settingsFocusIndex < 0 || m.settingsFocusIndex >= len(m.editableSettings) {
-					m.err = fmt.Errorf("internal error: invalid settingsFocusIndex %d", m.settingsFocusIndex)
-					m.editingSetting = false
-					break
-				}
-				settingToEdit := &m.editableSettings[m.settingsFocusIndex]
-				isValid := true
-				var parseErr error
-
-				// Validate and convert based on setting type.
-				switch settingToEdit.Type {
-				case "int":
-					val, errConv := strconv.Atoi(m.currentEditValue)
-					if errConv != nil {
-						parseErr = fmt.Errorf("invalid integer value: %w", errConv)
-						isValid = false
-					} else { // Apply change to AppConfig.
-						if settingToEdit.Path == "MaxRetries" {
-							m.appConfig.MaxRetries = val
-						}
-						settingToEdit.CurrentValue = val // Update UI model.
-					}
-				case "float":
-					val, errConv := strconv.ParseFloat(m.currentEditValue, 64)
-					if errConv != nil {
-						parseErr = fmt.Errorf("invalid float value: %w", errConv)
-						isValid = false
-					} else { // Apply change to AppConfig.
-						if settingToEdit.Path == "RiskThreshold" {
-							m.appConfig.RiskThreshold = val
-						}
-						settingToEdit.CurrentValue = val // Update UI model.
-					}
-					// Add case "string" here if string settings become editable.
-				}
-
-				if !isValid {
-					m.err = parseErr
-				} else {
-					m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixInfo+fmt.Sprintf(" Setting '%s' updated locally to '%s'. Use Ctrl+S to save.", settingToEdit.Name, m.currentEditValue)))
-				}
-				m.editingSetting = false // Exit edit mode.
-			case "esc": // Cancel edit.
-				m.editingSetting = false
-				m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixWarn+" Edit cancelled for '"+m.editableSettings[m.settingsFocusIndex].Name+"'."))
-			case "backspace": // Handle backspace.
-				if len(m.currentEditValue) > 0 {
-					m.currentEditValue = m.currentEditValue[:len(m.currentEditValue)-1]
-				}
-			default: // Append typed characters.
-				if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace { // Allow spaces for potential future string settings.
-					// TODO: Implement live input restrictions for int/float types if desired.
-					m.currentEditValue += msg.String()
-				}
-			}
+			var cmd tea.Cmd
+			m, cmd = tabViews[SettingsTab].HandleKey(m, msg)
+			cmds = append(cmds, cmd)
 		} else { // Not editing a setting, or not on Settings tab.
-			// Session control keybindings (P, R, A) if a session is active.
-			if m.session != nil {
+			// Session control keybindings (P, R, A) if a session is active, unless they are being typed as text.
+			if m.session != nil && !m.textFieldFocused() {
 				sState, _, _, _, _, _ := m.session.GetStats()
 				if sState == session.Running || sState == session.Paused {
 					tsNow := LogTimestampStyle.Render(time.Now().Format("15:04:05.000")) + " "
@@ -347,15 +1078,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Global keybindings.
 			switch msg.String() {
 			case "ctrl+c", "q": // Quit logic.
-				if m.session != nil {
-					sState, _, _, _, _, _ := m.session.GetStats()
-					if sState == session.Running || sState == session.Paused { // If session active, warn before quit.
-						m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixWarn+" Session active. Press 'a' to abort, or Ctrl+C again to force quit."))
-						if msg.String() == "q" && (m.activeTab != TargetInputTab || (m.targetURLInput == "" && m.numReportsInput == "")) { /* no quit on 'q' if session active */
-						}
-						// Do nothing on 'q' if session active; only allow force quit on Ctrl+Celse if msg.String() == "ctrl+c" { return m, tea.Quit } // Force quit on Ctrl+C
-						break // Do not fall through to other quit conditions if session is active
+				// In free-text fields, 'q' is typed into the focused field.
+				if msg.String() == "q" && m.textFieldFocused() {
+					var cmd tea.Cmd
+					m, cmd = tabViews[m.activeTab].HandleKey(m, msg)
+					cmds = append(cmds, cmd)
+					break
+				}
+				if m.activeSessions() > 0 { // If any session is active, warn before quit.
+					m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixWarn+" Session active. Press 'a' to abort, or Ctrl+C again to force quit."))
+					if msg.String() == "q" && (m.activeTab != TargetInputTab || (m.targetURLInput == "" && m.numReportsInput == "")) { /* no quit on 'q' if session active */
 					}
+					// Do nothing on 'q' if session active; only allow force quit on Ctrl+Celse if msg.String() == "ctrl+c" { return m, tea.Quit } // Force quit on Ctrl+C
+					break // Do not fall through to other quit conditions if session is active
 				}
 				// If no active session or not typing in target input, allow 'q' to quit.
 				if m.activeTab != TargetInputTab || (m.targetURLInput == "" && m.numReportsInput == "") {
@@ -372,134 +1107,79 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.activeTab = (m.activeTab + 1) % numTabs
 				m.editingSetting = false
 				m.settingsFocusIndex = 0
+				if m.activeTab == LogReviewTab { // Show the log as it is now.
+					cmds = append(cmds, m.reloadLogReview())
+				}
 			case "ctrl+p": // Previous tab.
 				m.activeTab = (m.activeTab - 1 + numTabs) % numTabs
 				m.editingSetting = false
 				m.settingsFocusIndex = 0
-
-			case "ctrl+s": // Save settings (only if on SettingsTab).
-				if m.activeTab == SettingsTab {
-					err := config.SaveAppConfig("config/sentinel.yaml", m.appConfig)
-					ts := LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))
-					if err != nil {
-						m.err = fmt.Errorf("failed to save config: %w", err)
-						m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+" Failed to save settings: "+err.Error()))
-					} else {
-						m.logMessages = append(m.logMessages, SuccessTextStyle.Render(ts+" "+LogPrefixInfo+" Settings saved to config/sentinel.yaml."))
-					}
-				}
-			case "ctrl+r": // Reload settings (only if on SettingsTab).
-				if m.activeTab == SettingsTab {
-					newCfg, err := config.LoadAppConfig("config/sentinel.yaml")
-					ts := LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))
-					if err != nil {
-						m.err = fmt.Errorf("failed to reload config: %w", err)
-						m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+" Failed to reload settings: "+err.Error()))
-					} else {
-						m.appConfig = newCfg
-						m.populateEditableSettings() // Refresh UI list with new values.
-						m.logMessages = append(m.logMessages, SuccessTextStyle.Render(ts+" "+LogPrefixInfo+" Settings reloaded from config/sentinel.yaml."))
-					}
+				if m.activeTab == LogReviewTab {
+					cmds = append(cmds, m.reloadLogReview())
 				}
 
-			// Tab-specific keybindings (when not editing settings).
+			// Tab-specific keybindings (when not editing settings), handled by the active tab's view.
 			default:
-				if m.activeTab == TargetInputTab { // Input handling for TargetInputTab.
-					switch msg.String() {
-					case "tab":
-						m.inputFocus = (m.inputFocus + 1) % 2 // Cycle focus: 0 for URL, 1 for NumReports.
-					case "enter": // Submit action for TargetInputTab.
-						numReportsInt, errConv := strconv.Atoi(m.numReportsInput)
-						if errConv != nil || numReportsInt <= 0 {
-							m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixError+" Number of reports must be a positive integer."))
-							m.err = fmt.Errorf("invalid number of reports: '%s'", m.numReportsInput)
-						} else if m.targetURLInput == "" {
-							m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixError+" Target URL cannot be empty."))
-							m.err = fmt.Errorf("target URL cannot be empty")
-						} else { // Valid inputs, proceed to session logic.
-							currentSessionState := session.Idle
-							if m.session != nil {
-								currentSessionState, _, _, _, _, _ = m.session.GetStats()
-							}
-							if currentSessionState == session.Running || currentSessionState == session.Paused {
-								m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixError+" A session is already active. Abort or wait for completion."))
-								m.err = fmt.Errorf("session already active")
-							} else { // Okay to start a new session.
-								m.session = session.NewSession(m.reporter, m.targetURLInput, numReportsInt)
-								m.err = m.session.Start() // Start the session.
-								if m.err != nil {         // Handle error from session.Start().
-									m.logMessages = append(m.logMessages, ErrorTextStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixError+fmt.Sprintf(" Error starting session: %v", m.err)))
-								} else { // Session started successfully.
-									m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixInfo+fmt.Sprintf(" New session started for %d reports to %s.", numReportsInt, m.targetURLInput)))
-									cmds = append(cmds, m.listenForSessionLogsCmd()) // Start listening for logs.
-								}
-								m.targetURLInput = "" // Clear target URL input.
-								m.inputFocus = 0      // Reset focus to URL input.
-							}
-						}
-					case "backspace": // Handle backspace for TargetInputTab.
-						if m.inputFocus == 0 && len(m.targetURLInput) > 0 {
-							m.targetURLInput = m.targetURLInput[:len(m.targetURLInput)-1]
-						}
-						if m.inputFocus == 1 && len(m.numReportsInput) > 0 {
-							m.numReportsInput = m.numReportsInput[:len(m.numReportsInput)-1]
-						}
-					default: // Character input for TargetInputTab.
-						if msg.Type == tea.KeyRunes && !strings.Contains(msg.String(), "ctrl+") { // Ignore control sequences.
-							runeStr := msg.String()
-							if m.inputFocus == 0 {
-								m.targetURLInput += runeStr
-							} // Append to URL input.
-							if m.inputFocus == 1 { // Append to NumReports input, filtering for digits.
-								for _, r := range runeStr {
-									if r >= '0' && r <= '9' {
-										m.numReportsInput += string(r)
-									}
-								}
-							}
-						}
-					}
-				} else if m.activeTab == SettingsTab { // Navigation/activation for SettingsTab (when not editing).
-					switch msg.String() {
-					case "up", "k":
-						if m.settingsFocusIndex > 0 {
-							m.settingsFocusIndex--
-						}
-					case "down", "j":
-						if m.settingsFocusIndex < len(m.editableSettings)-1 {
-							m.settingsFocusIndex++
-						}
-					case "enter": // Enter edit mode for selected setting.
-						if m.settingsFocusIndex < len(m.editableSettings) {
-							m.editingSetting = true
-							m.editingSettingPath = m.editableSettings[m.settingsFocusIndex].Path
-							m.originalEditValue = m.editableSettings[m.settingsFocusIndex].CurrentValue
-							m.currentEditValue = fmt.Sprintf("%v", m.originalEditValue)
-							m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))+" "+LogPrefixInfo+fmt.Sprintf(" Editing '%s'...", m.editableSettings[m.settingsFocusIndex].Name)))
-						}
-					}
-				}
+				var cmd tea.Cmd
+				m, cmd = tabViews[m.activeTab].HandleKey(m, msg)
+				cmds = append(cmds, cmd)
 			}
 		}
 	}
 
-	// Update session status string for display.
-	if m.session != nil {
-		sState, _, numToSend, attempted, successful, failed := m.session.GetStats()
-		targetStr := m.session.TargetURL
-		if len(targetStr) > 30 {
-			targetStr = targetStr[:27] + "..."
-		} // Truncate long URLs
-		m.sessionStatus = fmt.Sprintf("Session: %s | Target: %s | Reports: %d/%d | OK: %s | Fail: %s",
-			sState.String(), targetStr, attempted, numToSend,
-			SuccessTextStyle.Render(fmt.Sprintf("%d", successful)), ErrorTextStyle.Render(fmt.Sprintf("%d", failed)))
-	} else {
+	m.updateSessionStatus()
+	return m, tea.Batch(cmds...)
+}
+
+// updateSessionStatus refreshes the status line from the focused session. When more than one
+// session may run at once, it also shows how many are active.
+func (m *Model) updateSessionStatus() {
+	if m.session == nil {
 		m.sessionStatus = SubtleTextStyle.Render("Session: Idle")
+		return
+	}
+	m.sessionStatus = sessionStatusLine(m.session.Progress())
+	if limit := m.maxSessions(); limit > 1 {
+		m.sessionStatus = fmt.Sprintf("[%s] %s | Active: %d/%d", shortSessionID(m.session.ID), m.sessionStatus, m.activeSessions(), limit)
 	}
-	return m, tea.Batch(cmds...)
 }
 
-// renderHeader, renderFooter, renderTabBar, renderSettingsView, View remain here...
+// textFieldFocused reports whether the focused input takes free text, so letters that are
+// otherwise shortcuts ('q' to quit, P/R/A for session control) are typed into it instead: the
+// fields of the Log Review & Export tab, the Target Input tab's session label field and the Proxy
+// Management tab's import field.
+func (m Model) textFieldFocused() bool {
+	return m.activeTab == LogReviewTab || (m.activeTab == TargetInputTab && m.inputFocus == targetLabelField) ||
+		(m.activeTab == ProxyMgmtTab && m.proxyInputFocus == proxyImportField)
+}
+
+// sessionStatusLine formats a session's progress for the status line. The ETA is shown only
+// while it can be estimated, and the dropped log count only once updates have been dropped.
+func sessionStatusLine(p session.SessionProgress) string {
+	targetStr := p.Target
+	if len(targetStr) > 30 {
+		targetStr = targetStr[:27] + "..."
+	} // Truncate long URLs
+	stateStr := p.State.String()
+	if p.Label != "" {
+		stateStr += " (" + p.Label + ")"
+	}
+	if p.DryRun {
+		stateStr += " " + WarningTextStyle.Render("[DRY RUN]")
+	}
+	status := fmt.Sprintf("Session: %s | Target: %s | Reports: %d/%d (%.0f%%) | OK: %s | Fail: %s",
+		stateStr, targetStr, p.Attempted, p.Total, p.Percent,
+		SuccessTextStyle.Render(fmt.Sprintf("%d", p.Successful)), ErrorTextStyle.Render(fmt.Sprintf("%d", p.Failed)))
+	if p.ETA > 0 {
+		status += fmt.Sprintf(" | ETA: %s", p.ETA.Round(time.Second))
+	}
+	if p.DroppedLogs > 0 {
+		status += " | " + WarningTextStyle.Render(fmt.Sprintf("Dropped logs: %d", p.DroppedLogs))
+	}
+	return status
+}
+
+// renderHeader, renderFooter, renderTabBar, View remain here; per-tab content lives in tabs.go.
 // (Make sure to include the full, correct versions of these functions from the previous step's final file content)
 func (m Model) renderHeader() string {
 	title := HeaderStyle.Render("SentinelGo++ Cyber Operations Platform")
@@ -516,6 +1196,13 @@ func (m Model) renderFooter() string {
 		}
 	} else {
 		helpParts = append(helpParts, helpKeyStyle.Render("Ctrl+N/P:")+HelpTextStyle.Render(" Nav Tabs"))
+		if m.activeTab == LiveSessionLogsTab {
+			if len(m.sessions) > 1 {
+				helpParts = append(helpParts, helpKeyStyle.Render("Tab:")+HelpTextStyle.Render(" Next Session"))
+			}
+			helpParts = append(helpParts, helpKeyStyle.Render("Ctrl+E:")+HelpTextStyle.Render(" Export Run"))
+			helpParts = append(helpParts, helpKeyStyle.Render("Ctrl+Y:")+HelpTextStyle.Render(" Copy Failed as curl"))
+		}
 		if m.session != nil {
 			sState, _, _, _, _, _ := m.session.GetStats()
 			if sState == session.Running || sState == session.Paused {
@@ -539,6 +1226,18 @@ func (m Model) renderFooter() string {
 	footerElements = append(footerElements, HelpTextStyle.Render(helpFullString))
 	return lipgloss.NewStyle().PaddingTop(1).Render(lipgloss.JoinVertical(lipgloss.Left, footerElements...))
 }
+
+// renderDegradedBanner returns a warning banner when the proxy file failed to load or the proxy
+// pool is degraded (every proxy checked and unhealthy while HealthyOnly is set), or "" otherwise.
+func (m Model) renderDegradedBanner() string {
+	if m.proxyLoadWarning != "" && (m.proxyManager == nil || len(m.proxyManager.GetAllProxies()) == 0) {
+		return ErrorTextStyle.Copy().Bold(true).Render(SymbolWarning + " " + m.proxyLoadWarning)
+	}
+	if m.proxyManager == nil || !m.proxyManager.IsDegraded() {
+		return ""
+	}
+	return ErrorTextStyle.Copy().Bold(true).Render(SymbolWarning + " All proxies are unhealthy: reports will fail. Re-run health checks or press Ctrl+A on the Proxy Management tab to use any proxy.")
+}
 func (m Model) renderTabBar() string {
 	var renderedTabs []string
 	for i, name := range m.tabsDisplayNames {
@@ -551,145 +1250,16 @@ func (m Model) renderTabBar() string {
 	tabBarContainerStyle := lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderBottom(true).BorderTop(false).BorderLeft(false).BorderRight(false).BorderForeground(BorderColor).PaddingBottom(0)
 	return tabBarContainerStyle.Render(lipgloss.JoinHorizontal(lipgloss.Bottom, renderedTabs...))
 }
-func (m Model) renderSettingsView() string {
-	var content strings.Builder
-	content.WriteString(HeaderStyle.Render(SymbolListItem+" Application Configuration") + "\n\n")
-
-	if m.appConfig == nil {
-		content.WriteString(WarningTextStyle.Render(SymbolWarning + " Application configuration not loaded."))
-		return content.String()
-	}
-
-	// Define keyStyle for rendering setting names (bolded for emphasis)
-	keyStyle := NormalTextStyle.Copy().Bold(true)
-
-	for i, setting := range m.editableSettings {
-		lineStyle := NormalTextStyle
-		focusMarker := SymbolNotFocused + " "
-		if !m.editingSetting && i == m.settingsFocusIndex {
-			lineStyle = ActiveTabStyle.Copy().BorderStyle(lipgloss.HiddenBorder())
-			focusMarker = SymbolFocused + " "
-		}
-
-		// Render the setting name (key) using keyStyle, which is already bolded for emphasis.
-		keyStr := keyStyle.Render(setting.Name + ":")
-		var valueStr string
-		if m.editingSetting && i == m.settingsFocusIndex {
-			valueStr = FocusedInputStyle.Render(m.currentEditValue + "_")
-		} else {
-			currentValDisplay := fmt.Sprintf("%v", setting.CurrentValue)
-			if setting.Type == "float" {
-				if fVal, ok := setting.CurrentValue.(float64); ok {
-					currentValDisplay = fmt.Sprintf("%.2f", fVal)
-					if setting.Path == "RiskThreshold" {
-						currentValDisplay += "%"
-					}
-				} else {
-					currentValDisplay = ErrorTextStyle.Render("N/A (float expected)")
-				}
-			}
-			if setting.IsSensitive { // Masking logic
-				if len(currentValDisplay) > 8 {
-					currentValDisplay = currentValDisplay[:4] + strings.Repeat("*", len(currentValDisplay)-8) + currentValDisplay[len(currentValDisplay)-4:]
-				} else if len(currentValDisplay) > 0 {
-					currentValDisplay = strings.Repeat("*", len(currentValDisplay))
-				}
-			}
-			valueStr = NormalTextStyle.Render(currentValDisplay)
-		}
-
-		content.WriteString(lineStyle.Render(focusMarker+keyStr+" "+valueStr) + "\n")
-	}
-
-	content.WriteString(HelpTextStyle.Render("\n\n(Navigate with ↑/↓, Enter to Edit/Confirm, Esc to Cancel. Ctrl+S to Save, Ctrl+R to Reload from file.)"))
-	return content.String()
-}
 func (m Model) View() string {
-	helpKeyStyle := HelpTextStyle.Copy().Bold(true)
 	headerView := m.renderHeader()
 	tabBarView := m.renderTabBar()
 	var currentTabView strings.Builder
 	currentTabView.WriteString(NormalTextStyle.Render(m.sessionStatus + "\n"))
-
-	switch m.activeTab {
-	case TargetInputTab:
-		currentTabView.WriteString(HeaderStyle.Render(SymbolListItem+" Target Input") + "\n")
-		urlLabel := NormalTextStyle.Render(SymbolInputMarker + " Target URL")
-		var urlInputView string
-		urlInputDisplay := m.targetURLInput
-		if m.inputFocus == 0 {
-			urlInputDisplay += "_"
-			urlInputView = FocusedInputStyle.Render(SymbolFocused + " " + urlInputDisplay)
-		} else {
-			urlInputView = BlurredInputStyle.Render(SymbolNotFocused + " " + urlInputDisplay)
-		}
-		currentTabView.WriteString(urlLabel + "\n" + urlInputView + "\n\n")
-		numReportsLabel := NormalTextStyle.Render(SymbolInputMarker + " Number of Reports")
-		var numReportsInputView string
-		numReportsInputDisplay := m.numReportsInput
-		if m.inputFocus == 1 {
-			numReportsInputDisplay += "_"
-			numReportsInputView = FocusedInputStyle.Render(SymbolFocused + " " + numReportsInputDisplay)
-		} else {
-			numReportsInputView = BlurredInputStyle.Render(SymbolNotFocused + " " + numReportsInputDisplay)
-		}
-		currentTabView.WriteString(numReportsLabel + "\n" + numReportsInputView + "\n\n")
-		helpText := "Tab: Switch Fields | Enter: Submit Report"
-		if m.session != nil {
-			sState, _, _, _, _, _ := m.session.GetStats()
-			if sState == session.Running || sState == session.Paused {
-				sessionHelp := lipgloss.JoinHorizontal(lipgloss.Left, HelpTextStyle.Render(SymbolPointer+" Session: "), helpKeyStyle.Bold(true).Render("P "), HelpTextStyle.Render("Pause | "), helpKeyStyle.Bold(true).Render("R "), HelpTextStyle.Render("Resume | "), helpKeyStyle.Bold(true).Render("A "), HelpTextStyle.Render("Abort"))
-				helpText += " | " + sessionHelp
-			}
-		}
-		currentTabView.WriteString(HelpTextStyle.Render("\n" + helpText))
-	case ProxyMgmtTab:
-		currentTabView.WriteString(HeaderStyle.Render(SymbolListItem+" Proxy Pool Status") + "\n\n")
-		if m.proxyManager != nil {
-			allProxies := m.proxyManager.GetAllProxies()
-			totalProxies := len(allProxies)
-			healthyCount := 0
-			unknownCount := 0
-			for _, p := range allProxies {
-				if p.HealthStatus == "healthy" {
-					healthyCount++
-				} else if p.HealthStatus == "unknown" {
-					unknownCount++
-				}
-			}
-			unhealthyCount := totalProxies - healthyCount - unknownCount
-			statsStyle := NormalTextStyle.Copy().PaddingBottom(0)
-			currentTabView.WriteString(statsStyle.Render(fmt.Sprintf("%s Total Proxies: %s", SymbolInfo, lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("%d", totalProxies)))) + "\n")
-			currentTabView.WriteString(statsStyle.Render(fmt.Sprintf("%s Healthy:       %s", SymbolSuccess, SuccessTextStyle.Render(fmt.Sprintf("%d", healthyCount)))) + "\n")
-			currentTabView.WriteString(statsStyle.Render(fmt.Sprintf("%s Unhealthy:     %s", SymbolFailure, ErrorTextStyle.Render(fmt.Sprintf("%d", unhealthyCount)))) + "\n")
-			if unknownCount > 0 {
-				currentTabView.WriteString(statsStyle.Render(fmt.Sprintf("%s Unknown:       %s", SymbolWarning, WarningTextStyle.Render(fmt.Sprintf("%d", unknownCount)))) + "\n")
-			}
-			currentTabView.WriteString("\n" + SubtleTextStyle.Render(SymbolInfo+" Initial health checks run in background. Statuses update over time.") + "\n")
-		} else {
-			currentTabView.WriteString(WarningTextStyle.Render(SymbolWarning+" Proxy Manager not initialized.") + "\n")
-		}
-		currentTabView.WriteString(HelpTextStyle.Render("\n(Detailed proxy list, manual checks, and import/export coming soon...)"))
-	case SettingsTab:
-		currentTabView.WriteString(m.renderSettingsView())
-	case LiveSessionLogsTab:
-		currentTabView.WriteString(HeaderStyle.Render(SymbolListItem+" Live Session Logs") + "\n")
-		maxLogsToShow := m.height - 12
-		if maxLogsToShow < 1 {
-			maxLogsToShow = 5
-		}
-		displayLogs := m.logMessages
-		if len(m.logMessages) > maxLogsToShow {
-			displayLogs = m.logMessages[len(m.logMessages)-maxLogsToShow:]
-		}
-		for _, styledMsg := range displayLogs {
-			currentTabView.WriteString(styledMsg + "\n")
-		}
-	case LogReviewTab:
-		currentTabView.WriteString(HeaderStyle.Render(SymbolListItem+" Log Review & Export") + "\n\n")
-		mainMessage := InfoTextStyle.Render(fmt.Sprintf("%s Log review and advanced export functionalities are currently under development.", SymbolInfo))
-		currentTabView.WriteString(mainMessage + "\n")
+	if banner := m.renderDegradedBanner(); banner != "" {
+		currentTabView.WriteString(banner + "\n")
 	}
+
+	currentTabView.WriteString(tabViews[m.activeTab].Render(m))
 	footerView := m.renderFooter()
 	contentHeight := m.height - lipgloss.Height(headerView) - lipgloss.Height(tabBarView) - lipgloss.Height(footerView) - BoxStyle.GetVerticalPadding()
 	if contentHeight < 0 {
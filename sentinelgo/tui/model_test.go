@@ -0,0 +1,789 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/events"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/report"
+	"sentinelgo/sentinelgo/session"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// newTestModel builds a Model wired to an httptest server acting as both proxy and target,
+// without touching the proxy files or starting background health checks like NewInitialModel does.
+func newTestModel(t *testing.T, handler http.HandlerFunc) (Model, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	proxyURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cfg := &config.AppConfig{MaxRetries: 1, RiskThreshold: 75, DefaultHeaders: map[string]string{}, APIKeys: map[string]string{}}
+	logger := utils.NewLogger(io.Discard, "INFO")
+	pm := proxy.NewProxyManager([]*proxy.ProxyInfo{{URL: proxyURL, HealthStatus: "healthy"}}, proxy.StrategyRoundRobin, true)
+
+	m := Model{
+		activeTab:        TargetInputTab,
+		tabsDisplayNames: tabNames,
+		appConfig:        cfg,
+		logger:           logger,
+		proxyManager:     pm,
+		reporter:         report.NewReporter(cfg, pm, logger, nil),
+		numReportsInput:  "1",
+	}
+	m.populateEditableSettings()
+	return m, server.URL + "/report"
+}
+
+// keyRunes builds a tea.KeyMsg for typed characters.
+func keyRunes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// runCmd executes cmd and returns the produced messages, expanding tea.Batch results.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestTestConnectionCmd(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	msg := testConnectionCmd(m.reporter, target)()
+	result, ok := msg.(testConnectionMsg)
+	require.True(t, ok, "command should produce a testConnectionMsg")
+	require.NoError(t, result.err)
+	assert.Equal(t, http.StatusAccepted, result.result.StatusCode)
+	assert.Equal(t, target, result.targetURL)
+}
+
+func TestTestConnectionCmd_DryRun(t *testing.T) {
+	var hits atomic.Int32
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	})
+	m.appConfig.DryRun = true
+
+	msg := testConnectionCmd(m.reporter, target)()
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+	assert.Zero(t, hits.Load(), "a dry run sends nothing")
+	assert.Contains(t, m.testConnectionStatus, "Dry run, nothing sent")
+}
+
+func TestUpdate_TestConnectionKeyAndResult(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// With an empty URL the action is rejected without a command.
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(Model)
+	assert.Error(t, m.err)
+	assert.Nil(t, cmd)
+
+	m.targetURLInput = target
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(Model)
+	require.NotNil(t, cmd)
+	assert.Contains(t, m.testConnectionStatus, "Testing connection")
+	assert.Nil(t, m.session, "testing the connection must not start a session")
+
+	msgs := runCmd(cmd)
+	require.Len(t, msgs, 1)
+	updated, _ = m.Update(msgs[0])
+	m = updated.(Model)
+	assert.Contains(t, m.testConnectionStatus, "Status: 200")
+	assert.Contains(t, m.testConnectionStatus, "Proxy: ")
+	assert.True(t, strings.Contains(m.logMessages[len(m.logMessages)-1], "Test connection to "+target))
+	assert.Equal(t, target, m.targetURLInput, "the entered URL should be kept after testing")
+}
+
+func TestUpdate_TestConnectionFailure(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	updated, _ := m.Update(testConnectionMsg{targetURL: "http://dead.invalid", err: assert.AnError})
+	m = updated.(Model)
+	assert.Contains(t, m.testConnectionStatus, "Test failed")
+}
+
+func TestView_DegradedBanner(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.width, m.height = 200, 50
+	assert.NotContains(t, m.View(), "All proxies are unhealthy", "no banner while a healthy proxy remains")
+
+	for _, p := range m.proxyManager.GetAllProxies() {
+		require.NoError(t, m.proxyManager.UpdateProxyStatus(p.URL.String(), "unhealthy", 0))
+	}
+	assert.Contains(t, m.View(), "All proxies are unhealthy")
+}
+
+func TestNewInitialModel_MissingProxyFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.csv")
+	newCfg := func(required bool) *config.AppConfig {
+		return &config.AppConfig{MaxRetries: 1, DefaultHeaders: map[string]string{"ProxyFile": missing}, RequireProxyFile: required}
+	}
+	logger := utils.NewLogger(io.Discard, "INFO")
+
+	t.Run("warns by default", func(t *testing.T) {
+		m := NewInitialModel(newCfg(false), logger)
+		assert.NoError(t, m.StartupError())
+		assert.Empty(t, m.proxyManager.GetAllProxies())
+		m.width, m.height = 200, 50
+		assert.Contains(t, m.View(), "No proxies loaded from "+missing)
+	})
+
+	t.Run("fails when required", func(t *testing.T) {
+		m := NewInitialModel(newCfg(true), logger)
+		err := m.StartupError()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.Contains(t, err.Error(), "requireproxyfile")
+	})
+}
+
+func TestNewInitialModel_RestoresHealthSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	proxyFile := filepath.Join(dir, "proxies.csv")
+	require.NoError(t, os.WriteFile(proxyFile, []byte("10.0.0.1,8080,user,secret\n10.0.0.2,8080\n"), 0600))
+
+	// The previous run checked the first proxy and saved the results with Ctrl+S.
+	previous, err := proxy.LoadProxies(proxyFile)
+	require.NoError(t, err)
+	checked := time.Now().Add(-time.Minute).Truncate(time.Second)
+	previous[0].HealthStatus, previous[0].Latency, previous[0].LastChecked = "healthy", 80*time.Millisecond, checked
+	snapshots := filepath.Join(dir, "snapshots")
+	require.NoError(t, os.Mkdir(snapshots, 0700))
+	_, err = proxy.NewProxyManager(previous, proxy.StrategyRoundRobin, true).SaveHealth(filepath.Join(snapshots, "proxy-health-20240501-120000.json"))
+	require.NoError(t, err)
+
+	cfg := &config.AppConfig{MaxRetries: 1, DefaultHeaders: map[string]string{"ProxyFile": proxyFile}, HealthSnapshot: snapshots}
+	m := NewInitialModel(cfg, utils.NewLogger(io.Discard, "INFO"))
+	proxies := m.proxyManager.GetAllProxies()
+	require.Len(t, proxies, 2)
+	assert.Equal(t, "healthy", proxies[0].HealthStatus)
+	assert.True(t, checked.Equal(proxies[0].LastChecked))
+	assert.NotEqual(t, "healthy", proxies[1].HealthStatus, "a proxy the previous run did not check stays unchecked")
+	assert.Contains(t, strings.Join(m.logMessages, "\n"), "Restored the health of 1 proxies")
+
+	cfg.HealthSnapshot = filepath.Join(dir, "missing")
+	m = NewInitialModel(cfg, utils.NewLogger(io.Discard, "INFO"))
+	assert.Contains(t, strings.Join(m.logMessages, "\n"), "Proxy health not restored")
+	assert.NoError(t, m.StartupError())
+}
+
+func TestParseHealthCheckParams(t *testing.T) {
+	tests := []struct {
+		name            string
+		timeout         string
+		concurrency     string
+		wantTimeout     time.Duration
+		wantConcurrency int
+		wantErr         bool
+	}{
+		{"defaults", "10", "5", 10 * time.Second, 5, false},
+		{"fractional timeout", "2.5", "1", 2500 * time.Millisecond, 1, false},
+		{"surrounding spaces", " 3 ", " 20 ", 3 * time.Second, 20, false},
+		{"max bounds", "120", "100", 2 * time.Minute, 100, false},
+		{"empty timeout", "", "5", 0, 0, true},
+		{"zero timeout", "0", "5", 0, 0, true},
+		{"timeout too long", "121", "5", 0, 0, true},
+		{"non-numeric timeout", "abc", "5", 0, 0, true},
+		{"empty concurrency", "10", "", 0, 0, true},
+		{"zero concurrency", "10", "0", 0, 0, true},
+		{"concurrency too high", "10", "101", 0, 0, true},
+		{"fractional concurrency", "10", "1.5", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, concurrency, err := parseHealthCheckParams(tt.timeout, tt.concurrency)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTimeout, timeout)
+			assert.Equal(t, tt.wantConcurrency, concurrency)
+		})
+	}
+}
+
+func TestUpdate_RerunHealthCheck(t *testing.T) {
+	// The server acts as the proxy and answers the forwarded health check request.
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	m.activeTab = ProxyMgmtTab
+	m.healthCheckTimeoutInput = ""
+	m.healthCheckConcurrencyInput = "3"
+
+	// Typing filters to digits and a decimal point for the timeout field.
+	updated, _ := m.Update(keyRunes("1x.5"))
+	m = updated.(Model)
+	assert.Equal(t, "1.5", m.healthCheckTimeoutInput)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	updated, _ = m.Update(keyRunes(".2"))
+	m = updated.(Model)
+	assert.Equal(t, "32", m.healthCheckConcurrencyInput, "concurrency accepts digits only")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = updated.(Model)
+	require.NoError(t, m.err)
+	assert.True(t, m.healthCheckRunning)
+
+	var done *healthCheckDoneMsg
+	for _, msg := range runCmd(cmd) {
+		if d, ok := msg.(healthCheckDoneMsg); ok {
+			done = &d
+		}
+	}
+	require.NotNil(t, done, "re-run should produce a healthCheckDoneMsg")
+	assert.Equal(t, 1, done.result.Checked)
+	assert.Equal(t, 1, done.result.Healthy)
+
+	updated, _ = m.Update(*done)
+	m = updated.(Model)
+	assert.False(t, m.healthCheckRunning)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Health check completed")
+}
+
+func TestInit_RunsInitialHealthCheck(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	assert.Nil(t, m.Init(), "nothing to do without an initial check")
+
+	m.healthCheckRunning = true
+	m.spinnerActive = true
+	msgs := runCmd(m.Init())
+	var done *healthCheckDoneMsg
+	for _, msg := range msgs {
+		if d, ok := msg.(healthCheckDoneMsg); ok {
+			done = &d
+		}
+	}
+	require.NotNil(t, done, "Init should run the initial health check")
+	assert.True(t, done.initial)
+	assert.Equal(t, 1, done.result.Healthy)
+
+	for _, msg := range msgs {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	assert.False(t, m.healthCheckRunning)
+	assert.False(t, m.spinnerActive)
+	assert.Contains(t, strings.Join(m.logMessages, "\n"), "Initial proxy health check completed")
+}
+
+func TestInit_GeoIPLookupRunsInBackground(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	unlocated := *m.proxyManager.GetAllProxies()[0]
+	var lookupCtx context.Context
+	m.geoIP = &geoIPLookup{
+		proxies: []*proxy.ProxyInfo{&unlocated},
+		lookup: func(ctx context.Context, host string) (string, error) {
+			lookupCtx = ctx
+			return "DE", nil
+		},
+		source: "GeoLite2-Country.mmdb",
+	}
+
+	msgs := runCmd(m.Init())
+	require.Len(t, msgs, 1)
+	require.NotNil(t, lookupCtx)
+	_, hasDeadline := lookupCtx.Deadline()
+	assert.True(t, hasDeadline, "lookups are bounded by the startup timeout")
+	assert.Empty(t, m.proxyManager.GetAllProxies()[0].Region, "the pool is only changed by Update")
+
+	updated, _ := m.Update(msgs[0])
+	m = updated.(Model)
+	assert.Equal(t, "DE", m.proxyManager.GetAllProxies()[0].Region)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Applied 1 GeoIP regions from GeoLite2-Country.mmdb.")
+}
+
+func TestUpdate_StaleRecheckTick(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	updated, cmd := m.Update(staleRecheckTickMsg{})
+	m = updated.(Model)
+	assert.Nil(t, cmd, "ticks stop while health results never expire")
+	assert.False(t, m.staleRecheckActive)
+
+	m.proxyManager.MaxHealthyAge = 2 * time.Second
+	p := m.proxyManager.GetAllProxies()[0]
+	p.LastChecked = time.Now().Add(-time.Hour)
+
+	updated, cmd = m.Update(staleRecheckTickMsg{})
+	m = updated.(Model)
+	assert.True(t, m.healthCheckRunning)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Rechecking 1 proxies whose healthy status expired")
+
+	var done *healthCheckDoneMsg
+	var ticked bool
+	for _, msg := range runCmd(cmd) {
+		switch msg := msg.(type) {
+		case healthCheckDoneMsg:
+			done = &msg
+		case staleRecheckTickMsg:
+			ticked = true
+		}
+	}
+	assert.True(t, ticked, "the next tick is scheduled")
+	require.NotNil(t, done)
+	assert.True(t, done.recheck)
+	assert.Equal(t, 1, done.result.Checked)
+	assert.Equal(t, 1, done.result.Healthy)
+	assert.Equal(t, "healthy", p.HealthStatus)
+	assert.WithinDuration(t, time.Now(), p.LastChecked, time.Minute)
+
+	updated, _ = m.Update(*done)
+	m = updated.(Model)
+	assert.False(t, m.healthCheckRunning)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Stale proxy recheck completed")
+	assert.Empty(t, m.proxyManager.ProxiesNeedingRecheck())
+}
+
+func TestUpdate_HealthCheckDoneReportsDiff(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.healthCheckRunning = true
+
+	diff := proxy.DiffSnapshots(
+		proxy.PoolSnapshot{"http://a:1": "unhealthy", "http://b:1": "healthy", "http://c:1": "healthy"},
+		proxy.PoolSnapshot{"http://a:1": "healthy", "http://b:1": "unhealthy", "http://c:1": "unhealthy"},
+	)
+	updated, _ := m.Update(healthCheckDoneMsg{result: proxy.BatchCheckResult{Checked: 3, Healthy: 1, Revived: 1, Elapsed: 1500 * time.Millisecond}, diff: diff})
+	m = updated.(Model)
+	assert.False(t, m.healthCheckRunning)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Health check completed in 1.5s: 1/3 proxies healthy. Revived 1 dead proxies.")
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Since last check: 1 proxy recovered, 2 died.")
+
+	updated, _ = m.Update(healthCheckDoneMsg{result: proxy.BatchCheckResult{Checked: 3, Healthy: 1}})
+	m = updated.(Model)
+	assert.NotContains(t, m.logMessages[len(m.logMessages)-1], "Since last check", "an unchanged pool adds no diff summary")
+}
+
+func TestUpdate_HealthCheckDoneWarnsPoolTooSmall(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.appConfig.TargetRPS, m.appConfig.PerProxyRPS = 3, 2
+
+	updated, _ := m.Update(healthCheckDoneMsg{result: proxy.BatchCheckResult{Checked: 1, Healthy: 1}})
+	m = updated.(Model)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Pool too small for requested rate: 3 req/s at 2 req/s per proxy needs 2 healthy proxies, 1 are healthy.")
+
+	m.appConfig.TargetRPS = 2
+	updated, _ = m.Update(healthCheckDoneMsg{result: proxy.BatchCheckResult{Checked: 1, Healthy: 1}})
+	m = updated.(Model)
+	assert.NotContains(t, m.logMessages[len(m.logMessages)-1], "Pool too small", "one healthy proxy carries 2 req/s")
+}
+
+func TestUpdate_RerunHealthCheckInvalidParams(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.activeTab = ProxyMgmtTab
+	m.healthCheckTimeoutInput = "0"
+	m.healthCheckConcurrencyInput = "5"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = updated.(Model)
+	assert.Error(t, m.err)
+	assert.False(t, m.healthCheckRunning)
+	for _, msg := range runCmd(cmd) {
+		_, isDone := msg.(healthCheckDoneMsg)
+		assert.False(t, isDone, "no health check should be started")
+	}
+}
+
+func TestUpdate_SessionLogMirroredToFile(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	var buf bytes.Buffer
+	m.logger = utils.NewLogger(&buf, "INFO")
+	trackTestSession(&m, session.NewSession(m.reporter, "http://example.com/report", 1))
+
+	update := session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session paused.", Timestamp: time.Now()}
+	updated, _ := m.Update(sessionLogMsg{sessionID: m.session.ID, update: update})
+	m = updated.(Model)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Session paused.")
+
+	var entry utils.LogEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry), "exactly one file entry should be written")
+	assert.Equal(t, "Session paused.", entry.Message)
+	assert.Equal(t, "WARN", entry.Level)
+	assert.Equal(t, "session_update", entry.Outcome)
+	assert.Equal(t, m.session.ID, entry.SessionID)
+	assert.Equal(t, update.Timestamp.UTC().Format(time.RFC3339Nano), entry.AdditionalData["update_time"])
+}
+
+func TestListenForSessionLogsCmd_ClosedChannelIsTerminal(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	s := session.NewSession(m.reporter, "http://example.com/report", 1)
+	s.LogChannel <- session.LogUpdate{Level: session.LogLevelUpdateInfo, Message: "Session log channel closed by sender."}
+	close(s.LogChannel)
+
+	msg := m.listenForSessionLogsCmd(s)().(sessionLogMsg)
+	assert.False(t, msg.update.Terminal, "updates sent by the session are never terminal, whatever their text")
+	assert.Equal(t, s.ID, msg.sessionID)
+	msg = m.listenForSessionLogsCmd(s)().(sessionLogMsg)
+	assert.True(t, msg.update.Terminal, "the closed channel yields a terminal update")
+	assert.Equal(t, s.ID, msg.sessionID)
+
+	msg = m.listenForSessionLogsCmd(nil)().(sessionLogMsg)
+	assert.True(t, msg.update.Terminal, "there is nothing to listen to without a session")
+}
+
+func TestUpdate_SessionLogTerminalStopsListening(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	s := session.NewSession(m.reporter, "http://example.com/report", 1)
+	trackTestSession(&m, s)
+
+	updated, cmd := m.Update(sessionLogMsg{sessionID: s.ID, update: session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session log channel closed by sender.", Timestamp: time.Now()}})
+	m = updated.(Model)
+	assert.NotNil(t, cmd, "a non-terminal update keeps the listener running")
+
+	updated, cmd = m.Update(sessionLogMsg{sessionID: s.ID, update: session.LogUpdate{Level: session.LogLevelUpdateInfo, Message: "anything", Timestamp: time.Now(), Terminal: true}})
+	m = updated.(Model)
+	assert.Nil(t, cmd, "a terminal update stops the listener")
+	assert.Empty(t, m.sessions, "the finished session is no longer tracked")
+	assert.Same(t, s, m.session, "the finished session stays focused for export")
+	assert.Contains(t, m.sessionStatus, "Session:")
+}
+
+// trackTestSession makes s a tracked, focused session of m without starting it, as
+// startTUISession does for a started one.
+func trackTestSession(m *Model, s *session.Session) {
+	m.sessions = append(m.sessions, s)
+	m.session = s
+}
+
+func TestUpdate_SessionLogsRoutedBySessionID(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	var buf bytes.Buffer
+	m.logger = utils.NewLogger(&buf, "INFO")
+	m.appConfig.MaxConcurrentSessions = 2
+	a := session.NewSession(m.reporter, "http://example.com/a", 1)
+	b := session.NewSession(m.reporter, "http://example.com/b", 1)
+	trackTestSession(&m, b)
+	trackTestSession(&m, a) // a is focused.
+
+	// An update from b is attributed to b, whichever session is focused.
+	updated, cmd := m.Update(sessionLogMsg{sessionID: b.ID, update: session.LogUpdate{Level: session.LogLevelUpdateInfo, Message: "from b", Timestamp: time.Now()}})
+	m = updated.(Model)
+	assert.NotNil(t, cmd)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "["+shortSessionID(b.ID)+"] from b")
+	var entry utils.LogEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.Equal(t, b.ID, entry.SessionID)
+	assert.Equal(t, "http://example.com/b", entry.ReportURL)
+
+	// The listener re-armed for the update reads from b's channel, not the focused session's.
+	b.LogChannel <- session.LogUpdate{Level: session.LogLevelUpdateInfo, Message: "next from b"}
+	msgs := runCmd(cmd)
+	require.Len(t, msgs, 1)
+	next := msgs[0].(sessionLogMsg)
+	assert.Equal(t, b.ID, next.sessionID)
+	assert.Equal(t, "next from b", next.update.Message)
+
+	// b finishing stops tracking b only; a stays tracked and focused.
+	updated, cmd = m.Update(sessionLogMsg{sessionID: b.ID, update: session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session ended.", Timestamp: time.Now(), Terminal: true}})
+	m = updated.(Model)
+	assert.Nil(t, cmd)
+	assert.Equal(t, []*session.Session{a}, m.sessions)
+	assert.Same(t, a, m.session)
+	assert.Contains(t, m.sessionStatus, "["+shortSessionID(a.ID)+"]")
+}
+
+func TestUpdate_ConcurrentSessionsUpToLimit(t *testing.T) {
+	release := make(chan struct{})
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	m.appConfig.MaxConcurrentSessions = 2
+
+	m, firstCmd := submitTarget(t, m, target+"?first")
+	require.NoError(t, m.err)
+	first := m.session
+	m, secondCmd := submitTarget(t, m, target+"?second")
+	require.NoError(t, m.err)
+	second := m.session
+	require.NotSame(t, first, second, "a second session starts while the first runs")
+	assert.Equal(t, []*session.Session{first, second}, m.sessions)
+	assert.Contains(t, m.sessionStatus, "Active: 2/2")
+
+	m, cmd := submitTarget(t, m, target+"?third")
+	assert.EqualError(t, m.err, "session limit reached: 2 sessions active")
+	assert.Nil(t, cmd)
+	assert.Len(t, m.sessions, 2)
+
+	// Tab on the Live Session Logs tab moves the focus, and with it the session controls.
+	m.activeTab = LiveSessionLogsTab
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	assert.Same(t, first, m.session)
+	updated, _ = m.Update(keyRunes("p"))
+	m = updated.(Model)
+	require.NoError(t, m.err)
+	assert.Eventually(t, func() bool { return first.GetStateValue() == session.Paused }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, session.Running, second.GetStateValue(), "only the focused session is paused")
+
+	// Both sessions' logs are delivered by their own listeners until each channel closes.
+	close(release)
+	require.NoError(t, first.Resume())
+	for pending := []tea.Cmd{firstCmd, secondCmd}; len(pending) > 0; pending = pending[1:] {
+		for _, msg := range runCmd(pending[0]) {
+			if logMsg, ok := msg.(sessionLogMsg); ok {
+				updated, next := m.Update(logMsg)
+				m = updated.(Model)
+				pending = append(pending, next)
+			}
+		}
+	}
+	assert.Empty(t, m.sessions)
+	assert.Equal(t, session.Completed, first.GetStateValue())
+	assert.Equal(t, session.Completed, second.GetStateValue())
+}
+
+func TestUpdate_SessionKeysTypedIntoTextFields(t *testing.T) {
+	release := make(chan struct{})
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	defer close(release)
+	m, _ = submitTarget(t, m, target)
+	require.NotNil(t, m.session)
+
+	// On the Log Review & Export tab, P/R/A and 'q' are filter text, not session control or quit.
+	m.activeTab = LogReviewTab
+	for _, key := range []string{"p", "a", "q"} {
+		updated, cmd := m.Update(keyRunes(key))
+		m = updated.(Model)
+		assert.Empty(t, runCmd(cmd), "no command for %q", key)
+	}
+	assert.Equal(t, "paq", m.logReview.inputs[logReviewLevelField])
+	assert.Equal(t, session.Running, m.session.GetStateValue())
+}
+
+func TestTargetQueue(t *testing.T) {
+	var q targetQueue
+	_, ok := q.Pop()
+	assert.False(t, ok, "an empty queue has nothing to pop")
+
+	assert.Equal(t, 1, q.Push(queuedTarget{targetURL: "http://a", numReports: 1}))
+	assert.Equal(t, 2, q.Push(queuedTarget{targetURL: "http://b", numReports: 2}))
+	assert.Equal(t, 2, q.Len())
+
+	first, ok := q.Pop()
+	require.True(t, ok)
+	assert.Equal(t, queuedTarget{targetURL: "http://a", numReports: 1}, first, "targets are dequeued in submission order")
+	second, ok := q.Pop()
+	require.True(t, ok)
+	assert.Equal(t, "http://b", second.targetURL)
+	assert.Zero(t, q.Len())
+}
+
+// submitTarget types a target URL into a model on the Target Input tab and presses Enter.
+func submitTarget(t *testing.T, m Model, targetURL string) (Model, tea.Cmd) {
+	t.Helper()
+	m.activeTab = TargetInputTab
+	m.inputFocus = 0
+	m.targetURLInput = targetURL
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return updated.(Model), cmd
+}
+
+func TestUpdate_DuplicateSubmitRejectedByDefault(t *testing.T) {
+	release := make(chan struct{})
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	defer close(release)
+
+	m, _ = submitTarget(t, m, target)
+	require.NoError(t, m.err)
+	first := m.session
+
+	m, _ = submitTarget(t, m, target+"?again")
+	assert.EqualError(t, m.err, "session already active")
+	assert.Same(t, first, m.session)
+	assert.Zero(t, m.targetQueue.Len(), "reject mode must not queue the submission")
+}
+
+func TestUpdate_SubmittedSessionPublishesEvents(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv, err := events.Serve("127.0.0.1:0")
+	require.NoError(t, err)
+	defer srv.Close()
+	m.SetEventServer(srv)
+
+	m, _ = submitTarget(t, m, target)
+	require.NoError(t, m.err)
+	assert.NotNil(t, m.session.OnStateChange, "the session is watched by the event server")
+	assert.NotNil(t, m.session.OnJobComplete)
+	require.NoError(t, m.session.Wait(context.Background()))
+}
+
+func TestUpdate_SubmittedSessionNeverBlocksOnLogs(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	m.appConfig.LogChannelPolicy = session.LogPolicyBlock
+
+	// Update reads the session's progress between two log updates, so a session blocked on a
+	// full LogChannel, holding its lock, would freeze the UI.
+	m, _ = submitTarget(t, m, target)
+	require.NoError(t, m.err)
+	assert.Equal(t, session.LogPolicyDropNewest, m.session.LogPolicy)
+	assert.Contains(t, strings.Join(m.logMessages, "\n"), `Log channel policy "block" is not supported by the TUI`)
+	go func() {
+		for range m.session.LogChannel {
+		}
+	}()
+	require.NoError(t, m.session.Wait(context.Background()))
+}
+
+func TestUpdate_DuplicateSubmitQueued(t *testing.T) {
+	release := make(chan struct{})
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	m.appConfig.QueueTargets = true
+
+	m, _ = submitTarget(t, m, target)
+	require.NoError(t, m.err)
+	first := m.session
+
+	m, cmd := submitTarget(t, m, target+"?queued")
+	require.NoError(t, m.err)
+	assert.Nil(t, cmd)
+	assert.Same(t, first, m.session, "the active session keeps running")
+	assert.Equal(t, 1, m.targetQueue.Len())
+	assert.Empty(t, m.targetURLInput)
+
+	// Let the first session finish, then deliver the channel-closed notice the listener would send.
+	close(release)
+	for range first.LogChannel {
+	}
+	updated, cmd := m.Update(sessionLogMsg{sessionID: first.ID, update: session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session ended.", Timestamp: time.Now(), Terminal: true}})
+	m = updated.(Model)
+	require.NotNil(t, cmd, "the queued target should start and be listened to")
+	require.NotSame(t, first, m.session)
+	assert.Equal(t, target+"?queued", m.session.TargetURL)
+	assert.Zero(t, m.targetQueue.Len())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		for range m.session.LogChannel {
+		}
+	}()
+	require.NoError(t, m.session.Wait(ctx))
+}
+
+func TestSessionStatusLine(t *testing.T) {
+	p := session.SessionProgress{
+		State: session.Running, Target: "http://example.com/a/very/long/report/path", Total: 10,
+		Attempted: 5, Successful: 3, Failed: 1, Percent: 40, ETA: 15 * time.Second,
+	}
+	line := sessionStatusLine(p)
+	assert.Contains(t, line, "Reports: 5/10 (40%)")
+	assert.Contains(t, line, "ETA: 15s")
+	assert.Contains(t, line, "http://example.com/a/very/l...", "long targets are truncated")
+
+	p.ETA = 0
+	assert.NotContains(t, sessionStatusLine(p), "ETA")
+	assert.NotContains(t, sessionStatusLine(p), "Dropped logs")
+
+	p.DroppedLogs = 7
+	assert.Contains(t, sessionStatusLine(p), "Dropped logs: 7")
+
+	assert.NotContains(t, sessionStatusLine(p), "DRY RUN")
+	p.Label, p.DryRun = "evening-batch", true
+	assert.Contains(t, sessionStatusLine(p), "(evening-batch)")
+	assert.Contains(t, sessionStatusLine(p), "[DRY RUN]")
+}
+
+func TestSessionBreaker(t *testing.T) {
+	failed := session.SessionProgress{State: session.Completed, Failed: 2}
+	succeeded := session.SessionProgress{State: session.Completed, Successful: 1, Failed: 1}
+	aborted := session.SessionProgress{State: session.Aborted}
+
+	var b sessionBreaker
+	assert.False(t, b.Record(failed, 3))
+	assert.False(t, b.Record(failed, 3))
+	assert.False(t, b.Record(aborted, 3), "a user abort is neither a failure nor a success")
+	assert.False(t, b.Tripped())
+	assert.True(t, b.Record(failed, 3), "the third consecutive failure trips the breaker")
+	assert.True(t, b.Tripped())
+	assert.False(t, b.Record(failed, 3), "the breaker only reports tripping once")
+	assert.Equal(t, 4, b.ConsecutiveFailures())
+
+	assert.False(t, b.Record(succeeded, 3))
+	assert.False(t, b.Tripped(), "a successful session resets the breaker")
+	assert.Zero(t, b.ConsecutiveFailures())
+
+	var disabled sessionBreaker
+	for i := 0; i < 5; i++ {
+		assert.False(t, disabled.Record(failed, 0))
+	}
+	assert.False(t, disabled.Tripped())
+}
+
+func TestUpdate_BreakerHoldsQueuedTargets(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized) // Every report fails, as with expired credentials.
+	})
+	m.appConfig.QueueTargets = true
+	m.appConfig.MaxConsecutiveSessionFailures = 1
+	m, _ = submitTarget(t, m, target)
+	require.NoError(t, m.err)
+	first := m.session
+	closed := sessionLogMsg{sessionID: first.ID, update: session.LogUpdate{Level: session.LogLevelUpdateWarn, Message: "Session ended.", Timestamp: time.Now(), Terminal: true}}
+	m.targetQueue.Push(queuedTarget{targetURL: target + "?queued", numReports: 1})
+	for range first.LogChannel {
+	}
+
+	updated, cmd := m.Update(closed)
+	m = updated.(Model)
+	assert.Nil(t, cmd)
+	assert.Same(t, first, m.session, "the queued target must not start once the breaker trips")
+	assert.Equal(t, 1, m.targetQueue.Len())
+	require.Error(t, m.err)
+	assert.Contains(t, m.err.Error(), "1 consecutive sessions failed")
+}
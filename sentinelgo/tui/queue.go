@@ -0,0 +1,76 @@
+package tui
+
+import "sentinelgo/sentinelgo/session"
+
+// queuedTarget is a Target Input submission waiting for the active session to finish.
+type queuedTarget struct {
+	targetURL  string // URL to report.
+	numReports int    // Number of reports to send.
+	label      string // Label of the session, if any (see session.Session.Label).
+}
+
+// targetQueue is a FIFO of submissions made while a session was active, used when
+// AppConfig.QueueTargets is enabled. The zero value is an empty queue.
+type targetQueue struct {
+	items []queuedTarget
+}
+
+// Push appends t to the end of the queue and returns its 1-based position.
+func (q *targetQueue) Push(t queuedTarget) int {
+	q.items = append(q.items, t)
+	return len(q.items)
+}
+
+// Pop removes and returns the oldest queued target. ok is false if the queue is empty.
+func (q *targetQueue) Pop() (t queuedTarget, ok bool) {
+	if len(q.items) == 0 {
+		return queuedTarget{}, false
+	}
+	t = q.items[0]
+	q.items = q.items[1:]
+	return t, true
+}
+
+// Len returns the number of queued targets.
+func (q *targetQueue) Len() int {
+	return len(q.items)
+}
+
+// sessionBreaker stops queued targets from starting automatically after too many consecutive
+// failed sessions (e.g., expired credentials failing every report), so a bad run does not burn
+// through the whole queue. The zero value is closed (not tripped).
+type sessionBreaker struct {
+	consecutiveFailures int  // Failed sessions since the last successful one.
+	tripped             bool // Whether queued targets are held back.
+}
+
+// Record updates the breaker with a finished session's progress and reports whether this session
+// tripped it. A session with any successful report resets the breaker. A session that ended with
+// none counts as a failure, unless it was aborted by the user, which counts as neither.
+// The breaker trips once limit consecutive sessions have failed; limit <= 0 disables it.
+func (b *sessionBreaker) Record(p session.SessionProgress, limit int) bool {
+	switch {
+	case p.Successful > 0:
+		b.consecutiveFailures = 0
+		b.tripped = false
+		return false
+	case p.State == session.Aborted:
+		return false
+	}
+	b.consecutiveFailures++
+	if limit > 0 && b.consecutiveFailures >= limit && !b.tripped {
+		b.tripped = true
+		return true
+	}
+	return false
+}
+
+// Tripped reports whether queued targets are currently held back.
+func (b *sessionBreaker) Tripped() bool {
+	return b.tripped
+}
+
+// ConsecutiveFailures returns the number of failed sessions since the last successful one.
+func (b *sessionBreaker) ConsecutiveFailures() int {
+	return b.consecutiveFailures
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sentinelgo/sentinelgo/config"
+)
+
+// Fields of AppConfig whose map entries are editable on the Settings tab. An entry's
+// EditableSettingEntry.Path is the field name, a dot and the map key (e.g.
+// "DefaultHeaders.User-Agent"); the field name alone is the entry that adds a new key.
+const (
+	settingsHeadersField = "DefaultHeaders"
+	settingsAPIKeysField = "APIKeys"
+)
+
+// settingsMapEntryType is the EditableSettingEntry.Type of map entries and of the entries that
+// add them.
+const settingsMapEntryType = "map"
+
+// splitSettingPath splits a map setting's Path into the AppConfig field and the map key. The key
+// is everything after the first dot, so it may contain dots itself; it is empty for the entry
+// that adds a new key.
+func splitSettingPath(path string) (field, key string) {
+	field, key, _ = strings.Cut(path, ".")
+	return field, key
+}
+
+// mapSettingEntries returns the Settings tab entries for the DefaultHeaders and APIKeys maps of
+// cfg: one per key, sorted by key, each map followed by the entry that adds a key to it. API keys
+// and credential-bearing headers (see config.IsSensitiveHeader) are masked.
+func mapSettingEntries(cfg *config.AppConfig) []EditableSettingEntry {
+	var entries []EditableSettingEntry
+	for _, m := range []struct {
+		field, label string
+		values       map[string]string
+	}{
+		{settingsHeadersField, "Header", cfg.DefaultHeaders},
+		{settingsAPIKeysField, "API Key", cfg.APIKeys},
+	} {
+		keys := make([]string, 0, len(m.values))
+		for key := range m.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			entries = append(entries, EditableSettingEntry{
+				Name:         m.label + " " + key,
+				Path:         m.field + "." + key,
+				Type:         settingsMapEntryType,
+				CurrentValue: m.values[key],
+				IsSensitive:  m.field == settingsAPIKeysField || config.IsSensitiveHeader(key),
+			})
+		}
+		entries = append(entries, EditableSettingEntry{
+			Name:         "+ Add " + m.label + " (name=value)",
+			Path:         m.field,
+			Type:         settingsMapEntryType,
+			CurrentValue: "",
+			IsSensitive:  m.field == settingsAPIKeysField, // The value is typed along with the name.
+		})
+	}
+	return entries
+}
+
+// applyMapSetting applies an edit of the map setting at path to cfg and returns the Path of the
+// entry it changed. For an existing key, value replaces the key's value, and an empty value
+// removes the key. For the entry that adds a key (a path without one), value is "name=value";
+// an existing name is overwritten. Header names must be valid (see config.ValidHeaderName).
+// The map is replaced rather than modified (see config.AppConfig.SetDefaultHeader), as running
+// sessions may be reading it.
+func applyMapSetting(cfg *config.AppConfig, path, value string) (string, error) {
+	field, key := splitSettingPath(path)
+	if field != settingsHeadersField && field != settingsAPIKeysField {
+		return "", fmt.Errorf("unknown setting '%s'", path)
+	}
+	if key == "" {
+		name, newValue, found := strings.Cut(value, "=")
+		key, value = strings.TrimSpace(name), strings.TrimSpace(newValue)
+		if !found || key == "" {
+			return "", fmt.Errorf("enter the new entry as name=value")
+		}
+		if value == "" {
+			return "", fmt.Errorf("the value of '%s' cannot be empty", key)
+		}
+	}
+	if field == settingsHeadersField && !config.ValidHeaderName(key) {
+		return "", fmt.Errorf("'%s' is not a valid header name", key)
+	}
+	if field == settingsHeadersField {
+		cfg.SetDefaultHeader(key, value)
+	} else {
+		cfg.SetAPIKey(key, value)
+	}
+	return field + "." + key, nil
+}
+
+// maskSettingValue hides a sensitive setting's value, keeping the first and last 4 characters of
+// values longer than 8 characters.
+func maskSettingValue(value string) string {
+	if len(value) > 8 {
+		return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+	}
+	return strings.Repeat("*", len(value))
+}
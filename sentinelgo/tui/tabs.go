@@ -0,0 +1,923 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/session"
+	"sentinelgo/sentinelgo/utils"
+)
+
+// tabView is the behavior of a single TUI tab. Model.View renders the active tab through Render,
+// and Model.Update passes it the key presses that are not global keybindings (tab navigation,
+// quit and session controls). Handlers take and return the Model by value, like Update itself,
+// so each tab can be exercised in isolation.
+type tabView interface {
+	// Render returns the tab's content, shown below the session status line.
+	Render(m Model) string
+	// HandleKey applies a key press to the model and returns the updated model and any command to run.
+	HandleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd)
+}
+
+// tabViews holds the view for each Tab, indexed by Tab. Adding a tab means adding a Tab constant,
+// an entry in tabNames, and its tabView here.
+var tabViews = []tabView{
+	TargetInputTab:     targetInputTab{},
+	ProxyMgmtTab:       proxyMgmtTab{},
+	SettingsTab:        settingsTab{},
+	LiveSessionLogsTab: liveSessionLogsTab{},
+	LogReviewTab:       logReviewTab{},
+}
+
+// bundleBaseDir is the directory under which run bundles exported from the Live Session Logs tab are written.
+const bundleBaseDir = "bundles"
+
+// healthSnapshotBaseDir is the directory under which proxy health snapshots saved from the Proxy
+// Management tab are written.
+const healthSnapshotBaseDir = "snapshots"
+
+// logTimestamp returns the styled timestamp prefix used for TUI log lines.
+func logTimestamp() string {
+	return LogTimestampStyle.Render(time.Now().Format("15:04:05.000"))
+}
+
+// Indices of the Target Input tab's input fields (Model.inputFocus), in focus order.
+const (
+	targetURLField = iota
+	targetNumReportsField
+	targetLabelField
+	numTargetFields
+)
+
+// targetInputTab is the Target Input tab: the target URL, report count and session label
+// fields, submission, and connection testing.
+type targetInputTab struct{}
+
+// Render draws the input fields, the last test connection result, and the tab's help line.
+func (targetInputTab) Render(m Model) string {
+	helpKeyStyle := HelpTextStyle.Copy().Bold(true)
+	var view strings.Builder
+	view.WriteString(HeaderStyle.Render(SymbolListItem+" Target Input") + "\n")
+	urlLabel := NormalTextStyle.Render(SymbolInputMarker + " Target URL")
+	var urlInputView string
+	urlInputDisplay := m.targetURLInput
+	if m.inputFocus == 0 {
+		urlInputDisplay += "_"
+		urlInputView = FocusedInputStyle.Render(SymbolFocused + " " + urlInputDisplay)
+	} else {
+		urlInputView = BlurredInputStyle.Render(SymbolNotFocused + " " + urlInputDisplay)
+	}
+	view.WriteString(urlLabel + "\n" + urlInputView + "\n\n")
+	numReportsLabel := NormalTextStyle.Render(SymbolInputMarker + " Number of Reports")
+	var numReportsInputView string
+	numReportsInputDisplay := m.numReportsInput
+	if m.inputFocus == 1 {
+		numReportsInputDisplay += "_"
+		numReportsInputView = FocusedInputStyle.Render(SymbolFocused + " " + numReportsInputDisplay)
+	} else {
+		numReportsInputView = BlurredInputStyle.Render(SymbolNotFocused + " " + numReportsInputDisplay)
+	}
+	view.WriteString(numReportsLabel + "\n" + numReportsInputView + "\n\n")
+	sessionLabel := NormalTextStyle.Render(SymbolInputMarker + " Session Label (optional)")
+	var sessionLabelInputView string
+	sessionLabelInputDisplay := m.sessionLabelInput
+	if m.inputFocus == targetLabelField {
+		sessionLabelInputDisplay += "_"
+		sessionLabelInputView = FocusedInputStyle.Render(SymbolFocused + " " + sessionLabelInputDisplay)
+	} else {
+		sessionLabelInputView = BlurredInputStyle.Render(SymbolNotFocused + " " + sessionLabelInputDisplay)
+	}
+	view.WriteString(sessionLabel + "\n" + sessionLabelInputView + "\n\n")
+	if m.testConnectionStatus != "" {
+		view.WriteString(m.testConnectionStatus + "\n\n")
+	}
+	helpText := "Tab: Switch Fields | Enter: Submit Report | Ctrl+T: Test Connection | Ctrl+O: Resume Checkpoint"
+	if m.session != nil {
+		sState, _, _, _, _, _ := m.session.GetStats()
+		if sState == session.Running || sState == session.Paused {
+			sessionHelp := lipgloss.JoinHorizontal(lipgloss.Left, HelpTextStyle.Render(SymbolPointer+" Session: "), helpKeyStyle.Bold(true).Render("P "), HelpTextStyle.Render("Pause | "), helpKeyStyle.Bold(true).Render("R "), HelpTextStyle.Render("Resume | "), helpKeyStyle.Bold(true).Render("A "), HelpTextStyle.Render("Abort"))
+			helpText += " | " + sessionHelp
+		}
+	}
+	view.WriteString(HelpTextStyle.Render("\n" + helpText))
+	return view.String()
+}
+
+// HandleKey edits the focused field, tests the connection (Ctrl+T), resumes the last checkpoint
+// (Ctrl+O) and submits the target (Enter).
+func (targetInputTab) HandleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "tab":
+		m.inputFocus = (m.inputFocus + 1) % numTargetFields // Cycle focus: URL, NumReports, session label.
+	case "ctrl+o": // Resume the session auto-saved to autosavepath, e.g. after a crash or abort.
+		if m.activeSessions() >= m.maxSessions() {
+			m.err = m.sessionLimitError()
+			break
+		}
+		cmd = m.resumeSession()
+	case "ctrl+t": // Fire a single test request against the entered URL without starting a session.
+		if m.targetURLInput == "" {
+			m.err = fmt.Errorf("target URL cannot be empty")
+		} else {
+			m.testConnectionStatus = InfoTextStyle.Render(SymbolRunning + " Testing connection to " + m.targetURLInput + "...")
+			cmd = testConnectionCmd(m.reporter, m.targetURLInput)
+		}
+	case "enter": // Submit action for TargetInputTab.
+		numReportsInt, errConv := strconv.Atoi(m.numReportsInput)
+		if errConv != nil || numReportsInt <= 0 {
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" Number of reports must be a positive integer."))
+			m.err = fmt.Errorf("invalid number of reports: '%s'", m.numReportsInput)
+		} else if m.targetURLInput == "" {
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" Target URL cannot be empty."))
+			m.err = fmt.Errorf("target URL cannot be empty")
+		} else if errPolicy := m.appConfig.CheckTarget(m.targetURLInput); errPolicy != nil {
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" Target refused: "+errPolicy.Error()))
+			m.err = errPolicy
+		} else { // Valid inputs, proceed to session logic.
+			atLimit := m.activeSessions() >= m.maxSessions()
+			if atLimit && m.appConfig.QueueTargets {
+				position := m.targetQueue.Push(queuedTarget{targetURL: m.targetURLInput, numReports: numReportsInt, label: strings.TrimSpace(m.sessionLabelInput)})
+				m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" No session slot free (%d active); queued %d reports to %s (position %d).", m.activeSessions(), numReportsInt, m.targetURLInput, position)))
+				m.targetURLInput = ""
+				m.inputFocus = 0
+			} else if atLimit {
+				m.err = m.sessionLimitError()
+				m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" "+m.err.Error()+". Abort a session or wait for one to complete."))
+			} else { // Okay to start a new session.
+				cmd = m.startSession(m.targetURLInput, numReportsInt, strings.TrimSpace(m.sessionLabelInput))
+				m.targetURLInput = "" // Clear target URL input; the label is kept for the next target of the batch.
+				m.inputFocus = 0      // Reset focus to URL input.
+			}
+		}
+	case "backspace":
+		if m.inputFocus == 0 && len(m.targetURLInput) > 0 {
+			m.targetURLInput = m.targetURLInput[:len(m.targetURLInput)-1]
+		}
+		if m.inputFocus == 1 && len(m.numReportsInput) > 0 {
+			m.numReportsInput = m.numReportsInput[:len(m.numReportsInput)-1]
+		}
+		if m.inputFocus == targetLabelField && len(m.sessionLabelInput) > 0 {
+			m.sessionLabelInput = m.sessionLabelInput[:len(m.sessionLabelInput)-1]
+		}
+	default: // Character input.
+		if msg.Type == tea.KeyRunes && !strings.Contains(msg.String(), "ctrl+") { // Ignore control sequences.
+			runeStr := msg.String()
+			if m.inputFocus == 0 {
+				m.targetURLInput += runeStr
+			} // Append to URL input.
+			if m.inputFocus == 1 { // Append to NumReports input, filtering for digits.
+				for _, r := range runeStr {
+					if r >= '0' && r <= '9' {
+						m.numReportsInput += string(r)
+					}
+				}
+			}
+			if m.inputFocus == targetLabelField {
+				m.sessionLabelInput += runeStr
+			}
+		}
+	}
+	return m, cmd
+}
+
+// proxyMgmtTab is the Proxy Management tab: pool status counts and health check re-runs.
+type proxyMgmtTab struct{}
+
+// Render draws the pool status counts and the health check parameter fields.
+func (proxyMgmtTab) Render(m Model) string {
+	var view strings.Builder
+	view.WriteString(HeaderStyle.Render(SymbolListItem+" Proxy Pool Status") + "\n\n")
+	if m.proxyManager != nil {
+		allProxies := m.proxyManager.GetAllProxies()
+		totalProxies := len(allProxies)
+		healthyCount := 0
+		unknownCount := 0
+		deadCount := 0
+		for _, p := range allProxies {
+			if p.HealthStatus == "healthy" {
+				healthyCount++
+			} else if p.HealthStatus == "unknown" {
+				unknownCount++
+			} else if p.HealthStatus == proxy.StatusDead {
+				deadCount++
+			}
+		}
+		unhealthyCount := totalProxies - healthyCount - unknownCount - deadCount
+		statsStyle := NormalTextStyle.Copy().PaddingBottom(0)
+		view.WriteString(statsStyle.Render(fmt.Sprintf("%s Total Proxies: %s", SymbolInfo, lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("%d", totalProxies)))) + "\n")
+		view.WriteString(statsStyle.Render(fmt.Sprintf("%s Healthy:       %s", SymbolSuccess, SuccessTextStyle.Render(fmt.Sprintf("%d", healthyCount)))) + "\n")
+		view.WriteString(statsStyle.Render(fmt.Sprintf("%s Unhealthy:     %s", SymbolFailure, ErrorTextStyle.Render(fmt.Sprintf("%d", unhealthyCount)))) + "\n")
+		if unknownCount > 0 {
+			view.WriteString(statsStyle.Render(fmt.Sprintf("%s Unknown:       %s", SymbolWarning, WarningTextStyle.Render(fmt.Sprintf("%d", unknownCount)))) + "\n")
+		}
+		if deadCount > 0 {
+			view.WriteString(statsStyle.Render(fmt.Sprintf("%s Dead:          %s", SymbolFailure, ErrorTextStyle.Render(fmt.Sprintf("%d", deadCount)))) + "\n")
+		}
+		view.WriteString(statsStyle.Render(fmt.Sprintf("%s Selection:     %s", SymbolInfo, selectionModeLabel(m.proxyManager.IsHealthyOnly()))) + "\n")
+		if active := m.proxyManager.ActiveStrategy(); active != m.proxyManager.Strategy {
+			view.WriteString(statsStyle.Render(fmt.Sprintf("%s Strategy:      %s", SymbolWarning, WarningTextStyle.Render(fmt.Sprintf("%s (fallback from %s: pool below %d)", active, m.proxyManager.Strategy, m.proxyManager.FallbackBelow)))) + "\n")
+		}
+		view.WriteString(renderProxyList(allProxies, m.proxyListCursor))
+		view.WriteString("\n" + SubtleTextStyle.Render(SymbolInfo+" Health checks run in background. Statuses and latencies update when they finish.") + "\n\n")
+
+		// Health check parameters for re-runs, and the source to import more proxies from.
+		fieldLabels := []string{"Health Check Timeout (s)", "Concurrency", "Import Proxies From (file or API URL)"}
+		fieldValues := []string{m.healthCheckTimeoutInput, m.healthCheckConcurrencyInput, m.proxyImportInput}
+		for i, label := range fieldLabels {
+			view.WriteString(NormalTextStyle.Render(SymbolInputMarker+" "+label) + "\n")
+			if m.proxyInputFocus == i {
+				view.WriteString(FocusedInputStyle.Render(SymbolFocused+" "+fieldValues[i]+"_") + "\n")
+			} else {
+				view.WriteString(BlurredInputStyle.Render(SymbolNotFocused+" "+fieldValues[i]) + "\n")
+			}
+		}
+		spinner := spinnerFrames[m.spinnerFrame%len(spinnerFrames)]
+		if m.healthCheckRunning {
+			view.WriteString(InfoTextStyle.Render(spinner+" Health check running...") + "\n")
+		}
+		if m.proxyCheckHost != "" {
+			view.WriteString(InfoTextStyle.Render(spinner+" Checking proxy "+m.proxyCheckHost+"...") + "\n")
+		}
+	} else {
+		view.WriteString(WarningTextStyle.Render(SymbolWarning+" Proxy Manager not initialized.") + "\n")
+	}
+	view.WriteString(HelpTextStyle.Render("\nTab: Switch Fields | Up/Down: Select Proxy | Ctrl+T: Check Selected Proxy | Ctrl+R: Re-run Health Check | Ctrl+U: Reset Health | Ctrl+S: Save Health Snapshot | Ctrl+A: Toggle Healthy Only / Any Proxy | Ctrl+O: Import Proxies"))
+	return view.String()
+}
+
+// Indices of the Proxy Management tab's input fields, in focus order.
+const (
+	proxyTimeoutField = iota
+	proxyConcurrencyField
+	proxyImportField
+	numProxyFields
+)
+
+// HandleKey edits the health check and import fields, selects a proxy in the list (Up/Down),
+// checks the selected proxy (Ctrl+T), re-runs the health check over the pool (Ctrl+R), resets every
+// proxy's health to "unknown" for a fresh check (Ctrl+U), saves the
+// pool's health to a timestamped file under healthSnapshotBaseDir (Ctrl+S), toggles between selecting healthy proxies only and any proxy (Ctrl+A) and imports more
+// proxies into the pool (Ctrl+O).
+func (proxyMgmtTab) HandleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+a": // Switch selection mode live; running sessions pick it up on their next report.
+		if m.proxyManager == nil {
+			m.err = fmt.Errorf("proxy manager not initialized")
+			break
+		}
+		healthyOnly := !m.proxyManager.IsHealthyOnly()
+		m.proxyManager.SetHealthyOnly(healthyOnly)
+		label := selectionModeLabel(healthyOnly)
+		m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(logTimestamp()+" "+LogPrefixWarn+" Proxy selection switched to: "+label+"."))
+		if m.logger != nil {
+			m.logger.Warn(utils.LogEntry{Message: "Proxy selection mode changed", Outcome: "selection_mode_changed", AdditionalData: map[string]interface{}{"healthy_only": healthyOnly}})
+		}
+	case "ctrl+r": // Re-run the health check over the whole pool.
+		timeout, concurrency, err := parseHealthCheckParams(m.healthCheckTimeoutInput, m.healthCheckConcurrencyInput)
+		if err != nil {
+			m.err = err
+		} else if m.proxyManager == nil || len(m.proxyManager.GetAllProxies()) == 0 {
+			m.err = fmt.Errorf("no proxies loaded to check")
+		} else if m.healthCheckRunning {
+			m.err = fmt.Errorf("a health check is already running")
+		} else {
+			m.healthCheckRunning = true
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Re-running health check (timeout %s, concurrency %d)...", timeout, concurrency)))
+			cmd = tea.Batch(healthCheckCmd(m.proxyManager, timeout, concurrency), m.startSpinner())
+		}
+	case "ctrl+u": // Forget the pool's health, e.g. after a network outage marked it unhealthy.
+		if m.proxyManager == nil || len(m.proxyManager.GetAllProxies()) == 0 {
+			m.err = fmt.Errorf("no proxies loaded to reset")
+			break
+		}
+		if m.healthCheckRunning || m.proxyCheckHost != "" {
+			m.err = fmt.Errorf("a health check is already running")
+			break
+		}
+		reset := m.proxyManager.ResetHealth()
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Reset the health of %d proxies to unknown. Press Ctrl+R to re-check them.", reset)))
+		if m.logger != nil {
+			m.logger.Info(utils.LogEntry{Message: "Proxy health reset", Outcome: "health_reset", AdditionalData: map[string]interface{}{"proxies_reset": reset}})
+		}
+	case "ctrl+t": // Check the selected proxy on its own.
+		timeout, _, err := parseHealthCheckParams(m.healthCheckTimeoutInput, m.healthCheckConcurrencyInput)
+		var proxies []*proxy.ProxyInfo
+		if m.proxyManager != nil {
+			proxies = m.proxyManager.GetAllProxies()
+		}
+		if err != nil {
+			m.err = err
+		} else if len(proxies) == 0 {
+			m.err = fmt.Errorf("no proxies loaded to check")
+		} else if m.healthCheckRunning || m.proxyCheckHost != "" {
+			m.err = fmt.Errorf("a health check is already running")
+		} else {
+			p := proxies[clampProxyCursor(m.proxyListCursor, len(proxies))]
+			if p == nil || p.URL == nil {
+				m.err = fmt.Errorf("the selected proxy has no URL")
+				break
+			}
+			if p.HealthStatus == proxy.StatusDead { // A failed check would demote it to "unhealthy".
+				m.err = fmt.Errorf("proxy %s is dead; press Ctrl+R to re-test dead proxies", p.URL.Host)
+				break
+			}
+			m.proxyCheckHost = p.URL.Host
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Checking proxy %s (timeout %s)...", p.URL.Host, timeout)))
+			cmd = tea.Batch(proxyCheckCmd(m.proxyManager, p, timeout), m.startSpinner())
+		}
+	case "up", "down": // Move the selection in the proxy list.
+		if m.proxyManager == nil {
+			break
+		}
+		step := 1
+		if msg.String() == "up" {
+			step = -1
+		}
+		m.proxyListCursor = clampProxyCursor(m.proxyListCursor+step, len(m.proxyManager.GetAllProxies()))
+	case "ctrl+s": // Keep the results of the last health check.
+		if m.proxyManager == nil || len(m.proxyManager.GetAllProxies()) == 0 {
+			m.err = fmt.Errorf("no proxies loaded to save")
+			break
+		}
+		path := filepath.Join(healthSnapshotBaseDir, "proxy-health-"+time.Now().Format("20060102-150405")+".json")
+		err := os.MkdirAll(healthSnapshotBaseDir, 0700)
+		var saved proxy.HealthFile
+		if err == nil {
+			saved, err = m.proxyManager.SaveHealth(path)
+		}
+		if err != nil {
+			m.err = fmt.Errorf("failed to save proxy health: %w", err)
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" "+m.err.Error()))
+			break
+		}
+		m.logMessages = append(m.logMessages, SuccessTextStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Saved the health of %d proxies (%d healthy) to %s.", len(saved.Proxies), saved.Healthy, path)))
+	case "ctrl+o": // Add the proxies of another file or API to the pool, e.g. mid-session.
+		source := strings.TrimSpace(m.proxyImportInput)
+		if source == "" {
+			m.err = fmt.Errorf("enter a proxy file or API URL to import from")
+		} else if m.proxyManager == nil {
+			m.err = fmt.Errorf("proxy manager not initialized")
+		} else {
+			var apiTimeout time.Duration
+			if m.appConfig != nil {
+				apiTimeout = time.Duration(m.appConfig.ProxyAPITimeoutSeconds * float64(time.Second))
+			}
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+" Importing proxies from "+source+"..."))
+			cmd = proxyImportCmd(m.proxyManager, source, apiTimeout)
+		}
+	case "tab":
+		m.proxyInputFocus = (m.proxyInputFocus + 1) % numProxyFields
+	case "backspace":
+		if m.proxyInputFocus == proxyTimeoutField && len(m.healthCheckTimeoutInput) > 0 {
+			m.healthCheckTimeoutInput = m.healthCheckTimeoutInput[:len(m.healthCheckTimeoutInput)-1]
+		}
+		if m.proxyInputFocus == proxyConcurrencyField && len(m.healthCheckConcurrencyInput) > 0 {
+			m.healthCheckConcurrencyInput = m.healthCheckConcurrencyInput[:len(m.healthCheckConcurrencyInput)-1]
+		}
+		if m.proxyInputFocus == proxyImportField && len(m.proxyImportInput) > 0 {
+			m.proxyImportInput = m.proxyImportInput[:len(m.proxyImportInput)-1]
+		}
+	default: // Digits only (plus a decimal point for the timeout); any text for the import source.
+		if msg.Type == tea.KeyRunes {
+			if m.proxyInputFocus == proxyImportField {
+				m.proxyImportInput += msg.String()
+				break
+			}
+			for _, r := range msg.String() {
+				if m.proxyInputFocus == proxyTimeoutField && ((r >= '0' && r <= '9') || r == '.') {
+					m.healthCheckTimeoutInput += string(r)
+				}
+				if m.proxyInputFocus == proxyConcurrencyField && r >= '0' && r <= '9' {
+					m.healthCheckConcurrencyInput += string(r)
+				}
+			}
+		}
+	}
+	return m, cmd
+}
+
+// selectionModeLabel describes the proxy selection mode shown on the Proxy Management tab.
+func selectionModeLabel(healthyOnly bool) string {
+	if healthyOnly {
+		return "Healthy only"
+	}
+	return "Any proxy"
+}
+
+// maxProxyListRows caps the proxies listed on the Proxy Management tab.
+const maxProxyListRows = 10
+
+// clampProxyCursor returns cursor limited to the indices of a pool of n proxies.
+func clampProxyCursor(cursor, n int) int {
+	if cursor >= n {
+		cursor = n - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
+
+// renderProxyList lists proxies by host with their health status, the latency of the last
+// check for healthy ones, and their label, if any. Hosts are shown instead of full URLs so
+// credentials never reach the screen. The proxy at cursor is marked, and the list scrolls to
+// keep it in view.
+func renderProxyList(proxies []*proxy.ProxyInfo, cursor int) string {
+	if len(proxies) == 0 {
+		return ""
+	}
+	cursor = clampProxyCursor(cursor, len(proxies))
+	first := 0
+	if cursor >= maxProxyListRows {
+		first = cursor - maxProxyListRows + 1
+	}
+	var list strings.Builder
+	list.WriteString("\n")
+	if first > 0 {
+		list.WriteString(SubtleTextStyle.Render(fmt.Sprintf("  ... %d above", first)) + "\n")
+	}
+	for i := first; i < len(proxies); i++ {
+		if i == first+maxProxyListRows {
+			list.WriteString(SubtleTextStyle.Render(fmt.Sprintf("  ... and %d more", len(proxies)-i)) + "\n")
+			break
+		}
+		p := proxies[i]
+		if p == nil || p.URL == nil {
+			continue
+		}
+		marker := SymbolListItem
+		if i == cursor {
+			marker = SymbolFocused
+		}
+		latency := ""
+		if p.HealthStatus == "healthy" && p.Latency > 0 {
+			latency = p.Latency.Round(time.Millisecond).String()
+		}
+		line := fmt.Sprintf("  %s %-28s %-11s %-8s", marker, p.URL.Host, p.HealthStatus, latency)
+		if p.Label != "" {
+			line += " " + SubtleTextStyle.Render(p.Label)
+		}
+		list.WriteString(NormalTextStyle.Render(line) + "\n")
+	}
+	return list.String()
+}
+
+// settingsTab is the Settings tab: a list of editable settings, with save and reload.
+type settingsTab struct{}
+
+// settingsConfigPath is the config file the Settings tab saves to and reloads from, or the same
+// file in another format (e.g. config/sentinel.toml) if that is the one that exists.
+const settingsConfigPath = "config/sentinel.yaml"
+
+// Render draws the editable settings, masking sensitive values, and the edit field when editing.
+func (settingsTab) Render(m Model) string {
+	var content strings.Builder
+	content.WriteString(HeaderStyle.Render(SymbolListItem+" Application Configuration") + "\n\n")
+
+	if m.appConfig == nil {
+		content.WriteString(WarningTextStyle.Render(SymbolWarning + " Application configuration not loaded."))
+		return content.String()
+	}
+
+	// Define keyStyle for rendering setting names (bolded for emphasis)
+	keyStyle := NormalTextStyle.Copy().Bold(true)
+
+	for i, setting := range m.editableSettings {
+		lineStyle := NormalTextStyle
+		focusMarker := SymbolNotFocused + " "
+		if !m.editingSetting && i == m.settingsFocusIndex {
+			lineStyle = ActiveTabStyle.Copy().BorderStyle(lipgloss.HiddenBorder())
+			focusMarker = SymbolFocused + " "
+		}
+
+		// Render the setting name (key) using keyStyle, which is already bolded for emphasis.
+		keyStr := keyStyle.Render(setting.Name + ":")
+		var valueStr string
+		if m.editingSetting && i == m.settingsFocusIndex {
+			editValue := m.currentEditValue
+			if setting.IsSensitive {
+				editValue = maskSettingValue(editValue)
+			}
+			valueStr = FocusedInputStyle.Render(editValue + "_")
+		} else {
+			currentValDisplay := fmt.Sprintf("%v", setting.CurrentValue)
+			if setting.Type == "float" {
+				if fVal, ok := setting.CurrentValue.(float64); ok {
+					currentValDisplay = fmt.Sprintf("%.2f", fVal)
+					if setting.Path == "RiskThreshold" {
+						currentValDisplay += "%"
+					}
+				} else {
+					currentValDisplay = ErrorTextStyle.Render("N/A (float expected)")
+				}
+			}
+			if setting.IsSensitive {
+				currentValDisplay = maskSettingValue(currentValDisplay)
+			}
+			valueStr = NormalTextStyle.Render(currentValDisplay)
+		}
+
+		content.WriteString(lineStyle.Render(focusMarker+keyStr+" "+valueStr) + "\n")
+	}
+
+	content.WriteString(HelpTextStyle.Render("\n\n(Navigate with ↑/↓, Enter to Edit/Confirm, Esc to Cancel. Ctrl+S to Save, Ctrl+R to Reload from file.)"))
+	return content.String()
+}
+
+// HandleKey edits the value of the setting being edited, or, when not editing, moves the selection,
+// starts editing (Enter), saves the settings if they pass AppConfig.Validate (Ctrl+S) and reloads
+// them (Ctrl+R).
+// Model.Update routes every key here while a setting is being edited.
+func (s settingsTab) HandleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	if m.editingSetting {
+		return s.handleEditKey(m, msg), nil
+	}
+	switch msg.String() {
+	case "ctrl+s": // Save settings.
+		path := config.ResolveConfigPath(settingsConfigPath)
+		ts := logTimestamp()
+		if err := m.appConfig.Validate(); err != nil { // Saved settings must load back.
+			m.err = fmt.Errorf("settings not saved: %w", err)
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+" Settings not saved: "+err.Error()))
+			break
+		}
+		err := config.SaveAppConfig(path, m.appConfig)
+		if err != nil {
+			m.err = fmt.Errorf("failed to save config: %w", err)
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+" Failed to save settings: "+err.Error()))
+		} else {
+			m.logMessages = append(m.logMessages, SuccessTextStyle.Render(ts+" "+LogPrefixInfo+" Settings saved to "+path+"."))
+		}
+	case "ctrl+r": // Reload settings, discarding unsaved changes.
+		path := config.ResolveConfigPath(settingsConfigPath)
+		newCfg, err := config.LoadAppConfig(path)
+		ts := logTimestamp()
+		if err != nil {
+			m.err = fmt.Errorf("failed to reload config: %w", err)
+			m.logMessages = append(m.logMessages, ErrorTextStyle.Render(ts+" "+LogPrefixError+" Failed to reload settings: "+err.Error()))
+		} else {
+			m.appConfig = newCfg
+			if m.logger != nil && newCfg.LogLevel != "" {
+				m.logger.SetLevel(newCfg.LogLevel)
+			}
+			if m.proxyManager != nil {
+				m.proxyManager.SetMinRecheckInterval(time.Duration(newCfg.MinRecheckIntervalSeconds * float64(time.Second)))
+			}
+			if m.reporter != nil {
+				m.reporter.CloseIdleConnections() // Later attempts connect afresh rather than over connections opened before the reload.
+			}
+			m.populateEditableSettings() // Refresh UI list with new values.
+			m.logMessages = append(m.logMessages, SuccessTextStyle.Render(ts+" "+LogPrefixInfo+" Settings reloaded from "+path+"."))
+		}
+	case "up", "k":
+		if m.settingsFocusIndex > 0 {
+			m.settingsFocusIndex--
+		}
+	case "down", "j":
+		if m.settingsFocusIndex < len(m.editableSettings)-1 {
+			m.settingsFocusIndex++
+		}
+	case "enter": // Enter edit mode for selected setting.
+		if m.settingsFocusIndex < len(m.editableSettings) {
+			m.editingSetting = true
+			m.editingSettingPath = m.editableSettings[m.settingsFocusIndex].Path
+			m.originalEditValue = m.editableSettings[m.settingsFocusIndex].CurrentValue
+			m.currentEditValue = fmt.Sprintf("%v", m.originalEditValue)
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Editing '%s'...", m.editableSettings[m.settingsFocusIndex].Name)))
+		}
+	}
+	return m, nil
+}
+
+// handleEditKey handles a key while a setting is being edited: Enter validates and applies the
+// value, Esc cancels, and other keys edit the value.
+func (settingsTab) handleEditKey(m Model, msg tea.KeyMsg) Model {
+	switch msg.String() {
+	case "enter": // Confirm edit.
+		if m.settingsFocusIndex < 0 || m.settingsFocusIndex >= len(m.editableSettings) {
+			m.err = fmt.Errorf("internal error: invalid settingsFocusIndex %d", m.settingsFocusIndex)
+			m.editingSetting = false
+			break
+		}
+		settingToEdit := &m.editableSettings[m.settingsFocusIndex]
+		isValid := true
+		var parseErr error
+
+		// Validate and convert based on setting type.
+		switch settingToEdit.Type {
+		case "int":
+			val, errConv := strconv.Atoi(m.currentEditValue)
+			if errConv != nil {
+				parseErr = fmt.Errorf("invalid integer value: %w", errConv)
+				isValid = false
+			} else if errBounds := settingToEdit.checkBounds(float64(val)); errBounds != nil {
+				parseErr = fmt.Errorf("value not applied: %w", errBounds)
+				isValid = false
+			} else { // Apply change to AppConfig.
+				if settingToEdit.Path == "MaxRetries" {
+					m.appConfig.MaxRetries = val
+				}
+				settingToEdit.CurrentValue = val // Update UI model.
+			}
+		case "float":
+			val, errConv := strconv.ParseFloat(m.currentEditValue, 64)
+			if errConv != nil {
+				parseErr = fmt.Errorf("invalid float value: %w", errConv)
+				isValid = false
+			} else if math.IsNaN(val) || math.IsInf(val, 0) { // ParseFloat accepts "NaN" and "Inf".
+				parseErr = fmt.Errorf("invalid float value: %q is not a finite number", m.currentEditValue)
+				isValid = false
+			} else if errBounds := settingToEdit.checkBounds(val); errBounds != nil {
+				parseErr = fmt.Errorf("value not applied: %w", errBounds)
+				isValid = false
+			} else { // Apply change to AppConfig.
+				if settingToEdit.Path == "RiskThreshold" {
+					m.appConfig.RiskThreshold = val
+				}
+				settingToEdit.CurrentValue = val // Update UI model.
+			}
+		case "string":
+			val := strings.ToUpper(strings.TrimSpace(m.currentEditValue))
+			if settingToEdit.Path == "LogLevel" {
+				if _, ok := utils.ParseLevel(val); !ok {
+					parseErr = fmt.Errorf("invalid log level '%s': use DEBUG, INFO, WARN, ERROR or FATAL", m.currentEditValue)
+					isValid = false
+					break
+				}
+				m.appConfig.LogLevel = val
+				if m.logger != nil {
+					m.logger.SetLevel(val) // Takes effect at once, without a restart.
+				}
+			}
+			settingToEdit.CurrentValue = val // Update UI model.
+		case settingsMapEntryType: // A DefaultHeaders or APIKeys entry, or a new one.
+			name, sensitive := settingToEdit.Name, settingToEdit.IsSensitive
+			path, err := applyMapSetting(m.appConfig, settingToEdit.Path, m.currentEditValue)
+			if err != nil {
+				parseErr, isValid = err, false
+				break
+			}
+			m.populateEditableSettings() // Entries may have been added or removed.
+			m.focusSetting(path)
+			m.editingSetting = false
+			value := m.currentEditValue
+			if sensitive {
+				value = maskSettingValue(value)
+			}
+			action := fmt.Sprintf("updated locally to '%s'", value)
+			if _, key := splitSettingPath(settingToEdit.Path); key == "" {
+				name, action = path, "added locally"
+			} else if value == "" {
+				action = "removed locally"
+			}
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Setting '%s' %s. Use Ctrl+S to save.", name, action)))
+			return m
+		}
+
+		if !isValid {
+			m.err = parseErr
+		} else {
+			m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Setting '%s' updated locally to '%s'. Use Ctrl+S to save.", settingToEdit.Name, m.currentEditValue)))
+		}
+		m.editingSetting = false // Exit edit mode.
+	case "esc": // Cancel edit.
+		m.editingSetting = false
+		m.logMessages = append(m.logMessages, LogLevelWarnStyle.Render(logTimestamp()+" "+LogPrefixWarn+" Edit cancelled for '"+m.editableSettings[m.settingsFocusIndex].Name+"'."))
+	case "backspace":
+		if len(m.currentEditValue) > 0 {
+			m.currentEditValue = m.currentEditValue[:len(m.currentEditValue)-1]
+		}
+	default: // Append typed characters.
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace { // Allow spaces for potential future string settings.
+			// TODO: Implement live input restrictions for int/float types if desired.
+			m.currentEditValue += msg.String()
+		}
+	}
+	return m
+}
+
+// liveSessionLogsTab is the Live Session Logs tab: the most recent log lines that fit the window.
+type liveSessionLogsTab struct{}
+
+// Render draws as many of the latest log messages as fit the terminal height.
+func (liveSessionLogsTab) Render(m Model) string {
+	var view strings.Builder
+	view.WriteString(HeaderStyle.Render(SymbolListItem+" Live Session Logs") + "\n")
+	maxLogsToShow := m.height - 12
+	if maxLogsToShow < 1 {
+		maxLogsToShow = 5
+	}
+	displayLogs := m.logMessages
+	if len(m.logMessages) > maxLogsToShow {
+		displayLogs = m.logMessages[len(m.logMessages)-maxLogsToShow:]
+	}
+	for _, styledMsg := range displayLogs {
+		view.WriteString(styledMsg + "\n")
+	}
+	return view.String()
+}
+
+// HandleKey moves the focus to the next running session (Tab), exports the focused session's
+// run bundle (Ctrl+E) into a timestamped directory under bundleBaseDir and copies the last failed
+// report attempt as a curl command, with credentials redacted (Ctrl+Y) or kept (Alt+Y).
+func (liveSessionLogsTab) HandleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab": // Pick the session that the status line, P/R/A and Ctrl+E apply to.
+		if !m.focusNextSession() {
+			m.err = fmt.Errorf("no running session to focus")
+			break
+		}
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Focused session %s (%s).", shortSessionID(m.session.ID), m.session.TargetURL)))
+	case "ctrl+e":
+		return exportRunBundle(m), nil
+	case "ctrl+y", "alt+y": // Reproduce a failed report by hand.
+		return copyFailedCurl(m, msg.String() == "ctrl+y"), nil
+	}
+	return m, nil
+}
+
+// copyFailedCurl copies the reporter's last failed attempt as a curl command to the clipboard,
+// with credentials redacted if redact is set, and shows it in the log. The log always shows the
+// redacted command.
+func copyFailedCurl(m Model, redact bool) Model {
+	if m.reporter == nil {
+		m.err = fmt.Errorf("reporter not initialized")
+		return m
+	}
+	shown, ok := m.reporter.LastFailedCurl(true)
+	if !ok {
+		m.err = fmt.Errorf("no failed report attempt to copy")
+		return m
+	}
+	copied := shown
+	if !redact {
+		copied, _ = m.reporter.LastFailedCurl(false)
+	}
+	if err := copyToClipboard(copied); err != nil {
+		m.err = fmt.Errorf("failed to copy curl command: %w", err)
+		return m
+	}
+	note := "credentials redacted"
+	if !redact {
+		note = "with credentials"
+	}
+	m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+fmt.Sprintf(" Copied the last failed report as curl (%s): %s", note, shown)))
+	return m
+}
+
+// exportRunBundle exports the focused session's run bundle once it has finished.
+func exportRunBundle(m Model) Model {
+	if m.session == nil {
+		m.err = fmt.Errorf("no session to export")
+		return m
+	}
+	if state := m.session.GetStateValue(); state == session.Running || state == session.Paused {
+		m.err = fmt.Errorf("session still active; export once it has finished")
+		return m
+	}
+	dir := filepath.Join(bundleBaseDir, "run-"+time.Now().Format("20060102-150405")+"-"+shortSessionID(m.session.ID))
+	if err := m.session.ExportRunBundle(dir); err != nil {
+		m.err = fmt.Errorf("failed to export run bundle: %w", err)
+		m.logMessages = append(m.logMessages, ErrorTextStyle.Render(logTimestamp()+" "+LogPrefixError+" Failed to export run bundle: "+err.Error()))
+		return m
+	}
+	m.logMessages = append(m.logMessages, SuccessTextStyle.Render(logTimestamp()+" "+LogPrefixInfo+" Run bundle exported to "+dir+"."))
+	return m
+}
+
+// logReviewTab is the Log Review & Export tab: a filterable, scrollable view of the structured
+// session log, and export of the filtered entries to CSV or JSON.
+type logReviewTab struct{}
+
+// Render draws the filter and export fields, the load summary and as many of the selected
+// entries as fit the terminal height.
+func (logReviewTab) Render(m Model) string {
+	lr := m.logReview
+	var view strings.Builder
+	view.WriteString(HeaderStyle.Render(SymbolListItem+" Log Review & Export") + "\n")
+	var fields []string
+	for i, label := range logReviewFieldLabels {
+		if i == lr.focus {
+			fields = append(fields, FocusedInputStyle.Render(SymbolFocused+" "+label+": "+lr.inputs[i]+"_"))
+		} else {
+			fields = append(fields, BlurredInputStyle.Render(SymbolNotFocused+" "+label+": "+lr.inputs[i]))
+		}
+	}
+	view.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, fields...) + "\n")
+
+	switch {
+	case lr.loading:
+		view.WriteString(InfoTextStyle.Render(SymbolRunning+" Loading "+lr.logPath()+"...") + "\n")
+	case !lr.loaded:
+		view.WriteString(SubtleTextStyle.Render("Press Enter to load "+lr.logPath()+".") + "\n")
+	default:
+		summary := fmt.Sprintf("%d matching entries in %s", lr.matched, lr.logPath())
+		if lr.matched > len(lr.entries) {
+			summary += fmt.Sprintf(" (newest %d shown)", len(lr.entries))
+		}
+		view.WriteString(NormalTextStyle.Render(summary))
+		if lr.skipped > 0 {
+			view.WriteString(WarningTextStyle.Render(fmt.Sprintf(" | %s %d malformed lines skipped", SymbolWarning, lr.skipped)))
+		}
+		view.WriteString("\n")
+	}
+
+	maxRows := m.height - 16
+	if maxRows < 1 {
+		maxRows = 5
+	}
+	end := len(lr.entries) - lr.scroll
+	start := end - maxRows
+	if start < 0 {
+		start = 0
+	}
+	for _, entry := range lr.entries[start:end] {
+		view.WriteString(renderLogReviewEntry(entry) + "\n")
+	}
+	view.WriteString(HelpTextStyle.Render("\nTab: Switch Fields | Enter: Apply Filters & Reload | Up/Down, PgUp/PgDn: Scroll | Ctrl+E: Export Filtered (.csv or .json)"))
+	return view.String()
+}
+
+// renderLogReviewEntry formats one log entry as a single styled line.
+func renderLogReviewEntry(entry utils.LogEntry) string {
+	timestamp := entry.Timestamp
+	if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		timestamp = t.Local().Format("01-02 15:04:05.000")
+	}
+	levelStyle := NormalTextStyle
+	switch entry.Level {
+	case "DEBUG":
+		levelStyle = LogLevelDebugStyle
+	case "INFO":
+		levelStyle = LogLevelInfoStyle
+	case "WARN":
+		levelStyle = LogLevelWarnStyle
+	case "ERROR", "FATAL":
+		levelStyle = LogLevelErrorStyle
+	}
+	line := LogTimestampStyle.Render(timestamp) + " " + levelStyle.Render(fmt.Sprintf("%-5s", entry.Level))
+	if entry.SessionID != "" {
+		id := entry.SessionID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		line += " " + SubtleTextStyle.Render(id)
+	}
+	if entry.Outcome != "" {
+		line += " " + LogProxyStyle.Render("["+entry.Outcome+"]")
+	}
+	line += " " + LogMessageStyle.Render(entry.Message)
+	if entry.Error != "" {
+		line += " " + LogOutcomeFailureStyle.Render(entry.Error)
+	}
+	return line
+}
+
+// HandleKey edits the focused field, reloads with the current filters (Enter), scrolls the
+// entries and exports the filtered entries (Ctrl+E).
+func (logReviewTab) HandleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	lr := &m.logReview
+	switch msg.String() {
+	case "tab":
+		lr.focus = (lr.focus + 1) % numLogReviewFields
+	case "enter":
+		return m, m.reloadLogReview()
+	case "ctrl+e":
+		outPath := strings.TrimSpace(lr.inputs[logReviewExportField])
+		if outPath == "" {
+			m.err = fmt.Errorf("export path cannot be empty")
+			return m, nil
+		}
+		m.logMessages = append(m.logMessages, LogLevelInfoStyle.Render(logTimestamp()+" "+LogPrefixInfo+" Exporting filtered log entries to "+outPath+"..."))
+		return m, exportLogReviewCmd(lr.logPath(), lr.filter(), outPath)
+	case "up":
+		lr.scrollBy(1)
+	case "down":
+		lr.scrollBy(-1)
+	case "pgup":
+		lr.scrollBy(10)
+	case "pgdown":
+		lr.scrollBy(-10)
+	case "backspace":
+		if input := lr.inputs[lr.focus]; len(input) > 0 {
+			lr.inputs[lr.focus] = input[:len(input)-1]
+		}
+	default:
+		if msg.Type == tea.KeyRunes {
+			lr.inputs[lr.focus] += msg.String()
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,777 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentinelgo/sentinelgo/config"
+	"sentinelgo/sentinelgo/proxy"
+	"sentinelgo/sentinelgo/session"
+	"sentinelgo/sentinelgo/utils"
+)
+
+func TestTabViews_CoverEveryTab(t *testing.T) {
+	require.Len(t, tabViews, int(numTabs))
+	require.Len(t, tabNames, int(numTabs))
+	for i, view := range tabViews {
+		assert.NotNil(t, view, "tab %q has no view", tabNames[i])
+	}
+}
+
+func TestTargetInputTab_HandleKey(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := targetInputTab{}
+
+	m, _ = tab.HandleKey(m, keyRunes("http://x"))
+	assert.Equal(t, "http://x", m.targetURLInput)
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	assert.Equal(t, "http://", m.targetURLInput)
+
+	// The report count field only accepts digits.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyTab})
+	assert.Equal(t, 1, m.inputFocus)
+	m, _ = tab.HandleKey(m, keyRunes("2a5"))
+	assert.Equal(t, "125", m.numReportsInput)
+
+	// An empty report count is rejected without starting a session.
+	m.numReportsInput = ""
+	m, cmd := tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Error(t, m.err)
+	assert.Nil(t, cmd)
+	assert.Nil(t, m.session)
+
+	// Ctrl+T with a URL produces a test connection command.
+	m.err = nil
+	m.targetURLInput = target
+	m, cmd = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	assert.NoError(t, m.err)
+	assert.NotNil(t, cmd)
+	assert.Contains(t, m.testConnectionStatus, "Testing connection")
+
+	// Valid input starts a session and clears the URL field.
+	m.numReportsInput = "1"
+	m, cmd = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, m.session)
+	assert.NotNil(t, cmd)
+	assert.Empty(t, m.targetURLInput)
+	assert.Equal(t, 0, m.inputFocus)
+	m.session.Abort()
+}
+
+func TestTargetInputTab_SubmitRefusedByTargetPolicy(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.appConfig.TargetAllowlist = []string{"*.example.com"}
+	m.targetURLInput = "https://evil.test/report"
+	m.numReportsInput = "1"
+
+	m, cmd := targetInputTab{}.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.ErrorIs(t, m.err, config.ErrTargetNotAllowed)
+	assert.Nil(t, cmd)
+	assert.Nil(t, m.session)
+	assert.Equal(t, "https://evil.test/report", m.targetURLInput, "the refused URL is kept for correction")
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Target refused")
+}
+
+func TestTargetInputTab_SessionLabel(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := targetInputTab{}
+	m.targetURLInput = target
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyTab})
+	require.Equal(t, targetLabelField, m.inputFocus)
+	// 'q' is typed into the label field instead of quitting.
+	updated, cmd := m.Update(keyRunes("q"))
+	m = updated.(Model)
+	assert.Empty(t, runCmd(cmd), "no quit command")
+	m, _ = tab.HandleKey(m, keyRunes("uiet-batch"))
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	m, _ = tab.HandleKey(m, keyRunes("h"))
+	assert.Equal(t, "quiet-batch", m.sessionLabelInput)
+	assert.Contains(t, tab.Render(m), "quiet-batch_")
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, m.session)
+	defer m.session.Abort()
+	assert.Equal(t, "quiet-batch", m.session.Label)
+	assert.Equal(t, "quiet-batch", m.sessionLabelInput, "the label is kept for the next target")
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "(quiet-batch) started")
+	m.updateSessionStatus()
+	assert.Contains(t, m.sessionStatus, "(quiet-batch)")
+}
+
+func TestTargetInputTab_Render(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.targetURLInput = "http://target.example.com"
+
+	view := targetInputTab{}.Render(m)
+	assert.Contains(t, view, "Target Input")
+	assert.Contains(t, view, "http://target.example.com_", "the focused field shows a cursor")
+}
+
+func TestSettingsTab_HandleKey(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := settingsTab{}
+	require.NotEmpty(t, m.editableSettings)
+	require.Equal(t, "MaxRetries", m.editableSettings[0].Path)
+
+	// Navigation stays within bounds.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, 0, m.settingsFocusIndex)
+
+	// Enter starts editing with the current value.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.True(t, m.editingSetting)
+	assert.Equal(t, "1", m.currentEditValue)
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	m, _ = tab.HandleKey(m, keyRunes("4"))
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.editingSetting)
+	assert.NoError(t, m.err)
+	assert.Equal(t, 4, m.appConfig.MaxRetries)
+	assert.Equal(t, 4, m.editableSettings[0].CurrentValue)
+
+	// An invalid value is reported and leaves the config unchanged.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "abc"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Error(t, m.err)
+	assert.Equal(t, 4, m.appConfig.MaxRetries)
+
+	// Esc cancels an edit.
+	m.err = nil
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = tab.HandleKey(m, keyRunes("9"))
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, m.editingSetting)
+	assert.Equal(t, 4, m.appConfig.MaxRetries)
+}
+
+func TestSettingsTab_EditBounds(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := settingsTab{}
+	require.Equal(t, "MaxRetries", m.editableSettings[0].Path)
+	require.Equal(t, "RiskThreshold", m.editableSettings[1].Path)
+
+	// An out-of-range retry count is rejected and leaves the config unchanged.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "0"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.editingSetting)
+	require.Error(t, m.err)
+	assert.Contains(t, m.err.Error(), "Max Retries must be between 1 and 20 (got 0)")
+	assert.Contains(t, m.View(), "must be between 1 and 20", "the reason is shown in the footer")
+	assert.Equal(t, 1, m.appConfig.MaxRetries)
+	assert.Equal(t, 1, m.editableSettings[0].CurrentValue)
+
+	m.err = nil
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "20"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NoError(t, m.err)
+	assert.Equal(t, 20, m.appConfig.MaxRetries)
+
+	// The risk threshold is a percentage.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "150"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.Error(t, m.err)
+	assert.Contains(t, m.err.Error(), "must be between 0 and 100 (got 150)")
+	assert.Equal(t, 75.0, m.appConfig.RiskThreshold)
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "-1"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Error(t, m.err)
+	assert.Equal(t, 75.0, m.appConfig.RiskThreshold)
+
+	// ParseFloat accepts NaN and infinities, which are no valid threshold.
+	for _, input := range []string{"NaN", "Inf", "-Inf"} {
+		m.err = nil
+		m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+		m.currentEditValue = input
+		m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+		require.Error(t, m.err, input)
+		assert.Contains(t, m.err.Error(), "not a finite number", input)
+		assert.Equal(t, 75.0, m.appConfig.RiskThreshold, input)
+	}
+	assert.Error(t, m.editableSettings[1].checkBounds(math.NaN()), "NaN lies outside the bounds")
+
+	m.err = nil
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "50"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NoError(t, m.err)
+	assert.Equal(t, 50.0, m.appConfig.RiskThreshold)
+}
+
+func TestSettingsTab_SaveValidates(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := settingsTab{}
+
+	// Settings are saved relative to the working directory.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.Mkdir("config", 0700))
+
+	m.appConfig.MaxRetries = 0
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	assert.ErrorIs(t, m.err, config.ErrInvalidConfig)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Settings not saved: invalid configuration: maxretries must be at least 1")
+	assert.NoFileExists(t, settingsConfigPath)
+
+	m.err = nil
+	m.appConfig.MaxRetries = 2
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	require.NoError(t, m.err)
+	saved, err := config.LoadAppConfig(settingsConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, saved.MaxRetries)
+}
+
+func TestSettingsTab_ReloadAppliesRecheckInterval(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := settingsTab{}
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.Mkdir("config", 0700))
+
+	cfg := *m.appConfig
+	cfg.MinRecheckIntervalSeconds = 90
+	require.NoError(t, config.SaveAppConfig(settingsConfigPath, &cfg))
+	assert.Zero(t, m.proxyManager.MinRecheckInterval())
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	require.NoError(t, m.err)
+	assert.Equal(t, 90*time.Second, m.proxyManager.MinRecheckInterval())
+}
+
+func TestSettingsTab_EditLogLevel(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := settingsTab{}
+	m.settingsFocusIndex = 2
+	require.Equal(t, "LogLevel", m.editableSettings[2].Path)
+	assert.Equal(t, "INFO", m.editableSettings[2].CurrentValue)
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "debug"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NoError(t, m.err)
+	assert.Equal(t, utils.LevelDebug, m.logger.Level(), "the logger's level changes at once")
+	assert.Equal(t, "DEBUG", m.appConfig.LogLevel)
+	assert.Equal(t, "DEBUG", m.editableSettings[2].CurrentValue)
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "verbose"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Error(t, m.err)
+	assert.Equal(t, utils.LevelDebug, m.logger.Level())
+	assert.Equal(t, "DEBUG", m.appConfig.LogLevel)
+}
+
+func TestSplitSettingPath(t *testing.T) {
+	for path, want := range map[string][2]string{
+		"DefaultHeaders.User-Agent": {"DefaultHeaders", "User-Agent"},
+		"APIKeys.virus.total":       {"APIKeys", "virus.total"},
+		"APIKeys":                   {"APIKeys", ""},
+		"MaxRetries":                {"MaxRetries", ""},
+	} {
+		field, key := splitSettingPath(path)
+		assert.Equal(t, want, [2]string{field, key}, path)
+	}
+}
+
+func TestApplyMapSetting(t *testing.T) {
+	cfg := &config.AppConfig{DefaultHeaders: map[string]string{"User-Agent": "old"}}
+
+	path, err := applyMapSetting(cfg, "DefaultHeaders.User-Agent", "Sentinel/2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "DefaultHeaders.User-Agent", path)
+	assert.Equal(t, "Sentinel/2.0", cfg.DefaultHeaders["User-Agent"])
+
+	path, err = applyMapSetting(cfg, "APIKeys", " virustotal = vt-key=with=equals ")
+	require.NoError(t, err)
+	assert.Equal(t, "APIKeys.virustotal", path)
+	assert.Equal(t, map[string]string{"virustotal": "vt-key=with=equals"}, cfg.APIKeys, "a nil map is created")
+
+	path, err = applyMapSetting(cfg, "DefaultHeaders.User-Agent", "")
+	require.NoError(t, err)
+	assert.Equal(t, "DefaultHeaders.User-Agent", path)
+	assert.NotContains(t, cfg.DefaultHeaders, "User-Agent", "an empty value removes the key")
+
+	for _, tt := range []struct{ path, value, want string }{
+		{"DefaultHeaders", "no separator", "name=value"},
+		{"DefaultHeaders", "=value", "name=value"},
+		{"APIKeys", "shodan=", "cannot be empty"},
+		{"DefaultHeaders", "Bad Header=x", "not a valid header name"},
+		{"DefaultHeaders.Bad:Header", "x", "not a valid header name"},
+		{"CustomCookies.session", "x", "unknown setting"},
+	} {
+		_, err := applyMapSetting(cfg, tt.path, tt.value)
+		assert.ErrorContains(t, err, tt.want, "%s = %q", tt.path, tt.value)
+	}
+	assert.Empty(t, cfg.DefaultHeaders, "rejected edits change nothing")
+	assert.Len(t, cfg.APIKeys, 1)
+}
+
+func TestApplyMapSetting_ConcurrentWithReports(t *testing.T) {
+	// The server acts as the proxy and the target; run with -race to catch unguarded map access.
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			_, _ = m.reporter.SendReport(context.Background(), target, "race")
+		}
+	}()
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+			assert.NotContains(t, m.appConfig.DefaultHeaders, "X-Edit")
+			return
+		default:
+		}
+		_, err := applyMapSetting(m.appConfig, "DefaultHeaders", fmt.Sprintf("X-Edit=%d", i))
+		require.NoError(t, err)
+		_, err = applyMapSetting(m.appConfig, "DefaultHeaders.X-Edit", "")
+		require.NoError(t, err)
+	}
+}
+
+func TestSettingsTab_EditMapEntries(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := settingsTab{}
+	m.appConfig.DefaultHeaders["User-Agent"] = "Sentinel/1.0"
+	m.appConfig.APIKeys["virustotal"] = "vt-secret-key-1234"
+	m.populateEditableSettings()
+
+	// Edit an existing header.
+	m.focusSetting("DefaultHeaders.User-Agent")
+	require.Equal(t, "Header User-Agent", m.editableSettings[m.settingsFocusIndex].Name)
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "Sentinel/1.0", m.currentEditValue)
+	m.currentEditValue = "Sentinel/2.0"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NoError(t, m.err)
+	assert.False(t, m.editingSetting)
+	assert.Equal(t, "Sentinel/2.0", m.appConfig.DefaultHeaders["User-Agent"])
+
+	// Add a header through the add entry; the new entry is listed and selected.
+	m.focusSetting("DefaultHeaders")
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = tab.HandleKey(m, keyRunes("Accept-Language=en-US"))
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NoError(t, m.err)
+	assert.Equal(t, "en-US", m.appConfig.DefaultHeaders["Accept-Language"])
+	assert.Equal(t, "DefaultHeaders.Accept-Language", m.editableSettings[m.settingsFocusIndex].Path)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Setting 'DefaultHeaders.Accept-Language' added locally")
+
+	// An invalid addition is reported and leaves the maps unchanged.
+	m.focusSetting("DefaultHeaders")
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.currentEditValue = "not valid"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.ErrorContains(t, m.err, "name=value")
+	assert.Len(t, m.appConfig.DefaultHeaders, 2)
+
+	// API keys are masked on screen, while editing and in the log.
+	m.err = nil
+	view := tab.Render(m)
+	assert.Contains(t, view, "vt-s**********1234")
+	assert.NotContains(t, view, "vt-secret-key-1234")
+	m.focusSetting("APIKeys.virustotal")
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NotContains(t, tab.Render(m), "vt-secret-key-1234")
+	m.currentEditValue = "vt-rotated-key-5678"
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NoError(t, m.err)
+	assert.Equal(t, "vt-rotated-key-5678", m.appConfig.APIKeys["virustotal"])
+	assert.NotContains(t, m.logMessages[len(m.logMessages)-1], "vt-rotated-key-5678")
+
+	// Ctrl+S writes the updated maps.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.Mkdir("config", 0700))
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	require.NoError(t, m.err)
+	saved, err := config.LoadAppConfig(settingsConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"User-Agent": "Sentinel/2.0", "Accept-Language": "en-US"}, saved.DefaultHeaders)
+	assert.Equal(t, map[string]string{"virustotal": "vt-rotated-key-5678"}, saved.APIKeys)
+}
+
+func TestSettingsTab_Render(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	view := settingsTab{}.Render(m)
+	assert.Contains(t, view, "Application Configuration")
+	assert.Contains(t, view, "75.00%", "the risk threshold is shown as a percentage")
+
+	m.editingSetting = true
+	m.currentEditValue = "7"
+	assert.Contains(t, settingsTab{}.Render(m), "7_", "the edited value shows a cursor")
+}
+
+func TestLiveSessionLogsTab_ExportBundle(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tab := liveSessionLogsTab{}
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlE})
+	assert.EqualError(t, m.err, "no session to export")
+
+	// Bundles are written relative to the working directory.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	m.err = nil
+	m.session = session.NewSession(m.reporter, target, 1)
+	require.NoError(t, m.session.Start())
+	for range m.session.LogChannel {
+	}
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlE})
+	require.NoError(t, m.err)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Run bundle exported to")
+
+	dirs, err := filepath.Glob(filepath.Join(bundleBaseDir, "run-*", session.BundleResultsFile))
+	require.NoError(t, err)
+	assert.Len(t, dirs, 1)
+}
+
+func TestLiveSessionLogsTab_CopyFailedCurl(t *testing.T) {
+	m, target := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	m.appConfig.DefaultHeaders["Authorization"] = "Bearer secret"
+	var clipboard bytes.Buffer
+	clipboardOutput = &clipboard
+	t.Cleanup(func() { clipboardOutput = os.Stdout })
+	tab := liveSessionLogsTab{}
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlY})
+	assert.EqualError(t, m.err, "no failed report attempt to copy")
+	assert.Zero(t, clipboard.Len())
+
+	_, err := m.reporter.SendReport(context.Background(), target, "s1")
+	require.Error(t, err)
+	m.err = nil
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlY})
+	require.NoError(t, m.err)
+	copied := decodeOSC52(t, clipboard.String())
+	assert.Contains(t, copied, "-X 'POST'")
+	assert.Contains(t, copied, "-H 'Authorization: ***'")
+	assert.Contains(t, copied, "'"+target+"'")
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Copied the last failed report as curl (credentials redacted)")
+
+	clipboard.Reset()
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y"), Alt: true})
+	require.NoError(t, m.err)
+	assert.Contains(t, decodeOSC52(t, clipboard.String()), "-H 'Authorization: Bearer secret'")
+	assert.NotContains(t, m.logMessages[len(m.logMessages)-1], "secret", "the log only shows the redacted command")
+}
+
+// decodeOSC52 returns the text an OSC 52 clipboard sequence carries.
+func decodeOSC52(t *testing.T, seq string) string {
+	t.Helper()
+	require.True(t, strings.HasPrefix(seq, "\x1b]52;c;") && strings.HasSuffix(seq, "\a"), "%q", seq)
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(seq, "\x1b]52;c;"), "\a"))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestProxyMgmtTab_RenderListsLabels(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	p := m.proxyManager.GetAllProxies()[0]
+	p.Label = "Acme residential"
+
+	view := proxyMgmtTab{}.Render(m)
+	assert.Contains(t, view, p.URL.Host)
+	assert.Contains(t, view, "Acme residential")
+}
+
+func TestProxyMgmtTab_RenderShowsStrategyFallback(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.proxyManager.Strategy = proxy.StrategyWeighted
+	m.proxyManager.FallbackBelow = 2
+	assert.NotContains(t, proxyMgmtTab{}.Render(m), "fallback from", "nothing is shown before a fallback")
+
+	_, err := m.proxyManager.GetProxy()
+	require.NoError(t, err)
+	assert.Contains(t, proxyMgmtTab{}.Render(m), "round-robin (fallback from weighted: pool below 2)")
+}
+
+func TestProxyMgmtTab_ToggleSelectionMode(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := proxyMgmtTab{}
+	require.True(t, m.proxyManager.IsHealthyOnly())
+	assert.Contains(t, tab.Render(m), "Healthy only")
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlA})
+	assert.False(t, m.proxyManager.IsHealthyOnly())
+	assert.Contains(t, tab.Render(m), "Any proxy")
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Proxy selection switched to: Any proxy")
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlA})
+	assert.True(t, m.proxyManager.IsHealthyOnly())
+}
+
+func TestProxyMgmtTab_ResetHealth(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	tab := proxyMgmtTab{}
+	p := m.proxyManager.GetAllProxies()[0]
+	require.Equal(t, "healthy", p.HealthStatus)
+
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlU})
+	require.NoError(t, m.err)
+	assert.Equal(t, "unknown", p.HealthStatus)
+	assert.Zero(t, p.Latency)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Reset the health of 1 proxies to unknown")
+
+	m.healthCheckRunning = true
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlU})
+	assert.ErrorContains(t, m.err, "already running")
+}
+
+func TestLogReviewTab_FilterByLabel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+	log := `{"level":"INFO","message":"labelled","session_id":"abc12345-1","label":"evening-batch"}
+{"level":"INFO","message":"unlabelled","session_id":"def67890-2"}
+`
+	require.NoError(t, os.WriteFile(path, []byte(log), 0600))
+
+	var state logReviewState
+	state.inputs[logReviewLabelField] = "Evening-Batch"
+	msgs := runCmd(loadLogReviewCmd(path, state.filter()))
+	require.Len(t, msgs, 1)
+	loaded := msgs[0].(logReviewLoadedMsg)
+	require.NoError(t, loaded.err)
+	require.Len(t, loaded.entries, 1)
+	assert.Equal(t, "labelled", loaded.entries[0].Message)
+}
+
+func TestLogReviewTab_LoadFilterAndExport(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	dir := t.TempDir()
+	m.logReview.path = filepath.Join(dir, "session.log")
+	log := `{"timestamp":"2024-01-01T10:00:00Z","level":"INFO","message":"started","session_id":"abc12345-1","outcome":"state_transition"}
+not json
+{"timestamp":"2024-01-01T10:00:01Z","level":"ERROR","message":"report failed","session_id":"abc12345-1","outcome":"failed"}
+{"timestamp":"2024-01-01T10:00:02Z","level":"ERROR","message":"other session","session_id":"def67890-2","outcome":"failed"}
+`
+	require.NoError(t, os.WriteFile(m.logReview.path, []byte(log), 0600))
+
+	// Switching to the tab loads the log.
+	m.activeTab = LogReviewTab - 1
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	m = updated.(Model)
+	require.Equal(t, LogReviewTab, m.activeTab)
+	for _, msg := range runCmd(cmd) {
+		updated, _ = m.Update(msg)
+		m = updated.(Model)
+	}
+	assert.Len(t, m.logReview.entries, 3)
+	view := logReviewTab{}.Render(m)
+	assert.Contains(t, view, "3 matching entries")
+	assert.Contains(t, view, "1 malformed lines skipped")
+	assert.Contains(t, view, "other session")
+
+	// 'q' is typed into the focused field instead of quitting.
+	updated, cmd = m.Update(keyRunes("q"))
+	m = updated.(Model)
+	assert.Empty(t, runCmd(cmd), "no quit command")
+	assert.Equal(t, "q", m.logReview.inputs[logReviewLevelField])
+	m.logReview.inputs[logReviewLevelField] = ""
+
+	// Filter by level and session ID prefix, then reload with Enter.
+	tab := logReviewTab{}
+	m, _ = tab.HandleKey(m, keyRunes("error"))
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = tab.HandleKey(m, keyRunes("abc"))
+	m, cmd = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyEnter})
+	for _, msg := range runCmd(cmd) {
+		updated, _ = m.Update(msg)
+		m = updated.(Model)
+	}
+	require.Len(t, m.logReview.entries, 1)
+	assert.Equal(t, "report failed", m.logReview.entries[0].Message)
+
+	// Export the filtered entries to the path in the export field.
+	m, _ = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyTab})
+	m.logReview.inputs[logReviewExportField] = filepath.Join(dir, "out.json")
+	m, cmd = tab.HandleKey(m, tea.KeyMsg{Type: tea.KeyCtrlE})
+	for _, msg := range runCmd(cmd) {
+		updated, _ = m.Update(msg)
+		m = updated.(Model)
+	}
+	assert.NoError(t, m.err)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Exported 1 log entries")
+	data, err := os.ReadFile(filepath.Join(dir, "out.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "report failed")
+	assert.NotContains(t, string(data), "other session")
+}
+
+func TestProxyMgmtTab_ImportProxies(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	existing := m.proxyManager.GetAllProxies()[0].URL.String()
+	path := filepath.Join(t.TempDir(), "more_proxies.json")
+	data := `[{"proxy": "` + existing + `"}, {"proxy": "http://imported.example.com:8080", "region": "US"}]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	// Focus the import field; typed runes, including 'q', go into it.
+	m.activeTab = ProxyMgmtTab
+	for i := 0; i < proxyImportField; i++ {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(keyRunes("q"))
+	m = updated.(Model)
+	assert.Empty(t, runCmd(cmd), "no quit command")
+	assert.Equal(t, "q", m.proxyImportInput)
+	m.proxyImportInput = ""
+	updated, _ = m.Update(keyRunes(path))
+	m = updated.(Model)
+	require.Equal(t, path, m.proxyImportInput)
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(Model)
+	for _, msg := range runCmd(cmd) {
+		updated, _ = m.Update(msg)
+		m = updated.(Model)
+	}
+	assert.NoError(t, m.err)
+	assert.Len(t, m.proxyManager.GetAllProxies(), 2)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Imported 1 new proxies")
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "1 already in the pool")
+}
+
+func TestProxyMgmtTab_CheckSelectedProxy(t *testing.T) {
+	// The server acts as the first proxy; the second refuses connections.
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	closedURL, err := url.Parse(closed.URL)
+	require.NoError(t, err)
+	require.NoError(t, m.proxyManager.AddProxy(&proxy.ProxyInfo{URL: closedURL, HealthStatus: "healthy"}))
+	m.activeTab = ProxyMgmtTab
+	m.healthCheckTimeoutInput = "2"
+	m.healthCheckConcurrencyInput = "1"
+
+	check := func() {
+		t.Helper()
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+		m = updated.(Model)
+		require.NoError(t, m.err)
+		assert.NotEmpty(t, m.proxyCheckHost)
+		assert.Contains(t, proxyMgmtTab{}.Render(m), "Checking proxy "+m.proxyCheckHost)
+		for _, msg := range runCmd(cmd) {
+			updated, _ = m.Update(msg)
+			m = updated.(Model)
+		}
+		assert.Empty(t, m.proxyCheckHost)
+		assert.False(t, m.spinnerActive, "the spinner stops once the check is done")
+	}
+
+	// Down selects the second proxy; the cursor stays within the pool.
+	for i := 0; i < 3; i++ {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = updated.(Model)
+	}
+	assert.Equal(t, 1, m.proxyListCursor)
+	check()
+	assert.Equal(t, "unhealthy", m.proxyManager.GetAllProxies()[1].HealthStatus)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Proxy "+closedURL.Host+" is unhealthy")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = updated.(Model)
+	check()
+	checked := m.proxyManager.GetAllProxies()[0]
+	assert.Equal(t, "healthy", checked.HealthStatus)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Proxy "+checked.URL.Host+" is healthy (latency")
+	assert.Contains(t, proxyMgmtTab{}.Render(m), checked.Latency.Round(time.Millisecond).String(), "the latency of healthy proxies is listed")
+
+	// Dead proxies are left to the revival check of Ctrl+R.
+	checked.HealthStatus = proxy.StatusDead
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(Model)
+	assert.Error(t, m.err)
+	assert.Nil(t, cmd)
+}
+
+func TestRenderProxyList_ScrollsToCursor(t *testing.T) {
+	var proxies []*proxy.ProxyInfo
+	for i := 0; i < 15; i++ {
+		u, err := url.Parse(fmt.Sprintf("http://proxy%02d.example.com:8080", i))
+		require.NoError(t, err)
+		proxies = append(proxies, &proxy.ProxyInfo{URL: u, HealthStatus: "unknown"})
+	}
+
+	list := renderProxyList(proxies, 0)
+	assert.Contains(t, list, "proxy00")
+	assert.NotContains(t, list, "proxy10")
+	assert.Contains(t, list, "... and 5 more")
+
+	list = renderProxyList(proxies, 12)
+	assert.NotContains(t, list, "proxy02.")
+	assert.Contains(t, list, "... 3 above")
+	assert.Contains(t, list, SymbolFocused+" proxy12")
+	assert.Contains(t, list, "... and 2 more")
+}
+
+func TestProxyMgmtTab_SaveHealthSnapshot(t *testing.T) {
+	m, _ := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {})
+	m.activeTab = ProxyMgmtTab
+
+	// Snapshots are written relative to the working directory.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(Model)
+	require.NoError(t, m.err)
+	assert.Contains(t, m.logMessages[len(m.logMessages)-1], "Saved the health of 1 proxies (1 healthy)")
+
+	files, err := filepath.Glob(filepath.Join(healthSnapshotBaseDir, "proxy-health-*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var saved proxy.HealthFile
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Len(t, saved.Proxies, 1)
+	assert.Equal(t, "healthy", saved.Proxies[0].HealthStatus)
+	assert.Equal(t, m.proxyManager.GetAllProxies()[0].URL.String(), saved.Proxies[0].URL)
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 // LogLevel defines the severity level for log messages.
@@ -32,6 +35,14 @@ var levelToString = map[LogLevel]string{
 	LevelFatal: "FATAL",
 }
 
+// String returns the name of the level (e.g., "INFO"), or "UNKNOWN".
+func (level LogLevel) String() string {
+	if s, ok := levelToString[level]; ok {
+		return s
+	}
+	return "UNKNOWN"
+}
+
 // stringToLevel maps string representations of log levels to LogLevel enum values.
 // This is useful for parsing log levels from configuration.
 var stringToLevel = map[string]LogLevel{
@@ -50,6 +61,7 @@ type LogEntry struct {
 	Level           string                 `json:"level"`                      // Severity level of the log (e.g., "INFO", "ERROR").
 	Message         string                 `json:"message"`                    // The main log message.
 	SessionID       string                 `json:"session_id,omitempty"`       // ID of the reporting session, if applicable.
+	Label           string                 `json:"label,omitempty"`            // User-provided label of the reporting session, if any.
 	ReportURL       string                 `json:"report_url,omitempty"`       // Target URL of the report, if applicable.
 	Proxy           string                 `json:"proxy,omitempty"`            // Proxy used for the request, if applicable.
 	UserAgent       string                 `json:"user_agent,omitempty"`       // User-Agent string used for the request.
@@ -65,12 +77,35 @@ type LogEntry struct {
 	AdditionalData  map[string]interface{} `json:"additional_data,omitempty"`  // A map for any other contextual data relevant to the log entry.
 }
 
-// Logger provides a structured JSON logger that writes log entries to an io.Writer.
+// Logger provides a structured JSON logger that writes log entries to one or more io.Writers.
 // It supports different log levels and ensures thread-safe write operations.
 type Logger struct {
-	writer   io.Writer  // Destination for log output (e.g., os.Stdout, a file).
-	minLevel LogLevel   // Minimum log level to output; messages below this level are suppressed.
-	mu       sync.Mutex // Mutex to ensure thread-safe writes to the writer.
+	writers  []io.Writer  // Destinations for log output (e.g., os.Stdout, a file); see AddWriter.
+	minLevel atomic.Int32 // Minimum LogLevel to output; messages below it are suppressed. Set under mu.
+	mu       sync.Mutex   // Mutex to ensure thread-safe writes to the writer.
+	closer   io.Closer    // The file opened by NewRotatingLogger, closed by Close; nil otherwise.
+
+	redactHeaders map[string]bool // Canonical names of headers redacted besides those matched by IsSensitiveHeader.
+
+	maxBodyBytes int // Maximum bytes of RequestBody/ResponseBody written per entry; 0 means unlimited.
+}
+
+// RedactedHeaderValue replaces the values of redacted headers in log entries.
+const RedactedHeaderValue = "***"
+
+// sensitiveHeaderMarkers are lowercase substrings identifying headers that carry credentials.
+var sensitiveHeaderMarkers = []string{"authorization", "cookie", "token", "secret", "api-key", "apikey", "password"}
+
+// IsSensitiveHeader reports whether a header name suggests its value is a credential, e.g.
+// "Authorization", "Set-Cookie" or "X-Auth-Token". Names are matched case-insensitively.
+func IsSensitiveHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, marker := range sensitiveHeaderMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewLogger creates and returns a new Logger instance.
@@ -79,24 +114,218 @@ type Logger struct {
 //   - writer: The io.Writer where log entries will be written (e.g., os.Stdout, a file).
 //   - minLevelStr: The minimum log level as a string (e.g., "INFO", "DEBUG").
 //     If an invalid string is provided, it defaults to LevelInfo.
-func NewLogger(writer io.Writer, minLevelStr string) *Logger {
-	level, ok := stringToLevel[strings.ToUpper(minLevelStr)] // Ensure case-insensitivity for level string.
+//   - redactHeaders: Header names, matched case-insensitively, whose values in RequestHeaders
+//     and ResponseHeaders are written as RedactedHeaderValue. Headers matched by
+//     IsSensitiveHeader are always redacted; these are the others that carry secrets.
+func NewLogger(writer io.Writer, minLevelStr string, redactHeaders ...string) *Logger {
+	level, ok := ParseLevel(minLevelStr)
 	if !ok {
 		level = LevelInfo // Default to INFO if the provided string is invalid.
 	}
-	return &Logger{
-		writer:   writer,
-		minLevel: level,
+	l := &Logger{writers: []io.Writer{writer}}
+	l.minLevel.Store(int32(level))
+	if len(redactHeaders) > 0 {
+		l.redactHeaders = make(map[string]bool, len(redactHeaders))
+		for _, name := range redactHeaders {
+			l.redactHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+	return l
+}
+
+// AddWriter makes the Logger also write every entry to w (e.g., an in-memory buffer read by the
+// TUI, alongside the session log file). Each entry is marshaled once and the same line is written
+// to every writer in the order they were added; a writer that fails does not stop the line from
+// reaching the others. Writers are only written under the Logger's mutex, so they need no
+// locking of their own unless they are also used elsewhere.
+func (l *Logger) AddWriter(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writers = append(l.writers, w)
+}
+
+// writeLine writes line to every writer. It must be called with mu held.
+func (l *Logger) writeLine(line []byte) {
+	for _, w := range l.writers {
+		if _, err := w.Write(line); err != nil {
+			// If writing to a writer fails, attempt to write an error message to io.Discard
+			// to avoid crashing or polluting stderr directly from library code.
+			fmt.Fprintf(io.Discard, "{\"timestamp\":\"%s\",\"level\":\"ERROR\",\"message\":\"Failed to write log entry to writer\",\"error\":\"%s\"}\n",
+				time.Now().UTC().Format(time.RFC3339Nano), err.Error())
+		}
 	}
 }
 
+// redact returns h with the values of redacted headers (see NewLogger) replaced. h itself, which the caller may
+// still use, is never modified: a clone is returned when anything needs redacting.
+func (l *Logger) redact(h http.Header) http.Header {
+	var clone http.Header
+	for name := range h {
+		if !IsSensitiveHeader(name) && !l.redactHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		if clone == nil {
+			clone = h.Clone()
+		}
+		clone[name] = []string{RedactedHeaderValue}
+	}
+	if clone == nil {
+		return h
+	}
+	return clone
+}
+
+// SetLevel changes the minimum level of entries written (e.g., "DEBUG" while debugging a live
+// session) from the next entry on. An invalid level string leaves the level unchanged; use
+// ParseLevel to validate it first.
+func (l *Logger) SetLevel(levelStr string) {
+	level, ok := ParseLevel(levelStr)
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.minLevel.Store(int32(level))
+}
+
+// Level returns the minimum level of entries written.
+func (l *Logger) Level() LogLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LogLevel(l.minLevel.Load())
+}
+
+// NewRotatingLogger creates a Logger that appends to the file at path and rotates it by size:
+// when a write would grow the file past maxBytes, the file is renamed to path+".1" (shifting
+// existing backups to ".2", ".3" and so on, up to maxBackups, and dropping the oldest) and a new
+// file is started. Rotation happens under the Logger's mutex, between entries. With maxBackups
+// of 0 or less the full file is discarded instead of kept; with maxBytes of 0 or less the file
+// is never rotated. Callers should Close the Logger when done. redactHeaders are as for NewLogger.
+func NewRotatingLogger(path string, minLevelStr string, maxBytes int64, maxBackups int, redactHeaders ...string) (*Logger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file '%s': %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat log file '%s': %w", path, err)
+	}
+	rf := &rotatingFile{path: path, file: file, size: info.Size(), maxBytes: maxBytes, maxBackups: maxBackups}
+	logger := NewLogger(rf, minLevelStr, redactHeaders...)
+	logger.closer = rf
+	return logger, nil
+}
+
+// Close closes the file opened by NewRotatingLogger. It does nothing for Loggers created with
+// NewLogger, whose writer belongs to the caller.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// rotatingFile is the writer of a Logger created by NewRotatingLogger. It is only written under
+// the Logger's mutex, so it needs no locking of its own.
+type rotatingFile struct {
+	path       string
+	file       *os.File
+	size       int64 // Bytes in the current file.
+	maxBytes   int64
+	maxBackups int
+}
+
+// Write appends p to the current file, first rotating it if p would take it past maxBytes. A
+// single entry larger than maxBytes is still written whole, to a fresh file.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.maxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxBytes {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, shifts the backups along and opens a new, empty file.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file '%s' for rotation: %w", rf.path, err)
+	}
+	rf.file = nil
+	if rf.maxBackups > 0 {
+		for i := rf.maxBackups - 1; i >= 1; i-- { // The oldest backup is overwritten by the rename.
+			if err := os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to rotate log backup: %w", err)
+			}
+		}
+		if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate log file '%s': %w", rf.path, err)
+		}
+	}
+	file, err := os.OpenFile(rf.path, os.O_APPEND|os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file '%s' after rotation: %w", rf.path, err)
+	}
+	rf.file, rf.size = file, 0
+	return nil
+}
+
+// Close closes the current file.
+func (rf *rotatingFile) Close() error {
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// ParseLevel returns the LogLevel named by s (e.g., "INFO", "warn"), case-insensitively.
+// The second result is false if s names no known level.
+func ParseLevel(s string) (LogLevel, bool) {
+	level, ok := stringToLevel[strings.ToUpper(s)]
+	return level, ok
+}
+
+// SetMaxBodyBytes caps the number of bytes of the RequestBody and ResponseBody fields written
+// per log entry, so very large bodies cannot produce multi-megabyte log lines. Truncation never
+// splits a multi-byte rune. A value of 0 or less disables the cap.
+func (l *Logger) SetMaxBodyBytes(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxBodyBytes = n
+}
+
+// TruncateUTF8 shortens s to at most maxBytes bytes without splitting a multi-byte rune,
+// appending a marker with the number of bytes dropped. s is returned unchanged if it already
+// fits or if maxBytes is 0 or less.
+func TruncateUTF8(s string, maxBytes int) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) { // Back up to the start of the rune straddling the cap.
+		cut--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", s[:cut], len(s)-cut)
+}
+
 // Log writes a LogEntry at the specified LogLevel if the level is at or above the Logger's minimum level.
 // The LogEntry is augmented with a timestamp and string representation of the level before being
 // marshaled to JSON and written to the Logger's io.Writer.
 // Writes are thread-safe. If JSON marshaling fails, a fallback plain text error is logged.
-// If writing to the primary writer fails, the error is written to io.Discard.
+// If writing to a writer fails, the error is written to io.Discard and the other writers
+// still receive the entry.
 func (l *Logger) Log(level LogLevel, entry LogEntry) {
-	if level < l.minLevel {
+	if level < LogLevel(l.minLevel.Load()) {
 		return // Suppress messages below the minimum level.
 	}
 
@@ -107,22 +336,24 @@ func (l *Logger) Log(level LogLevel, entry LogEntry) {
 	l.mu.Lock() // Ensure thread-safe write to the output.
 	defer l.mu.Unlock()
 
+	// Bodies are the only fields that can grow unbounded; cap them before marshaling.
+	entry.RequestBody = TruncateUTF8(entry.RequestBody, l.maxBodyBytes)
+	entry.ResponseBody = TruncateUTF8(entry.ResponseBody, l.maxBodyBytes)
+	// entry is the caller's copy, but its header maps are shared; redact copies of them.
+	entry.RequestHeaders = l.redact(entry.RequestHeaders)
+	entry.ResponseHeaders = l.redact(entry.ResponseHeaders)
+
 	jsonData, err := json.Marshal(entry)
 	if err != nil {
 		// Fallback to a simple error log if marshaling fails, to avoid losing error information.
 		// This fallback log is also JSON-like for some consistency.
-		fmt.Fprintf(l.writer, "{\"timestamp\":\"%s\",\"level\":\"ERROR\",\"message\":\"Failed to marshal log entry\",\"original_level\":\"%s\",\"error\":\"%s\"}\n",
-			time.Now().UTC().Format(time.RFC3339Nano), entry.Level, err.Error())
+		l.writeLine([]byte(fmt.Sprintf("{\"timestamp\":\"%s\",\"level\":\"ERROR\",\"message\":\"Failed to marshal log entry\",\"original_level\":\"%s\",\"error\":\"%s\"}\n",
+			time.Now().UTC().Format(time.RFC3339Nano), entry.Level, err.Error())))
 		return
 	}
 
 	// Write the JSON data followed by a newline (JSON Lines format).
-	if _, err = fmt.Fprintln(l.writer, string(jsonData)); err != nil {
-		// If writing to the primary writer fails, attempt to write an error message to io.Discard
-		// to avoid crashing or polluting stderr directly from library code.
-		fmt.Fprintf(io.Discard, "{\"timestamp\":\"%s\",\"level\":\"ERROR\",\"message\":\"Failed to write log entry to primary writer\",\"error\":\"%s\"}\n",
-			time.Now().UTC().Format(time.RFC3339Nano), err.Error())
-	}
+	l.writeLine(append(jsonData, '\n'))
 }
 
 // Debug logs a message at LevelDebug.
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxBytes int
+		wantKept string
+	}{
+		{"fits", "hello", 10, "hello"},
+		{"exact fit", "hello", 5, "hello"},
+		{"ascii cut", "hello world", 5, "hello"},
+		{"disabled", strings.Repeat("a", 100), 0, strings.Repeat("a", 100)},
+		{"cut inside two-byte rune", "aé", 2, "a"},      // é is 2 bytes.
+		{"cut inside three-byte rune", "ab世界", 4, "ab"}, // 世 is 3 bytes.
+		{"cut inside four-byte rune", "😀😀", 7, "😀"},     // 😀 is 4 bytes.
+		{"cut on rune boundary", "世界世界", 6, "世界"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateUTF8(tt.input, tt.maxBytes)
+			assert.True(t, utf8.ValidString(got), "Truncated string must not contain broken runes: %q", got)
+			assert.True(t, strings.HasPrefix(got, tt.wantKept), "got %q, want prefix %q", got, tt.wantKept)
+			if tt.wantKept == tt.input {
+				assert.Equal(t, tt.input, got)
+			} else {
+				assert.Equal(t, tt.wantKept, strings.SplitN(got, "...[truncated", 2)[0], "Kept content should end at the last whole rune within the cap")
+				assert.Contains(t, got, "...[truncated ")
+			}
+		})
+	}
+}
+
+func TestLogger_MaxBodyBytes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "INFO")
+	logger.SetMaxBodyBytes(10)
+
+	body := strings.Repeat("日本語", 10) // 90 bytes of 3-byte runes.
+	logger.Info(LogEntry{Message: "response", RequestBody: "short", ResponseBody: body})
+
+	var entry LogEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "short", entry.RequestBody, "Bodies within the cap should be logged verbatim")
+	assert.True(t, utf8.ValidString(entry.ResponseBody))
+	assert.Equal(t, "日本語...[truncated 81 bytes]", entry.ResponseBody)
+	assert.NotContains(t, entry.ResponseBody, "�", "No replacement characters should result from the cut")
+}
+
+func TestNewRotatingLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentinelgo_session.log")
+	const maxBytes = 512
+	logger, err := NewRotatingLogger(path, "INFO", maxBytes, 2)
+	require.NoError(t, err)
+	for i := 0; i < 40; i++ {
+		logger.Info(LogEntry{Message: fmt.Sprintf("entry %02d", i)})
+	}
+	logger.Debug(LogEntry{Message: "suppressed"})
+	require.NoError(t, logger.Close())
+
+	var newest []LogEntry
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err, "%s should exist", name)
+		assert.LessOrEqual(t, len(data), maxBytes, "%s should not exceed the size limit", name)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		for _, line := range lines {
+			var entry LogEntry
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), "rotation must not split entries")
+			if name == path {
+				newest = append(newest, entry)
+			}
+		}
+	}
+	assert.NoFileExists(t, path+".3", "only maxBackups backups are kept")
+	require.NotEmpty(t, newest)
+	assert.Equal(t, "entry 39", newest[len(newest)-1].Message)
+
+	// Reopening appends to the current file and keeps counting its size.
+	logger, err = NewRotatingLogger(path, "INFO", maxBytes, 0)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		logger.Info(LogEntry{Message: "reopened"})
+	}
+	require.NoError(t, logger.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(data), maxBytes)
+	assert.Contains(t, string(data), "reopened")
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "INFO")
+	assert.Equal(t, LevelInfo, logger.Level())
+
+	logger.Debug(LogEntry{Message: "before"})
+	logger.SetLevel("debug")
+	assert.Equal(t, LevelDebug, logger.Level())
+	logger.Debug(LogEntry{Message: "after"})
+	logger.SetLevel("VERBOSE") // Invalid: the level is unchanged.
+	assert.Equal(t, LevelDebug, logger.Level())
+
+	var entry LogEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), "exactly one entry should be written")
+	assert.Equal(t, "after", entry.Message)
+	assert.Equal(t, "DEBUG", entry.Level)
+}
+
+func TestLogger_RedactHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "INFO", "x-session")
+
+	reqHeaders := http.Header{
+		"Authorization": {"Bearer secret-token-123"},
+		"X-Api-Key":     {"key-456"},
+		"X-Auth-Token":  {"token-abc"},
+		"X-Session":     {"session-def"},
+		"User-Agent":    {"SentinelGo"},
+	}
+	reqHeaders["x-api-key"] = []string{"key-non-canonical"} // Set without canonicalization.
+	reqHeaders["x-session"] = []string{"session-non-canonical"}
+	respHeaders := http.Header{"Set-Cookie": {"session=cookie-789"}, "Content-Type": {"text/plain"}}
+	entry := LogEntry{Message: "attempt", RequestHeaders: reqHeaders, ResponseHeaders: respHeaders}
+	logger.Info(entry)
+
+	out := buf.String()
+	for _, secret := range []string{"secret-token-123", "key-456", "key-non-canonical", "token-abc", "session-def", "session-non-canonical", "cookie-789"} {
+		assert.NotContains(t, out, secret)
+	}
+	var logged LogEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logged))
+	assert.Equal(t, []string{RedactedHeaderValue}, logged.RequestHeaders["Authorization"])
+	assert.Equal(t, []string{"SentinelGo"}, logged.RequestHeaders["User-Agent"], "other headers are kept")
+	assert.Equal(t, []string{"text/plain"}, logged.ResponseHeaders["Content-Type"])
+
+	assert.Equal(t, "Bearer secret-token-123", entry.RequestHeaders.Get("Authorization"), "the caller's headers are not modified")
+	assert.Equal(t, "session=cookie-789", respHeaders.Get("Set-Cookie"))
+}
+
+func TestIsSensitiveHeader(t *testing.T) {
+	for _, name := range []string{"Authorization", "proxy-authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token", "X-Client-Secret", "X-APIKEY", "X-Password"} {
+		assert.True(t, IsSensitiveHeader(name), name)
+	}
+	for _, name := range []string{"User-Agent", "Content-Type", "Referer", "X-Request-ID"} {
+		assert.False(t, IsSensitiveHeader(name), name)
+	}
+}
+
+// failingWriter is an io.Writer whose writes always fail.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestLogger_AddWriter(t *testing.T) {
+	var file, ring bytes.Buffer
+	logger := NewLogger(&file, "INFO")
+	logger.AddWriter(failingWriter{}) // A failing writer between the two must not affect them.
+	logger.AddWriter(&ring)
+
+	logger.Info(LogEntry{Message: "first", Outcome: "accepted"})
+	logger.Debug(LogEntry{Message: "suppressed"})
+	logger.Warn(LogEntry{Message: "second"})
+
+	assert.NotEmpty(t, file.String())
+	assert.Equal(t, file.String(), ring.String(), "both writers receive identical lines")
+	lines := strings.Split(strings.TrimSuffix(ring.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var entry LogEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "first", entry.Message)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "second", entry.Message)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, ok := ParseLevel("warn")
+	assert.True(t, ok)
+	assert.Equal(t, LevelWarn, level)
+
+	_, ok = ParseLevel("VERBOSE")
+	assert.False(t, ok)
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedExportFormat is returned by ExportLogEntries when the output path has neither a
+// ".csv" nor a ".json" extension.
+var ErrUnsupportedExportFormat = errors.New("unsupported export format (use a .csv or .json file)")
+
+// LogFilter selects structured log entries. Empty fields match every entry. Level, Outcome and
+// Label must match exactly, ignoring case; SessionID matches any session ID it is a prefix of, so
+// the short IDs shown in the TUI can be used.
+type LogFilter struct {
+	Level     string
+	Outcome   string
+	SessionID string
+	Label     string
+}
+
+// Match reports whether entry passes the filter.
+func (f LogFilter) Match(entry LogEntry) bool {
+	if f.Level != "" && !strings.EqualFold(strings.TrimSpace(f.Level), entry.Level) {
+		return false
+	}
+	if f.Outcome != "" && !strings.EqualFold(strings.TrimSpace(f.Outcome), entry.Outcome) {
+		return false
+	}
+	if f.SessionID != "" && !strings.HasPrefix(strings.ToLower(entry.SessionID), strings.ToLower(strings.TrimSpace(f.SessionID))) {
+		return false
+	}
+	if f.Label != "" && !strings.EqualFold(strings.TrimSpace(f.Label), entry.Label) {
+		return false
+	}
+	return true
+}
+
+// ScanLogEntries reads a JSON-lines log written by Logger from r one line at a time, so logs of
+// any size can be reviewed without loading them into memory, and calls fn for each entry that
+// matches filter. Lines that are not a JSON object (e.g. a partial write after a crash) are
+// skipped and counted in skipped; blank lines are ignored. Scanning stops at the first read
+// error or error returned by fn.
+func ScanLogEntries(r io.Reader, filter LogFilter, fn func(LogEntry) error) (skipped int, err error) {
+	reader := bufio.NewReader(r)
+	for {
+		line, readErr := reader.ReadBytes('\n') // Unlike bufio.Scanner, no limit on line length.
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var entry LogEntry
+			if json.Unmarshal(line, &entry) != nil {
+				skipped++
+			} else if filter.Match(entry) {
+				if err := fn(entry); err != nil {
+					return skipped, err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return skipped, nil
+		}
+		if readErr != nil {
+			return skipped, fmt.Errorf("failed to read log: %w", readErr)
+		}
+	}
+}
+
+// logExportColumns are the LogEntry fields written by a CSV export, in order. Headers and
+// bodies are left out; export to JSON to keep every field.
+var logExportColumns = []string{"timestamp", "level", "session_id", "label", "outcome", "message", "report_url", "proxy", "response_status", "log_id", "error"}
+
+// ExportLogEntries streams the entries of the log at logPath that match filter to outPath: as CSV
+// (one row per entry, with logExportColumns) if it ends in ".csv", or as a JSON array of complete
+// entries if it ends in ".json". It returns how many entries were exported and how many malformed
+// lines were skipped. A failed export removes the partly written file.
+func ExportLogEntries(logPath string, filter LogFilter, outPath string) (exported, skipped int, err error) {
+	ext := strings.ToLower(filepath.Ext(outPath))
+	if ext != ".csv" && ext != ".json" {
+		return 0, 0, fmt.Errorf("%w: '%s'", ErrUnsupportedExportFormat, outPath)
+	}
+	in, err := os.Open(logPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open log file '%s': %w", logPath, err)
+	}
+	defer in.Close()
+	out, err := os.Create(outPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create export file '%s': %w", outPath, err)
+	}
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close export file '%s': %w", outPath, closeErr)
+		}
+		if err != nil {
+			os.Remove(outPath)
+		}
+	}()
+
+	w := bufio.NewWriter(out)
+	if ext == ".csv" {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(logExportColumns); err != nil {
+			return 0, 0, fmt.Errorf("failed to write export file '%s': %w", outPath, err)
+		}
+		skipped, err = ScanLogEntries(in, filter, func(entry LogEntry) error {
+			exported++
+			status := ""
+			if entry.ResponseStatus != 0 {
+				status = strconv.Itoa(entry.ResponseStatus)
+			}
+			return cw.Write([]string{entry.Timestamp, entry.Level, entry.SessionID, entry.Label, entry.Outcome, entry.Message, entry.ReportURL, entry.Proxy, status, entry.LogID, entry.Error})
+		})
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+	} else {
+		w.WriteString("[")
+		skipped, err = ScanLogEntries(in, filter, func(entry LogEntry) error {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if exported > 0 {
+				w.WriteString(",")
+			}
+			exported++
+			w.WriteString("\n  ")
+			_, err = w.Write(data)
+			return err
+		})
+		w.WriteString("\n]\n")
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return exported, skipped, fmt.Errorf("failed to export log entries to '%s': %w", outPath, err)
+	}
+	return exported, skipped, nil
+}
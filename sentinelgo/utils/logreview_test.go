@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestLog writes a JSON-lines log with three entries, a malformed line and a blank line.
+func writeTestLog(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "DEBUG")
+	logger.Info(LogEntry{Message: "started", SessionID: "abc12345-0001", Label: "evening-batch", Outcome: "state_transition"})
+	buf.WriteString("{\"timestamp\": \"truncated\n\n")
+	logger.Error(LogEntry{Message: "report failed", SessionID: "abc12345-0001", Outcome: "failed", Error: "boom, \"quoted\"", ResponseStatus: 500})
+	logger.Error(LogEntry{Message: "other session", SessionID: "def67890-0002", Outcome: "failed"})
+	path := filepath.Join(t.TempDir(), "session.log")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+	return path
+}
+
+func TestLogFilter_Match(t *testing.T) {
+	entry := LogEntry{Level: "ERROR", Outcome: "failed", SessionID: "ABC12345-0001", Label: "Evening-Batch"}
+	assert.True(t, LogFilter{}.Match(entry))
+	assert.True(t, LogFilter{Level: "error", Outcome: " FAILED ", SessionID: "abc123", Label: "evening-batch"}.Match(entry))
+	assert.False(t, LogFilter{Level: "INFO"}.Match(entry))
+	assert.False(t, LogFilter{Outcome: "fail"}.Match(entry), "outcomes must match exactly")
+	assert.False(t, LogFilter{SessionID: "0001"}.Match(entry), "session IDs match by prefix")
+	assert.False(t, LogFilter{Label: "evening"}.Match(entry), "labels must match exactly")
+}
+
+func TestScanLogEntries(t *testing.T) {
+	f, err := os.Open(writeTestLog(t))
+	require.NoError(t, err)
+	defer f.Close()
+
+	var messages []string
+	skipped, err := ScanLogEntries(f, LogFilter{Level: "ERROR"}, func(entry LogEntry) error {
+		messages = append(messages, entry.Message)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped, "the malformed line is counted, the blank line is not")
+	assert.Equal(t, []string{"report failed", "other session"}, messages)
+
+	stop := errors.New("stop")
+	_, err = ScanLogEntries(strings.NewReader("{}\n{}\n"), LogFilter{}, func(LogEntry) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestExportLogEntries_CSV(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "export.csv")
+	exported, skipped, err := ExportLogEntries(writeTestLog(t), LogFilter{SessionID: "abc"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, exported)
+	assert.Equal(t, 1, skipped)
+
+	f, err := os.Open(out)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, logExportColumns, rows[0])
+	assert.Equal(t, "evening-batch", rows[1][3])
+	assert.Equal(t, "started", rows[1][5])
+	assert.Equal(t, "500", rows[2][8])
+	assert.Equal(t, "boom, \"quoted\"", rows[2][10], "values are CSV-escaped")
+}
+
+func TestExportLogEntries_JSON(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "export.json")
+	exported, _, err := ExportLogEntries(writeTestLog(t), LogFilter{Outcome: "failed"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, exported)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var entries []LogEntry
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "def67890-0002", entries[1].SessionID)
+
+	empty := filepath.Join(dir, "empty.json")
+	exported, _, err = ExportLogEntries(writeTestLog(t), LogFilter{Level: "DEBUG"}, empty)
+	require.NoError(t, err)
+	assert.Zero(t, exported)
+	data, err = os.ReadFile(empty)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &entries))
+	assert.Empty(t, entries)
+}
+
+func TestExportLogEntries_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, _, err := ExportLogEntries(writeTestLog(t), LogFilter{}, filepath.Join(dir, "export.txt"))
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+
+	out := filepath.Join(dir, "export.csv")
+	_, _, err = ExportLogEntries(filepath.Join(dir, "missing.log"), LogFilter{}, out)
+	assert.Error(t, err)
+	assert.NoFileExists(t, out)
+}